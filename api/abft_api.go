@@ -2,10 +2,14 @@ package api
 
 import (
 	"context"
+	"errors"
+	"fmt"
 
 	"github.com/artheranet/lachesis/inter/idx"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/rpc"
+
+	"github.com/artheranet/arthera-node/internal/inter"
 )
 
 // PublicAbftAPI provides an API to access consensus related information.
@@ -73,3 +77,140 @@ func (s *PublicAbftAPI) GetOriginatedEpochFee(ctx context.Context, validatorID h
 	}
 	return (*hexutil.Big)(v), nil
 }
+
+// GetValidatorHistory returns validator's per-epoch performance within the given epochs range, inclusive.
+// The range may contain up to 100 epochs. Epochs without recorded metrics are skipped.
+// * When epoch is -2 it refers to the current epoch, which contains only the counters collected so far.
+// * When epoch is -1 it refers to the latest sealed epoch.
+func (s *PublicAbftAPI) GetValidatorHistory(ctx context.Context, validatorID hexutil.Uint, fromEpoch, toEpoch rpc.BlockNumber) ([]map[string]interface{}, error) {
+	from, err := resolveEpoch(ctx, s.b, fromEpoch)
+	if err != nil {
+		return nil, err
+	}
+	to, err := resolveEpoch(ctx, s.b, toEpoch)
+	if err != nil {
+		return nil, err
+	}
+	if from > to {
+		return nil, errors.New("invalid epochs range")
+	}
+	if to-from >= maxEpochRange {
+		return nil, fmt.Errorf("epochs range is too wide, the maximum is %d", maxEpochRange)
+	}
+
+	res := make([]map[string]interface{}, 0, to-from+1)
+	for epoch := from; epoch <= to; epoch++ {
+		m, err := s.b.GetValidatorEpochMetrics(ctx, epoch, idx.ValidatorID(validatorID))
+		if err != nil {
+			return nil, err
+		}
+		if m == nil {
+			continue
+		}
+		info := map[string]interface{}{
+			"epoch":              hexutil.Uint64(epoch),
+			"sealed":             m.Sealed,
+			"events":             hexutil.Uint64(m.Events),
+			"blockVotes":         hexutil.Uint64(m.BlockVotes),
+			"epochVotes":         hexutil.Uint64(m.EpochVotes),
+			"misbehaviourProofs": hexutil.Uint64(m.MisbehaviourProofs),
+		}
+		if m.Sealed {
+			info["weight"] = (*hexutil.Big)(m.Weight)
+			info["uptime"] = hexutil.Uint64(m.Uptime)
+			info["missedBlocks"] = hexutil.Uint64(m.MissedBlocks)
+			info["missedTime"] = hexutil.Uint64(m.MissedTime)
+			info["downtimeForgiven"] = m.DowntimeForgiven
+			info["originatedFee"] = (*hexutil.Big)(m.OriginatedFee)
+			info["cheater"] = m.Cheater
+		}
+		res = append(res, info)
+	}
+	return res, nil
+}
+
+// RPCMarshalMisbehaviourEvidence converts the given misbehaviour evidence to the RPC output.
+func RPCMarshalMisbehaviourEvidence(ev inter.MisbehaviourEvidence) map[string]interface{} {
+	res := map[string]interface{}{
+		"type":         ev.Type.String(),
+		"reporter":     hexutil.Uint64(ev.Reporter),
+		"offender":     hexutil.Uint64(ev.Offender),
+		"epoch":        hexutil.Uint64(ev.Epoch),
+		"event":        hexutil.Bytes(ev.Event.Bytes()),
+		"appliedBlock": hexutil.Uint64(ev.AppliedBlock),
+	}
+	if ev.Block != 0 {
+		res["block"] = hexutil.Uint64(ev.Block)
+	}
+	return res
+}
+
+// GetMisbehaviourProofs returns the evidence of misbehaviour proofs applied in the epoch.
+// * When epoch is -2 it refers to the current epoch.
+// * When epoch is -1 it refers to the latest sealed epoch.
+func (s *PublicAbftAPI) GetMisbehaviourProofs(ctx context.Context, epoch rpc.BlockNumber) ([]map[string]interface{}, error) {
+	e, err := resolveEpoch(ctx, s.b, epoch)
+	if err != nil {
+		return nil, err
+	}
+	evidence, err := s.b.GetMisbehaviourEvidence(ctx, e)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]map[string]interface{}, len(evidence))
+	for i, ev := range evidence {
+		res[i] = RPCMarshalMisbehaviourEvidence(ev)
+	}
+	return res, nil
+}
+
+// GetCheaters returns the validators reported as cheaters within the given epochs range, inclusive,
+// along with the misbehaviour proofs against them. Cheaters without proofs were detected by the consensus itself.
+// The range may contain up to 100 epochs.
+// * When epoch is -2 it refers to the current epoch.
+// * When epoch is -1 it refers to the latest sealed epoch.
+func (s *PublicAbftAPI) GetCheaters(ctx context.Context, fromEpoch, toEpoch rpc.BlockNumber) ([]map[string]interface{}, error) {
+	from, err := resolveEpoch(ctx, s.b, fromEpoch)
+	if err != nil {
+		return nil, err
+	}
+	to, err := resolveEpoch(ctx, s.b, toEpoch)
+	if err != nil {
+		return nil, err
+	}
+	if from > to {
+		return nil, errors.New("invalid epochs range")
+	}
+	if to-from >= maxEpochRange {
+		return nil, fmt.Errorf("epochs range is too wide, the maximum is %d", maxEpochRange)
+	}
+
+	res := make([]map[string]interface{}, 0)
+	for epoch := from; epoch <= to; epoch++ {
+		cheaters, err := s.b.GetEpochCheaters(ctx, epoch)
+		if err != nil {
+			return nil, err
+		}
+		if len(cheaters) == 0 {
+			continue
+		}
+		evidence, err := s.b.GetMisbehaviourEvidence(ctx, epoch)
+		if err != nil {
+			return nil, err
+		}
+		for _, vid := range cheaters {
+			proofs := make([]map[string]interface{}, 0)
+			for _, ev := range evidence {
+				if ev.Offender == vid {
+					proofs = append(proofs, RPCMarshalMisbehaviourEvidence(ev))
+				}
+			}
+			res = append(res, map[string]interface{}{
+				"epoch":       hexutil.Uint64(epoch),
+				"validatorId": hexutil.Uint64(vid),
+				"proofs":      proofs,
+			})
+		}
+	}
+	return res, nil
+}
@@ -35,6 +35,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/artheranet/arthera-node/gossip/evmstore"
 	"github.com/artheranet/arthera-node/gossip/gasprice"
 	"github.com/artheranet/arthera-node/internal/evmcore"
 	"github.com/artheranet/arthera-node/params"
@@ -1820,6 +1821,11 @@ func (s *PublicTransactionPoolAPI) GetTransactionReceipt(ctx context.Context, ha
 	if tx.To() == nil {
 		fields["contractAddress"] = receipt.ContractAddress
 	}
+	// Assign the gas payment breakdown, if it was recorded during block processing
+	payments, err := s.b.GetGasPaymentsByNumber(ctx, rpc.BlockNumber(blockNumber))
+	if err == nil && len(payments) > int(index) && payments[index] != nil {
+		fields["gasPayment"] = RPCMarshalGasPayment(payments[index])
+	}
 	return fields, nil
 }
 
@@ -2268,7 +2274,8 @@ func (api *PublicDebugAPI) traceTx(ctx context.Context, message evmcore.Message,
 			return nil, err
 		}
 	}
-	vmenv := vm.NewEVM(vmctx, txContext, statedb, api.b.ChainConfig(), vm.Config{Debug: true, Tracer: tracer, NoBaseFee: true})
+	vmConfig := api.b.BlockVMConfig(idx.Block(vmctx.BlockNumber.Uint64()), vm.Config{Debug: true, Tracer: tracer, NoBaseFee: true})
+	vmenv := vm.NewEVM(vmctx, txContext, statedb, api.b.ChainConfig(), vmConfig)
 
 	// Define a meaningful timeout of a single transaction trace
 	if config.Timeout != nil {
@@ -2407,6 +2414,7 @@ func (api *PublicDebugAPI) traceBlock(ctx context.Context, block *evmcore.EvmBlo
 	}
 	// Feed the transactions into the tracers and return
 	blockCtx := api.b.GetBlockContext(blockHeader)
+	vmConfig := api.b.BlockVMConfig(idx.Block(block.NumberU64()), params.DefaultVMConfig)
 	var failed error
 	for i, tx := range txs {
 		// Send the trace task over for execution
@@ -2415,7 +2423,7 @@ func (api *PublicDebugAPI) traceBlock(ctx context.Context, block *evmcore.EvmBlo
 		// Generate the next state snapshot fast without tracing
 		msg, _ := tx.AsMessage(signer, block.BaseFee)
 		statedb.Prepare(tx.Hash(), i)
-		vmenv := vm.NewEVM(blockCtx, evmcore.NewEVMTxContext(msg), statedb, api.b.ChainConfig(), params.DefaultVMConfig)
+		vmenv := vm.NewEVM(blockCtx, evmcore.NewEVMTxContext(msg), statedb, api.b.ChainConfig(), vmConfig)
 		if _, err := evmcore.ApplyMessage(vmenv, msg, new(evmcore.GasPool).AddGas(msg.Gas())); err != nil {
 			failed = err
 			break
@@ -2450,6 +2458,7 @@ func (api *PublicDebugAPI) stateAtTransaction(ctx context.Context, block *evmcor
 	}
 	// Recompute transactions up to the target index.
 	signer := gsignercache.Wrap(types.MakeSigner(api.b.ChainConfig(), block.Number))
+	vmConfig := api.b.BlockVMConfig(idx.Block(block.NumberU64()), params.DefaultVMConfig)
 	for idx, tx := range block.Transactions {
 		// Assemble the transaction call message and return if the requested offset
 		msg, _ := tx.AsMessage(signer, block.BaseFee)
@@ -2459,7 +2468,7 @@ func (api *PublicDebugAPI) stateAtTransaction(ctx context.Context, block *evmcor
 			return msg, context, statedb, nil
 		}
 		// Not yet the searched for transaction, execute on top of the current state
-		vmenv := vm.NewEVM(context, txContext, statedb, api.b.ChainConfig(), params.DefaultVMConfig)
+		vmenv := vm.NewEVM(context, txContext, statedb, api.b.ChainConfig(), vmConfig)
 		statedb.Prepare(tx.Hash(), idx)
 		if _, err := evmcore.ApplyMessage(vmenv, msg, new(evmcore.GasPool).AddGas(tx.Gas())); err != nil {
 			return nil, vm.BlockContext{}, nil, fmt.Errorf("transaction %#x failed: %v", tx.Hash(), err)
@@ -2500,6 +2509,11 @@ func (api *PrivateDebugAPI) ChaindbCompact() error {
 	return nil
 }
 
+// PruneStatus returns the progress of the online state pruning.
+func (api *PrivateDebugAPI) PruneStatus() evmstore.PruneStatus {
+	return api.b.PruneStatus()
+}
+
 // SetHead rewinds the head of the blockchain to a previous block.
 func (api *PrivateDebugAPI) SetHead(number hexutil.Uint64) error {
 	return errors.New("lachesis cannot rewind blocks due to the BFT algorithm")
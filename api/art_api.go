@@ -0,0 +1,473 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/artheranet/lachesis/inter/idx"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/rpc"
+
+	"github.com/artheranet/arthera-node/contracts/pyag"
+	"github.com/artheranet/arthera-node/contracts/subscriber"
+	"github.com/artheranet/arthera-node/gossip/evmstore"
+	"github.com/artheranet/arthera-node/gossip/gasprice"
+	"github.com/artheranet/arthera-node/internal/evmcore"
+	"github.com/artheranet/arthera-node/internal/evmcore/vmcontext"
+	"github.com/artheranet/arthera-node/params"
+)
+
+// PublicArtheraAPI provides an API to access Arthera specific information,
+// such as subscriptions to the Subscribers contract.
+// It offers only methods that operate on public data that is freely available to anyone.
+type PublicArtheraAPI struct {
+	b Backend
+}
+
+// NewPublicArtheraAPI creates a new Arthera API.
+func NewPublicArtheraAPI(b Backend) *PublicArtheraAPI {
+	return &PublicArtheraAPI{b}
+}
+
+// SubscriptionResult is result struct for GetSubscription
+type SubscriptionResult struct {
+	Subscriber   common.Address `json:"subscriber"`
+	ContractSub  bool           `json:"contractSub"`
+	Active       bool           `json:"active"`
+	Id           *hexutil.Big   `json:"id"`
+	PlanId       *hexutil.Big   `json:"planId"`
+	StartTime    hexutil.Uint64 `json:"startTime"`
+	EndTime      hexutil.Uint64 `json:"endTime"`
+	Balance      *hexutil.Big   `json:"balance"`
+	CapType      *hexutil.Big   `json:"capType"`
+	CapUnits     *hexutil.Big   `json:"capUnits"`
+	CapWindow    hexutil.Uint64 `json:"capWindow"`
+	CapRemaining *hexutil.Big   `json:"capRemaining"`
+	PeriodUsage  *hexutil.Big   `json:"periodUsage"`
+	LastCapReset hexutil.Uint64 `json:"lastCapReset"`
+	Whitelisted  *bool          `json:"whitelisted,omitempty"`
+}
+
+// evmRunnerAt returns an EVM runner operating on the state of the given block.
+// The runner is meant for read-only calls into the system contracts.
+func (s *PublicArtheraAPI) evmRunnerAt(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (*state.StateDB, *vm.EVM, error) {
+	statedb, header, err := s.b.StateAndHeaderByNumberOrHash(ctx, blockNrOrHash)
+	if statedb == nil || err != nil {
+		return nil, nil, err
+	}
+	vmConfig := params.DefaultVMConfig
+	msg := types.NewMessage(params.ZeroAddress, nil, 0, new(big.Int), 0, new(big.Int), new(big.Int), new(big.Int), []byte{}, nil, true)
+	evm, _, err := s.b.GetEVM(ctx, msg, statedb, header, &vmConfig)
+	if err != nil {
+		return nil, nil, err
+	}
+	return statedb, evm, nil
+}
+
+// GetSubscription returns the subscription of an account at the given block.
+// The subscription is looked up as a contract subscription if the address holds code,
+// and as an EOA subscription otherwise.
+// If caller is specified and the address is a contract, the result reports whether
+// the caller is whitelisted to use the contract's subscription.
+// Returns nil if the account has no subscription.
+func (s *PublicArtheraAPI) GetSubscription(ctx context.Context, address common.Address, blockNrOrHash rpc.BlockNumberOrHash, caller *common.Address) (*SubscriptionResult, error) {
+	statedb, evm, err := s.evmRunnerAt(ctx, blockNrOrHash)
+	if statedb == nil || err != nil {
+		return nil, err
+	}
+	runner := &vmcontext.SharedEVMRunner{EVM: evm}
+	contractSub := statedb.GetCodeSize(address) > 0
+
+	sub, err := subscriber.GetSubscriptionData(runner, address, contractSub)
+	if err != nil {
+		return nil, err
+	}
+	if !evmcore.SubscriptionDataValid(sub) {
+		return nil, statedb.Error()
+	}
+	capWindow, err := subscriber.GetCapWindow(runner, address, contractSub)
+	if err != nil {
+		return nil, err
+	}
+	capRemaining, err := subscriber.GetCapRemaining(runner, address, contractSub)
+	if err != nil {
+		return nil, err
+	}
+	plan, err := subscriber.GetPlan(runner, sub.PlanId)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &SubscriptionResult{
+		Subscriber:   address,
+		ContractSub:  contractSub,
+		Active:       evmcore.SubscriptionDataActive(sub, evm.Context.Time),
+		Id:           (*hexutil.Big)(sub.Id),
+		PlanId:       (*hexutil.Big)(sub.PlanId),
+		StartTime:    hexutil.Uint64(sub.StartTime.Uint64()),
+		EndTime:      hexutil.Uint64(sub.EndTime.Uint64()),
+		Balance:      (*hexutil.Big)(sub.Balance),
+		CapWindow:    hexutil.Uint64(capWindow.Unix()),
+		CapRemaining: (*hexutil.Big)(capRemaining),
+		PeriodUsage:  (*hexutil.Big)(sub.PeriodUsage),
+	}
+	if sub.LastCapReset != nil {
+		res.LastCapReset = hexutil.Uint64(sub.LastCapReset.Uint64())
+	}
+	if plan != nil {
+		res.CapType = (*hexutil.Big)(plan.CapFrequency)
+		res.CapUnits = (*hexutil.Big)(plan.CapUnits)
+	}
+	if caller != nil && contractSub {
+		whitelisted, err := subscriber.IsWhitelistedForContract(runner, address, *caller)
+		if err != nil {
+			return nil, err
+		}
+		res.Whitelisted = &whitelisted
+	}
+	return res, statedb.Error()
+}
+
+// IsWhitelisted returns whether the account is allowed to use the subscription of the contract at the given block.
+func (s *PublicArtheraAPI) IsWhitelisted(ctx context.Context, contract common.Address, account common.Address, blockNrOrHash rpc.BlockNumberOrHash) (bool, error) {
+	statedb, evm, err := s.evmRunnerAt(ctx, blockNrOrHash)
+	if statedb == nil || err != nil {
+		return false, err
+	}
+	return subscriber.IsWhitelistedForContract(&vmcontext.SharedEVMRunner{EVM: evm}, contract, account)
+}
+
+// RPCMarshalGasPayment converts the given gas payment to the RPC output.
+func RPCMarshalGasPayment(p *evmcore.GasPayment) map[string]interface{} {
+	fields := map[string]interface{}{
+		"receiverSubscriptionGas":    hexutil.Uint64(p.ReceiverSubscriptionGas),
+		"receiverSubscriptionRefund": hexutil.Uint64(p.ReceiverSubscriptionRefund),
+		"senderSubscriptionGas":      hexutil.Uint64(p.SenderSubscriptionGas),
+		"senderSubscriptionRefund":   hexutil.Uint64(p.SenderSubscriptionRefund),
+		"payAsYouGoGas":              hexutil.Uint64(p.PayAsYouGoGas),
+		"payAsYouGoRefund":           hexutil.Uint64(p.PayAsYouGoRefund),
+		"payAsYouGoCost":             (*hexutil.Big)(p.PayAsYouGoCost()),
+		"deployerRewarded":           p.DeployerRewarded(),
+	}
+	if p.DeployerRewarded() {
+		fields["deployerRewardRecipient"] = p.DeployerRewardRecipient
+		fields["deployerReward"] = (*hexutil.Big)(p.DeployerReward)
+	}
+	return fields
+}
+
+// GetGasPayment returns the breakdown of who paid for the gas of the given transaction.
+// Returns nil if the transaction is unknown or its payment wasn't recorded.
+func (s *PublicArtheraAPI) GetGasPayment(ctx context.Context, hash common.Hash) (map[string]interface{}, error) {
+	tx, blockNumber, index, err := s.b.GetTransaction(ctx, hash)
+	if tx == nil || err != nil {
+		return nil, err
+	}
+	payments, err := s.b.GetGasPaymentsByNumber(ctx, rpc.BlockNumber(blockNumber))
+	if err != nil {
+		return nil, err
+	}
+	if len(payments) <= int(index) || payments[index] == nil {
+		return nil, nil
+	}
+	return RPCMarshalGasPayment(payments[index]), nil
+}
+
+// GetBlockGasPayments returns the gas payments of all the transactions of a block, indexed by transaction hash.
+func (s *PublicArtheraAPI) GetBlockGasPayments(ctx context.Context, number rpc.BlockNumber) (map[common.Hash]interface{}, error) {
+	block, err := s.b.BlockByNumber(ctx, number)
+	if block == nil || err != nil {
+		return nil, err
+	}
+	payments, err := s.b.GetGasPaymentsByNumber(ctx, rpc.BlockNumber(block.NumberU64()))
+	if err != nil {
+		return nil, err
+	}
+	res := make(map[common.Hash]interface{}, len(payments))
+	for i, p := range payments {
+		if p == nil || i >= len(block.Transactions) {
+			continue
+		}
+		res[block.Transactions[i].Hash()] = RPCMarshalGasPayment(p)
+	}
+	return res, nil
+}
+
+// CostEstimateResult is result struct for EstimateCost
+type CostEstimateResult struct {
+	Gas                     hexutil.Uint64         `json:"gas"`
+	GasPrice                *hexutil.Big           `json:"gasPrice"`
+	ReceiverSubscriptionGas hexutil.Uint64         `json:"receiverSubscriptionGas"`
+	SenderSubscriptionGas   hexutil.Uint64         `json:"senderSubscriptionGas"`
+	PayAsYouGoGas           hexutil.Uint64         `json:"payAsYouGoGas"`
+	PayAsYouGoCost          *hexutil.Big           `json:"payAsYouGoCost"`
+	Affordable              bool                   `json:"affordable"`
+	CapResetTime            *hexutil.Uint64        `json:"capResetTime,omitempty"`
+	Reason                  string                 `json:"reason,omitempty"`
+	Payment                 map[string]interface{} `json:"payment,omitempty"`
+}
+
+// EstimateCost estimates the gas of the given transaction and how its cost would be split
+// between the receiver's subscription, the sender's subscription and the sender's balance (Pay-as-You-Go).
+// If the sender can afford the transaction, it's also executed with the actual gas price
+// to report the payment breakdown after gas refunds.
+func (s *PublicArtheraAPI) EstimateCost(ctx context.Context, args TransactionArgs, blockNrOrHash *rpc.BlockNumberOrHash) (*CostEstimateResult, error) {
+	bNrOrHash := rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)
+	if blockNrOrHash != nil {
+		bNrOrHash = *blockNrOrHash
+	}
+	if args.From == nil {
+		args.From = new(common.Address)
+	}
+	if args.GasPrice != nil && (args.MaxFeePerGas != nil || args.MaxPriorityFeePerGas != nil) {
+		return nil, errors.New("both gasPrice and (maxFeePerGas or maxPriorityFeePerGas) specified")
+	}
+	if args.Gas == nil {
+		// estimate without gas price, so the estimation isn't capped by the sender's balance
+		estArgs := args
+		estArgs.GasPrice, estArgs.MaxFeePerGas, estArgs.MaxPriorityFeePerGas = nil, nil, nil
+		gas, err := DoEstimateGas(ctx, s.b, estArgs, bNrOrHash, s.b.RPCGasCap())
+		if err != nil {
+			return nil, err
+		}
+		args.Gas = &gas
+	}
+	if args.GasPrice == nil && args.MaxFeePerGas == nil && args.MaxPriorityFeePerGas == nil {
+		price := s.b.SuggestGasTipCap(ctx, gasprice.AsDefaultCertainty)
+		price.Add(price, s.b.MinGasPrice())
+		args.GasPrice = (*hexutil.Big)(price)
+	}
+
+	statedb, header, err := s.b.StateAndHeaderByNumberOrHash(ctx, bNrOrHash)
+	if statedb == nil || err != nil {
+		return nil, err
+	}
+	msg, err := args.ToMessage(s.b.RPCGasCap(), header.BaseFee)
+	if err != nil {
+		return nil, err
+	}
+	vmConfig := params.DefaultVMConfig
+	vmConfig.NoBaseFee = true
+	evm, _, err := s.b.GetEVM(ctx, msg, statedb, header, &vmConfig)
+	if err != nil {
+		return nil, err
+	}
+	preview := evmcore.PreviewGasPayment(msg, statedb, evm.Context.Time, &vmcontext.SharedEVMRunner{EVM: evm})
+	res := &CostEstimateResult{
+		Gas:                     hexutil.Uint64(preview.Gas),
+		GasPrice:                (*hexutil.Big)(preview.GasPrice),
+		ReceiverSubscriptionGas: hexutil.Uint64(preview.ReceiverSubscriptionGas),
+		SenderSubscriptionGas:   hexutil.Uint64(preview.SenderSubscriptionGas),
+		PayAsYouGoGas:           hexutil.Uint64(preview.PayAsYouGoGas),
+		PayAsYouGoCost:          (*hexutil.Big)(preview.PayAsYouGoCost),
+		Affordable:              preview.Affordable,
+		Reason:                  preview.Reason,
+	}
+	if preview.CapResetTime != 0 {
+		capResetTime := hexutil.Uint64(preview.CapResetTime.Unix())
+		res.CapResetTime = &capResetTime
+	}
+	if !preview.Affordable {
+		return res, nil
+	}
+
+	// execute the message on a copy of the state to get the payment after refunds
+	statedb, header, err = s.b.StateAndHeaderByNumberOrHash(ctx, bNrOrHash)
+	if statedb == nil || err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, s.b.RPCEVMTimeout())
+	defer cancel()
+	evm, vmError, err := s.b.GetEVM(ctx, msg, statedb, header, &vmConfig)
+	if err != nil {
+		return nil, err
+	}
+	go func() {
+		<-ctx.Done()
+		evm.Cancel()
+	}()
+	result, err := evmcore.ApplyMessage(evm, msg, new(evmcore.GasPool).AddGas(math.MaxUint64))
+	if err := vmError(); err != nil {
+		return nil, err
+	}
+	if evm.Cancelled() {
+		return nil, fmt.Errorf("execution aborted (timeout = %v)", s.b.RPCEVMTimeout())
+	}
+	if err != nil {
+		return nil, fmt.Errorf("err: %w (supplied gas %d)", err, msg.Gas())
+	}
+	if result.Payment != nil {
+		res.Payment = RPCMarshalGasPayment(result.Payment)
+	}
+	return res, nil
+}
+
+// maxDeployerRewardRecords limits the number of indexed rewards scanned by a single call
+const maxDeployerRewardRecords = 100000
+
+// AddressReward is the amount of rewards attributed to an address
+type AddressReward struct {
+	Address common.Address `json:"address"`
+	Amount  *hexutil.Big   `json:"amount"`
+}
+
+// EpochReward is the amount of rewards credited within an epoch
+type EpochReward struct {
+	Epoch  hexutil.Uint64 `json:"epoch"`
+	Amount *hexutil.Big   `json:"amount"`
+}
+
+// DeployerRewardsResult is result struct for GetDeveloperRewards and GetContractRewards
+type DeployerRewardsResult struct {
+	Owner     common.Address  `json:"owner"`
+	Contract  *common.Address `json:"contract,omitempty"`
+	FromBlock hexutil.Uint64  `json:"fromBlock"`
+	ToBlock   hexutil.Uint64  `json:"toBlock"`
+	Total     *hexutil.Big    `json:"total"`
+	Contracts []AddressReward `json:"contracts,omitempty"`
+	Owners    []AddressReward `json:"owners,omitempty"`
+	Epochs    []EpochReward   `json:"epochs"`
+	// Pending is the amount of rewards of the owner in the PayAsYouGoGasRewards contract which are not claimed yet
+	Pending *hexutil.Big `json:"pending"`
+	// Claimed is the amount of the indexed rewards of the owner which were claimed from the PayAsYouGoGasRewards contract
+	Claimed *hexutil.Big `json:"claimed"`
+}
+
+func (s *PublicArtheraAPI) resolveBlocksRange(ctx context.Context, fromBlock, toBlock rpc.BlockNumber) (idx.Block, idx.Block, error) {
+	from, err := s.b.ResolveRpcBlockNumberOrHash(ctx, rpc.BlockNumberOrHashWithNumber(fromBlock))
+	if err != nil {
+		return 0, 0, err
+	}
+	to, err := s.b.ResolveRpcBlockNumberOrHash(ctx, rpc.BlockNumberOrHashWithNumber(toBlock))
+	if err != nil {
+		return 0, 0, err
+	}
+	if from > to {
+		return 0, 0, errors.New("fromBlock is higher than toBlock")
+	}
+	return from, to, nil
+}
+
+// aggregateDeployerRewards sums the rewards per epoch and per address
+func aggregateDeployerRewards(rewards []evmstore.DeployerReward, byOwner bool) (*big.Int, []AddressReward, []EpochReward) {
+	total := new(big.Int)
+	var addresses []AddressReward
+	var epochs []EpochReward
+	addressIdx := make(map[common.Address]int)
+	for _, r := range rewards {
+		total.Add(total, r.Amount)
+
+		if len(epochs) == 0 || uint64(epochs[len(epochs)-1].Epoch) != uint64(r.Epoch) {
+			epochs = append(epochs, EpochReward{Epoch: hexutil.Uint64(r.Epoch), Amount: (*hexutil.Big)(new(big.Int))})
+		}
+		last := epochs[len(epochs)-1].Amount.ToInt()
+		last.Add(last, r.Amount)
+
+		addr := r.Contract
+		if !byOwner {
+			addr = r.Owner
+		}
+		i, ok := addressIdx[addr]
+		if !ok {
+			i = len(addresses)
+			addressIdx[addr] = i
+			addresses = append(addresses, AddressReward{Address: addr, Amount: (*hexutil.Big)(new(big.Int))})
+		}
+		amount := addresses[i].Amount.ToInt()
+		amount.Add(amount, r.Amount)
+	}
+	return total, addresses, epochs
+}
+
+// ownerRewardsState returns the pending and claimed rewards of the contracts owner at the latest block.
+// The PayAsYouGoGasRewards contract keeps the rewards credited to an owner until they are claimed,
+// so the claimed rewards are the indexed rewards of the owner which aren't pending anymore.
+func (s *PublicArtheraAPI) ownerRewardsState(ctx context.Context, owner common.Address) (*big.Int, *big.Int, error) {
+	statedb, evm, err := s.evmRunnerAt(ctx, rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber))
+	if statedb == nil || err != nil {
+		return nil, nil, err
+	}
+	pending, err := pyag.GetRewards(&vmcontext.SharedEVMRunner{EVM: evm}, owner)
+	if err != nil {
+		return nil, nil, err
+	}
+	total, err := s.b.OwnerDeployerRewardsTotal(ctx, owner)
+	if err != nil {
+		return nil, nil, err
+	}
+	claimed := new(big.Int)
+	if total.Cmp(pending) > 0 {
+		// pending rewards may include the rewards credited before the index was enabled
+		claimed.Sub(total, pending)
+	}
+	return pending, claimed, nil
+}
+
+// GetDeveloperRewards returns the Pay-as-You-Go rewards credited to a contracts owner within the blocks range,
+// grouped by contract and by epoch, along with the pending and claimed rewards of the owner.
+func (s *PublicArtheraAPI) GetDeveloperRewards(ctx context.Context, owner common.Address, fromBlock, toBlock rpc.BlockNumber) (*DeployerRewardsResult, error) {
+	from, to, err := s.resolveBlocksRange(ctx, fromBlock, toBlock)
+	if err != nil {
+		return nil, err
+	}
+	rewards, err := s.b.OwnerDeployerRewards(ctx, owner, from, to, maxDeployerRewardRecords)
+	if err != nil {
+		return nil, err
+	}
+	total, contracts, epochs := aggregateDeployerRewards(rewards, true)
+	pending, claimed, err := s.ownerRewardsState(ctx, owner)
+	if err != nil {
+		return nil, err
+	}
+	return &DeployerRewardsResult{
+		Owner:     owner,
+		FromBlock: hexutil.Uint64(from),
+		ToBlock:   hexutil.Uint64(to),
+		Total:     (*hexutil.Big)(total),
+		Contracts: contracts,
+		Epochs:    epochs,
+		Pending:   (*hexutil.Big)(pending),
+		Claimed:   (*hexutil.Big)(claimed),
+	}, nil
+}
+
+// GetContractRewards returns the Pay-as-You-Go rewards earned by a contract within the blocks range,
+// grouped by owner and by epoch, along with the pending and claimed rewards of the current contract owner.
+func (s *PublicArtheraAPI) GetContractRewards(ctx context.Context, contract common.Address, fromBlock, toBlock rpc.BlockNumber) (*DeployerRewardsResult, error) {
+	from, to, err := s.resolveBlocksRange(ctx, fromBlock, toBlock)
+	if err != nil {
+		return nil, err
+	}
+	rewards, err := s.b.ContractDeployerRewards(ctx, contract, from, to, maxDeployerRewardRecords)
+	if err != nil {
+		return nil, err
+	}
+	statedb, _, err := s.b.StateAndHeaderByNumberOrHash(ctx, rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber))
+	if statedb == nil || err != nil {
+		return nil, err
+	}
+	owner := pyag.GetOwnerOfContractFast(contract, statedb)
+	pending, claimed, err := s.ownerRewardsState(ctx, owner)
+	if err != nil {
+		return nil, err
+	}
+	total, owners, epochs := aggregateDeployerRewards(rewards, false)
+	return &DeployerRewardsResult{
+		Owner:     owner,
+		Contract:  &contract,
+		FromBlock: hexutil.Uint64(from),
+		ToBlock:   hexutil.Uint64(to),
+		Total:     (*hexutil.Big)(total),
+		Owners:    owners,
+		Epochs:    epochs,
+		Pending:   (*hexutil.Big)(pending),
+		Claimed:   (*hexutil.Big)(claimed),
+	}, nil
+}
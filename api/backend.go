@@ -34,6 +34,8 @@ import (
 	"github.com/ethereum/go-ethereum/params"
 	"github.com/ethereum/go-ethereum/rpc"
 
+	"github.com/artheranet/arthera-node/gossip/evmstore"
+	"github.com/artheranet/arthera-node/gossip/txtrace"
 	"github.com/artheranet/arthera-node/internal/evmcore"
 	"github.com/artheranet/arthera-node/internal/inter"
 	"github.com/artheranet/arthera-node/internal/inter/iblockproc"
@@ -57,6 +59,7 @@ type Backend interface {
 	SuggestGasTipCap(ctx context.Context, certainty uint64) *big.Int
 	EffectiveMinGasPrice(ctx context.Context) *big.Int
 	ChainDb() ethdb.Database
+	PruneStatus() evmstore.PruneStatus
 	AccountManager() *accounts.Manager
 	ExtRPCEnabled() bool
 	RPCGasCap() uint64            // global gas cap for eth_call over rpc: DoS protection
@@ -74,9 +77,15 @@ type Backend interface {
 	ResolveRpcBlockNumberOrHash(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (idx.Block, error)
 	BlockByHash(ctx context.Context, hash common.Hash) (*evmcore.EvmBlock, error)
 	GetReceiptsByNumber(ctx context.Context, number rpc.BlockNumber) (types.Receipts, error)
+	GetGasPaymentsByNumber(ctx context.Context, number rpc.BlockNumber) ([]*evmcore.GasPayment, error)
+	TxTraceByHash(ctx context.Context, h common.Hash) ([]txtrace.ActionTrace, error)
+	OwnerDeployerRewards(ctx context.Context, owner common.Address, from, to idx.Block, limit int) ([]evmstore.DeployerReward, error)
+	ContractDeployerRewards(ctx context.Context, contract common.Address, from, to idx.Block, limit int) ([]evmstore.DeployerReward, error)
+	OwnerDeployerRewardsTotal(ctx context.Context, owner common.Address) (*big.Int, error)
 	GetTd(hash common.Hash) *big.Int
 	GetEVM(ctx context.Context, msg evmcore.Message, state *state.StateDB, header *evmcore.EvmHeader, vmConfig *vm.Config) (*vm.EVM, func() error, error)
 	GetBlockContext(header *evmcore.EvmHeader) vm.BlockContext
+	BlockVMConfig(n idx.Block, vmConfig vm.Config) vm.Config
 	MinGasPrice() *big.Int
 	MaxGasLimit() uint64
 
@@ -98,6 +107,10 @@ type Backend interface {
 	GetEventPayload(ctx context.Context, shortEventID string) (*inter.EventPayload, error)
 	GetEvent(ctx context.Context, shortEventID string) (*inter.Event, error)
 	GetHeads(ctx context.Context, epoch rpc.BlockNumber) (hash.Events, error)
+	ForEachEpochEventFrom(ctx context.Context, epoch rpc.BlockNumber, from hash.Event, onEvent func(event *inter.EventPayload) bool) error
+	SubscribeNewEventsNotify(ch chan<- *inter.EventPayload) notify.Subscription
+	SubscribeConfirmedEventsNotify(ch chan<- inter.ConfirmedEventNotify) notify.Subscription
+	SubscribeNewEpochNotify(ch chan<- idx.Epoch) notify.Subscription
 	CurrentEpoch(ctx context.Context) idx.Epoch
 	SealedEpochTiming(ctx context.Context) (start inter.Timestamp, end inter.Timestamp)
 
@@ -106,6 +119,9 @@ type Backend interface {
 	GetDowntime(ctx context.Context, vid idx.ValidatorID) (idx.Block, inter.Timestamp, error)
 	GetUptime(ctx context.Context, vid idx.ValidatorID) (*big.Int, error)
 	GetOriginatedFee(ctx context.Context, vid idx.ValidatorID) (*big.Int, error)
+	GetValidatorEpochMetrics(ctx context.Context, epoch idx.Epoch, vid idx.ValidatorID) (*iblockproc.ValidatorEpochMetrics, error)
+	GetEpochCheaters(ctx context.Context, epoch idx.Epoch) ([]idx.ValidatorID, error)
+	GetMisbehaviourEvidence(ctx context.Context, epoch idx.Epoch) ([]inter.MisbehaviourEvidence, error)
 }
 
 func GetAPIs(apiBackend Backend) []rpc.API {
@@ -160,6 +176,16 @@ func GetAPIs(apiBackend Backend) []rpc.API {
 			Version:   "1.0",
 			Service:   NewPublicAbftAPI(apiBackend),
 			Public:    true,
+		}, {
+			Namespace: "art",
+			Version:   "1.0",
+			Service:   NewPublicArtheraAPI(apiBackend),
+			Public:    true,
+		}, {
+			Namespace: "trace",
+			Version:   "1.0",
+			Service:   NewPublicTxTraceAPI(apiBackend),
+			Public:    true,
 		},
 	}
 
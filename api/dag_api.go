@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"math/big"
 
+	"github.com/artheranet/lachesis/hash"
+	"github.com/artheranet/lachesis/inter/idx"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/rpc"
@@ -79,3 +81,378 @@ func (s *PublicBlockChainAPI) GetEpochStats(ctx context.Context, requestedEpoch
 		"totalTxRewardWeight":   (*hexutil.Big)(new(big.Int)),
 	}, nil
 }
+
+const (
+	// defaultPageLimit is the number of items of a page if the limit isn't specified
+	defaultPageLimit = 100
+	// maxPageLimit is the maximum number of items of a page
+	maxPageLimit = 1000
+	// maxEpochRange is the maximum number of epochs returned by a single range query
+	maxEpochRange = 100
+)
+
+func pageLimit(limit *hexutil.Uint) int {
+	if limit == nil || *limit == 0 {
+		return defaultPageLimit
+	}
+	if *limit > maxPageLimit {
+		return maxPageLimit
+	}
+	return int(*limit)
+}
+
+// EventsPage is a page of the epoch events
+type EventsPage struct {
+	Events []map[string]interface{} `json:"events"`
+	// Next is the cursor of the next page, nil if it's the last page
+	Next *hexutil.Bytes `json:"next"`
+}
+
+// resolveEpoch converts the epoch number to the epoch index.
+// * When epoch is -2 the current epoch is returned.
+// * When epoch is -1 the latest sealed epoch is returned.
+func resolveEpoch(ctx context.Context, b Backend, epoch rpc.BlockNumber) (idx.Epoch, error) {
+	current := b.CurrentEpoch(ctx)
+	switch {
+	case epoch == rpc.PendingBlockNumber:
+		return current, nil
+	case epoch == rpc.LatestBlockNumber:
+		return current - 1, nil
+	case epoch >= 0 && idx.Epoch(epoch) <= current:
+		return idx.Epoch(epoch), nil
+	default:
+		return 0, errors.New("epoch is not in range")
+	}
+}
+
+// getEvent returns the event header by hash or short ID, or an error if it isn't found
+func (s *PublicDAGChainAPI) getEvent(ctx context.Context, shortEventID string) (*inter.Event, error) {
+	event, err := s.b.GetEvent(ctx, shortEventID)
+	if err != nil {
+		return nil, err
+	}
+	if event == nil {
+		return nil, fmt.Errorf("event %s not found", shortEventID)
+	}
+	return event, nil
+}
+
+// GetEventsByEpoch returns the events of the epoch in the lamport order, optionally only the events of the given creator.
+// The page starts from the cursor, which is the next page cursor returned with the previous page.
+// * When epoch is -2 the events of the current epoch are returned.
+// * When epoch is -1 the events of the latest sealed epoch are returned.
+func (s *PublicDAGChainAPI) GetEventsByEpoch(ctx context.Context, epoch rpc.BlockNumber, creator *hexutil.Uint, cursor *hexutil.Bytes, limit *hexutil.Uint) (*EventsPage, error) {
+	requested, err := resolveEpoch(ctx, s.b, epoch)
+	if err != nil {
+		return nil, err
+	}
+	var from hash.Event
+	if cursor != nil {
+		if len(*cursor) != len(from) {
+			return nil, errors.New("invalid cursor")
+		}
+		from = hash.BytesToEvent(*cursor)
+	}
+
+	n := pageLimit(limit)
+	page := &EventsPage{
+		Events: make([]map[string]interface{}, 0, n),
+	}
+	err = s.b.ForEachEpochEventFrom(ctx, rpc.BlockNumber(requested), from, func(event *inter.EventPayload) bool {
+		if creator != nil && event.Creator() != idx.ValidatorID(*creator) {
+			return ctx.Err() == nil
+		}
+		if len(page.Events) == n {
+			next := hexutil.Bytes(event.ID().Bytes())
+			page.Next = &next
+			return false
+		}
+		page.Events = append(page.Events, inter.RPCMarshalEvent(event))
+		return ctx.Err() == nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return page, ctx.Err()
+}
+
+// GetEventParents returns the headers of the event parents.
+func (s *PublicDAGChainAPI) GetEventParents(ctx context.Context, shortEventID string) ([]map[string]interface{}, error) {
+	event, err := s.getEvent(ctx, shortEventID)
+	if err != nil {
+		return nil, err
+	}
+	parents := make([]map[string]interface{}, 0, len(event.Parents()))
+	for _, id := range event.Parents() {
+		parent, err := s.getEvent(ctx, hexutil.Encode(id.Bytes()))
+		if err != nil {
+			return nil, err
+		}
+		parents = append(parents, inter.RPCMarshalEvent(parent))
+	}
+	return parents, nil
+}
+
+// GetEventChildren returns the headers of the known events which have the given event as a parent.
+// The children are looked up among the later events of the same epoch, so the call is expensive for old events.
+// The page starts from the cursor, which is the next page cursor returned with the previous page,
+// the lookup stops once the page is full, so the next page may be empty.
+func (s *PublicDAGChainAPI) GetEventChildren(ctx context.Context, shortEventID string, cursor *hexutil.Bytes, limit *hexutil.Uint) (*EventsPage, error) {
+	event, err := s.getEvent(ctx, shortEventID)
+	if err != nil {
+		return nil, err
+	}
+	// a child has a higher lamport than any of its parents
+	start := make([]byte, 0, len(hash.Event{}))
+	start = append(start, event.Epoch().Bytes()...)
+	start = append(start, (event.Lamport() + 1).Bytes()...)
+	from := hash.BytesToEvent(append(start, make([]byte, cap(start)-len(start))...))
+	if cursor != nil {
+		if len(*cursor) != len(from) {
+			return nil, errors.New("invalid cursor")
+		}
+		next := hash.BytesToEvent(*cursor)
+		if next.Epoch() != event.Epoch() || next.Lamport() <= event.Lamport() {
+			return nil, errors.New("invalid cursor")
+		}
+		from = next
+	}
+
+	n := pageLimit(limit)
+	page := &EventsPage{
+		Events: make([]map[string]interface{}, 0),
+	}
+	err = s.b.ForEachEpochEventFrom(ctx, rpc.BlockNumber(event.Epoch()), from, func(child *inter.EventPayload) bool {
+		if len(page.Events) == n {
+			next := hexutil.Bytes(child.ID().Bytes())
+			page.Next = &next
+			return false
+		}
+		for _, p := range child.Parents() {
+			if p == event.ID() {
+				page.Events = append(page.Events, inter.RPCMarshalEvent(child))
+				break
+			}
+		}
+		return ctx.Err() == nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return page, ctx.Err()
+}
+
+// GetAtropos returns the Atropos event which decided the block, along with the block time.
+func (s *PublicDAGChainAPI) GetAtropos(ctx context.Context, number rpc.BlockNumber) (map[string]interface{}, error) {
+	header, err := s.b.HeaderByNumber(ctx, number)
+	if err != nil {
+		return nil, err
+	}
+	if header == nil {
+		return nil, fmt.Errorf("block %d not found", number)
+	}
+	if header.Number.Sign() == 0 {
+		return nil, errors.New("genesis block has no Atropos")
+	}
+	// the block hash is the Atropos ID
+	atropos, err := s.getEvent(ctx, header.Hash.Hex())
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		"block":     (*hexutil.Big)(header.Number),
+		"blockTime": hexutil.Uint64(header.Time),
+		"atropos":   inter.RPCMarshalEvent(atropos),
+	}, nil
+}
+
+// GetEpochRange returns the timing and the blocks range of the epochs within the given range, inclusive.
+// The range may contain up to 100 epochs, the next epochs may be requested with the following range.
+// * When epoch is -2 it refers to the current epoch.
+// * When epoch is -1 it refers to the latest sealed epoch.
+func (s *PublicDAGChainAPI) GetEpochRange(ctx context.Context, fromEpoch, toEpoch rpc.BlockNumber) ([]map[string]interface{}, error) {
+	from, err := resolveEpoch(ctx, s.b, fromEpoch)
+	if err != nil {
+		return nil, err
+	}
+	to, err := resolveEpoch(ctx, s.b, toEpoch)
+	if err != nil {
+		return nil, err
+	}
+	if from > to {
+		return nil, errors.New("invalid epochs range")
+	}
+	if to-from >= maxEpochRange {
+		return nil, fmt.Errorf("epochs range is too wide, the maximum is %d", maxEpochRange)
+	}
+	current := s.b.CurrentEpoch(ctx)
+
+	res := make([]map[string]interface{}, 0, to-from+1)
+	for epoch := from; epoch <= to; epoch++ {
+		bs, es, err := s.b.GetEpochBlockState(ctx, rpc.BlockNumber(epoch))
+		if err != nil {
+			return nil, err
+		}
+		if bs == nil || es == nil {
+			// the state of this epoch isn't available
+			continue
+		}
+		info := map[string]interface{}{
+			"epoch":       hexutil.Uint64(epoch),
+			"start":       hexutil.Uint64(es.EpochStart),
+			"firstBlock":  hexutil.Uint64(bs.LastBlock.Idx + 1),
+			"validators":  hexutil.Uint64(es.Validators.Len()),
+			"totalWeight": hexutil.Uint64(es.Validators.TotalWeight()),
+			"sealed":      epoch < current,
+		}
+		// the state at the start of the next epoch describes the end of this one,
+		// the current state is used if the epoch isn't sealed yet
+		nextEpoch := rpc.PendingBlockNumber
+		if epoch < current {
+			nextEpoch = rpc.BlockNumber(epoch + 1)
+		}
+		nextBs, nextEs, err := s.b.GetEpochBlockState(ctx, nextEpoch)
+		if err != nil {
+			return nil, err
+		}
+		if nextBs != nil {
+			info["lastBlock"] = hexutil.Uint64(nextBs.LastBlock.Idx)
+		}
+		if nextEs != nil && epoch < current {
+			info["end"] = hexutil.Uint64(nextEs.EpochStart)
+		}
+		res = append(res, info)
+	}
+	return res, nil
+}
+
+// eventNotifyBuffer is the buffer size of the DAG notification channels,
+// the notifications which don't fit the buffer of a slow subscriber are dropped
+const eventNotifyBuffer = 256
+
+// NewEvents creates a subscription that fires for every event connected to the local DAG,
+// both the received and the locally emitted ones.
+func (s *PublicDAGChainAPI) NewEvents(ctx context.Context) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+
+	rpcSub := notifier.CreateSubscription()
+
+	go func() {
+		events := make(chan *inter.EventPayload, eventNotifyBuffer)
+		eventsSub := s.b.SubscribeNewEventsNotify(events)
+		defer eventsSub.Unsubscribe()
+
+		for {
+			select {
+			case e := <-events:
+				fields := inter.RPCMarshalEvent(e)
+				fields["txCount"] = hexutil.Uint64(len(e.Txs()))
+				_ = notifier.Notify(rpcSub.ID, fields)
+			case <-rpcSub.Err():
+				return
+			case <-notifier.Closed():
+				return
+			case <-eventsSub.Err():
+				return
+			}
+		}
+	}()
+
+	return rpcSub, nil
+}
+
+// ConfirmedEvents creates a subscription that fires for every event confirmed by an Atropos.
+// The notification carries the Atropos ID, the local confirmation time and the confirmation delay,
+// which is the time elapsed since the event creation, in nanoseconds.
+func (s *PublicDAGChainAPI) ConfirmedEvents(ctx context.Context) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+
+	rpcSub := notifier.CreateSubscription()
+
+	go func() {
+		confirmed := make(chan inter.ConfirmedEventNotify, eventNotifyBuffer)
+		confirmedSub := s.b.SubscribeConfirmedEventsNotify(confirmed)
+		defer confirmedSub.Unsubscribe()
+
+		for {
+			select {
+			case c := <-confirmed:
+				fields := inter.RPCMarshalEvent(c.Event)
+				txCount := 0
+				if c.Event.AnyTxs() {
+					// the header doesn't carry the transactions
+					payload, err := s.b.GetEventPayload(context.Background(), hexutil.Encode(c.Event.ID().Bytes()))
+					if err == nil && payload != nil {
+						txCount = len(payload.Txs())
+					}
+				}
+				confirmedAt := inter.Timestamp(c.Time.UnixNano())
+				delay := inter.Timestamp(0)
+				if confirmedAt > c.Event.CreationTime() {
+					delay = confirmedAt - c.Event.CreationTime()
+				}
+				fields["txCount"] = hexutil.Uint64(txCount)
+				fields["atropos"] = hexutil.Bytes(c.Atropos.Bytes())
+				fields["confirmedAt"] = hexutil.Uint64(confirmedAt)
+				fields["confirmationDelay"] = hexutil.Uint64(delay)
+				_ = notifier.Notify(rpcSub.ID, fields)
+			case <-rpcSub.Err():
+				return
+			case <-notifier.Closed():
+				return
+			case <-confirmedSub.Err():
+				return
+			}
+		}
+	}()
+
+	return rpcSub, nil
+}
+
+// NewEpoch creates a subscription that fires when a new epoch starts.
+func (s *PublicDAGChainAPI) NewEpoch(ctx context.Context) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+
+	rpcSub := notifier.CreateSubscription()
+
+	go func() {
+		epochs := make(chan idx.Epoch, eventNotifyBuffer)
+		epochsSub := s.b.SubscribeNewEpochNotify(epochs)
+		defer epochsSub.Unsubscribe()
+
+		for {
+			select {
+			case epoch := <-epochs:
+				fields := map[string]interface{}{
+					"epoch": hexutil.Uint64(epoch),
+				}
+				bs, es, err := s.b.GetEpochBlockState(context.Background(), rpc.PendingBlockNumber)
+				if err == nil && es != nil && es.Epoch == epoch {
+					fields["start"] = hexutil.Uint64(es.EpochStart)
+					fields["prevStart"] = hexutil.Uint64(es.PrevEpochStart)
+					fields["firstBlock"] = hexutil.Uint64(bs.LastBlock.Idx + 1)
+					fields["validators"] = hexutil.Uint64(es.Validators.Len())
+					fields["totalWeight"] = hexutil.Uint64(es.Validators.TotalWeight())
+				}
+				_ = notifier.Notify(rpcSub.ID, fields)
+			case <-rpcSub.Err():
+				return
+			case <-notifier.Closed():
+				return
+			case <-epochsSub.Err():
+				return
+			}
+		}
+	}()
+
+	return rpcSub, nil
+}
@@ -0,0 +1,145 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rpc"
+
+	"github.com/artheranet/arthera-node/gossip/txtrace"
+)
+
+// maxTraceFilterBlocks limits the number of blocks scanned by a single trace_filter call
+const maxTraceFilterBlocks = 10000
+
+// PublicTxTraceAPI provides Parity-style access to the call traces recorded by a trace node.
+type PublicTxTraceAPI struct {
+	b Backend
+}
+
+// NewPublicTxTraceAPI creates a new trace API.
+func NewPublicTxTraceAPI(b Backend) *PublicTxTraceAPI {
+	return &PublicTxTraceAPI{b}
+}
+
+// Transaction returns all the call traces of the given transaction.
+func (s *PublicTxTraceAPI) Transaction(ctx context.Context, hash common.Hash) ([]txtrace.ActionTrace, error) {
+	return s.b.TxTraceByHash(ctx, hash)
+}
+
+// Block returns the call traces of all the transactions of the given block.
+func (s *PublicTxTraceAPI) Block(ctx context.Context, number rpc.BlockNumber) ([]txtrace.ActionTrace, error) {
+	block, err := s.b.BlockByNumber(ctx, number)
+	if err != nil {
+		return nil, err
+	}
+	if block == nil {
+		return nil, fmt.Errorf("block %v not found", number)
+	}
+	traces := make([]txtrace.ActionTrace, 0, len(block.Transactions))
+	for _, tx := range block.Transactions {
+		txTraces, err := s.b.TxTraceByHash(ctx, tx.Hash())
+		if err != nil {
+			return nil, err
+		}
+		traces = append(traces, txTraces...)
+	}
+	return traces, nil
+}
+
+// TraceFilterArgs are the arguments of the trace_filter call
+type TraceFilterArgs struct {
+	FromBlock   *rpc.BlockNumber `json:"fromBlock"`
+	ToBlock     *rpc.BlockNumber `json:"toBlock"`
+	FromAddress []common.Address `json:"fromAddress"`
+	ToAddress   []common.Address `json:"toAddress"`
+	After       *uint64          `json:"after"`
+	Count       *uint64          `json:"count"`
+}
+
+func addressFilter(addresses []common.Address) map[common.Address]bool {
+	if len(addresses) == 0 {
+		return nil
+	}
+	set := make(map[common.Address]bool, len(addresses))
+	for _, addr := range addresses {
+		set[addr] = true
+	}
+	return set
+}
+
+// Filter returns the call traces matching the given block range and sender/receiver addresses.
+// If both address lists are set, a trace must match both of them.
+func (s *PublicTxTraceAPI) Filter(ctx context.Context, args TraceFilterArgs) ([]txtrace.ActionTrace, error) {
+	fromBlock, toBlock := rpc.LatestBlockNumber, rpc.LatestBlockNumber
+	if args.FromBlock != nil {
+		fromBlock = *args.FromBlock
+	}
+	if args.ToBlock != nil {
+		toBlock = *args.ToBlock
+	}
+	from, err := s.b.ResolveRpcBlockNumberOrHash(ctx, rpc.BlockNumberOrHashWithNumber(fromBlock))
+	if err != nil {
+		return nil, err
+	}
+	to, err := s.b.ResolveRpcBlockNumberOrHash(ctx, rpc.BlockNumberOrHashWithNumber(toBlock))
+	if err != nil {
+		return nil, err
+	}
+	if from > to {
+		return nil, errors.New("fromBlock is higher than toBlock")
+	}
+	if to-from >= maxTraceFilterBlocks {
+		return nil, fmt.Errorf("block range is too wide, max %d blocks", maxTraceFilterBlocks)
+	}
+
+	fromAddresses := addressFilter(args.FromAddress)
+	toAddresses := addressFilter(args.ToAddress)
+	var after, count uint64
+	if args.After != nil {
+		after = *args.After
+	}
+	if args.Count != nil {
+		count = *args.Count
+	}
+
+	traces := make([]txtrace.ActionTrace, 0)
+	matched := uint64(0)
+	for n := from; n <= to; n++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		block, err := s.b.BlockByNumber(ctx, rpc.BlockNumber(n))
+		if err != nil {
+			return nil, err
+		}
+		if block == nil {
+			break
+		}
+		for _, tx := range block.Transactions {
+			txTraces, err := s.b.TxTraceByHash(ctx, tx.Hash())
+			if err != nil {
+				return nil, err
+			}
+			for _, trace := range txTraces {
+				if fromAddresses != nil && !fromAddresses[trace.Sender()] {
+					continue
+				}
+				if toAddresses != nil && !toAddresses[trace.Receiver()] {
+					continue
+				}
+				matched++
+				if matched <= after {
+					continue
+				}
+				traces = append(traces, trace)
+				if count != 0 && uint64(len(traces)) >= count {
+					return traces, nil
+				}
+			}
+		}
+	}
+	return traces, nil
+}
@@ -15,6 +15,10 @@ var (
 		Name:  "export.evm.exclude",
 		Usage: `DB of EVM keys to exclude from genesis`,
 	}
+	GenesisExportDiff = cli.StringFlag{
+		Name:  "export.diff",
+		Usage: `Genesis files separated by comma (a base genesis followed by its diffs) to export a differential genesis over`,
+	}
 	GenesisExportSections = cli.StringFlag{
 		Name:  "export.sections",
 		Usage: `Genesis sections to export separated by comma (e.g. "brs-1" or "ers" or "evm-2")`,
@@ -99,13 +103,14 @@ be gzipped
 			{
 				Name:      "genesis",
 				Usage:     "Export current state into a genesis file",
-				ArgsUsage: "<filename or dry-run> [<epochFrom> <epochTo>] [--export.evm.mode=MODE --export.evm.exclude=DB_PATH --export.sections=A,B,C]",
+				ArgsUsage: "<filename or dry-run> [<epochFrom> <epochTo>] [--export.evm.mode=MODE --export.evm.exclude=DB_PATH --export.sections=A,B,C --export.diff=BASE,DIFF1,...]",
 				Action:    utils.MigrateFlags(exportGenesis),
 				Flags: []cli.Flag{
 					DataDirFlag,
 					EvmExportMode,
 					EvmExportExclude,
 					GenesisExportSections,
+					GenesisExportDiff,
 				},
 				Description: `
     arthera-node export genesis
@@ -116,6 +121,10 @@ Optional second and third arguments control the first and
 last epoch to write.
 Pass dry-run instead of filename for calculation of hashes without exporting data.
 EVM export mode is configured with --export.evm.mode.
+With --export.diff, a differential genesis is exported, which refers to the last genesis
+file of the given chain. It contains only the EVM items which are missing in the chain,
+and the epochs and blocks which follow the chain, unless the epochs range is specified.
+The differential genesis is applied with --genesis=BASE --genesis.diffs=DIFF1,DIFF2,...
 `,
 			},
 			{
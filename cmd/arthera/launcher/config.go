@@ -15,10 +15,10 @@ import (
 	"gopkg.in/urfave/cli.v1"
 	"math/big"
 	"os"
-	"path"
 	"path/filepath"
 	"reflect"
 	"strings"
+	"time"
 
 	"github.com/artheranet/arthera-node/genesis/genesisstore"
 	"github.com/artheranet/arthera-node/gossip"
@@ -28,6 +28,7 @@ import (
 	"github.com/artheranet/arthera-node/internal/evmcore"
 	"github.com/artheranet/arthera-node/internal/vecmt"
 	"github.com/artheranet/lachesis/abft"
+	"github.com/artheranet/lachesis/inter/idx"
 	"github.com/artheranet/lachesis/utils/cachescale"
 	"github.com/ethereum/go-ethereum/cmd/utils"
 	"github.com/ethereum/go-ethereum/common"
@@ -83,6 +84,11 @@ var (
 		Name:  "genesis.allowUnknown",
 		Usage: "Allow to use unknown genesis file.",
 	}
+	// GenesisDiffsFlag specifies differential genesis files to apply over the network genesis
+	GenesisDiffsFlag = cli.StringFlag{
+		Name:  "genesis.diffs",
+		Usage: "'paths to differential genesis files' separated by comma - the chain of diffs to apply over the --genesis file.",
+	}
 
 	RPCGlobalGasCapFlag = cli.Uint64Flag{
 		Name:  "rpc.gascap",
@@ -108,6 +114,15 @@ var (
 		Name:  "dummy.balance",
 		Usage: "Whether to enable a dummy balance for accounts that have a valid subscription",
 	}
+	TraceNodeFlag = cli.BoolFlag{
+		Name:  "tracenode",
+		Usage: "Record call traces of all applied transactions and enable the trace_ RPC namespace",
+	}
+	TxPoolSponsorSlotsFlag = cli.Uint64Flag{
+		Name:  "txpool.sponsorslots",
+		Usage: "Maximum number of transaction slots sponsored by a single contract subscription",
+		Value: evmcore.DefaultTxPoolConfig.SponsorSlots,
+	}
 
 	SyncModeFlag = cli.StringFlag{
 		Name:  "syncmode",
@@ -121,6 +136,43 @@ var (
 		Value: "archive",
 	}
 
+	StatePruningBlocksFlag = cli.Uint64Flag{
+		Name:  "statepruning.blocks",
+		Usage: "Number of latest block states to keep by the online state pruning (0 = disabled)",
+		Value: 0,
+	}
+	StatePruningIntervalFlag = cli.DurationFlag{
+		Name:  "statepruning.interval",
+		Usage: "Interval between the online state pruning runs",
+		Value: time.Hour,
+	}
+	StatePruningPauseFlag = cli.DurationFlag{
+		Name:  "statepruning.pause",
+		Usage: "Pause between the deletion batches of the online state pruning",
+		Value: 10 * time.Millisecond,
+	}
+	StatePruningBloomSizeFlag = cli.Uint64Flag{
+		Name:  "statepruning.bloomsize",
+		Usage: "Megabytes of memory allocated to the bloom-filter of the online state pruning",
+		Value: 512,
+	}
+
+	HistoryEpochsFlag = cli.Uint64Flag{
+		Name:  "history.epochs",
+		Usage: "Number of latest epochs whose events and LLR block votes are kept, the older ones are expired (0 = keep all)",
+		Value: 0,
+	}
+	HistoryBlocksFlag = cli.Uint64Flag{
+		Name:  "history.blocks",
+		Usage: "Number of latest blocks whose receipts and transaction lookups are kept, the older ones are expired (0 = keep all)",
+		Value: 0,
+	}
+	HistoryIntervalFlag = cli.DurationFlag{
+		Name:  "history.interval",
+		Usage: "Interval between the history expiry runs",
+		Value: time.Minute,
+	}
+
 	ExitWhenAgeFlag = cli.DurationFlag{
 		Name:  "exitwhensynced.age",
 		Usage: "Exits after synchronisation reaches the required age",
@@ -217,49 +269,95 @@ func mayGetGenesisStore(ctx *cli.Context) *genesisstore.Store {
 		}
 		return fake.FakeGenesisStore(num, utils2.ToArt(1_000_000_000), utils2.ToArt(5_000_000))
 	case ctx.GlobalIsSet(GenesisFlag.Name):
-		genesisPath := ctx.GlobalString(GenesisFlag.Name)
+		var diffs []string
+		if ctx.GlobalIsSet(GenesisDiffsFlag.Name) {
+			diffs = strings.Split(ctx.GlobalString(GenesisDiffsFlag.Name), ",")
+		}
+		genesisStore, _, err := openGenesisChain(ctx.GlobalString(GenesisFlag.Name), diffs, func(genesisStore *genesisstore.Store, genesisHashes genesis.Hashes) {
+			checkGenesisTrusted(ctx, genesisStore, genesisHashes)
+		})
+		if err != nil {
+			utils.Fatalf("Failed to read genesis file: %v", err)
+		}
+		return genesisStore
+	}
+	return nil
+}
 
-		f, err := os.Open(genesisPath)
+// openGenesisChain opens the base genesis file and applies the chain of differential genesis files over it.
+// Each differential genesis is checked against the hashes of the genesis which it declares as its base,
+// and every file of the chain is checked by the check callback, as a diff may be published by anyone.
+// It returns the store of the last file and its hashes.
+func openGenesisChain(basePath string, diffPaths []string, check func(*genesisstore.Store, genesis.Hashes)) (*genesisstore.Store, genesis.Hashes, error) {
+	open := func(path string) (*genesisstore.Store, genesis.Hashes, error) {
+		f, err := os.Open(path)
 		if err != nil {
-			utils.Fatalf("Failed to open genesis file: %v", err)
+			return nil, nil, err
 		}
 		genesisStore, genesisHashes, err := genesisstore.OpenGenesisStore(f)
 		if err != nil {
-			utils.Fatalf("Failed to read genesis file: %v", err)
+			_ = f.Close()
+			return nil, nil, fmt.Errorf("%s: %v", path, err)
 		}
+		return genesisStore, genesisHashes, nil
+	}
 
-		// check if it's a trusted preset
-		{
-			g := genesisStore.Genesis()
-			gHeader := genesis.Header{
-				GenesisID:   g.GenesisID,
-				NetworkID:   g.NetworkID,
-				NetworkName: g.NetworkName,
-			}
-			for _, allowed := range AllowedArtheraGenesis {
-				matchHashes := allowed.Hashes.Equal(genesisHashes)
-				matchHeader := allowed.Header.Equal(gHeader)
-				if matchHashes && matchHeader {
-					log.Info("Genesis file is a known preset", "name", allowed.Name)
-					goto notExperimental
-				}
-			}
-			if ctx.GlobalBool(ExperimentalGenesisFlag.Name) {
-				log.Warn("Genesis file doesn't refer to any trusted preset")
-			} else {
-				utils.Fatalf(
-					"Genesis file doesn't refer to any trusted preset: \n\tEpochsSection=%s \n\tBlocksSection=%s \n\tEvmSection=%s \n\tHeader=%s \nEnable unknown genesis with --genesis.allowUnknown",
-					genesisHashes[genesisstore.EpochsSection].String(),
-					genesisHashes[genesisstore.BlocksSection].String(),
-					genesisHashes[genesisstore.EvmSection].String(),
-					gHeader.GenesisID.String(),
-				)
-			}
-		notExperimental:
+	genesisStore, genesisHashes, err := open(basePath)
+	if err != nil {
+		return nil, nil, err
+	}
+	if _, ok := genesisHashes[genesisstore.BaseSection]; ok {
+		_ = genesisStore.Close()
+		return nil, nil, fmt.Errorf("%s: genesis file is differential, it has to be applied over its base genesis", basePath)
+	}
+	check(genesisStore, genesisHashes)
+	for _, diffPath := range diffPaths {
+		diffStore, diffHashes, err := open(diffPath)
+		if err != nil {
+			_ = genesisStore.Close()
+			return nil, nil, err
 		}
-		return genesisStore
+		err = diffStore.ApplyOver(genesisStore, genesisHashes)
+		if err != nil {
+			_ = diffStore.Close()
+			_ = genesisStore.Close()
+			return nil, nil, fmt.Errorf("%s: %v", diffPath, err)
+		}
+		// referring to a trusted base doesn't make the diff trusted, its own hashes have to be a known preset
+		check(diffStore, diffHashes)
+		log.Info("Differential genesis is applied", "file", diffPath)
+		genesisStore, genesisHashes = diffStore, diffHashes
+	}
+	return genesisStore, genesisHashes, nil
+}
+
+// checkGenesisTrusted checks if the genesis is a trusted preset, unknown genesis is allowed only with --genesis.allowUnknown
+func checkGenesisTrusted(ctx *cli.Context, genesisStore *genesisstore.Store, genesisHashes genesis.Hashes) {
+	g := genesisStore.Genesis()
+	gHeader := genesis.Header{
+		GenesisID:   g.GenesisID,
+		NetworkID:   g.NetworkID,
+		NetworkName: g.NetworkName,
+	}
+	for _, allowed := range AllowedArtheraGenesis {
+		matchHashes := allowed.Hashes.Equal(genesisHashes)
+		matchHeader := allowed.Header.Equal(gHeader)
+		if matchHashes && matchHeader {
+			log.Info("Genesis file is a known preset", "name", allowed.Name)
+			return
+		}
+	}
+	if ctx.GlobalBool(ExperimentalGenesisFlag.Name) {
+		log.Warn("Genesis file doesn't refer to any trusted preset")
+	} else {
+		utils.Fatalf(
+			"Genesis file doesn't refer to any trusted preset: \n\tEpochsSection=%s \n\tBlocksSection=%s \n\tEvmSection=%s \n\tHeader=%s \nEnable unknown genesis with --genesis.allowUnknown",
+			genesisHashes[genesisstore.EpochsSection].String(),
+			genesisHashes[genesisstore.BlocksSection].String(),
+			genesisHashes[genesisstore.EvmSection].String(),
+			gHeader.GenesisID.String(),
+		)
 	}
-	return nil
 }
 
 func setBootnodes(ctx *cli.Context, urls []string, cfg *node.Config) {
@@ -332,6 +430,9 @@ func setTxPool(ctx *cli.Context, cfg *evmcore.TxPoolConfig) {
 	if ctx.GlobalIsSet(utils.TxPoolGlobalQueueFlag.Name) {
 		cfg.GlobalQueue = ctx.GlobalUint64(utils.TxPoolGlobalQueueFlag.Name)
 	}
+	if ctx.GlobalIsSet(TxPoolSponsorSlotsFlag.Name) {
+		cfg.SponsorSlots = ctx.GlobalUint64(TxPoolSponsorSlotsFlag.Name)
+	}
 	if ctx.GlobalIsSet(utils.TxPoolLifetimeFlag.Name) {
 		cfg.Lifetime = ctx.GlobalDuration(utils.TxPoolLifetimeFlag.Name)
 	}
@@ -376,6 +477,30 @@ func gossipStoreConfigWithFlags(ctx *cli.Context, src gossip.StoreConfig) (gossi
 		cfg.EVM.Cache.TrieDirtyDisabled = ctx.GlobalString(utils.GCModeFlag.Name) == "archive"
 		cfg.EVM.Cache.GreedyGC = ctx.GlobalString(utils.GCModeFlag.Name) == "full"
 	}
+	if ctx.GlobalIsSet(StatePruningBlocksFlag.Name) {
+		cfg.EVM.Pruning.Blocks = ctx.GlobalUint64(StatePruningBlocksFlag.Name)
+	}
+	if ctx.GlobalIsSet(StatePruningIntervalFlag.Name) {
+		cfg.EVM.Pruning.Interval = ctx.GlobalDuration(StatePruningIntervalFlag.Name)
+	}
+	if ctx.GlobalIsSet(StatePruningPauseFlag.Name) {
+		cfg.EVM.Pruning.BatchPause = ctx.GlobalDuration(StatePruningPauseFlag.Name)
+	}
+	if ctx.GlobalIsSet(StatePruningBloomSizeFlag.Name) {
+		cfg.EVM.Pruning.BloomSize = ctx.GlobalUint64(StatePruningBloomSizeFlag.Name)
+	}
+	if ctx.GlobalIsSet(HistoryEpochsFlag.Name) {
+		cfg.History.Epochs = idx.Epoch(ctx.GlobalUint64(HistoryEpochsFlag.Name))
+	}
+	if ctx.GlobalIsSet(HistoryBlocksFlag.Name) {
+		cfg.History.Blocks = idx.Block(ctx.GlobalUint64(HistoryBlocksFlag.Name))
+	}
+	if ctx.GlobalIsSet(HistoryIntervalFlag.Name) {
+		cfg.History.Interval = ctx.GlobalDuration(HistoryIntervalFlag.Name)
+	}
+	if ctx.GlobalIsSet(TraceNodeFlag.Name) {
+		cfg.TraceTransactions = ctx.GlobalBool(TraceNodeFlag.Name)
+	}
 	return cfg, nil
 }
 
@@ -497,8 +622,12 @@ func mayMakeAllConfigs(ctx *cli.Context) (*config, error) {
 	if err != nil {
 		return nil, err
 	}
+	if cfg.Emitter.Validator.ID != 0 && len(cfg.Emitter.ProtectionDB.Path) == 0 {
+		cfg.Emitter.ProtectionDB.Path = protectionDBPath(cfg.Node)
+	}
 	if cfg.Emitter.Validator.ID != 0 && len(cfg.Emitter.PrevEmittedEventFile.Path) == 0 {
-		cfg.Emitter.PrevEmittedEventFile.Path = cfg.Node.ResolvePath(path.Join("emitter", fmt.Sprintf("last-%d", cfg.Emitter.Validator.ID)))
+		// legacy file to import into the protection DB
+		cfg.Emitter.PrevEmittedEventFile.Path = cfg.Node.ResolvePath(filepath.Join("emitter", fmt.Sprintf("last-%d", cfg.Emitter.Validator.ID)))
 	}
 	setTxPool(ctx, &cfg.TxPool)
 
@@ -5,6 +5,7 @@ import (
 	"github.com/artheranet/arthera-node/contracts/driver"
 	"github.com/artheranet/arthera-node/genesis"
 	"github.com/artheranet/arthera-node/genesis/builder"
+	"github.com/artheranet/arthera-node/genesis/genesisspec"
 	"github.com/artheranet/arthera-node/genesis/genesisstore"
 	"github.com/artheranet/arthera-node/internal/inter"
 	"github.com/artheranet/arthera-node/internal/inter/ibr"
@@ -34,6 +35,11 @@ var genesisTypeFlag = cli.StringFlag{
 	Value: "devnet",
 }
 
+var genesisSpecFlag = cli.StringFlag{
+	Name:  "genesis.spec",
+	Usage: "Path to a JSON or TOML genesis spec file, overrides the genesis type",
+}
+
 var (
 	createGenesisCommand = cli.Command{
 		Action:    utils.MigrateFlags(createGenesisCmd),
@@ -41,7 +47,7 @@ var (
 		Usage:     "Create genesis",
 		ArgsUsage: "<file>",
 		Category:  "MISCELLANEOUS COMMANDS",
-		Flags:     []cli.Flag{genesisTypeFlag},
+		Flags:     []cli.Flag{genesisTypeFlag, genesisSpecFlag},
 	}
 )
 
@@ -65,6 +71,20 @@ func createGenesisCmd(ctx *cli.Context) error {
 
 	fileName := ctx.Args().Get(0)
 
+	if ctx.GlobalIsSet(genesisSpecFlag.Name) {
+		specFile := ctx.GlobalString(genesisSpecFlag.Name)
+		spec, err := genesisspec.Load(specFile)
+		if err != nil {
+			return err
+		}
+		fmt.Println("Creating genesis from spec " + specFile)
+		genesisStore, currentHash, err := spec.Build()
+		if err != nil {
+			return err
+		}
+		return WriteGenesisStore(fileName, genesisStore, currentHash)
+	}
+
 	fmt.Println("Creating " + genesisType + " genesis")
 	genesisStore, currentHash := CreateGenesis(genesisType)
 	err := WriteGenesisStore(fileName, genesisStore, currentHash)
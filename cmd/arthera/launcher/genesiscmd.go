@@ -11,12 +11,14 @@ import (
 	"github.com/artheranet/arthera-node/gossip/evmstore"
 	"github.com/artheranet/arthera-node/internal/inter/ibr"
 	"github.com/artheranet/arthera-node/internal/inter/ier"
+	"github.com/artheranet/arthera-node/utils/dbutil/autocompact"
 	"github.com/artheranet/arthera-node/utils/devnullfile"
 	"github.com/artheranet/arthera-node/utils/iodb"
 	"github.com/artheranet/lachesis/common/bigendian"
 	"github.com/artheranet/lachesis/hash"
 	"github.com/artheranet/lachesis/inter/idx"
 	"github.com/artheranet/lachesis/kvdb"
+	"github.com/artheranet/lachesis/kvdb/batched"
 	"github.com/artheranet/lachesis/kvdb/pebble"
 	"github.com/ethereum/go-ethereum/cmd/utils"
 	"github.com/ethereum/go-ethereum/log"
@@ -200,6 +202,29 @@ func getEpochBlock(epoch idx.Epoch, store *gossip.Store) idx.Block {
 	return bs.LastBlock.Idx
 }
 
+// writeEvmKeys writes the keys of EVM items into a new DB
+func writeEvmKeys(items genesis.EvmItems, dir string) (kvdb.Store, error) {
+	db, err := pebble.New(dir, 1024*opt.MiB, utils.MakeDatabaseHandles()/2, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	keysDB := batched.Wrap(autocompact.Wrap2M(db, opt.GiB, 16*opt.GiB, true, "evm-keys"))
+	items.ForEach(func(key, _ []byte) bool {
+		if err == nil {
+			err = keysDB.Put(key, []byte{0})
+		}
+		return err == nil
+	})
+	if err == nil {
+		err = keysDB.Flush()
+	}
+	if err != nil {
+		_ = keysDB.Close()
+		return nil, err
+	}
+	return keysDB, nil
+}
+
 func exportGenesis(ctx *cli.Context) error {
 	if len(ctx.Args()) < 1 {
 		utils.Fatalf("This command requires an argument.")
@@ -226,6 +251,25 @@ func exportGenesis(ctx *cli.Context) error {
 		return errors.New("--export.evm.mode must be one of {full, ext-mpt, mpt}")
 	}
 
+	// the base of a differential genesis
+	var (
+		diffBase       *genesisstore.Store
+		diffBaseHashes genesis.Hashes
+	)
+	if diffChain := ctx.String(GenesisExportDiff.Name); len(diffChain) > 0 {
+		if mode == "full" {
+			// only the content-addressed items may be excluded by their keys
+			return errors.New("--export.diff requires --export.evm.mode to be one of {ext-mpt, mpt}")
+		}
+		chain := strings.Split(diffChain, ",")
+		var err error
+		diffBase, diffBaseHashes, err = openGenesisChain(chain[0], chain[1:], func(*genesisstore.Store, genesis.Hashes) {})
+		if err != nil {
+			return err
+		}
+		defer diffBase.Close()
+	}
+
 	var excludeEvmDB kvdb.Store
 	if excludeEvmDBPath := ctx.String(EvmExportExclude.Name); len(excludeEvmDBPath) > 0 {
 		db, err := pebble.New(excludeEvmDBPath, 1024*opt.MiB, utils.MakeDatabaseHandles()/2, nil, nil)
@@ -294,6 +338,40 @@ func exportGenesis(ctx *cli.Context) error {
 	if to > gdb.GetEpoch() {
 		to = gdb.GetEpoch()
 	}
+	if diffBase != nil {
+		if !diffBase.Header().Equal(header) {
+			return errors.New("genesis header doesn't match the base genesis")
+		}
+		var baseEpoch idx.Epoch
+		diffBase.Epochs().ForEach(func(er ier.LlrIdxFullEpochRecord) bool {
+			baseEpoch = er.Idx
+			return false
+		})
+		if len(ctx.Args()) < 2 {
+			// export only the epochs which follow the base genesis
+			from = baseEpoch + 1
+		}
+		log.Info("Exporting differential genesis", "base epoch", baseEpoch)
+
+		writer := newUnitWriter(plain)
+		err := writer.Start(header, genesisstore.BaseSection, tmpPath)
+		if err != nil {
+			return err
+		}
+		b, err := genesisstore.EncodeBaseHashes(diffBaseHashes)
+		if err != nil {
+			return err
+		}
+		_, err = writer.Write(b)
+		if err != nil {
+			return err
+		}
+		baseHash, err := writer.Flush()
+		if err != nil {
+			return err
+		}
+		fmt.Printf("- Base hash: %v \n", baseHash.String())
+	}
 	if len(sections["ers"]) > 0 {
 		log.Info("Exporting epochs", "from", from, "to", to)
 		writer := newUnitWriter(plain)
@@ -327,7 +405,7 @@ func exportGenesis(ctx *cli.Context) error {
 	if len(sections["brs"]) > 0 {
 		toBlock := getEpochBlock(to, gdb)
 		fromBlock := getEpochBlock(from, gdb)
-		if sections["brs"] != "brs" {
+		if sections["brs"] != "brs" || diffBase != nil {
 			// to continue prev section, include blocks of prev epochs too, excluding first blocks of prev epoch (which is last block if prev section)
 			fromBlock = getEpochBlock(from-1, gdb) + 1
 		}
@@ -353,6 +431,7 @@ func exportGenesis(ctx *cli.Context) error {
 			b, _ := rlp.EncodeToBytes(ibr.LlrIdxFullBlockRecord{
 				LlrFullBlockRecord: *br,
 				Idx:                i,
+				GasPaymentsRLP:     gdb.EvmStore().GetGasPaymentsRLP(i),
 			})
 			_, err := writer.Write(b)
 			if err != nil {
@@ -368,6 +447,16 @@ func exportGenesis(ctx *cli.Context) error {
 	}
 
 	if len(sections["evm"]) > 0 {
+		var baseKeysDB kvdb.Store
+		if diffBase != nil {
+			log.Info("Reading EVM keys of the base genesis")
+			var err error
+			baseKeysDB, err = writeEvmKeys(diffBase.RawEvmItems(), path.Join(tmpPath, "base-evm-keys"))
+			if err != nil {
+				return err
+			}
+			defer baseKeysDB.Close()
+		}
 		log.Info("Exporting EVM data")
 		writer := newUnitWriter(plain)
 		err := writer.Start(header, sections["evm"], tmpPath)
@@ -385,6 +474,10 @@ func exportGenesis(ctx *cli.Context) error {
 		if excludeEvmDB != nil {
 			it = excludingIterator{it, excludeEvmDB}
 		}
+		if baseKeysDB != nil {
+			// exclude the items of the base genesis
+			it = excludingIterator{it, baseKeysDB}
+		}
 		defer it.Release()
 		err = iodb.Write(writer, it)
 		if err != nil {
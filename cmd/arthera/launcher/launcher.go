@@ -23,6 +23,7 @@ import (
 	"github.com/artheranet/arthera-node/genesis"
 	"github.com/artheranet/arthera-node/genesis/genesisstore"
 	"github.com/artheranet/arthera-node/gossip"
+	"github.com/artheranet/arthera-node/graphql"
 	"github.com/artheranet/arthera-node/internal/dbconfig"
 	"github.com/artheranet/arthera-node/internal/evmcore"
 	"github.com/artheranet/arthera-node/internal/valkeystore"
@@ -104,11 +105,13 @@ func initFlags() {
 		utils.TxPoolGlobalSlotsFlag,
 		utils.TxPoolAccountQueueFlag,
 		utils.TxPoolGlobalQueueFlag,
+		TxPoolSponsorSlotsFlag,
 		utils.TxPoolLifetimeFlag,
 	}
 	artheraFlags = []cli.Flag{
 		GenesisFlag,
 		ExperimentalGenesisFlag,
+		GenesisDiffsFlag,
 		utils.IdentityFlag,
 		DataDirFlag,
 		utils.MinFreeDiskSpaceFlag,
@@ -121,9 +124,22 @@ func initFlags() {
 		configFileFlag,
 		validatorIDFlag,
 		validatorPubkeyFlag,
+		validatorNextPubkeyFlag,
 		validatorPasswordFlag,
+		validatorSignerFlag,
+		validatorSignerTokenFlag,
+		validatorSignerTLSCertFlag,
+		validatorSignerTLSKeyFlag,
+		validatorSignerTLSCAFlag,
 		SyncModeFlag,
 		GCModeFlag,
+		StatePruningBlocksFlag,
+		StatePruningIntervalFlag,
+		StatePruningPauseFlag,
+		StatePruningBloomSizeFlag,
+		HistoryEpochsFlag,
+		HistoryBlocksFlag,
+		HistoryIntervalFlag,
 		genesisTypeFlag,
 		TestnetFlag,
 		DevnetFlag,
@@ -156,6 +172,7 @@ func initFlags() {
 		RPCGlobalEVMTimeoutFlag,
 		RPCGlobalTimeoutFlag,
 		SubDummyBalanceFlag,
+		TraceNodeFlag,
 	}
 
 	metricsFlags = []cli.Flag{
@@ -332,14 +349,35 @@ func makeNode(ctx *cli.Context, cfg *config, genesisStore *genesisstore.Store) (
 		log.Info("Unlocked fake validator account", "address", coinbase.Address.Hex())
 	}
 
-	// unlock validator key
-	if !valPubkey.Empty() {
-		err := unlockValidatorKey(ctx, valPubkey, valKeystore)
+	var signer valkeystore.SignerI
+	if url := ctx.GlobalString(validatorSignerFlag.Name); url != "" {
+		// validator key is held by the remote signer
+		signerCfg, err := remoteSignerConfig(ctx)
 		if err != nil {
-			utils.Fatalf("Failed to unlock validator key: %v", err)
+			utils.Fatalf("Failed to read remote signer config: %v", err)
 		}
+		remoteSigner, err := makeRemoteSigner(url, signerCfg, valPubkey, cfg.Emitter.Validator.NextPubKey)
+		if err != nil {
+			utils.Fatalf("Failed to connect to remote signer: %v", err)
+		}
+		log.Info("Using remote validator signer", "url", url)
+		signer = remoteSigner
+	} else {
+		// unlock validator keys
+		if !valPubkey.Empty() {
+			err := unlockValidatorKey(ctx, valPubkey, valKeystore, 0)
+			if err != nil {
+				utils.Fatalf("Failed to unlock validator key: %v", err)
+			}
+		}
+		if nextPubkey := cfg.Emitter.Validator.NextPubKey; !nextPubkey.Empty() {
+			err := unlockValidatorKey(ctx, nextPubkey, valKeystore, 1)
+			if err != nil {
+				utils.Fatalf("Failed to unlock next validator key: %v", err)
+			}
+		}
+		signer = valkeystore.NewSigner(valKeystore)
 	}
-	signer := valkeystore.NewSigner(valKeystore)
 
 	// Create and register a gossip network service.
 	newTxPool := func(reader evmcore.StateReader) gossip.TxPool {
@@ -374,6 +412,12 @@ func makeNode(ctx *cli.Context, cfg *config, genesisStore *genesisstore.Store) (
 	}
 
 	stack.RegisterAPIs(svc.APIs())
+	if ctx.GlobalIsSet(utils.GraphQLEnabledFlag.Name) {
+		err = graphql.New(stack, svc.EthAPI, cfg.Arthera.FilterAPI, cfg.Node.GraphQLCors, cfg.Node.GraphQLVirtualHosts)
+		if err != nil {
+			utils.Fatalf("Failed to register the GraphQL service: %v", err)
+		}
+	}
 	stack.RegisterProtocols(svc.Protocols())
 	stack.RegisterLifecycle(svc)
 
@@ -196,6 +196,7 @@ func exportTxTraces(ctx *cli.Context) error {
 }
 
 func makeRawGossipStoreTrace(producer kvdb.FlushableDBProducer, cfg *config) (*gossip.Store, error) {
+	cfg.ArtheraStore.TraceTransactions = true
 	gdb := makeGossipStore(producer, cfg)
 
 	if gdb.TxTraceStore() == nil {
@@ -9,6 +9,7 @@ import (
 
 	"github.com/artheranet/arthera-node/gossip/emitter"
 	"github.com/artheranet/arthera-node/internal/inter/validatorpk"
+	"github.com/artheranet/arthera-node/internal/valkeystore"
 )
 
 var validatorIDFlag = cli.UintFlag{
@@ -23,12 +24,96 @@ var validatorPubkeyFlag = cli.StringFlag{
 	Value: "",
 }
 
+var validatorNextPubkeyFlag = cli.StringFlag{
+	Name:  "validator.nextpubkey",
+	Usage: "Public key the validator is being rotated to. The node switches to it at the epoch where the key change takes effect",
+	Value: "",
+}
+
 var validatorPasswordFlag = cli.StringFlag{
 	Name:  "validator.password",
 	Usage: "Password to unlock validator private key",
 	Value: "",
 }
 
+var validatorKeyTypeFlag = cli.StringFlag{
+	Name:  "keytype",
+	Usage: "Type of the validator key: secp256k1, ed25519 or bls12381",
+	Value: "secp256k1",
+}
+
+var validatorSignerFlag = cli.StringFlag{
+	Name:  "validator.signer",
+	Usage: "URL of a remote signer which holds the validator key (http://localhost:port, https://host:port or unix:///path/to/socket)",
+	Value: "",
+}
+
+var validatorSignerTokenFlag = cli.StringFlag{
+	Name:  "validator.signer.token",
+	Usage: "File of the bearer token of the remote signer",
+	Value: "",
+}
+
+var validatorSignerTLSCertFlag = cli.StringFlag{
+	Name:  "validator.signer.tlscert",
+	Usage: "TLS client certificate file for the remote signer (mTLS)",
+	Value: "",
+}
+
+var validatorSignerTLSKeyFlag = cli.StringFlag{
+	Name:  "validator.signer.tlskey",
+	Usage: "TLS client private key file for the remote signer (mTLS)",
+	Value: "",
+}
+
+var validatorSignerTLSCAFlag = cli.StringFlag{
+	Name:  "validator.signer.tlsca",
+	Usage: "CA file to verify the remote signer certificate against",
+	Value: "",
+}
+
+// remoteSignerConfig reads the authentication of the remote signer connection from the flags
+func remoteSignerConfig(ctx *cli.Context) (valkeystore.RemoteSignerConfig, error) {
+	var cfg valkeystore.RemoteSignerConfig
+	if path := ctx.GlobalString(validatorSignerTokenFlag.Name); path != "" {
+		token, err := valkeystore.ReadTokenFile(path)
+		if err != nil {
+			return cfg, err
+		}
+		cfg.Token = token
+	}
+	tlsConfig, err := valkeystore.LoadClientTLSConfig(
+		ctx.GlobalString(validatorSignerTLSCertFlag.Name),
+		ctx.GlobalString(validatorSignerTLSKeyFlag.Name),
+		ctx.GlobalString(validatorSignerTLSCAFlag.Name))
+	if err != nil {
+		return cfg, err
+	}
+	cfg.TLS = tlsConfig
+	return cfg, nil
+}
+
+// makeRemoteSigner connects to the remote signer and checks that it holds the validator keys
+func makeRemoteSigner(url string, cfg valkeystore.RemoteSignerConfig, pubkeys ...validatorpk.PubKey) (*valkeystore.RemoteSigner, error) {
+	signer, err := valkeystore.NewRemoteSigner(url, cfg)
+	if err != nil {
+		return nil, err
+	}
+	for _, pubkey := range pubkeys {
+		if pubkey.Empty() {
+			continue
+		}
+		has, err := signer.Has(pubkey)
+		if err != nil {
+			return nil, err
+		}
+		if !has {
+			return nil, errors.Errorf("remote signer doesn't hold the validator key %s", pubkey.String())
+		}
+	}
+	return signer, nil
+}
+
 // setValidatorID retrieves the validator ID either from the directly specified
 // command line flags or from the keystore if CLI indexed.
 func setValidator(ctx *cli.Context, cfg *emitter.Config) error {
@@ -60,8 +145,19 @@ func setValidator(ctx *cli.Context, cfg *emitter.Config) error {
 		cfg.Validator.PubKey = pk
 	}
 
+	if ctx.GlobalIsSet(validatorNextPubkeyFlag.Name) {
+		pk, err := validatorpk.FromString(ctx.GlobalString(validatorNextPubkeyFlag.Name))
+		if err != nil {
+			return err
+		}
+		cfg.Validator.NextPubKey = pk
+	}
+
 	if cfg.Validator.ID != 0 && cfg.Validator.PubKey.Empty() {
 		return errors.New("validator public key is not set")
 	}
+	if !cfg.Validator.NextPubKey.Empty() && cfg.Validator.NextPubKey.Equal(cfg.Validator.PubKey) {
+		return errors.New("next validator public key is the same as the current one")
+	}
 	return nil
 }
@@ -4,17 +4,31 @@ import (
 	"crypto/ecdsa"
 	"crypto/rand"
 	"fmt"
+	"math/big"
+	"os"
 	"path"
+	"strconv"
 	"strings"
+	"text/tabwriter"
+	"time"
 
 	"github.com/ethereum/go-ethereum/cmd/utils"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/node"
 	"gopkg.in/urfave/cli.v1"
 
+	"github.com/artheranet/lachesis/inter/idx"
+
+	"github.com/artheranet/arthera-node/contracts"
+	"github.com/artheranet/arthera-node/contracts/abis"
 	"github.com/artheranet/arthera-node/internal/inter/validatorpk"
+	"github.com/artheranet/arthera-node/internal/slashprotection"
 	"github.com/artheranet/arthera-node/internal/valkeystore"
 	"github.com/artheranet/arthera-node/internal/valkeystore/encryption"
+	"github.com/artheranet/arthera-node/internal/valsig"
 )
 
 var (
@@ -50,12 +64,17 @@ Make sure you backup your keys regularly.`,
 					DataDirFlag,
 					utils.KeyStoreDirFlag,
 					utils.PasswordFileFlag,
+					validatorKeyTypeFlag,
 				},
 				Description: `
-    arthera validator new
+    arthera validator new [--keytype bls12381]
 
 Creates a new validator private key and prints the public key.
 
+The key type is one of secp256k1 (default), ed25519 or bls12381.
+Keys of types other than secp256k1 may be used only after the network
+has enabled them, and are set as the validator key via UpdateValidatorPubkey.
+
 The key is saved in encrypted format, you are prompted for a passphrase.
 
 You must remember this passphrase to unlock your key in the future.
@@ -95,6 +114,93 @@ Converts an account private key to a validator private key and saves in the vali
 Prints the address of the validator.
 `,
 			},
+			{
+				Name:   "rotate",
+				Usage:  "Create a new key to rotate the validator key to",
+				Action: utils.MigrateFlags(validatorKeyRotate),
+				Flags: []cli.Flag{
+					DataDirFlag,
+					utils.KeyStoreDirFlag,
+					utils.PasswordFileFlag,
+					validatorKeyTypeFlag,
+				},
+				ArgsUsage: "<validator ID>",
+				Description: `
+    arthera validator rotate 1 [--keytype bls12381]
+
+Creates a new validator key and prints the steps to rotate the validator to it:
+
+1. Restart the node with the new key passed as --validator.nextpubkey along with
+   the current --validator.pubkey. Both keys are unlocked, the passwords are taken
+   from the consecutive lines of the --validator.password file.
+2. Submit the key change, which is applied by UpdateValidatorPubkey.
+3. The node keeps signing with the current key until the epoch where the change
+   takes effect, then it switches to the new key automatically.
+4. Once switched, restart the node with the new key as --validator.pubkey.
+
+The node refuses to emit events if neither key matches the validator profile of the epoch.
+`,
+			},
+			{
+				Name:   "report",
+				Usage:  "Print the per-epoch performance history of a validator",
+				Action: utils.MigrateFlags(validatorReport),
+				Flags: []cli.Flag{
+					DataDirFlag,
+				},
+				ArgsUsage: "<validator ID> [<from epoch> [<to epoch>]]",
+				Description: `
+    arthera validator report 1 100 200
+
+Prints uptime, missed blocks, originated fees, confirmed events, LLR votes and
+misbehaviour proofs of the validator for every epoch within the range, inclusive.
+By default, the last 100 epochs are reported. The node must be stopped.
+`,
+			},
+			{
+				Name:     "protection",
+				Usage:    "Manage the doublesign protection DB",
+				Category: "VALIDATOR COMMANDS",
+				Description: `
+
+The doublesign protection DB keeps every event, block votes range and epoch vote
+signed by the validator keys of this node, and the node refuses to sign anything
+which conflicts with them.
+
+When moving a validator to another host, export the records on the old host and
+import them on the new host before starting it. The node must be stopped.`,
+				Subcommands: []cli.Command{
+					{
+						Name:      "export",
+						Usage:     "Export the doublesign protection records",
+						Action:    utils.MigrateFlags(validatorProtectionExport),
+						ArgsUsage: "<filename>",
+						Flags: []cli.Flag{
+							DataDirFlag,
+						},
+						Description: `
+    arthera validator protection export protection.json
+
+Exports the doublesign protection records in the interchange format.
+`,
+					},
+					{
+						Name:      "import",
+						Usage:     "Import the doublesign protection records",
+						Action:    utils.MigrateFlags(validatorProtectionImport),
+						ArgsUsage: "<filename>",
+						Flags: []cli.Flag{
+							DataDirFlag,
+						},
+						Description: `
+    arthera validator protection import protection.json
+
+Merges the doublesign protection records in the interchange format into the DB.
+The existing records take priority over the conflicting imported ones.
+`,
+					},
+				},
+			},
 		},
 	}
 )
@@ -117,7 +223,10 @@ func validatorPrintAddress(ctx *cli.Context) error {
 		utils.Fatalf("Failed to decode the validator key: %v", err)
 	}
 
-	privKey := privkey.Decoded.(*ecdsa.PrivateKey)
+	privKey, ok := privkey.Decoded.(*ecdsa.PrivateKey)
+	if !ok {
+		utils.Fatalf("Validator keys of type %#x have no address", pubkey.Type)
+	}
 	addr := crypto.PubkeyToAddress(privKey.PublicKey)
 
 	fmt.Printf("Address:		%s\n", addr.Hex())
@@ -125,21 +234,17 @@ func validatorPrintAddress(ctx *cli.Context) error {
 	return nil
 }
 
-// validatorKeyCreate creates a new validator key into the keystore defined by the CLI flags.
-func validatorKeyCreate(ctx *cli.Context) error {
-	cfg := makeAllConfigs(ctx)
-	utils.SetNodeConfig(ctx, &cfg.Node)
-
+// createValidatorKey generates a new validator key of the type defined by the CLI flags and saves it into the keystore.
+func createValidatorKey(ctx *cli.Context, cfg *config) (validatorpk.PubKey, string) {
 	password := getPassPhrase("Your new validator key is locked with a password. Please give a password. Do not forget this password.", true, 0, utils.MakePasswordList(ctx))
 
-	privateKeyECDSA, err := ecdsa.GenerateKey(crypto.S256(), rand.Reader)
+	keyType, err := valsig.TypeOf(ctx.String(validatorKeyTypeFlag.Name))
 	if err != nil {
 		utils.Fatalf("Failed to create account: %v", err)
 	}
-	privateKey := crypto.FromECDSA(privateKeyECDSA)
-	publicKey := validatorpk.PubKey{
-		Raw:  crypto.FromECDSAPub(&privateKeyECDSA.PublicKey),
-		Type: validatorpk.Types.Secp256k1,
+	privateKey, publicKey, err := valsig.GenerateKey(keyType, rand.Reader)
+	if err != nil {
+		utils.Fatalf("Failed to create account: %v", err)
 	}
 
 	valKeystore := valkeystore.NewDefaultFileRawKeystore(path.Join(getValKeystoreDir(cfg.Node), "validator"))
@@ -153,13 +258,19 @@ func validatorKeyCreate(ctx *cli.Context) error {
 	if err != nil {
 		utils.Fatalf("Failed to decrypt the account: %v", err)
 	}
+	return publicKey, valKeystore.PathOf(publicKey)
+}
 
-	//addr := crypto.PubkeyToAddress(privateKeyECDSA.PublicKey)
+// validatorKeyCreate creates a new validator key into the keystore defined by the CLI flags.
+func validatorKeyCreate(ctx *cli.Context) error {
+	cfg := makeAllConfigs(ctx)
+	utils.SetNodeConfig(ctx, &cfg.Node)
+
+	publicKey, keyPath := createValidatorKey(ctx, cfg)
 
 	fmt.Printf("\nYour new key was generated\n\n")
 	fmt.Printf("Public key: 		%s\n", publicKey.String())
-	//fmt.Printf("   Address: 		%s\n", addr)
-	fmt.Printf("Path of the secret key file: %s\n\n", valKeystore.PathOf(publicKey))
+	fmt.Printf("Path of the secret key file: %s\n\n", keyPath)
 	fmt.Printf("- You can share your public key with anyone. Others need it to validate messages from you.\n")
 	fmt.Printf("- You must NEVER share the secret key with anyone! The key controls access to your validator!\n")
 	fmt.Printf("- You must BACKUP your key file! Without the key, it's impossible to operate the validator!\n")
@@ -167,6 +278,42 @@ func validatorKeyCreate(ctx *cli.Context) error {
 	return nil
 }
 
+// validatorKeyRotate creates a new validator key and prints the steps to rotate the validator to it.
+func validatorKeyRotate(ctx *cli.Context) error {
+	if len(ctx.Args()) < 1 {
+		utils.Fatalf("This command requires an argument.")
+	}
+	vid, err := strconv.ParseUint(ctx.Args().First(), 10, 32)
+	if err != nil || vid == 0 {
+		utils.Fatalf("Invalid validator ID %q", ctx.Args().First())
+	}
+	cfg := makeAllConfigs(ctx)
+	utils.SetNodeConfig(ctx, &cfg.Node)
+
+	publicKey, keyPath := createValidatorKey(ctx, cfg)
+
+	calldata, err := abis.NodeDriver.Pack("updateValidatorPubkey", new(big.Int).SetUint64(vid), publicKey.Bytes())
+	if err != nil {
+		utils.Fatalf("Failed to pack the key change: %v", err)
+	}
+
+	fmt.Printf("\nYour new key was generated\n\n")
+	fmt.Printf("Public key: 		%s\n", publicKey.String())
+	fmt.Printf("Path of the secret key file: %s\n\n", keyPath)
+	fmt.Printf("To rotate validator %d to the new key:\n\n", vid)
+	fmt.Printf("1. Restart the node with the additional flag:\n")
+	fmt.Printf("	--%s %s\n", validatorNextPubkeyFlag.Name, publicKey.String())
+	fmt.Printf("   If --%s is used, add the password of the new key as the second line of the file.\n", validatorPasswordFlag.Name)
+	fmt.Printf("2. Submit the key change. The NodeDriver call, accepted only from the driver backend, is:\n")
+	fmt.Printf("	to:   %s\n", contracts.NodeDriverSmartContractAddress.Hex())
+	fmt.Printf("	data: %s\n", hexutil.Encode(calldata))
+	fmt.Printf("3. The node switches to the new key at the epoch where the change takes effect.\n")
+	fmt.Printf("   Then restart it with --%s %s and without --%s.\n\n", validatorPubkeyFlag.Name, publicKey.String(), validatorNextPubkeyFlag.Name)
+	fmt.Printf("- You must BACKUP the new key file and REMEMBER its password!\n")
+	fmt.Printf("- Keep the old key until the switch, the validator can't emit events if neither key matches.\n\n")
+	return nil
+}
+
 // validatorKeyConvert converts account key to validator key.
 func validatorKeyConvert(ctx *cli.Context) error {
 	if len(ctx.Args()) < 2 {
@@ -182,6 +329,10 @@ func validatorKeyConvert(ctx *cli.Context) error {
 	if err != nil {
 		utils.Fatalf("Failed to decode the validator pubkey: %v", err)
 	}
+	if pubkey.Type != validatorpk.Types.Secp256k1 {
+		// account keys are secp256k1 keys, other key types are created with 'validator new'
+		utils.Fatalf("Account keys may be converted only to secp256k1 validator keys")
+	}
 
 	var acckeypath string
 	if strings.HasPrefix(ctx.Args().First(), "0x") {
@@ -201,3 +352,115 @@ func validatorKeyConvert(ctx *cli.Context) error {
 	fmt.Println("\nYour key was converted and saved to " + valkeypath)
 	return nil
 }
+
+// validatorReport prints the recorded per-epoch metrics of a validator.
+func validatorReport(ctx *cli.Context) error {
+	if len(ctx.Args()) < 1 {
+		utils.Fatalf("This command requires an argument.")
+	}
+	parseUint := func(i int) uint64 {
+		n, err := strconv.ParseUint(ctx.Args().Get(i), 10, 32)
+		if err != nil {
+			utils.Fatalf("Invalid argument %q: %v", ctx.Args().Get(i), err)
+		}
+		return n
+	}
+	vid := idx.ValidatorID(parseUint(0))
+
+	cfg := makeAllConfigs(ctx)
+
+	rawDbs := makeDirectDBsProducer(cfg)
+	gdb := makeGossipStore(rawDbs, cfg)
+	defer gdb.Close()
+
+	to := gdb.GetEpoch()
+	from := idx.Epoch(1)
+	if to > 100 {
+		from = to - 99
+	}
+	if len(ctx.Args()) > 1 {
+		from = idx.Epoch(parseUint(1))
+	}
+	if len(ctx.Args()) > 2 {
+		to = idx.Epoch(parseUint(2))
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "EPOCH\tWEIGHT\tUPTIME\tMISSED BLOCKS\tMISSED TIME\tORIGINATED FEE\tEVENTS\tBLOCK VOTES\tEPOCH VOTES\tMPS\tCHEATER")
+	for epoch := from; epoch <= to; epoch++ {
+		m := gdb.GetValidatorEpochMetrics(epoch, vid)
+		if m == nil {
+			continue
+		}
+		if !m.Sealed {
+			fmt.Fprintf(w, "%d\t-\t-\t-\t-\t-\t%d\t%d\t%d\t%d\t-\n",
+				epoch, m.Events, m.BlockVotes, m.EpochVotes, m.MisbehaviourProofs)
+			continue
+		}
+		missed := fmt.Sprintf("%d", m.MissedBlocks)
+		if m.DowntimeForgiven {
+			missed += " (forgiven)"
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\t%d\t%d\t%d\t%d\t%t\n",
+			epoch, m.Weight, time.Duration(m.Uptime), missed, time.Duration(m.MissedTime), m.OriginatedFee,
+			m.Events, m.BlockVotes, m.EpochVotes, m.MisbehaviourProofs, m.Cheater)
+	}
+	return w.Flush()
+}
+
+// protectionDBPath returns the default path of the doublesign protection DB
+func protectionDBPath(cfg node.Config) string {
+	return cfg.ResolvePath(path.Join("emitter", "protection"))
+}
+
+func openProtectionDB(ctx *cli.Context) *slashprotection.DB {
+	cfg := makeAllConfigs(ctx)
+	dir := cfg.Emitter.ProtectionDB.Path
+	if len(dir) == 0 {
+		dir = protectionDBPath(cfg.Node)
+	}
+	db, err := slashprotection.Open(dir, true)
+	if err != nil {
+		utils.Fatalf("Failed to open doublesign protection DB: %v", err)
+	}
+	return db
+}
+
+func validatorProtectionExport(ctx *cli.Context) error {
+	if len(ctx.Args()) < 1 {
+		utils.Fatalf("This command requires an argument.")
+	}
+	db := openProtectionDB(ctx)
+	defer db.Close()
+
+	fh, err := os.OpenFile(ctx.Args().First(), os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	defer fh.Close()
+	if err := db.Export(fh); err != nil {
+		return err
+	}
+	log.Info("Exported doublesign protection records", "file", ctx.Args().First())
+	return nil
+}
+
+func validatorProtectionImport(ctx *cli.Context) error {
+	if len(ctx.Args()) < 1 {
+		utils.Fatalf("This command requires an argument.")
+	}
+	db := openProtectionDB(ctx)
+	defer db.Close()
+
+	fh, err := os.Open(ctx.Args().First())
+	if err != nil {
+		return err
+	}
+	defer fh.Close()
+	imported, err := db.Import(fh)
+	if err != nil {
+		return err
+	}
+	log.Info("Imported doublesign protection records", "file", ctx.Args().First(), "records", imported)
+	return nil
+}
@@ -54,14 +54,14 @@ func makeValidatorPasswordList(ctx *cli.Context) []string {
 	return nil
 }
 
-func unlockValidatorKey(ctx *cli.Context, pubKey validatorpk.PubKey, valKeystore valkeystore.KeystoreI) error {
+func unlockValidatorKey(ctx *cli.Context, pubKey validatorpk.PubKey, valKeystore valkeystore.KeystoreI, i int) error {
 	if !valKeystore.Has(pubKey) {
 		return valkeystore.ErrNotFound
 	}
 	var err error
 	for trials := 0; trials < 3; trials++ {
 		prompt := fmt.Sprintf("Unlocking validator key %s | Attempt %d/%d", pubKey.String(), trials+1, 3)
-		password := getPassPhrase(prompt, false, 0, makeValidatorPasswordList(ctx))
+		password := getPassPhrase(prompt, false, i, makeValidatorPasswordList(ctx))
 		err = valKeystore.Unlock(pubKey, password)
 		if err == nil {
 			log.Info("Unlocked validator key", "pubkey", pubKey.String())
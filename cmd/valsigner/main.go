@@ -0,0 +1,209 @@
+// valsigner is a minimal remote signer for validator keys.
+// It serves the keys of a local validator keystore and enforces the doublesign protection.
+package main
+
+import (
+	"crypto/tls"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/log"
+	cli "gopkg.in/urfave/cli.v1"
+
+	"github.com/artheranet/arthera-node/internal/inter/validatorpk"
+	"github.com/artheranet/arthera-node/internal/slashprotection"
+	"github.com/artheranet/arthera-node/internal/valkeystore"
+)
+
+var (
+	keystoreFlag = cli.StringFlag{
+		Name:  "keystore",
+		Usage: "Directory of the validator keystore",
+	}
+	pubkeyFlag = cli.StringSliceFlag{
+		Name:  "pubkey",
+		Usage: "Public key of a validator key to serve",
+	}
+	passwordFlag = cli.StringFlag{
+		Name:  "password",
+		Usage: "Password file to unlock the validator keys, one password per line",
+	}
+	listenFlag = cli.StringFlag{
+		Name:  "listen",
+		Usage: "TCP address (host:port) or unix socket path to listen on, a non-loopback address requires mTLS",
+		Value: "127.0.0.1:18545",
+	}
+	tokenFileFlag = cli.StringFlag{
+		Name:  "token-file",
+		Usage: "File of the bearer token which the clients have to present, required for TCP unless mTLS is used",
+	}
+	tlsCertFlag = cli.StringFlag{
+		Name:  "tls.cert",
+		Usage: "TLS certificate file of the server",
+	}
+	tlsKeyFlag = cli.StringFlag{
+		Name:  "tls.key",
+		Usage: "TLS private key file of the server",
+	}
+	tlsClientCAFlag = cli.StringFlag{
+		Name:  "tls.clientca",
+		Usage: "CA file to verify the client certificates against (mTLS)",
+	}
+	protectionFlag = cli.StringFlag{
+		Name:  "protection",
+		Usage: "Directory of the doublesign protection DB",
+	}
+	allowRawFlag = cli.BoolFlag{
+		Name:  "allow-raw",
+		Usage: "Allow signing of raw digests, which bypasses the doublesign protection",
+	}
+)
+
+func main() {
+	log.Root().SetHandler(log.LvlFilterHandler(log.LvlInfo, log.StreamHandler(os.Stderr, log.TerminalFormat(false))))
+
+	app := cli.NewApp()
+	app.Name = "valsigner"
+	app.Usage = "remote signer for validator keys"
+	app.Flags = []cli.Flag{
+		keystoreFlag,
+		pubkeyFlag,
+		passwordFlag,
+		listenFlag,
+		tokenFileFlag,
+		tlsCertFlag,
+		tlsKeyFlag,
+		tlsClientCAFlag,
+		protectionFlag,
+		allowRawFlag,
+	}
+	app.Action = run
+	if err := app.Run(os.Args); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func readPasswords(path string) ([]string, error) {
+	if path == "" {
+		return nil, nil
+	}
+	text, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	lines := strings.Split(string(text), "\n")
+	for i := range lines {
+		lines[i] = strings.TrimRight(lines[i], "\r")
+	}
+	return lines, nil
+}
+
+func isUnixSocket(addr string) bool {
+	return strings.Contains(addr, "/")
+}
+
+func listen(addr string) (net.Listener, error) {
+	if isUnixSocket(addr) {
+		_ = os.Remove(addr)
+		return net.Listen("unix", addr)
+	}
+	return net.Listen("tcp", addr)
+}
+
+// checkListenAuth refuses to serve TCP without an authentication,
+// and to serve a non-loopback address without mTLS
+func checkListenAuth(addr string, token bool, mtls bool) error {
+	if isUnixSocket(addr) {
+		return nil
+	}
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return err
+	}
+	if !valkeystore.IsLoopbackHost(host) && !mtls {
+		return fmt.Errorf("listening on the non-loopback address %s requires --%s and --%s", addr, tlsCertFlag.Name, tlsClientCAFlag.Name)
+	}
+	if !token && !mtls {
+		return fmt.Errorf("listening on TCP requires --%s or mTLS", tokenFileFlag.Name)
+	}
+	return nil
+}
+
+func run(ctx *cli.Context) error {
+	if ctx.String(keystoreFlag.Name) == "" || ctx.String(protectionFlag.Name) == "" {
+		return fmt.Errorf("--%s and --%s are required", keystoreFlag.Name, protectionFlag.Name)
+	}
+	var token string
+	if path := ctx.String(tokenFileFlag.Name); path != "" {
+		var err error
+		token, err = valkeystore.ReadTokenFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read token file: %v", err)
+		}
+	}
+	var tlsConfig *tls.Config
+	if ctx.String(tlsCertFlag.Name) != "" {
+		var err error
+		tlsConfig, err = valkeystore.LoadServerTLSConfig(ctx.String(tlsCertFlag.Name), ctx.String(tlsKeyFlag.Name), ctx.String(tlsClientCAFlag.Name))
+		if err != nil {
+			return fmt.Errorf("failed to load TLS config: %v", err)
+		}
+	} else if ctx.String(tlsClientCAFlag.Name) != "" {
+		return fmt.Errorf("--%s requires --%s", tlsClientCAFlag.Name, tlsCertFlag.Name)
+	}
+	mtls := tlsConfig != nil && tlsConfig.ClientAuth == tls.RequireAndVerifyClientCert
+	if err := checkListenAuth(ctx.String(listenFlag.Name), token != "", mtls); err != nil {
+		return err
+	}
+
+	passwords, err := readPasswords(ctx.String(passwordFlag.Name))
+	if err != nil {
+		return fmt.Errorf("failed to read password file: %v", err)
+	}
+
+	keystore := valkeystore.NewDefaultFileKeystore(ctx.String(keystoreFlag.Name))
+	pubkeys := make([]validatorpk.PubKey, 0, len(ctx.StringSlice(pubkeyFlag.Name)))
+	for i, str := range ctx.StringSlice(pubkeyFlag.Name) {
+		pubkey, err := validatorpk.FromString(str)
+		if err != nil {
+			return err
+		}
+		password := ""
+		if i < len(passwords) {
+			password = passwords[i]
+		}
+		if err := keystore.Unlock(pubkey, password); err != nil {
+			return fmt.Errorf("failed to unlock validator key %s: %v", str, err)
+		}
+		log.Info("Unlocked validator key", "pubkey", str)
+		pubkeys = append(pubkeys, pubkey)
+	}
+
+	protection, err := slashprotection.Open(ctx.String(protectionFlag.Name), true)
+	if err != nil {
+		return fmt.Errorf("failed to open doublesign protection DB: %v", err)
+	}
+	defer protection.Close()
+
+	server := valkeystore.NewSignerServer(
+		valkeystore.NewSigner(keystore),
+		pubkeys,
+		protection,
+		ctx.Bool(allowRawFlag.Name),
+		token)
+
+	listener, err := listen(ctx.String(listenFlag.Name))
+	if err != nil {
+		return err
+	}
+	if tlsConfig != nil {
+		listener = tls.NewListener(listener, tlsConfig)
+	}
+	log.Info("Serving validator signer", "addr", listener.Addr().String(), "tls", tlsConfig != nil, "mtls", mtls, "token", token != "")
+	return http.Serve(listener, server)
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"github.com/artheranet/arthera-node/contracts"
 	"github.com/artheranet/arthera-node/contracts/abis"
+	"github.com/artheranet/arthera-node/contracts/precompiles"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/params"
@@ -36,22 +37,30 @@ func init() {
 	}
 }
 
-type PreCompiledContract struct{}
+// PreCompiledContract lets the NodeDriver contract modify the EVM state.
+// Calls are metered according to the GasRules, only the legacy flat costs are charged if GasRules is nil.
+type PreCompiledContract struct {
+	GasRules *precompiles.GasRules
+}
 
-func (_ PreCompiledContract) Run(evm *vm.EVM, caller common.Address, input []byte, suppliedGas uint64) ([]byte, uint64, error) {
+func (c PreCompiledContract) Run(evm *vm.EVM, caller common.Address, input []byte, suppliedGas uint64) ([]byte, uint64, error) {
 	if caller != contracts.NodeDriverSmartContractAddress {
 		return nil, 0, vm.ErrExecutionReverted
 	}
 	if len(input) < 4 {
 		return nil, 0, vm.ErrExecutionReverted
 	}
+	m := precompiles.NewMeter(evm, contracts.EvmWriterSmartContractAddress, c.GasRules, suppliedGas)
+	method, err := abis.EVMWriter.MethodById(input[:4])
+	if err != nil {
+		return nil, 0, vm.ErrExecutionReverted
+	}
+	if !m.Method(method.Name) {
+		return nil, 0, vm.ErrOutOfGas
+	}
 	if bytes.Equal(input[:4], setBalanceMethodID) {
 		input = input[4:]
 		// setBalance
-		if suppliedGas < params.CallValueTransferGas {
-			return nil, 0, vm.ErrOutOfGas
-		}
-		suppliedGas -= params.CallValueTransferGas
 		if len(input) != 64 {
 			return nil, 0, vm.ErrExecutionReverted
 		}
@@ -59,6 +68,9 @@ func (_ PreCompiledContract) Run(evm *vm.EVM, caller common.Address, input []byt
 		acc := common.BytesToAddress(input[12:32])
 		input = input[32:]
 		value := new(big.Int).SetBytes(input[:32])
+		if !m.Account(acc) {
+			return nil, 0, vm.ErrOutOfGas
+		}
 
 		if acc == evm.TxContext.Origin {
 			// Origin balance shouldn't decrease during his transaction
@@ -76,10 +88,6 @@ func (_ PreCompiledContract) Run(evm *vm.EVM, caller common.Address, input []byt
 	} else if bytes.Equal(input[:4], copyCodeMethodID) {
 		input = input[4:]
 		// copyCode
-		if suppliedGas < params.CreateGas {
-			return nil, 0, vm.ErrOutOfGas
-		}
-		suppliedGas -= params.CreateGas
 		if len(input) != 64 {
 			return nil, 0, vm.ErrExecutionReverted
 		}
@@ -87,27 +95,24 @@ func (_ PreCompiledContract) Run(evm *vm.EVM, caller common.Address, input []byt
 		accTo := common.BytesToAddress(input[12:32])
 		input = input[32:]
 		accFrom := common.BytesToAddress(input[12:32])
+		if !m.Account(accFrom) || !m.Account(accTo) {
+			return nil, 0, vm.ErrOutOfGas
+		}
 
 		code := evm.StateDB.GetCode(accFrom)
 		if code == nil {
 			code = []byte{}
 		}
 		cost := uint64(len(code)) * (params.CreateDataGas + params.MemoryGas)
-		if suppliedGas < cost {
+		if !m.UseGas(cost) {
 			return nil, 0, vm.ErrOutOfGas
 		}
-		suppliedGas -= cost
 		if accTo != accFrom {
 			evm.StateDB.SetCode(accTo, code)
 		}
 	} else if bytes.Equal(input[:4], swapCodeMethodID) {
 		input = input[4:]
 		// swapCode
-		cost := 2 * params.CreateGas
-		if suppliedGas < cost {
-			return nil, 0, vm.ErrOutOfGas
-		}
-		suppliedGas -= cost
 		if len(input) != 64 {
 			return nil, 0, vm.ErrExecutionReverted
 		}
@@ -115,6 +120,9 @@ func (_ PreCompiledContract) Run(evm *vm.EVM, caller common.Address, input []byt
 		acc0 := common.BytesToAddress(input[12:32])
 		input = input[32:]
 		acc1 := common.BytesToAddress(input[12:32])
+		if !m.Account(acc0) || !m.Account(acc1) {
+			return nil, 0, vm.ErrOutOfGas
+		}
 		code0 := evm.StateDB.GetCode(acc0)
 		if code0 == nil {
 			code0 = []byte{}
@@ -125,11 +133,10 @@ func (_ PreCompiledContract) Run(evm *vm.EVM, caller common.Address, input []byt
 		}
 		cost0 := uint64(len(code0)) * (params.CreateDataGas + params.MemoryGas)
 		cost1 := uint64(len(code1)) * (params.CreateDataGas + params.MemoryGas)
-		cost = (cost0 + cost1) / 2 // 50% discount because trie size won't increase after pruning
-		if suppliedGas < cost {
+		cost := (cost0 + cost1) / 2 // 50% discount because trie size won't increase after pruning
+		if !m.UseGas(cost) {
 			return nil, 0, vm.ErrOutOfGas
 		}
-		suppliedGas -= cost
 		if acc0 != acc1 {
 			evm.StateDB.SetCode(acc0, code1)
 			evm.StateDB.SetCode(acc1, code0)
@@ -137,10 +144,6 @@ func (_ PreCompiledContract) Run(evm *vm.EVM, caller common.Address, input []byt
 	} else if bytes.Equal(input[:4], setStorageMethodID) {
 		input = input[4:]
 		// setStorage
-		if suppliedGas < params.SstoreSetGasEIP2200 {
-			return nil, 0, vm.ErrOutOfGas
-		}
-		suppliedGas -= params.SstoreSetGasEIP2200
 		if len(input) != 96 {
 			return nil, 0, vm.ErrExecutionReverted
 		}
@@ -149,15 +152,14 @@ func (_ PreCompiledContract) Run(evm *vm.EVM, caller common.Address, input []byt
 		key := common.BytesToHash(input[:32])
 		input = input[32:]
 		value := common.BytesToHash(input[:32])
+		if !m.Sstore(acc, key, value) {
+			return nil, 0, vm.ErrOutOfGas
+		}
 
 		evm.StateDB.SetState(acc, key, value)
 	} else if bytes.Equal(input[:4], incNonceMethodID) {
 		input = input[4:]
 		// incNonce
-		if suppliedGas < params.CallValueTransferGas {
-			return nil, 0, vm.ErrOutOfGas
-		}
-		suppliedGas -= params.CallValueTransferGas
 		if len(input) != 64 {
 			return nil, 0, vm.ErrExecutionReverted
 		}
@@ -165,6 +167,9 @@ func (_ PreCompiledContract) Run(evm *vm.EVM, caller common.Address, input []byt
 		acc := common.BytesToAddress(input[12:32])
 		input = input[32:]
 		value := new(big.Int).SetBytes(input[:32])
+		if !m.Account(acc) {
+			return nil, 0, vm.ErrOutOfGas
+		}
 
 		if acc == evm.TxContext.Origin {
 			// Origin nonce shouldn't change during his transaction
@@ -183,5 +188,5 @@ func (_ PreCompiledContract) Run(evm *vm.EVM, caller common.Address, input []byt
 	} else {
 		return nil, 0, vm.ErrExecutionReverted
 	}
-	return nil, suppliedGas, nil
+	return nil, m.Gas(), nil
 }
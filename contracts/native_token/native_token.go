@@ -2,14 +2,15 @@ package native_token
 
 import (
 	"bytes"
+	"errors"
 	"github.com/artheranet/arthera-node/contracts"
 	"github.com/artheranet/arthera-node/contracts/abis"
+	"github.com/artheranet/arthera-node/contracts/precompiles"
 	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/crypto"
-	"github.com/ethereum/go-ethereum/params"
 	"math/big"
 )
 
@@ -25,8 +26,37 @@ var (
 	nameMethodID         []byte
 	symbolMethodID       []byte
 	decimalsMethodID     []byte
+	// EIP-2612 and allowance helpers
+	domainSeparatorMethodID   []byte
+	noncesMethodID            []byte
+	permitMethodID            []byte
+	increaseAllowanceMethodID []byte
+	decreaseAllowanceMethodID []byte
+)
+
+// permitMethods are available only after the NativeTokenPermit upgrade
+var permitMethods = map[string]bool{
+	"DOMAIN_SEPARATOR":  true,
+	"nonces":            true,
+	"permit":            true,
+	"increaseAllowance": true,
+	"decreaseAllowance": true,
+}
+
+const (
+	tokenName    = "Arthera"
+	tokenSymbol  = "AA"
+	tokenVersion = "1"
 )
 
+var (
+	eip712DomainTypeHash = crypto.Keccak256Hash([]byte("EIP712Domain(string name,string version,uint256 chainId,address verifyingContract)"))
+	permitTypeHash       = crypto.Keccak256Hash([]byte("Permit(address owner,address spender,uint256 value,uint256 nonce,uint256 deadline)"))
+)
+
+// errMalformedInput reverts the call of a method with the arguments of a wrong size
+var errMalformedInput = errors.New("malformed input")
+
 var (
 	zeroAddress   = common.Address{}
 	abiUint8, _   = abi.NewType("uint8", "", nil)
@@ -47,6 +77,12 @@ func init() {
 		"name":         &nameMethodID,
 		"symbol":       &symbolMethodID,
 		"decimals":     &decimalsMethodID,
+		// EIP-2612 and allowance helpers
+		"DOMAIN_SEPARATOR":  &domainSeparatorMethodID,
+		"nonces":            &noncesMethodID,
+		"permit":            &permitMethodID,
+		"increaseAllowance": &increaseAllowanceMethodID,
+		"decreaseAllowance": &decreaseAllowanceMethodID,
 	} {
 		method, exist := abis.IERC20WithMetadata.Methods[name]
 		if !exist {
@@ -58,38 +94,87 @@ func init() {
 	}
 }
 
-type PreCompiledContract struct{}
+// PreCompiledContract exposes the native balance as an ERC-20 token.
+// Calls are metered according to the GasRules, only the legacy flat costs are charged if GasRules is nil.
+// Permit enables the EIP-2612 permits and the allowance helpers, and approvals on behalf of the caller
+// rather than the tx origin.
+type PreCompiledContract struct {
+	GasRules *precompiles.GasRules
+	Permit   bool
+}
 
-func (_ PreCompiledContract) Run(evm *vm.EVM, caller common.Address, input []byte, suppliedGas uint64) ([]byte, uint64, error) {
+func (c PreCompiledContract) Run(evm *vm.EVM, caller common.Address, input []byte, suppliedGas uint64) ([]byte, uint64, error) {
 	if len(input) < 4 {
 		return nil, 0, vm.ErrExecutionReverted
 	}
 	if evm.StateDB.GetCodeSize(contracts.NativeTokenSmartContractAddress) == 0 {
 		evm.StateDB.SetCode(contracts.NativeTokenSmartContractAddress, []byte{0})
 	}
+	m := precompiles.NewMeter(evm, contracts.NativeTokenSmartContractAddress, c.GasRules, suppliedGas)
+
+	method, err := abis.IERC20WithMetadata.MethodById(input[:4])
+	if err != nil {
+		return nil, 0, vm.ErrExecutionReverted
+	}
+	if !c.Permit && permitMethods[method.Name] {
+		return nil, 0, vm.ErrExecutionReverted
+	}
+	if !m.Method(method.Name) {
+		return nil, 0, vm.ErrOutOfGas
+	}
+	var ret []byte
 	methodId := input[:4]
 	args := input[4:]
 	if bytes.Equal(methodId, totalSupplyMethodID) {
-		return totalSupply(evm, caller, args, suppliedGas)
+		return totalSupply(evm, caller, args, m.Gas())
 	} else if bytes.Equal(methodId, balanceOfMethodID) {
-		return balanceOf(evm, caller, args, suppliedGas)
+		ret, err = balanceOf(evm, m, caller, args)
 	} else if bytes.Equal(methodId, transferMethodID) {
-		return transfer(evm, caller, args, suppliedGas)
+		ret, err = transfer(evm, m, caller, args)
 	} else if bytes.Equal(methodId, allowanceMethodID) {
-		return allowance(evm, caller, args, suppliedGas)
+		ret, err = allowance(evm, m, caller, args)
 	} else if bytes.Equal(methodId, approveMethodID) {
-		return approve(evm, caller, args, suppliedGas)
+		if c.Permit {
+			ret, err = approve(evm, m, caller, args)
+		} else {
+			ret, err = legacyApprove(evm, m, caller, args)
+		}
 	} else if bytes.Equal(methodId, transferFromMethodID) {
-		return transferFrom(evm, caller, args, suppliedGas)
+		if c.Permit {
+			ret, err = transferFrom(evm, m, caller, args)
+		} else {
+			ret, err = legacyTransferFrom(evm, m, caller, args)
+		}
+	} else if bytes.Equal(methodId, increaseAllowanceMethodID) {
+		ret, err = increaseAllowance(evm, m, caller, args)
+	} else if bytes.Equal(methodId, decreaseAllowanceMethodID) {
+		ret, err = decreaseAllowance(evm, m, caller, args)
+	} else if bytes.Equal(methodId, permitMethodID) {
+		ret, err = permit(evm, m, caller, args)
+	} else if bytes.Equal(methodId, noncesMethodID) {
+		ret, err = nonces(evm, m, caller, args)
+	} else if bytes.Equal(methodId, domainSeparatorMethodID) {
+		ret = domainSeparator(evm.ChainConfig().ChainID).Bytes()
 	} else if bytes.Equal(methodId, nameMethodID) {
-		return packAbiString("Arthera"), suppliedGas, nil
+		ret = packAbiString(tokenName)
 	} else if bytes.Equal(methodId, symbolMethodID) {
-		return packAbiString("AA"), suppliedGas, nil
+		ret = packAbiString(tokenSymbol)
 	} else if bytes.Equal(methodId, decimalsMethodID) {
-		return packAbiUint8(18), suppliedGas, nil
+		ret = packAbiUint8(18)
 	} else {
 		return nil, 0, vm.ErrExecutionReverted
 	}
+	if err == vm.ErrOutOfGas {
+		return nil, 0, err
+	}
+	if err == errMalformedInput {
+		if c.GasRules == nil || !c.Permit {
+			// a malformed input consumed the whole gas before the upgrades
+			return nil, 0, vm.ErrExecutionReverted
+		}
+		return nil, m.Gas(), vm.ErrExecutionReverted
+	}
+	return ret, m.Gas(), err
 }
 
 // function totalSupply() returns (uint256)
@@ -103,83 +188,268 @@ func totalSupply(evm *vm.EVM, caller common.Address, input []byte, suppliedGas u
 }
 
 // function balanceOf(address account) returns (uint256)
-func balanceOf(evm *vm.EVM, _ common.Address, input []byte, suppliedGas uint64) ([]byte, uint64, error) {
+func balanceOf(evm *vm.EVM, m *precompiles.Meter, _ common.Address, input []byte) ([]byte, error) {
 	if len(input) != 32 {
-		return nil, 0, vm.ErrExecutionReverted
+		return nil, errMalformedInput
 	}
 	acc := common.BytesToAddress(input[12:32])
+	if !m.Account(acc) {
+		return nil, vm.ErrOutOfGas
+	}
 	balance := evm.StateDB.GetBalance(acc)
-	return packAbiUint256(balance), suppliedGas, nil
+	return packAbiUint256(balance), nil
 }
 
 // function transfer(address to, uint256 amount) returns (bool)
-func transfer(evm *vm.EVM, owner common.Address, input []byte, suppliedGas uint64) ([]byte, uint64, error) {
-	if suppliedGas < params.CallValueTransferGas {
-		return nil, 0, vm.ErrOutOfGas
-	}
-	suppliedGas -= params.CallValueTransferGas
+func transfer(evm *vm.EVM, m *precompiles.Meter, owner common.Address, input []byte) ([]byte, error) {
 	if len(input) != 64 {
-		return nil, 0, vm.ErrExecutionReverted
+		return nil, errMalformedInput
 	}
 	to := common.BytesToAddress(input[12:32])
 	input = input[32:]
 	amount := new(big.Int).SetBytes(input[:32])
-	ret := _transfer(evm, owner, to, amount)
-	if ret != nil {
-		return ret, suppliedGas, vm.ErrExecutionReverted
+	if ret, err := _transfer(evm, m, owner, to, amount); err != nil {
+		return ret, err
 	}
-	return packAbiBool(true), suppliedGas, nil
+	return packAbiBool(true), nil
 }
 
 // function approve(address spender, uint256 amount) returns (bool)
-func approve(evm *vm.EVM, _ common.Address, input []byte, suppliedGas uint64) ([]byte, uint64, error) {
+func approve(evm *vm.EVM, m *precompiles.Meter, owner common.Address, input []byte) ([]byte, error) {
 	if len(input) != 64 {
-		return nil, 0, vm.ErrExecutionReverted
+		return nil, errMalformedInput
 	}
-	if suppliedGas < params.SstoreSetGasEIP2200 {
-		return nil, 0, vm.ErrOutOfGas
+	spender := common.BytesToAddress(input[12:32])
+	input = input[32:]
+	amount := new(big.Int).SetBytes(input[:32])
+	if ret, err := _approve(evm, m, owner, spender, amount); err != nil {
+		return ret, err
+	}
+	return packAbiBool(true), nil
+}
+
+// legacyApprove approves on behalf of the tx origin, as before the NativeTokenPermit upgrade
+func legacyApprove(evm *vm.EVM, _ *precompiles.Meter, _ common.Address, input []byte) ([]byte, error) {
+	if len(input) != 64 {
+		return nil, errMalformedInput
 	}
-	suppliedGas -= params.SstoreSetGasEIP2200
 	spender := common.BytesToAddress(input[12:32])
 	input = input[32:]
 	amount := new(big.Int).SetBytes(input[:32])
-	ret := _approve(evm, evm.TxContext.Origin, spender, amount)
-	if ret != nil {
-		return ret, suppliedGas, vm.ErrExecutionReverted
+	if ret := _legacyApprove(evm, evm.TxContext.Origin, spender, amount); ret != nil {
+		return ret, vm.ErrExecutionReverted
 	}
-	return packAbiBool(true), suppliedGas, nil
+	return packAbiBool(true), nil
 }
 
 // function allowance(address owner, address spender) returns (uint256)
-func allowance(evm *vm.EVM, _ common.Address, input []byte, suppliedGas uint64) ([]byte, uint64, error) {
+func allowance(evm *vm.EVM, m *precompiles.Meter, _ common.Address, input []byte) ([]byte, error) {
 	if len(input) != 64 {
-		return nil, 0, vm.ErrExecutionReverted
+		return nil, errMalformedInput
 	}
 	owner := common.BytesToAddress(input[12:32])
 	input = input[32:]
 	spender := common.BytesToAddress(input[12:32])
-	return packAbiUint256(_allowance(evm, owner, spender)), suppliedGas, nil
+	amount, err := _allowance(evm, m, owner, spender)
+	if err != nil {
+		return nil, err
+	}
+	return packAbiUint256(amount), nil
 }
 
 // function transferFrom(address from, address to, uint256 amount) returns (bool)
-func transferFrom(evm *vm.EVM, spender common.Address, input []byte, suppliedGas uint64) ([]byte, uint64, error) {
+func transferFrom(evm *vm.EVM, m *precompiles.Meter, spender common.Address, input []byte) ([]byte, error) {
 	if len(input) != 96 {
-		return nil, 0, vm.ErrExecutionReverted
+		return nil, errMalformedInput
 	}
 	from := common.BytesToAddress(input[12:32])
 	input = input[32:]
 	to := common.BytesToAddress(input[12:32])
 	input = input[32:]
 	amount := new(big.Int).SetBytes(input[:32])
-	ret := _spendAllowance(evm, from, spender, amount)
-	if ret != nil {
-		return ret, suppliedGas, vm.ErrExecutionReverted
+	if ret, err := _spendAllowance(evm, m, from, spender, amount); err != nil {
+		return ret, err
+	}
+	if ret, err := _transfer(evm, m, from, to, amount); err != nil {
+		return ret, err
+	}
+	return packAbiBool(true), nil
+}
+
+// legacyTransferFrom ignores a failure of the transfer, as before the NativeTokenPermit upgrade
+func legacyTransferFrom(evm *vm.EVM, m *precompiles.Meter, spender common.Address, input []byte) ([]byte, error) {
+	if len(input) != 96 {
+		return nil, errMalformedInput
+	}
+	from := common.BytesToAddress(input[12:32])
+	input = input[32:]
+	to := common.BytesToAddress(input[12:32])
+	input = input[32:]
+	amount := new(big.Int).SetBytes(input[:32])
+	currentAllowance, err := _allowance(evm, m, from, spender)
+	if err != nil {
+		return nil, err
+	}
+	if currentAllowance.Cmp(maxUint256) != 0 {
+		if currentAllowance.Cmp(amount) < 0 {
+			return packAbiError("ERC20: insufficient allowance"), vm.ErrExecutionReverted
+		}
+		_legacyApprove(evm, from, spender, new(big.Int).Sub(currentAllowance, amount))
+	}
+	_, _ = _transfer(evm, m, from, to, amount)
+	return packAbiBool(true), nil
+}
+
+// function increaseAllowance(address spender, uint256 addedValue) returns (bool)
+func increaseAllowance(evm *vm.EVM, m *precompiles.Meter, owner common.Address, input []byte) ([]byte, error) {
+	if len(input) != 64 {
+		return nil, errMalformedInput
+	}
+	spender := common.BytesToAddress(input[12:32])
+	input = input[32:]
+	addedValue := new(big.Int).SetBytes(input[:32])
+	currentAllowance, err := _allowance(evm, m, owner, spender)
+	if err != nil {
+		return nil, err
+	}
+	amount := new(big.Int).Add(currentAllowance, addedValue)
+	if amount.Cmp(maxUint256) > 0 {
+		return packAbiError("ERC20: allowance overflow"), vm.ErrExecutionReverted
+	}
+	if ret, err := _approve(evm, m, owner, spender, amount); err != nil {
+		return ret, err
 	}
-	_transfer(evm, from, to, amount)
-	return packAbiBool(true), suppliedGas, nil
+	return packAbiBool(true), nil
 }
 
-func _approve(evm *vm.EVM, owner common.Address, spender common.Address, amount *big.Int) []byte {
+// function decreaseAllowance(address spender, uint256 subtractedValue) returns (bool)
+func decreaseAllowance(evm *vm.EVM, m *precompiles.Meter, owner common.Address, input []byte) ([]byte, error) {
+	if len(input) != 64 {
+		return nil, errMalformedInput
+	}
+	spender := common.BytesToAddress(input[12:32])
+	input = input[32:]
+	subtractedValue := new(big.Int).SetBytes(input[:32])
+	currentAllowance, err := _allowance(evm, m, owner, spender)
+	if err != nil {
+		return nil, err
+	}
+	if currentAllowance.Cmp(subtractedValue) < 0 {
+		return packAbiError("ERC20: decreased allowance below zero"), vm.ErrExecutionReverted
+	}
+	if ret, err := _approve(evm, m, owner, spender, new(big.Int).Sub(currentAllowance, subtractedValue)); err != nil {
+		return ret, err
+	}
+	return packAbiBool(true), nil
+}
+
+// function nonces(address owner) returns (uint256)
+func nonces(evm *vm.EVM, m *precompiles.Meter, _ common.Address, input []byte) ([]byte, error) {
+	if len(input) != 32 {
+		return nil, errMalformedInput
+	}
+	owner := common.BytesToAddress(input[12:32])
+	nonce, err := _nonce(evm, m, owner)
+	if err != nil {
+		return nil, err
+	}
+	return packAbiUint256(nonce), nil
+}
+
+// function permit(address owner, address spender, uint256 value, uint256 deadline, uint8 v, bytes32 r, bytes32 s)
+func permit(evm *vm.EVM, m *precompiles.Meter, _ common.Address, input []byte) ([]byte, error) {
+	if len(input) != 224 {
+		return nil, errMalformedInput
+	}
+	owner := common.BytesToAddress(input[12:32])
+	spender := common.BytesToAddress(input[44:64])
+	value := new(big.Int).SetBytes(input[64:96])
+	deadline := new(big.Int).SetBytes(input[96:128])
+	v := new(big.Int).SetBytes(input[128:160])
+	r := new(big.Int).SetBytes(input[160:192])
+	s := new(big.Int).SetBytes(input[192:224])
+
+	if evm.Context.Time.Cmp(deadline) > 0 {
+		return packAbiError("ERC20Permit: expired deadline"), vm.ErrExecutionReverted
+	}
+	if !v.IsUint64() || (v.Uint64() != 27 && v.Uint64() != 28) || !crypto.ValidateSignatureValues(byte(v.Uint64()-27), r, s, true) {
+		return packAbiError("ERC20Permit: invalid signature"), vm.ErrExecutionReverted
+	}
+
+	nonce, err := _nonce(evm, m, owner)
+	if err != nil {
+		return nil, err
+	}
+	digest := permitDigest(evm.ChainConfig().ChainID, owner, spender, value, nonce, deadline)
+	sig := make([]byte, 65)
+	copy(sig[0:32], input[160:192])
+	copy(sig[32:64], input[192:224])
+	sig[64] = byte(v.Uint64() - 27)
+	pubKey, err := crypto.SigToPub(digest.Bytes(), sig)
+	if err != nil || crypto.PubkeyToAddress(*pubKey) != owner {
+		return packAbiError("ERC20Permit: invalid signature"), vm.ErrExecutionReverted
+	}
+
+	nonceKey := getNonceKey(owner)
+	nextNonce := common.BigToHash(new(big.Int).Add(nonce, common.Big1))
+	if !m.Sstore(contracts.NativeTokenSmartContractAddress, nonceKey, nextNonce) {
+		return nil, vm.ErrOutOfGas
+	}
+	evm.StateDB.SetState(contracts.NativeTokenSmartContractAddress, nonceKey, nextNonce)
+	if ret, err := _approve(evm, m, owner, spender, value); err != nil {
+		return ret, err
+	}
+	return nil, nil
+}
+
+// domainSeparator returns the EIP-712 domain separator of the native token
+func domainSeparator(chainID *big.Int) common.Hash {
+	return crypto.Keccak256Hash(
+		eip712DomainTypeHash.Bytes(),
+		crypto.Keccak256([]byte(tokenName)),
+		crypto.Keccak256([]byte(tokenVersion)),
+		common.BigToHash(chainID).Bytes(),
+		common.LeftPadBytes(contracts.NativeTokenSmartContractAddress.Bytes(), 32),
+	)
+}
+
+// permitDigest returns the EIP-712 typed data hash signed by the owner to permit an approval
+func permitDigest(chainID *big.Int, owner, spender common.Address, value, nonce, deadline *big.Int) common.Hash {
+	structHash := crypto.Keccak256Hash(
+		permitTypeHash.Bytes(),
+		common.LeftPadBytes(owner.Bytes(), 32),
+		common.LeftPadBytes(spender.Bytes(), 32),
+		common.BigToHash(value).Bytes(),
+		common.BigToHash(nonce).Bytes(),
+		common.BigToHash(deadline).Bytes(),
+	)
+	return crypto.Keccak256Hash(
+		[]byte{0x19, 0x01},
+		domainSeparator(chainID).Bytes(),
+		structHash.Bytes(),
+	)
+}
+
+func _approve(evm *vm.EVM, m *precompiles.Meter, owner common.Address, spender common.Address, amount *big.Int) ([]byte, error) {
+	if owner == zeroAddress {
+		return packAbiError("ERC20: approve from the zero address"), vm.ErrExecutionReverted
+	}
+	if spender == zeroAddress {
+		return packAbiError("ERC20: approve to the zero address"), vm.ErrExecutionReverted
+	}
+	storageKey := getAllowanceKey(owner, spender)
+	value := common.BigToHash(amount)
+	if !m.Sstore(contracts.NativeTokenSmartContractAddress, storageKey, value) || !m.Log(3, 32) {
+		return nil, vm.ErrOutOfGas
+	}
+	evm.StateDB.SetState(contracts.NativeTokenSmartContractAddress, storageKey, value)
+	event := createApprovalEvent(owner, spender, amount, evm.Context.BlockNumber.Uint64())
+	evm.StateDB.AddLog(&event)
+	return nil, nil
+}
+
+// _legacyApprove emits the approval event on behalf of the tx origin, as before the NativeTokenPermit upgrade
+func _legacyApprove(evm *vm.EVM, owner common.Address, spender common.Address, amount *big.Int) []byte {
 	if owner == zeroAddress {
 		return packAbiError("ERC20: approve from the zero address")
 	}
@@ -193,41 +463,59 @@ func _approve(evm *vm.EVM, owner common.Address, spender common.Address, amount
 	return nil
 }
 
-func _allowance(evm *vm.EVM, owner common.Address, spender common.Address) *big.Int {
+func _allowance(evm *vm.EVM, m *precompiles.Meter, owner common.Address, spender common.Address) (*big.Int, error) {
 	storageKey := getAllowanceKey(owner, spender)
+	if !m.Sload(contracts.NativeTokenSmartContractAddress, storageKey) {
+		return nil, vm.ErrOutOfGas
+	}
+	ret := evm.StateDB.GetState(contracts.NativeTokenSmartContractAddress, storageKey)
+	return ret.Big(), nil
+}
+
+func _nonce(evm *vm.EVM, m *precompiles.Meter, owner common.Address) (*big.Int, error) {
+	storageKey := getNonceKey(owner)
+	if !m.Sload(contracts.NativeTokenSmartContractAddress, storageKey) {
+		return nil, vm.ErrOutOfGas
+	}
 	ret := evm.StateDB.GetState(contracts.NativeTokenSmartContractAddress, storageKey)
-	return ret.Big()
+	return ret.Big(), nil
 }
 
-func _spendAllowance(evm *vm.EVM, owner common.Address, spender common.Address, amount *big.Int) []byte {
-	currentAllowance := _allowance(evm, owner, spender)
+func _spendAllowance(evm *vm.EVM, m *precompiles.Meter, owner common.Address, spender common.Address, amount *big.Int) ([]byte, error) {
+	currentAllowance, err := _allowance(evm, m, owner, spender)
+	if err != nil {
+		return nil, err
+	}
 	if currentAllowance.Cmp(maxUint256) != 0 {
 		if currentAllowance.Cmp(amount) < 0 {
-			return packAbiError("ERC20: insufficient allowance")
+			return packAbiError("ERC20: insufficient allowance"), vm.ErrExecutionReverted
 		}
-		_approve(evm, owner, spender, new(big.Int).Sub(currentAllowance, amount))
+		return _approve(evm, m, owner, spender, new(big.Int).Sub(currentAllowance, amount))
 	}
-	return nil
+	return nil, nil
 }
 
-func _transfer(evm *vm.EVM, from common.Address, to common.Address, amount *big.Int) []byte {
+func _transfer(evm *vm.EVM, m *precompiles.Meter, from common.Address, to common.Address, amount *big.Int) ([]byte, error) {
 	if from == zeroAddress {
-		return packAbiError("ERC20: transfer from the zero address")
+		return packAbiError("ERC20: transfer from the zero address"), vm.ErrExecutionReverted
 	}
 	if to == zeroAddress {
-		return packAbiError("ERC20: transfer to the zero address")
+		return packAbiError("ERC20: transfer to the zero address"), vm.ErrExecutionReverted
 	}
 	if amount.Cmp(big.NewInt(0)) == 0 {
-		return nil
+		return nil, nil
+	}
+	if !m.Account(to) || !m.Log(3, 32) {
+		return nil, vm.ErrOutOfGas
 	}
 	if evm.Context.CanTransfer(evm.StateDB, from, amount) {
 		evm.Context.Transfer(evm.StateDB, from, to, amount)
 		event := createTransferEvent(from, to, amount, evm.Context.BlockNumber.Uint64())
 		evm.StateDB.AddLog(&event)
 	} else {
-		return packAbiError("transfer amount exceeds balance")
+		return packAbiError("transfer amount exceeds balance"), vm.ErrExecutionReverted
 	}
-	return nil
+	return nil, nil
 }
 
 func createTransferEvent(from common.Address, to common.Address, amount *big.Int, blockNumber uint64) types.Log {
@@ -272,6 +560,13 @@ func getAllowanceKey(owner common.Address, spender common.Address) common.Hash {
 	)
 }
 
+func getNonceKey(owner common.Address) common.Hash {
+	return crypto.Keccak256Hash(
+		common.LeftPadBytes(owner.Bytes(), 32),
+		common.LeftPadBytes(big.NewInt(2).Bytes(), 32),
+	)
+}
+
 func getTotalSupplyKey() common.Hash {
 	return crypto.Keccak256Hash(
 		common.LeftPadBytes(big.NewInt(0).Bytes(), 32),
@@ -0,0 +1,249 @@
+package native_token
+
+import (
+	"math"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	ethparams "github.com/ethereum/go-ethereum/params"
+	"github.com/stretchr/testify/require"
+
+	"github.com/artheranet/arthera-node/contracts"
+	"github.com/artheranet/arthera-node/contracts/abis"
+	"github.com/artheranet/arthera-node/contracts/precompiles"
+)
+
+func TestSign(t *testing.T) {
+	require := require.New(t)
+
+	require.Equal([]byte{0xd5, 0x05, 0xac, 0xcf}, permitMethodID)
+	require.Equal([]byte{0x7e, 0xce, 0xbe, 0x00}, noncesMethodID)
+	require.Equal([]byte{0x36, 0x44, 0xe5, 0x15}, domainSeparatorMethodID)
+	require.Equal([]byte{0x39, 0x50, 0x93, 0x51}, increaseAllowanceMethodID)
+	require.Equal([]byte{0xa4, 0x57, 0xc2, 0xd7}, decreaseAllowanceMethodID)
+}
+
+func TestPermitDigest(t *testing.T) {
+	require := require.New(t)
+
+	key, err := crypto.GenerateKey()
+	require.NoError(err)
+	owner := crypto.PubkeyToAddress(key.PublicKey)
+	spender := common.HexToAddress("0x1000000000000000000000000000000000000001")
+	chainID := big.NewInt(10243)
+
+	digest := permitDigest(chainID, owner, spender, big.NewInt(100), common.Big0, big.NewInt(1000))
+	sig, err := crypto.Sign(digest.Bytes(), key)
+	require.NoError(err)
+
+	pubKey, err := crypto.SigToPub(digest.Bytes(), sig)
+	require.NoError(err)
+	require.Equal(owner, crypto.PubkeyToAddress(*pubKey))
+
+	// any change of the permit fields must invalidate the signature
+	otherDigest := permitDigest(chainID, owner, spender, big.NewInt(100), common.Big1, big.NewInt(1000))
+	pubKey, err = crypto.SigToPub(otherDigest.Bytes(), sig)
+	require.NoError(err)
+	require.NotEqual(owner, crypto.PubkeyToAddress(*pubKey))
+
+	require.NotEqual(domainSeparator(chainID), domainSeparator(big.NewInt(10242)))
+}
+
+var (
+	testOrigin  = common.HexToAddress("0x2000000000000000000000000000000000000002")
+	testCaller  = common.HexToAddress("0x3000000000000000000000000000000000000003")
+	testSpender = common.HexToAddress("0x4000000000000000000000000000000000000004")
+	testBalance = big.NewInt(1e18)
+)
+
+func newTestEVM(c *PreCompiledContract) *vm.EVM {
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	statedb.SetBalance(testOrigin, testBalance)
+	statedb.SetBalance(testCaller, testBalance)
+	blockCtx := vm.BlockContext{
+		CanTransfer: func(db vm.StateDB, addr common.Address, amount *big.Int) bool {
+			return db.GetBalance(addr).Cmp(amount) >= 0
+		},
+		Transfer: func(db vm.StateDB, sender, recipient common.Address, amount *big.Int) {
+			db.SubBalance(sender, amount)
+			db.AddBalance(recipient, amount)
+		},
+		GetHash:     func(uint64) common.Hash { return common.Hash{} },
+		BlockNumber: big.NewInt(1),
+		Time:        big.NewInt(1000),
+		Difficulty:  big.NewInt(1),
+		BaseFee:     big.NewInt(0),
+		GasLimit:    math.MaxUint64,
+	}
+	txCtx := vm.TxContext{Origin: testOrigin, GasPrice: big.NewInt(0)}
+	return vm.NewEVM(blockCtx, txCtx, statedb, ethparams.TestChainConfig, vm.Config{
+		StatePrecompiles: map[common.Address]vm.PrecompiledStateContract{
+			contracts.NativeTokenSmartContractAddress: c,
+		},
+	})
+}
+
+// callToken calls the native token and returns the output and the gas used
+func callToken(t *testing.T, evm *vm.EVM, caller common.Address, method string, args ...interface{}) ([]byte, uint64, error) {
+	input, err := abis.IERC20WithMetadata.Pack(method, args...)
+	require.NoError(t, err)
+	const gas = 1000000
+	ret, leftOverGas, err := evm.Call(vm.AccountRef(caller), contracts.NativeTokenSmartContractAddress, input, gas, new(big.Int))
+	return ret, gas - leftOverGas, err
+}
+
+func tokenAllowance(t *testing.T, evm *vm.EVM, owner, spender common.Address) *big.Int {
+	ret, _, err := callToken(t, evm, testCaller, "allowance", owner, spender)
+	require.NoError(t, err)
+	return new(big.Int).SetBytes(ret)
+}
+
+func TestLegacyExecution(t *testing.T) {
+	require := require.New(t)
+	evm := newTestEVM(&PreCompiledContract{})
+
+	// the approval is made on behalf of the tx origin for the flat cost
+	ret, gasUsed, err := callToken(t, evm, testCaller, "approve", testSpender, big.NewInt(100))
+	require.NoError(err)
+	require.Equal(packAbiBool(true), ret)
+	require.Equal(ethparams.SstoreSetGasEIP2200, gasUsed)
+	require.Equal(big.NewInt(100), tokenAllowance(t, evm, testOrigin, testSpender))
+	require.Zero(tokenAllowance(t, evm, testCaller, testSpender).Sign())
+
+	ret, gasUsed, err = callToken(t, evm, testCaller, "transfer", testSpender, big.NewInt(10))
+	require.NoError(err)
+	require.Equal(packAbiBool(true), ret)
+	require.Equal(ethparams.CallValueTransferGas, gasUsed)
+	require.Equal(new(big.Int).Sub(testBalance, big.NewInt(10)), evm.StateDB.GetBalance(testCaller))
+	require.Equal(big.NewInt(10), evm.StateDB.GetBalance(testSpender))
+
+	// a failed transfer doesn't fail transferFrom
+	evm.StateDB.SubBalance(testOrigin, testBalance)
+	ret, gasUsed, err = callToken(t, evm, testSpender, "transferFrom", testOrigin, testCaller, big.NewInt(40))
+	require.NoError(err)
+	require.Equal(packAbiBool(true), ret)
+	require.Zero(gasUsed)
+	require.Equal(big.NewInt(60), tokenAllowance(t, evm, testOrigin, testSpender))
+	require.Zero(evm.StateDB.GetBalance(testOrigin).Sign())
+
+	// the permit methods aren't available
+	for _, method := range []string{"DOMAIN_SEPARATOR", "nonces", "increaseAllowance", "decreaseAllowance"} {
+		m := abis.IERC20WithMetadata.Methods[method]
+		args := make([]interface{}, len(m.Inputs))
+		for i, in := range m.Inputs {
+			if in.Type.T == abi.AddressTy {
+				args[i] = testSpender
+			} else {
+				args[i] = big.NewInt(1)
+			}
+		}
+		_, _, err = callToken(t, evm, testCaller, method, args...)
+		require.Equal(vm.ErrExecutionReverted, err, method)
+	}
+
+	// a malformed input consumes the whole gas
+	for _, method := range []string{"balanceOf", "transfer", "approve", "allowance", "transferFrom"} {
+		const gas = 1000000
+		input := append(common.CopyBytes(abis.IERC20WithMetadata.Methods[method].ID), make([]byte, 31)...)
+		_, leftOverGas, err := evm.Call(vm.AccountRef(testCaller), contracts.NativeTokenSmartContractAddress, input, gas, new(big.Int))
+		require.Equal(vm.ErrExecutionReverted, err, method)
+		require.Zero(leftOverGas, method)
+	}
+}
+
+func TestPermitExecution(t *testing.T) {
+	require := require.New(t)
+	gasRules := precompiles.DefaultGasRules()
+	logGas := gasRules.LogGas + 3*gasRules.LogTopicGas + 32*gasRules.LogDataGas
+	evm := newTestEVM(&PreCompiledContract{GasRules: &gasRules, Permit: true})
+
+	// the approval is made on behalf of the caller
+	ret, gasUsed, err := callToken(t, evm, testCaller, "approve", testSpender, big.NewInt(100))
+	require.NoError(err)
+	require.Equal(packAbiBool(true), ret)
+	require.Equal(gasRules.ColdSloadGas+gasRules.SstoreSetGas+logGas, gasUsed)
+	require.Equal(big.NewInt(100), tokenAllowance(t, evm, testCaller, testSpender))
+	require.Zero(tokenAllowance(t, evm, testOrigin, testSpender).Sign())
+
+	// the transfer is spent from the allowance, the slot accessed by the previous calls of the transaction is warm
+	ret, gasUsed, err = callToken(t, evm, testSpender, "transferFrom", testCaller, testOrigin, big.NewInt(40))
+	require.NoError(err)
+	require.Equal(packAbiBool(true), ret)
+	require.Equal(ethparams.CallValueTransferGas+
+		gasRules.WarmAccessGas+gasRules.WarmAccessGas+gasRules.SstoreResetGas+logGas+
+		gasRules.ColdAccountAccessGas+logGas, gasUsed)
+	require.Equal(big.NewInt(60), tokenAllowance(t, evm, testCaller, testSpender))
+	require.Equal(new(big.Int).Sub(testBalance, big.NewInt(40)), evm.StateDB.GetBalance(testCaller))
+	require.Equal(new(big.Int).Add(testBalance, big.NewInt(40)), evm.StateDB.GetBalance(testOrigin))
+
+	// a transfer over the allowance or the balance fails
+	_, _, err = callToken(t, evm, testSpender, "transferFrom", testCaller, testOrigin, big.NewInt(61))
+	require.Equal(vm.ErrExecutionReverted, err)
+	_, _, err = callToken(t, evm, testSpender, "transfer", testOrigin, big.NewInt(1))
+	require.Equal(vm.ErrExecutionReverted, err)
+	require.Equal(big.NewInt(60), tokenAllowance(t, evm, testCaller, testSpender))
+
+	// the permit is signed by the owner, who doesn't send a transaction
+	key, err := crypto.GenerateKey()
+	require.NoError(err)
+	owner := crypto.PubkeyToAddress(key.PublicKey)
+	evm.StateDB.AddBalance(owner, big.NewInt(500))
+	deadline := big.NewInt(2000)
+	sign := func(value, nonce *big.Int) (uint8, [32]byte, [32]byte) {
+		digest := permitDigest(ethparams.TestChainConfig.ChainID, owner, testSpender, value, nonce, deadline)
+		sig, err := crypto.Sign(digest.Bytes(), key)
+		require.NoError(err)
+		var r, s [32]byte
+		copy(r[:], sig[:32])
+		copy(s[:], sig[32:64])
+		return sig[64] + 27, r, s
+	}
+
+	v, r, s := sign(big.NewInt(300), common.Big0)
+	_, gasUsed, err = callToken(t, evm, testCaller, "permit", owner, testSpender, big.NewInt(300), deadline, v, r, s)
+	require.NoError(err)
+	require.Equal(ethparams.EcrecoverGas+
+		gasRules.ColdSloadGas+gasRules.WarmAccessGas+gasRules.SstoreSetGas+
+		gasRules.ColdSloadGas+gasRules.SstoreSetGas+logGas, gasUsed)
+	require.Equal(big.NewInt(300), tokenAllowance(t, evm, owner, testSpender))
+	ret, _, err = callToken(t, evm, testCaller, "nonces", owner)
+	require.NoError(err)
+	require.Equal(big.NewInt(1), new(big.Int).SetBytes(ret))
+
+	// the permit can't be replayed
+	_, _, err = callToken(t, evm, testCaller, "permit", owner, testSpender, big.NewInt(300), deadline, v, r, s)
+	require.Equal(vm.ErrExecutionReverted, err)
+
+	_, _, err = callToken(t, evm, testSpender, "transferFrom", owner, testSpender, big.NewInt(200))
+	require.NoError(err)
+	require.Equal(big.NewInt(300), evm.StateDB.GetBalance(owner))
+	require.Equal(big.NewInt(200), evm.StateDB.GetBalance(testSpender))
+	require.Equal(big.NewInt(100), tokenAllowance(t, evm, owner, testSpender))
+
+	_, _, err = callToken(t, evm, owner, "decreaseAllowance", testSpender, big.NewInt(30))
+	require.NoError(err)
+	_, _, err = callToken(t, evm, owner, "increaseAllowance", testSpender, big.NewInt(5))
+	require.NoError(err)
+	require.Equal(big.NewInt(75), tokenAllowance(t, evm, owner, testSpender))
+	_, _, err = callToken(t, evm, owner, "decreaseAllowance", testSpender, big.NewInt(76))
+	require.Equal(vm.ErrExecutionReverted, err)
+
+	// an expired permit
+	evm.Context.Time = big.NewInt(2001)
+	v, r, s = sign(big.NewInt(300), common.Big1)
+	_, _, err = callToken(t, evm, testCaller, "permit", owner, testSpender, big.NewInt(300), deadline, v, r, s)
+	require.Equal(vm.ErrExecutionReverted, err)
+	require.Equal(big.NewInt(75), tokenAllowance(t, evm, owner, testSpender))
+
+	// out of gas
+	input, err := abis.IERC20WithMetadata.Pack("approve", testSpender, big.NewInt(1))
+	require.NoError(err)
+	_, _, err = evm.Call(vm.AccountRef(testCaller), contracts.NativeTokenSmartContractAddress, input, gasRules.SstoreResetGas, new(big.Int))
+	require.Equal(vm.ErrOutOfGas, err)
+}
@@ -0,0 +1,190 @@
+package precompiles
+
+import (
+	"github.com/artheranet/arthera-node/contracts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// MethodGas is a base cost charged for a call of a precompiled contract method
+type MethodGas struct {
+	Contract common.Address
+	Method   string
+	Gas      uint64
+}
+
+// GasRules is the gas schedule shared by the stateful precompiled contracts.
+// Storage and account access costs follow the EIP-2929 warm/cold rules.
+type GasRules struct {
+	ColdSloadGas         uint64
+	WarmAccessGas        uint64
+	ColdAccountAccessGas uint64
+	SstoreSetGas         uint64
+	SstoreResetGas       uint64
+	LogGas               uint64
+	LogTopicGas          uint64
+	LogDataGas           uint64
+
+	Methods []MethodGas
+}
+
+// DefaultGasRules returns the default gas schedule, aligned with the EVM opcodes costs
+func DefaultGasRules() GasRules {
+	return GasRules{
+		ColdSloadGas:         params.ColdSloadCostEIP2929,
+		WarmAccessGas:        params.WarmStorageReadCostEIP2929,
+		ColdAccountAccessGas: params.ColdAccountAccessCostEIP2929,
+		SstoreSetGas:         params.SstoreSetGasEIP2200,
+		SstoreResetGas:       params.SstoreResetGasEIP2200 - params.ColdSloadCostEIP2929,
+		LogGas:               params.LogGas,
+		LogTopicGas:          params.LogTopicGas,
+		LogDataGas:           params.LogDataGas,
+		Methods: []MethodGas{
+			{contracts.NativeTokenSmartContractAddress, "transfer", params.CallValueTransferGas},
+			{contracts.NativeTokenSmartContractAddress, "transferFrom", params.CallValueTransferGas},
+			{contracts.NativeTokenSmartContractAddress, "permit", params.EcrecoverGas},
+			{contracts.EvmWriterSmartContractAddress, "setBalance", params.CallValueTransferGas},
+			{contracts.EvmWriterSmartContractAddress, "copyCode", params.CreateGas},
+			{contracts.EvmWriterSmartContractAddress, "swapCode", 2 * params.CreateGas},
+			{contracts.EvmWriterSmartContractAddress, "incNonce", params.CallValueTransferGas},
+		},
+	}
+}
+
+// legacyMethods are the flat costs of the method calls which aren't metered by a gas schedule
+var legacyMethods = GasRules{
+	Methods: []MethodGas{
+		{contracts.NativeTokenSmartContractAddress, "transfer", params.CallValueTransferGas},
+		{contracts.NativeTokenSmartContractAddress, "approve", params.SstoreSetGasEIP2200},
+		{contracts.NativeTokenSmartContractAddress, "increaseAllowance", params.SstoreSetGasEIP2200},
+		{contracts.NativeTokenSmartContractAddress, "decreaseAllowance", params.SstoreSetGasEIP2200},
+		{contracts.NativeTokenSmartContractAddress, "permit", params.EcrecoverGas + 2*params.SstoreSetGasEIP2200},
+		{contracts.EvmWriterSmartContractAddress, "setBalance", params.CallValueTransferGas},
+		{contracts.EvmWriterSmartContractAddress, "copyCode", params.CreateGas},
+		{contracts.EvmWriterSmartContractAddress, "swapCode", 2 * params.CreateGas},
+		{contracts.EvmWriterSmartContractAddress, "setStorage", params.SstoreSetGasEIP2200},
+		{contracts.EvmWriterSmartContractAddress, "incNonce", params.CallValueTransferGas},
+	},
+}
+
+// IsZero returns true if no gas schedule is set, in which case the default one is used
+func (r GasRules) IsZero() bool {
+	return r.ColdSloadGas == 0 && r.WarmAccessGas == 0 && r.ColdAccountAccessGas == 0 &&
+		r.SstoreSetGas == 0 && r.SstoreResetGas == 0 &&
+		r.LogGas == 0 && r.LogTopicGas == 0 && r.LogDataGas == 0 &&
+		len(r.Methods) == 0
+}
+
+// Copy returns a deep copy of the gas rules
+func (r GasRules) Copy() GasRules {
+	cp := r
+	if r.Methods != nil {
+		cp.Methods = make([]MethodGas, len(r.Methods))
+		copy(cp.Methods, r.Methods)
+	}
+	return cp
+}
+
+// MethodGas returns the base cost of a contract method call
+func (r GasRules) MethodGas(contract common.Address, method string) uint64 {
+	for _, m := range r.Methods {
+		if m.Contract == contract && m.Method == method {
+			return m.Gas
+		}
+	}
+	return 0
+}
+
+// Meter charges gas for the operations of a precompiled contract call.
+// If no gas schedule is set, only the flat legacy costs of the methods are charged.
+// The accessed accounts and slots are added to the access list of the transaction, as the EVM opcodes do,
+// so they are warm for the rest of the transaction unless the call is reverted.
+type Meter struct {
+	evm      *vm.EVM
+	contract common.Address
+	rules    *GasRules
+	gas      uint64
+}
+
+// NewMeter creates a meter for a call of the precompiled contract with the supplied gas
+func NewMeter(evm *vm.EVM, contract common.Address, rules *GasRules, suppliedGas uint64) *Meter {
+	return &Meter{
+		evm:      evm,
+		contract: contract,
+		rules:    rules,
+		gas:      suppliedGas,
+	}
+}
+
+// Gas returns the remaining gas
+func (m *Meter) Gas() uint64 {
+	return m.gas
+}
+
+// UseGas subtracts the amount from the remaining gas, returns false if there isn't enough gas
+func (m *Meter) UseGas(amount uint64) bool {
+	if m.gas < amount {
+		return false
+	}
+	m.gas -= amount
+	return true
+}
+
+// Method charges the base cost of the method call
+func (m *Meter) Method(method string) bool {
+	if m.rules == nil {
+		return m.UseGas(legacyMethods.MethodGas(m.contract, method))
+	}
+	return m.UseGas(m.rules.MethodGas(m.contract, method))
+}
+
+func (m *Meter) slotAccess(addr common.Address, slot common.Hash) uint64 {
+	if _, slotWarm := m.evm.StateDB.SlotInAccessList(addr, slot); slotWarm {
+		return m.rules.WarmAccessGas
+	}
+	m.evm.StateDB.AddSlotToAccessList(addr, slot)
+	return m.rules.ColdSloadGas
+}
+
+// Sload charges a read of the storage slot of the given account
+func (m *Meter) Sload(addr common.Address, slot common.Hash) bool {
+	if m.rules == nil {
+		return true
+	}
+	return m.UseGas(m.slotAccess(addr, slot))
+}
+
+// Sstore charges a write of the value into the storage slot of the given account
+func (m *Meter) Sstore(addr common.Address, slot common.Hash, value common.Hash) bool {
+	if m.rules == nil {
+		return true
+	}
+	cost := m.slotAccess(addr, slot)
+	if current := m.evm.StateDB.GetState(addr, slot); current == (common.Hash{}) && value != (common.Hash{}) {
+		cost += m.rules.SstoreSetGas
+	} else {
+		cost += m.rules.SstoreResetGas
+	}
+	return m.UseGas(cost)
+}
+
+// Account charges an access to the account, e.g. a balance read or a code read
+func (m *Meter) Account(addr common.Address) bool {
+	if m.rules == nil {
+		return true
+	}
+	if m.evm.StateDB.AddressInAccessList(addr) {
+		return m.UseGas(m.rules.WarmAccessGas)
+	}
+	m.evm.StateDB.AddAddressToAccessList(addr)
+	return m.UseGas(m.rules.ColdAccountAccessGas)
+}
+
+// Log charges an emission of a log record
+func (m *Meter) Log(topics int, dataSize int) bool {
+	if m.rules == nil {
+		return true
+	}
+	return m.UseGas(m.rules.LogGas + uint64(topics)*m.rules.LogTopicGas + uint64(dataSize)*m.rules.LogDataGas)
+}
@@ -0,0 +1,86 @@
+package precompiles
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/stretchr/testify/require"
+
+	"github.com/artheranet/arthera-node/contracts"
+)
+
+func newTestEVM() *vm.EVM {
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	blockCtx := vm.BlockContext{
+		BlockNumber: big.NewInt(1),
+		Time:        big.NewInt(1),
+		Difficulty:  big.NewInt(1),
+		BaseFee:     big.NewInt(0),
+	}
+	return vm.NewEVM(blockCtx, vm.TxContext{}, statedb, params.TestChainConfig, vm.Config{})
+}
+
+func TestMeter(t *testing.T) {
+	require := require.New(t)
+	evm := newTestEVM()
+	rules := DefaultGasRules()
+	acc := common.HexToAddress("0x1")
+	slot := common.HexToHash("0x2")
+	const gas = 1000000
+
+	m := NewMeter(evm, contracts.NativeTokenSmartContractAddress, &rules, gas)
+	require.True(m.Method("transfer"))
+	require.True(m.Account(acc))
+	require.True(m.Account(acc))
+	require.True(m.Sload(acc, slot))
+	require.True(m.Sstore(acc, slot, common.HexToHash("0x3")))
+	require.True(m.Log(3, 32))
+	require.Equal(uint64(gas)-
+		params.CallValueTransferGas-
+		rules.ColdAccountAccessGas-rules.WarmAccessGas-
+		rules.ColdSloadGas-rules.WarmAccessGas-rules.SstoreSetGas-
+		rules.LogGas-3*rules.LogTopicGas-32*rules.LogDataGas, m.Gas())
+
+	// the accessed accounts and slots are added to the access list of the transaction
+	require.True(evm.StateDB.AddressInAccessList(acc))
+	_, slotWarm := evm.StateDB.SlotInAccessList(acc, slot)
+	require.True(slotWarm)
+
+	// so they are warm for the next calls
+	m = NewMeter(evm, contracts.NativeTokenSmartContractAddress, &rules, gas)
+	require.True(m.Account(acc))
+	require.True(m.Sload(acc, slot))
+	require.Equal(uint64(gas)-2*rules.WarmAccessGas, m.Gas())
+
+	// out of gas
+	m = NewMeter(evm, contracts.NativeTokenSmartContractAddress, &rules, rules.ColdSloadGas-1)
+	require.False(m.Sload(acc, common.HexToHash("0x4")))
+}
+
+func TestLegacyMeter(t *testing.T) {
+	require := require.New(t)
+	evm := newTestEVM()
+	acc := common.HexToAddress("0x1")
+	const gas = 100000
+
+	// only the flat costs of the methods are charged
+	m := NewMeter(evm, contracts.NativeTokenSmartContractAddress, nil, gas)
+	require.True(m.Method("approve"))
+	require.True(m.Method("balanceOf"))
+	require.True(m.Account(acc))
+	require.True(m.Sload(acc, common.Hash{}))
+	require.True(m.Sstore(acc, common.Hash{}, common.HexToHash("0x1")))
+	require.True(m.Log(3, 32))
+	require.Equal(uint64(gas)-params.SstoreSetGasEIP2200, m.Gas())
+
+	m = NewMeter(evm, contracts.EvmWriterSmartContractAddress, nil, gas)
+	require.True(m.Method("setStorage"))
+	require.True(m.Method("swapCode"))
+	require.Equal(uint64(gas)-params.SstoreSetGasEIP2200-2*params.CreateGas, m.Gas())
+	require.False(m.Method("copyCode"))
+}
@@ -17,6 +17,7 @@ var (
 	getOwnerOfContract = runner.NewBoundMethod(contracts.PayAsYouGoGasRewardsContractAddress, abis.PayAsYouGoGasRewards, "getOwnerOfContract", params.MaxGasForSetOwnerOfContract)
 	setOwnerOfContract = runner.NewBoundMethod(contracts.PayAsYouGoGasRewardsContractAddress, abis.PayAsYouGoGasRewards, "setOwnerOfContract", params.MaxGasForSetOwnerOfContract)
 	addReward          = runner.NewBoundMethod(contracts.PayAsYouGoGasRewardsContractAddress, abis.PayAsYouGoGasRewards, "addReward", params.MaxGasForAddReward)
+	getRewards         = runner.NewBoundMethod(contracts.PayAsYouGoGasRewardsContractAddress, abis.PayAsYouGoGasRewards, "getRewards", params.MaxGasForGetRewards)
 )
 
 func getStorageKey(addr common.Address) common.Hash {
@@ -88,3 +89,17 @@ func SetOwnerOfContract(evmRunner vmcontext.EVMRunner, contract common.Address,
 
 	return nil
 }
+
+// GetRewards returns the rewards earned by the contract which are not claimed yet.
+func GetRewards(evmRunner vmcontext.EVMRunner, contract common.Address) (*big.Int, error) {
+	var result *big.Int
+	evmRunner.StopGasMetering()
+	evmRunner.StopDebug()
+	defer evmRunner.StartGasMetering()
+	defer evmRunner.StartDebug()
+	err := getRewards.Query(evmRunner, &result, contract)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
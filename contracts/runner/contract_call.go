@@ -51,6 +51,10 @@ func (am Method) decodeResult(result interface{}, output []byte) error {
 	if result == nil {
 		return nil
 	}
+	// multiple outputs are copied into the fields of the result struct
+	if len(am.abi.Methods[am.method].Outputs) > 1 {
+		return am.abi.UnpackIntoInterface(result, am.method, output)
+	}
 	return am.abi.UnpackIntoInterface(&result, am.method, output)
 }
 
@@ -20,6 +20,8 @@ var (
 	getCapWindow             = runner.NewBoundMethod(contracts.SubscribersSmartContractAddress, abis.Subscribers, "getCapWindow", params.MaxGasForGetSub)
 	isWhitelistedForContract = runner.NewBoundMethod(contracts.SubscribersSmartContractAddress, abis.Subscribers, "isWhitelistedForContract", params.MaxGasForIsWhitelisted)
 	subscribersById          = runner.NewBoundMethod(contracts.SubscribersSmartContractAddress, abis.Subscribers, "subscribersById", params.MaxGasForGetSub)
+	getPlans                 = runner.NewBoundMethod(contracts.SubscribersSmartContractAddress, abis.Subscribers, "getPlans", params.MaxGasForGetPlans)
+	getPlan                  = runner.NewBoundMethod(contracts.SubscribersSmartContractAddress, abis.Subscribers, "getPlan", params.MaxGasForGetPlan)
 )
 
 type Subscription struct {
@@ -32,6 +34,19 @@ type Subscription struct {
 	PeriodUsage  *big.Int
 }
 
+type Plan struct {
+	PlanId       *big.Int
+	Name         string
+	Description  string
+	Duration     *big.Int
+	Units        *big.Int
+	Price        *big.Int
+	CapFrequency *big.Int
+	CapUnits     *big.Int
+	ForContract  bool
+	Active       bool
+}
+
 func HasActiveSubscription(evmRunner vmcontext.EVMRunner, subscriber common.Address, contractSub bool) (bool, error) {
 	var result bool
 	if subscriber == params.ZeroAddress {
@@ -162,3 +177,51 @@ func GetSubscriberById(evmRunner vmcontext.EVMRunner, id *big.Int) (common.Addre
 	}
 	return result, nil
 }
+
+func GetPlans(evmRunner vmcontext.EVMRunner) ([]Plan, error) {
+	var result []Plan
+	evmRunner.StopDebug()
+	evmRunner.StopGasMetering()
+	defer evmRunner.StartGasMetering()
+	defer evmRunner.StartDebug()
+	err := getPlans.Query(evmRunner, &result)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func GetPlan(evmRunner vmcontext.EVMRunner, planId *big.Int) (*Plan, error) {
+	var result Plan
+	if planId == nil || planId.BitLen() == 0 {
+		return nil, nil
+	}
+	evmRunner.StopDebug()
+	evmRunner.StopGasMetering()
+	defer evmRunner.StartGasMetering()
+	defer evmRunner.StartDebug()
+	err := getPlan.Query(evmRunner, &result, planId)
+	if err != nil {
+		return nil, err
+	}
+	// unknown plans are returned zeroed
+	if result.PlanId == nil || result.PlanId.Cmp(planId) != 0 {
+		return nil, nil
+	}
+	return &result, nil
+}
+
+// CreatePlan returns the calldata of the plan creation, which may be sent by the contract owner only
+func CreatePlan(name, description string, duration, units, usdPrice, capFrequency, capUnits *big.Int, forContract bool) ([]byte, error) {
+	return abis.Subscribers.Pack(
+		"createPlan",
+		name,
+		description,
+		duration,
+		units,
+		usdPrice,
+		capFrequency,
+		capUnits,
+		forContract,
+	)
+}
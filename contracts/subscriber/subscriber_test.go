@@ -0,0 +1,98 @@
+package subscriber
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+
+	"github.com/artheranet/arthera-node/contracts"
+	"github.com/artheranet/arthera-node/contracts/abis"
+	"github.com/artheranet/arthera-node/params"
+)
+
+type testRunner struct {
+	calls  int
+	input  []byte
+	gas    uint64
+	output []byte
+}
+
+func (r *testRunner) Execute(recipient common.Address, input []byte, gas uint64, value *big.Int) ([]byte, error) {
+	panic("not expected")
+}
+
+func (r *testRunner) ExecuteFrom(sender, recipient common.Address, input []byte, gas uint64, value *big.Int) ([]byte, error) {
+	panic("not expected")
+}
+
+func (r *testRunner) Query(recipient common.Address, input []byte, gas uint64) ([]byte, error) {
+	if recipient != contracts.SubscribersSmartContractAddress {
+		panic("unexpected contract")
+	}
+	r.calls++
+	r.input = input
+	r.gas = gas
+	return r.output, nil
+}
+
+func (r *testRunner) StopGasMetering()  {}
+func (r *testRunner) StartGasMetering() {}
+func (r *testRunner) StopDebug()        {}
+func (r *testRunner) StartDebug()       {}
+
+func packPlan(t *testing.T, p Plan) []byte {
+	out, err := abis.Subscribers.Methods["getPlan"].Outputs.Pack(
+		p.PlanId, p.Name, p.Description, p.Duration, p.Units, p.Price, p.CapFrequency, p.CapUnits, p.ForContract, p.Active)
+	require.NoError(t, err)
+	return out
+}
+
+func TestGetPlan(t *testing.T) {
+	require := require.New(t)
+
+	plan := Plan{
+		PlanId:       big.NewInt(2),
+		Name:         "basic",
+		Description:  "basic plan",
+		Duration:     big.NewInt(30 * 24 * 3600),
+		Units:        big.NewInt(1000000),
+		Price:        big.NewInt(10),
+		CapFrequency: big.NewInt(1),
+		CapUnits:     big.NewInt(5000),
+		ForContract:  true,
+		Active:       true,
+	}
+	runner := &testRunner{output: packPlan(t, plan)}
+
+	got, err := GetPlan(runner, big.NewInt(2))
+	require.NoError(err)
+	require.Equal(&plan, got)
+
+	// the plan is queried directly rather than found among all the plans
+	expInput, err := abis.Subscribers.Pack("getPlan", big.NewInt(2))
+	require.NoError(err)
+	require.Equal(1, runner.calls)
+	require.Equal(expInput, runner.input)
+	require.Equal(params.MaxGasForGetPlan, runner.gas)
+
+	// unknown plan
+	runner.output = packPlan(t, Plan{
+		PlanId:       new(big.Int),
+		Duration:     new(big.Int),
+		Units:        new(big.Int),
+		Price:        new(big.Int),
+		CapFrequency: new(big.Int),
+		CapUnits:     new(big.Int),
+	})
+	got, err = GetPlan(runner, big.NewInt(3))
+	require.NoError(err)
+	require.Nil(got)
+
+	// no plan
+	got, err = GetPlan(runner, new(big.Int))
+	require.NoError(err)
+	require.Nil(got)
+	require.Equal(2, runner.calls)
+}
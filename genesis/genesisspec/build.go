@@ -0,0 +1,133 @@
+package genesisspec
+
+import (
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/artheranet/lachesis/hash"
+	"github.com/artheranet/lachesis/inter/idx"
+	"github.com/artheranet/lachesis/kvdb/memorydb"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"github.com/artheranet/arthera-node/contracts"
+	"github.com/artheranet/arthera-node/contracts/driver"
+	"github.com/artheranet/arthera-node/contracts/subscriber"
+	"github.com/artheranet/arthera-node/genesis"
+	"github.com/artheranet/arthera-node/genesis/builder"
+	"github.com/artheranet/arthera-node/genesis/genesisstore"
+)
+
+// ownerTx builds a genesis transaction sent by the owner of the system contracts,
+// it's an internal transaction which carries the sender in place of the signature
+func ownerTx(nonce uint64, owner, to common.Address, calldata []byte) *types.Transaction {
+	return types.NewTx(&types.LegacyTx{
+		Nonce:    nonce,
+		GasPrice: common.Big0,
+		Gas:      1e10,
+		To:       &to,
+		Value:    common.Big0,
+		Data:     calldata,
+		V:        new(big.Int),
+		R:        new(big.Int),
+		S:        new(big.Int).SetBytes(owner.Bytes()),
+	})
+}
+
+// Build creates the genesis store described by the spec
+func (s *Spec) Build() (*genesisstore.Store, hash.Hash, error) {
+	if err := s.Validate(); err != nil {
+		return nil, hash.Hash{}, err
+	}
+	if s.Time == 0 {
+		s.Time = uint64(time.Now().Unix())
+	}
+	rules, err := s.NetworkRules()
+	if err != nil {
+		return nil, hash.Hash{}, err
+	}
+	validators, err := s.GenesisValidators()
+	if err != nil {
+		return nil, hash.Hash{}, err
+	}
+
+	genesisBuilder := builder.NewGenesisBuilder(memorydb.NewProducer(""))
+
+	delegations := make([]driver.Delegation, 0, len(validators))
+	for i, v := range validators {
+		spec := s.Validators[i]
+		genesisBuilder.AddBalance(v.Address, toBig(spec.Balance))
+
+		delegation := driver.Delegation{
+			Address:            v.Address,
+			ValidatorID:        v.ID,
+			Stake:              toBig(spec.Stake),
+			LockedStake:        new(big.Int),
+			EarlyUnlockPenalty: new(big.Int),
+			Rewards:            new(big.Int),
+		}
+		if spec.Lockup != nil {
+			delegation.LockedStake = toBig(spec.Lockup.Amount)
+			delegation.LockupEndTime = idx.Epoch(s.Time + spec.Lockup.Duration)
+			delegation.LockupDuration = spec.Lockup.Duration
+		}
+		delegations = append(delegations, delegation)
+	}
+
+	for _, a := range s.Accounts {
+		genesisBuilder.AddBalance(a.Address, toBig(a.Balance))
+	}
+
+	genesisBuilder.DeployBaseContracts()
+
+	statedb := genesisBuilder.GetStateDB()
+	for _, c := range s.Contracts {
+		if statedb.GetCodeSize(c.Address) != 0 {
+			return nil, hash.Hash{}, fmt.Errorf("contract %s: the address is occupied by a system contract", c.Address.String())
+		}
+		genesisBuilder.SetCode(c.Address, c.Code)
+		genesisBuilder.SetNonce(c.Address, c.Nonce)
+		genesisBuilder.AddBalance(c.Address, toBig(c.Balance))
+		for key, val := range c.Storage {
+			genesisBuilder.SetStorage(c.Address, key, val)
+		}
+	}
+
+	genesisBuilder.InitializeEpoch(1, 2, rules, s.GenesisTime())
+
+	owner := validators[0].Address
+	if s.Owner != nil {
+		owner = *s.Owner
+	}
+	genesisTxs := genesisBuilder.GetGenesisTxs(0, validators, genesisBuilder.TotalSupply(), delegations, owner)
+	// the plans are created by the owner, as the system contracts are owned by it after the initialization
+	ownerNonce := statedb.GetNonce(owner)
+	for i, p := range s.Plans {
+		calldata, err := subscriber.CreatePlan(
+			p.Name,
+			p.Description,
+			new(big.Int).SetUint64(p.Duration),
+			toBig(p.Units),
+			toBig(p.UsdPrice),
+			new(big.Int).SetUint64(p.CapFrequency),
+			toBig(p.CapUnits),
+			p.ForContract,
+		)
+		if err != nil {
+			return nil, hash.Hash{}, fmt.Errorf("plan %s: %w", p.Name, err)
+		}
+		genesisTxs = append(genesisTxs, ownerTx(ownerNonce+uint64(i), owner, contracts.SubscribersSmartContractAddress, calldata))
+	}
+	// a reverted genesis transaction fails the execution, so a plan can't be silently dropped
+	err = genesisBuilder.ExecuteGenesisTxs(builder.DefaultBlockProc(), genesisTxs)
+	if err != nil {
+		return nil, hash.Hash{}, err
+	}
+
+	return genesisBuilder.Build(genesis.Header{
+		GenesisID:   genesisBuilder.CurrentHash(),
+		NetworkID:   rules.NetworkID,
+		NetworkName: rules.Name,
+	}), genesisBuilder.CurrentHash(), nil
+}
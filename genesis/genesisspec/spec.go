@@ -0,0 +1,316 @@
+package genesisspec
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/artheranet/lachesis/inter/idx"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/naoina/toml"
+
+	"github.com/artheranet/arthera-node/genesis"
+	"github.com/artheranet/arthera-node/internal/inter"
+	"github.com/artheranet/arthera-node/internal/inter/validatorpk"
+	"github.com/artheranet/arthera-node/params"
+)
+
+type (
+	// Spec is a declarative description of a genesis
+	Spec struct {
+		// BaseRules is the name of the rules the network rules are derived from: mainnet, testnet, devnet or fakenet
+		BaseRules   string `json:"baseRules" toml:"BaseRules"`
+		NetworkID   uint64 `json:"networkId" toml:"NetworkID"`
+		NetworkName string `json:"networkName" toml:"NetworkName"`
+		// Rules is a diff applied on top of the base rules, in the same format as the rules updates
+		Rules map[string]interface{} `json:"rules,omitempty" toml:"Rules,omitempty"`
+		// Time is the genesis time in unix seconds, the current time is used if zero
+		Time uint64 `json:"time" toml:"Time"`
+		// Owner is the owner of the system contracts, the first validator is used if not specified
+		Owner *common.Address `json:"owner,omitempty" toml:"Owner,omitempty"`
+
+		Validators []Validator `json:"validators" toml:"Validators"`
+		Accounts   []Account   `json:"accounts,omitempty" toml:"Accounts,omitempty"`
+		Contracts  []Contract  `json:"contracts,omitempty" toml:"Contracts,omitempty"`
+		Plans      []Plan      `json:"plans,omitempty" toml:"Plans,omitempty"`
+	}
+
+	// Validator is a genesis validator with its self-stake
+	Validator struct {
+		ID uint32 `json:"id" toml:"ID"`
+		// Address must match the public key if specified
+		Address *common.Address       `json:"address,omitempty" toml:"Address,omitempty"`
+		PubKey  validatorpk.PubKey    `json:"pubkey" toml:"PubKey"`
+		Stake   *math.HexOrDecimal256 `json:"stake" toml:"Stake"`
+		Balance *math.HexOrDecimal256 `json:"balance,omitempty" toml:"Balance,omitempty"`
+		Lockup  *Lockup               `json:"lockup,omitempty" toml:"Lockup,omitempty"`
+	}
+
+	// Lockup locks a part of the validator's stake since the genesis
+	Lockup struct {
+		Amount *math.HexOrDecimal256 `json:"amount" toml:"Amount"`
+		// Duration in seconds
+		Duration uint64 `json:"duration" toml:"Duration"`
+	}
+
+	// Account is a premined account
+	Account struct {
+		Address common.Address        `json:"address" toml:"Address"`
+		Balance *math.HexOrDecimal256 `json:"balance" toml:"Balance"`
+	}
+
+	// Contract is an extra code and storage allocation
+	Contract struct {
+		Address common.Address              `json:"address" toml:"Address"`
+		Balance *math.HexOrDecimal256       `json:"balance,omitempty" toml:"Balance,omitempty"`
+		Nonce   uint64                      `json:"nonce,omitempty" toml:"Nonce,omitempty"`
+		Code    hexutil.Bytes               `json:"code" toml:"Code"`
+		Storage map[common.Hash]common.Hash `json:"storage,omitempty" toml:"Storage,omitempty"`
+	}
+
+	// Plan is an initial subscription plan of the Subscribers contract
+	Plan struct {
+		Name        string `json:"name" toml:"Name"`
+		Description string `json:"description" toml:"Description"`
+		// Duration in seconds
+		Duration     uint64                `json:"duration" toml:"Duration"`
+		Units        *math.HexOrDecimal256 `json:"units" toml:"Units"`
+		UsdPrice     *math.HexOrDecimal256 `json:"usdPrice" toml:"UsdPrice"`
+		CapFrequency uint64                `json:"capFrequency" toml:"CapFrequency"`
+		CapUnits     *math.HexOrDecimal256 `json:"capUnits" toml:"CapUnits"`
+		ForContract  bool                  `json:"forContract" toml:"ForContract"`
+	}
+)
+
+var tomlSettings = toml.Config{
+	NormFieldName: func(_ reflect.Type, key string) string {
+		return key
+	},
+	FieldToKey: func(_ reflect.Type, field string) string {
+		return field
+	},
+	MissingField: func(rt reflect.Type, field string) error {
+		return fmt.Errorf("field '%s' is not defined in %s", field, rt.String())
+	},
+}
+
+// Load reads the spec from a JSON or TOML file, the format is defined by the file extension
+func Load(path string) (*Spec, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	spec := &Spec{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		dec := json.NewDecoder(bufio.NewReader(f))
+		dec.DisallowUnknownFields()
+		err = dec.Decode(spec)
+	case ".toml":
+		err = tomlSettings.NewDecoder(bufio.NewReader(f)).Decode(spec)
+	default:
+		return nil, fmt.Errorf("unknown genesis spec format %s, expected .json or .toml", filepath.Ext(path))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode genesis spec %s: %v", path, err)
+	}
+	return spec, nil
+}
+
+func toBig(v *math.HexOrDecimal256) *big.Int {
+	if v == nil {
+		return new(big.Int)
+	}
+	return new(big.Int).Set((*big.Int)(v))
+}
+
+// GenesisTime returns the genesis time
+func (s *Spec) GenesisTime() inter.Timestamp {
+	if s.Time == 0 {
+		return inter.FromUnix(time.Now().Unix())
+	}
+	return inter.FromUnix(int64(s.Time))
+}
+
+// NetworkRules returns the network rules, derived from the base rules and the overrides
+func (s *Spec) NetworkRules() (params.ProtocolRules, error) {
+	var rules params.ProtocolRules
+	switch s.BaseRules {
+	case "mainnet":
+		rules = params.MainNetRules()
+	case "testnet":
+		rules = params.TestNetRules()
+	case "devnet", "":
+		rules = params.DevNetRules()
+	case "fakenet":
+		rules = params.FakeNetRules()
+	default:
+		return rules, fmt.Errorf("unknown base rules %s, supported values are: mainnet, testnet, devnet, fakenet", s.BaseRules)
+	}
+	if len(s.Rules) != 0 {
+		diff, err := json.Marshal(s.Rules)
+		if err != nil {
+			return rules, err
+		}
+		rules, err = params.UpdateRules(rules, diff)
+		if err != nil {
+			return rules, fmt.Errorf("invalid rules overrides: %v", err)
+		}
+	}
+	if s.NetworkID != 0 {
+		rules.NetworkID = s.NetworkID
+	}
+	if s.NetworkName != "" {
+		rules.Name = s.NetworkName
+	}
+	return rules, nil
+}
+
+// GenesisValidators returns the genesis validators
+func (s *Spec) GenesisValidators() (genesis.Validators, error) {
+	validators := make(genesis.Validators, 0, len(s.Validators))
+	for _, v := range s.Validators {
+		if v.PubKey.Type != validatorpk.Types.Secp256k1 {
+			return nil, fmt.Errorf("validator %d: unsupported pubkey type %#x", v.ID, v.PubKey.Type)
+		}
+		ecdsaPubkey, err := crypto.UnmarshalPubkey(v.PubKey.Raw)
+		if err != nil {
+			return nil, fmt.Errorf("validator %d: invalid pubkey: %v", v.ID, err)
+		}
+		addr := crypto.PubkeyToAddress(*ecdsaPubkey)
+		if v.Address != nil && *v.Address != addr {
+			return nil, fmt.Errorf("validator %d: address %s doesn't match the pubkey address %s", v.ID, v.Address.String(), addr.String())
+		}
+		validators = append(validators, genesis.Validator{
+			ID:            idx.ValidatorID(v.ID),
+			Address:       addr,
+			PubKey:        v.PubKey.Copy(),
+			CreationTime:  s.GenesisTime(),
+			CreationEpoch: 0,
+		})
+	}
+	return validators, nil
+}
+
+func validateRules(rules params.ProtocolRules) error {
+	if rules.NetworkID == 0 {
+		return errors.New("network ID must be specified")
+	}
+	if rules.Name == "" {
+		return errors.New("network name must be specified")
+	}
+	if rules.Blocks.MaxBlockGas == 0 {
+		return errors.New("rules: Blocks.MaxBlockGas must be positive")
+	}
+	if rules.Epochs.MaxEpochGas == 0 || rules.Epochs.MaxEpochDuration == 0 {
+		return errors.New("rules: Epochs.MaxEpochGas and Epochs.MaxEpochDuration must be positive")
+	}
+	if rules.Dag.MaxParents < 2 {
+		return errors.New("rules: Dag.MaxParents must be at least 2")
+	}
+	if rules.Economy.MinGasPrice == nil || rules.Economy.MinGasPrice.Sign() < 0 {
+		return errors.New("rules: Economy.MinGasPrice must be non-negative")
+	}
+	if !rules.Upgrades.Berlin && rules.Upgrades.London {
+		return errors.New("rules: London upgrade requires Berlin")
+	}
+	return nil
+}
+
+// Validate checks the spec consistency
+func (s *Spec) Validate() error {
+	rules, err := s.NetworkRules()
+	if err != nil {
+		return err
+	}
+	if err := validateRules(rules); err != nil {
+		return err
+	}
+
+	if len(s.Validators) == 0 {
+		return errors.New("at least one validator is required")
+	}
+	validators, err := s.GenesisValidators()
+	if err != nil {
+		return err
+	}
+	ids := make(map[idx.ValidatorID]bool, len(validators))
+	addrs := make(map[common.Address]bool, len(validators))
+	for i, v := range validators {
+		if v.ID == 0 {
+			return errors.New("validator ID must be positive")
+		}
+		if ids[v.ID] {
+			return fmt.Errorf("duplicate validator ID %d", v.ID)
+		}
+		if addrs[v.Address] {
+			return fmt.Errorf("duplicate validator address %s", v.Address.String())
+		}
+		ids[v.ID] = true
+		addrs[v.Address] = true
+
+		spec := s.Validators[i]
+		stake := toBig(spec.Stake)
+		if stake.Sign() <= 0 {
+			return fmt.Errorf("validator %d: stake must be positive", v.ID)
+		}
+		if spec.Lockup != nil {
+			locked := toBig(spec.Lockup.Amount)
+			if locked.Sign() < 0 || locked.Cmp(stake) > 0 {
+				return fmt.Errorf("validator %d: locked amount must be within the stake", v.ID)
+			}
+			if spec.Lockup.Duration == 0 {
+				return fmt.Errorf("validator %d: lockup duration must be positive", v.ID)
+			}
+		}
+	}
+
+	accounts := make(map[common.Address]bool, len(s.Accounts))
+	for _, a := range s.Accounts {
+		if accounts[a.Address] {
+			return fmt.Errorf("duplicate account %s", a.Address.String())
+		}
+		accounts[a.Address] = true
+		if toBig(a.Balance).Sign() < 0 {
+			return fmt.Errorf("account %s: negative balance", a.Address.String())
+		}
+	}
+	contracts := make(map[common.Address]bool, len(s.Contracts))
+	for _, c := range s.Contracts {
+		if contracts[c.Address] {
+			return fmt.Errorf("duplicate contract %s", c.Address.String())
+		}
+		contracts[c.Address] = true
+		if len(c.Code) == 0 {
+			return fmt.Errorf("contract %s: code must be specified", c.Address.String())
+		}
+		if toBig(c.Balance).Sign() < 0 {
+			return fmt.Errorf("contract %s: negative balance", c.Address.String())
+		}
+	}
+
+	for _, p := range s.Plans {
+		if p.Name == "" {
+			return errors.New("plan name must be specified")
+		}
+		if p.Duration == 0 || toBig(p.Units).Sign() <= 0 {
+			return fmt.Errorf("plan %s: duration and units must be positive", p.Name)
+		}
+		if toBig(p.UsdPrice).Sign() < 0 || toBig(p.CapUnits).Sign() < 0 {
+			return fmt.Errorf("plan %s: negative price or cap", p.Name)
+		}
+	}
+	return nil
+}
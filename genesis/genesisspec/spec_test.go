@@ -0,0 +1,201 @@
+package genesisspec
+
+import (
+	"math"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/artheranet/lachesis/hash"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/stretchr/testify/require"
+
+	"github.com/artheranet/arthera-node/contracts/subscriber"
+	"github.com/artheranet/arthera-node/gossip"
+	"github.com/artheranet/arthera-node/internal/evmcore"
+	"github.com/artheranet/arthera-node/internal/evmcore/vmcontext"
+	"github.com/artheranet/arthera-node/params"
+)
+
+const (
+	testPubKey  = "0xc004bf689e0aa508fc18c9820348cea64cc8b3b3dff85af513fef6309a514c21b33d96e6113904e21c49e012cb73c46d1e5b8ab7cad64131b27a8578d9f87a298f49"
+	testAddress = "0xC34ad0296f606749Ff8F77b75A7d577eB2CDF846"
+)
+
+const testJSONSpec = `{
+  "baseRules": "devnet",
+  "networkId": 12345,
+  "networkName": "spec-test",
+  "time": 1700000000,
+  "rules": {"Blocks": {"MaxBlockGas": 30000000}},
+  "validators": [{
+    "id": 1,
+    "address": "` + testAddress + `",
+    "pubkey": "` + testPubKey + `",
+    "stake": "5000000000000000000000000",
+    "balance": "0x1000",
+    "lockup": {"amount": "1000", "duration": 86400}
+  }],
+  "accounts": [{"address": "0x0000000000000000000000000000000000001234", "balance": "100"}],
+  "contracts": [{
+    "address": "0x0000000000000000000000000000000000005678",
+    "code": "0x6000",
+    "storage": {"0x0000000000000000000000000000000000000000000000000000000000000001": "0x0000000000000000000000000000000000000000000000000000000000000002"}
+  }],
+  "plans": [{"name": "basic", "description": "Basic plan", "duration": 2592000, "units": "1000000", "usdPrice": "100", "capFrequency": 86400, "capUnits": "50000", "forContract": false}]
+}`
+
+const testTOMLSpec = `
+BaseRules = "devnet"
+NetworkID = 12345
+NetworkName = "spec-test"
+Time = 1700000000
+
+[[Validators]]
+ID = 1
+PubKey = "` + testPubKey + `"
+Stake = "5000000000000000000000000"
+
+[[Plans]]
+Name = "basic"
+Description = "Basic plan"
+Duration = 2592000
+Units = "1000000"
+UsdPrice = "100"
+CapFrequency = 86400
+CapUnits = "50000"
+ForContract = true
+`
+
+func writeSpec(t *testing.T, name, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+	return path
+}
+
+func TestLoadJSON(t *testing.T) {
+	require := require.New(t)
+
+	spec, err := Load(writeSpec(t, "genesis.json", testJSONSpec))
+	require.NoError(err)
+	require.NoError(spec.Validate())
+
+	rules, err := spec.NetworkRules()
+	require.NoError(err)
+	require.Equal(uint64(12345), rules.NetworkID)
+	require.Equal("spec-test", rules.Name)
+	require.Equal(uint64(30000000), rules.Blocks.MaxBlockGas)
+
+	validators, err := spec.GenesisValidators()
+	require.NoError(err)
+	require.Len(validators, 1)
+	require.Equal(common.HexToAddress(testAddress), validators[0].Address)
+	require.Equal(uint64(0x1000), toBig(spec.Validators[0].Balance).Uint64())
+	require.Equal(uint64(1000), toBig(spec.Validators[0].Lockup.Amount).Uint64())
+
+	require.Len(spec.Contracts, 1)
+	require.Equal([]byte{0x60, 0x00}, []byte(spec.Contracts[0].Code))
+	require.Len(spec.Contracts[0].Storage, 1)
+	require.Len(spec.Plans, 1)
+}
+
+func TestLoadTOML(t *testing.T) {
+	require := require.New(t)
+
+	spec, err := Load(writeSpec(t, "genesis.toml", testTOMLSpec))
+	require.NoError(err)
+	require.NoError(spec.Validate())
+
+	validators, err := spec.GenesisValidators()
+	require.NoError(err)
+	require.Equal(common.HexToAddress(testAddress), validators[0].Address)
+	require.Len(spec.Plans, 1)
+	require.True(spec.Plans[0].ForContract)
+}
+
+func TestValidate(t *testing.T) {
+	require := require.New(t)
+
+	load := func() *Spec {
+		spec, err := Load(writeSpec(t, "genesis.json", testJSONSpec))
+		require.NoError(err)
+		return spec
+	}
+
+	spec := load()
+	wrong := common.HexToAddress("0x1")
+	spec.Validators[0].Address = &wrong
+	require.Error(spec.Validate())
+
+	spec = load()
+	spec.Validators = append(spec.Validators, spec.Validators[0])
+	require.Error(spec.Validate())
+
+	spec = load()
+	spec.Validators = nil
+	require.Error(spec.Validate())
+
+	spec = load()
+	spec.Validators[0].Lockup.Duration = 0
+	require.Error(spec.Validate())
+
+	spec = load()
+	spec.Rules = map[string]interface{}{"Blocks": map[string]interface{}{"MaxBlockGas": 0}}
+	require.Error(spec.Validate())
+
+	spec = load()
+	spec.BaseRules = "unknown"
+	require.Error(spec.Validate())
+
+	_, err := Load(writeSpec(t, "genesis.yaml", testJSONSpec))
+	require.Error(err)
+}
+
+func TestBuild(t *testing.T) {
+	require := require.New(t)
+
+	spec, err := Load(writeSpec(t, "genesis.json", testJSONSpec))
+	require.NoError(err)
+	genesisStore, _, err := spec.Build()
+	require.NoError(err)
+	defer genesisStore.Close()
+
+	store := gossip.NewMemStore()
+	defer store.Close()
+	_, err = store.ApplyGenesis(genesisStore.Genesis())
+	require.NoError(err)
+
+	statedb, err := store.EvmStore().StateDB(hash.Hash(store.GetBlockState().FinalizedStateRoot))
+	require.NoError(err)
+	rules := store.GetRules()
+	blockCtx := vm.BlockContext{
+		CanTransfer: evmcore.CanTransfer,
+		Transfer:    evmcore.Transfer,
+		GetHash:     func(uint64) common.Hash { return common.Hash{} },
+		BlockNumber: big.NewInt(1),
+		Time:        new(big.Int).SetUint64(spec.Time),
+		Difficulty:  big.NewInt(1),
+		BaseFee:     big.NewInt(0),
+		GasLimit:    math.MaxUint64,
+	}
+	msg := types.NewMessage(params.ZeroAddress, nil, 0, new(big.Int), 0, new(big.Int), new(big.Int), new(big.Int), nil, nil, true)
+	evm := vm.NewEVM(blockCtx, evmcore.NewEVMTxContext(msg), statedb, rules.EvmChainConfig([]params.UpgradeHeight{{Upgrades: rules.Upgrades, Height: 0}}), rules.VMConfig(params.DefaultVMConfig))
+
+	plans, err := subscriber.GetPlans(&vmcontext.SharedEVMRunner{EVM: evm})
+	require.NoError(err)
+	var basic *subscriber.Plan
+	for i := range plans {
+		if plans[i].Name == "basic" {
+			basic = &plans[i]
+		}
+	}
+	require.NotNil(basic, "the plan of the spec is created")
+	require.Equal("Basic plan", basic.Description)
+	require.Equal(uint64(2592000), basic.Duration.Uint64())
+	require.Equal(uint64(1000000), basic.Units.Uint64())
+	require.Equal(uint64(50000), basic.CapUnits.Uint64())
+	require.False(basic.ForContract)
+}
@@ -11,6 +11,8 @@ const (
 	BlocksSection = "brs"
 	EpochsSection = "ers"
 	EvmSection    = "evm"
+	// BaseSection refers to the genesis which a differential genesis is applied over
+	BaseSection = "base"
 )
 
 type FilesMap func(string) (io.Reader, error)
@@ -20,6 +22,8 @@ type Store struct {
 	fMap  FilesMap
 	head  genesis.Header
 	close func() error
+	// base is the genesis which the differential genesis is applied over
+	base *Store
 
 	logger.Instance
 }
@@ -37,5 +41,11 @@ func NewStore(fMap FilesMap, head genesis.Header, close func() error) *Store {
 // Close leaves underlying database.
 func (s *Store) Close() error {
 	s.fMap = nil
-	return s.close()
+	err := s.close()
+	if s.base != nil {
+		if baseErr := s.base.Close(); err == nil {
+			err = baseErr
+		}
+	}
+	return err
 }
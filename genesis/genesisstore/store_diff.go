@@ -0,0 +1,139 @@
+package genesisstore
+
+import (
+	"errors"
+	"io/ioutil"
+	"sort"
+
+	"github.com/artheranet/lachesis/hash"
+	"github.com/ethereum/go-ethereum/rlp"
+
+	"github.com/artheranet/arthera-node/genesis"
+	"github.com/artheranet/arthera-node/genesis/genesisstore/fileshash"
+	"github.com/artheranet/arthera-node/genesis/genesisstore/readersmap"
+	"github.com/artheranet/arthera-node/internal/inter/ibr"
+	"github.com/artheranet/arthera-node/internal/inter/ier"
+)
+
+var (
+	ErrNotDiff      = errors.New("genesis isn't differential")
+	ErrBaseMismatch = errors.New("differential genesis doesn't refer to the base genesis")
+)
+
+type (
+	// baseHash is a section hash of the base genesis
+	baseHash struct {
+		Name string
+		Hash hash.Hash
+	}
+
+	// chained sections of a differential genesis and its base, the newest first
+	chainedBlocks []genesis.Blocks
+	chainedEpochs []genesis.Epochs
+	// chained EVM items, the oldest first so that the newer items overwrite the older ones
+	chainedEvmItems []genesis.EvmItems
+)
+
+// EncodeBaseHashes encodes the section hashes of the base genesis into the base section of a differential genesis.
+func EncodeBaseHashes(hashes genesis.Hashes) ([]byte, error) {
+	names := make([]string, 0, len(hashes))
+	for name := range hashes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	list := make([]baseHash, len(names))
+	for i, name := range names {
+		list[i] = baseHash{name, hashes[name]}
+	}
+	return rlp.EncodeToBytes(list)
+}
+
+// BaseHashes returns the section hashes of the genesis which the differential genesis is applied over,
+// or nil if the genesis isn't differential. The base section is consumed, so it may be called only once.
+func (s *Store) BaseHashes() (genesis.Hashes, error) {
+	f, err := s.fMap(BaseSection)
+	if isSectionNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	// read the section entirely to check its hash
+	b, err := ioutil.ReadAll(f)
+	if err != nil {
+		return nil, err
+	}
+	var list []baseHash
+	err = rlp.DecodeBytes(b, &list)
+	if err != nil {
+		return nil, err
+	}
+
+	hashes := make(genesis.Hashes, len(list))
+	for _, h := range list {
+		hashes[h.Name] = h.Hash
+	}
+	return hashes, nil
+}
+
+// isSectionNotFound returns true if the error means that the genesis doesn't have the section
+func isSectionNotFound(err error) bool {
+	return err == fileshash.ErrRootNotFound || err == readersmap.ErrNotFound
+}
+
+// ApplyOver chains the differential genesis over its base genesis.
+// The base hashes are hashes of the base genesis file, which are checked against the base section.
+func (s *Store) ApplyOver(base *Store, baseHashes genesis.Hashes) error {
+	hashes, err := s.BaseHashes()
+	if err != nil {
+		return err
+	}
+	if hashes == nil {
+		return ErrNotDiff
+	}
+	if !hashes.Equal(baseHashes) || !s.head.Equal(base.head) {
+		return ErrBaseMismatch
+	}
+	s.base = base
+	return nil
+}
+
+func (cc chainedBlocks) ForEach(fn func(ibr.LlrIdxFullBlockRecord) bool) {
+	stopped := false
+	for _, c := range cc {
+		c.ForEach(func(br ibr.LlrIdxFullBlockRecord) bool {
+			stopped = stopped || !fn(br)
+			return !stopped
+		})
+		if stopped {
+			return
+		}
+	}
+}
+
+func (cc chainedEpochs) ForEach(fn func(ier.LlrIdxFullEpochRecord) bool) {
+	stopped := false
+	for _, c := range cc {
+		c.ForEach(func(er ier.LlrIdxFullEpochRecord) bool {
+			stopped = stopped || !fn(er)
+			return !stopped
+		})
+		if stopped {
+			return
+		}
+	}
+}
+
+func (cc chainedEvmItems) ForEach(fn func(key, value []byte) bool) {
+	stopped := false
+	for _, c := range cc {
+		c.ForEach(func(key, value []byte) bool {
+			stopped = stopped || !fn(key, value)
+			return !stopped
+		})
+		if stopped {
+			return
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package genesisstore
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/artheranet/lachesis/hash"
+	"github.com/artheranet/lachesis/inter/idx"
+	"github.com/artheranet/lachesis/kvdb/memorydb"
+	"github.com/stretchr/testify/require"
+
+	"github.com/artheranet/arthera-node/genesis"
+	"github.com/artheranet/arthera-node/genesis/genesisstore/readersmap"
+	"github.com/artheranet/arthera-node/internal/inter/ier"
+	"github.com/artheranet/arthera-node/utils/iodb"
+)
+
+type testEpochs []idx.Epoch
+
+func (ee testEpochs) ForEach(fn func(ier.LlrIdxFullEpochRecord) bool) {
+	for _, e := range ee {
+		if !fn(ier.LlrIdxFullEpochRecord{Idx: e}) {
+			return
+		}
+	}
+}
+
+func newTestStore(head genesis.Header, files map[string][]byte) *Store {
+	return NewStore(func(name string) (io.Reader, error) {
+		b, ok := files[name]
+		if !ok {
+			return nil, readersmap.ErrNotFound
+		}
+		return bytes.NewReader(b), nil
+	}, head, func() error {
+		return nil
+	})
+}
+
+func testEvmSection(t *testing.T, kv ...string) []byte {
+	db := memorydb.New()
+	for i := 0; i < len(kv); i += 2 {
+		require.NoError(t, db.Put([]byte(kv[i]), []byte(kv[i+1])))
+	}
+	buf := bytes.NewBuffer(nil)
+	it := db.NewIterator(nil, nil)
+	defer it.Release()
+	require.NoError(t, iodb.Write(buf, it))
+	return buf.Bytes()
+}
+
+func TestDiffStore(t *testing.T) {
+	require := require.New(t)
+
+	head := genesis.Header{GenesisID: hash.FakeHash(1), NetworkID: 1, NetworkName: "test"}
+	baseHashes := genesis.Hashes{
+		EvmSection:    hash.FakeHash(2),
+		EpochsSection: hash.FakeHash(3),
+	}
+	baseSection, err := EncodeBaseHashes(baseHashes)
+	require.NoError(err)
+
+	base := newTestStore(head, map[string][]byte{
+		EvmSection: testEvmSection(t, "a", "1", "b", "1"),
+	})
+	newDiff := func() *Store {
+		return newTestStore(head, map[string][]byte{
+			BaseSection: baseSection,
+			EvmSection:  testEvmSection(t, "b", "2", "c", "2"),
+		})
+	}
+
+	// a complete genesis isn't differential
+	hashes, err := base.BaseHashes()
+	require.NoError(err)
+	require.Nil(hashes)
+	require.Equal(ErrNotDiff, newTestStore(head, nil).ApplyOver(base, baseHashes))
+
+	// a failed read of the base section isn't taken for a complete genesis
+	readErr := errors.New("read failed")
+	broken := NewStore(func(string) (io.Reader, error) {
+		return nil, readErr
+	}, head, func() error {
+		return nil
+	})
+	hashes, err = broken.BaseHashes()
+	require.Equal(readErr, err)
+	require.Nil(hashes)
+	require.Equal(readErr, broken.ApplyOver(base, baseHashes))
+
+	// the base has to match
+	wrongHashes := genesis.Hashes{EvmSection: hash.FakeHash(2)}
+	require.Equal(ErrBaseMismatch, newDiff().ApplyOver(base, wrongHashes))
+	otherHead := head
+	otherHead.NetworkID = 2
+	require.Equal(ErrBaseMismatch, newDiff().ApplyOver(newTestStore(otherHead, nil), baseHashes))
+
+	diff := newDiff()
+	require.NoError(diff.ApplyOver(base, baseHashes))
+
+	// EVM items of the base are overwritten by the diff
+	got := map[string]string{}
+	var keys []string
+	diff.RawEvmItems().ForEach(func(key, value []byte) bool {
+		got[string(key)] = string(value)
+		keys = append(keys, string(key))
+		return true
+	})
+	require.Equal(map[string]string{"a": "1", "b": "2", "c": "2"}, got)
+	require.Equal([]string{"a", "b", "b", "c"}, keys)
+
+	// the newest epochs come first
+	var epochs []idx.Epoch
+	chainedEpochs{testEpochs{5, 4}, testEpochs{3, 2, 1}}.ForEach(func(er ier.LlrIdxFullEpochRecord) bool {
+		epochs = append(epochs, er.Idx)
+		return er.Idx > 3
+	})
+	require.Equal([]idx.Epoch{5, 4, 3}, epochs)
+
+	require.NoError(diff.Close())
+}
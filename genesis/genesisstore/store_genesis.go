@@ -39,7 +39,7 @@ func getSectionName(base string, i int) string {
 	if i == 0 {
 		return base
 	}
-	return fmt.Sprintf("%s-%d", BlocksSection, i)
+	return fmt.Sprintf("%s-%d", base, i)
 }
 
 func (s Store) Header() genesis.Header {
@@ -47,6 +47,9 @@ func (s Store) Header() genesis.Header {
 }
 
 func (s *Store) Blocks() genesis.Blocks {
+	if s.base != nil {
+		return chainedBlocks{Blocks{s.fMap}, s.base.Blocks()}
+	}
 	return Blocks{s.fMap}
 }
 
@@ -74,6 +77,9 @@ func (s Blocks) ForEach(fn func(ibr.LlrIdxFullBlockRecord) bool) {
 }
 
 func (s *Store) Epochs() genesis.Epochs {
+	if s.base != nil {
+		return chainedEpochs{Epochs{s.fMap}, s.base.Epochs()}
+	}
 	return Epochs{s.fMap}
 }
 
@@ -101,6 +107,9 @@ func (s Epochs) ForEach(fn func(ier.LlrIdxFullEpochRecord) bool) {
 }
 
 func (s *Store) RawEvmItems() genesis.EvmItems {
+	if s.base != nil {
+		return chainedEvmItems{s.base.RawEvmItems(), RawEvmItems{s.fMap}}
+	}
 	return RawEvmItems{s.fMap}
 }
 
@@ -0,0 +1,50 @@
+package gossip
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/stretchr/testify/require"
+
+	"github.com/artheranet/arthera-node/api"
+	"github.com/artheranet/arthera-node/gossip/contract/ballot"
+)
+
+func TestDeveloperRewardsAPI(t *testing.T) {
+	require := require.New(t)
+
+	env := newTestEnv(2, 3)
+	defer env.Close()
+
+	// the deployer becomes the owner the rewards of the contract are credited to
+	addr, tx, cBallot, err := ballot.DeployBallot(env.Pay(1), env, [][32]byte{ballotOption("Option 1")})
+	require.NoError(err)
+	_, err = env.ApplyTxs(nextEpoch, tx)
+	require.NoError(err)
+
+	tx, err = cBallot.GiveRightToVote(env.Pay(1), env.Address(2))
+	require.NoError(err)
+	r, err := env.ApplyTxs(nextEpoch, tx)
+	require.NoError(err)
+	require.Equal(uint64(1), r[0].Status)
+
+	artAPI := api.NewPublicArtheraAPI(env.EthAPI)
+	ctx := context.Background()
+
+	res, err := artAPI.GetDeveloperRewards(ctx, env.Address(1), 0, rpc.LatestBlockNumber)
+	require.NoError(err)
+	require.Len(res.Contracts, 1)
+	require.Equal(addr, res.Contracts[0].Address)
+	require.Positive(res.Total.ToInt().Sign())
+	require.Equal(res.Total.ToInt(), res.Pending.ToInt(), "rewards are pending until claimed")
+	require.Zero(res.Claimed.ToInt().Sign())
+
+	res, err = artAPI.GetContractRewards(ctx, addr, 0, rpc.LatestBlockNumber)
+	require.NoError(err)
+	require.Equal(env.Address(1), res.Owner)
+	require.Len(res.Owners, 1)
+	require.Positive(res.Total.ToInt().Sign())
+	require.Equal(res.Total.ToInt(), res.Pending.ToInt())
+	require.Zero(res.Claimed.ToInt().Sign())
+}
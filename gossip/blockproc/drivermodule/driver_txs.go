@@ -19,6 +19,7 @@ import (
 	"github.com/artheranet/arthera-node/internal/inter/drivertype"
 	"github.com/artheranet/arthera-node/internal/inter/iblockproc"
 	"github.com/artheranet/arthera-node/internal/inter/validatorpk"
+	"github.com/artheranet/arthera-node/internal/valsig"
 )
 
 const (
@@ -198,7 +199,23 @@ func (p *DriverTxListener) OnNewLog(l *types.Log) {
 			log.Warn("Unexpected UpdatedValidatorPubkey Driver event")
 			return
 		}
-		profile.PubKey, _ = validatorpk.FromBytes(pubkey)
+		rules := p.es.Rules
+		if p.bs.DirtyRules != nil {
+			rules = *p.bs.DirtyRules
+		}
+		if !rules.Upgrades.ValidatorKeyTypes {
+			profile.PubKey, _ = validatorpk.FromBytes(pubkey)
+			p.bs.NextValidatorProfiles[validatorID] = profile
+			return
+		}
+		newPubkey, _ := validatorpk.FromBytes(pubkey)
+		if newPubkey.Type != validatorpk.Types.Secp256k1 {
+			if err := valsig.ValidatePubKey(newPubkey); err != nil {
+				log.Warn("Ignored malformed validator pubkey", "validator", validatorID, "err", err)
+				return
+			}
+		}
+		profile.PubKey = newPubkey
 		p.bs.NextValidatorProfiles[validatorID] = profile
 	}
 	// Update rules
@@ -36,7 +36,7 @@ func (p *EVMModule) Start(block iblockproc.BlockCtx, statedb *state.StateDB, rea
 		statedb:       statedb,
 		onNewLog:      onNewLog,
 		net:           net,
-		vmCfg:         vmCfg,
+		vmCfg:         net.VMConfig(vmCfg),
 		chainCfg:      chainCfg,
 		blockIdx:      utils.U64toBig(uint64(block.Idx)),
 		prevBlockHash: prevBlockHash,
@@ -60,6 +60,7 @@ type ArtheraEVMProcessor struct {
 	incomingTxs types.Transactions
 	skippedTxs  []uint32
 	receipts    types.Receipts
+	payments    []*evmcore.GasPayment
 }
 
 func (p *ArtheraEVMProcessor) evmBlockWith(txs types.Transactions) *evmcore.EvmBlock {
@@ -88,7 +89,7 @@ func (p *ArtheraEVMProcessor) Execute(txs types.Transactions) types.Receipts {
 
 	// Process txs
 	evmBlock := p.evmBlockWith(txs)
-	receipts, _, skipped, err := evmProcessor.Process(evmBlock, p.statedb, p.vmCfg, &p.gasUsed, func(l *types.Log, _ *state.StateDB) {
+	receipts, payments, _, skipped, err := evmProcessor.Process(evmBlock, p.statedb, p.vmCfg, &p.gasUsed, func(l *types.Log, _ *state.StateDB) {
 		// Note: l.Index is properly set before
 		l.TxIndex += txsOffset
 		p.onNewLog(l)
@@ -105,10 +106,14 @@ func (p *ArtheraEVMProcessor) Execute(txs types.Transactions) types.Receipts {
 			r.TransactionIndex += txsOffset
 		}
 	}
+	if tracer, ok := p.vmCfg.Tracer.(evmcore.TxTracer); ok && p.vmCfg.Debug {
+		tracer.Flush()
+	}
 
 	p.incomingTxs = append(p.incomingTxs, txs...)
 	p.skippedTxs = append(p.skippedTxs, skipped...)
 	p.receipts = append(p.receipts, receipts...)
+	p.payments = append(p.payments, payments...)
 
 	return receipts
 }
@@ -130,3 +135,8 @@ func (p *ArtheraEVMProcessor) Finalize() (evmBlock *evmcore.EvmBlock, skippedTxs
 
 	return
 }
+
+// GasPayments returns the gas payments of the executed transactions, in the same order as receipts
+func (p *ArtheraEVMProcessor) GasPayments() []*evmcore.GasPayment {
+	return p.payments
+}
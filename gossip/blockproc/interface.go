@@ -50,6 +50,7 @@ type ConfirmedEventsModule interface {
 type EVMProcessor interface {
 	Execute(txs types.Transactions) types.Receipts
 	Finalize() (evmBlock *evmcore.EvmBlock, skippedTxs []uint32, receipts types.Receipts)
+	GasPayments() []*evmcore.GasPayment
 }
 
 type EVM interface {
@@ -21,6 +21,7 @@ import (
 	"github.com/artheranet/arthera-node/gossip/blockproc/verwatcher"
 	"github.com/artheranet/arthera-node/gossip/emitter"
 	"github.com/artheranet/arthera-node/gossip/evmstore"
+	"github.com/artheranet/arthera-node/gossip/txtrace"
 	"github.com/artheranet/arthera-node/internal/evmcore"
 	"github.com/artheranet/arthera-node/internal/inter"
 	"github.com/artheranet/arthera-node/internal/inter/iblockproc"
@@ -130,9 +131,25 @@ func consensusCallbackBeginBlockFn(
 		// events with txs
 		confirmedEvents := make(hash.OrderedEvents, 0, 3*es.Validators.Len())
 
+		// per-validator counters of the block, merged into the epoch metrics at the end of block
+		validatorCounters := make(map[idx.ValidatorID]*iblockproc.ValidatorEpochMetrics)
+		countersOf := func(vid idx.ValidatorID) *iblockproc.ValidatorEpochMetrics {
+			c, ok := validatorCounters[vid]
+			if !ok {
+				c = &iblockproc.ValidatorEpochMetrics{}
+				validatorCounters[vid] = c
+			}
+			return c
+		}
+
 		mpsCheatersMap := make(map[idx.ValidatorID]struct{})
-		reportCheater := func(reporter, cheater idx.ValidatorID) {
+		var mpsEvidence []inter.MisbehaviourEvidence
+		reportCheater := func(reporter, cheater idx.ValidatorID, evidence inter.MisbehaviourEvidence) {
 			mpsCheatersMap[cheater] = struct{}{}
+			countersOf(cheater).MisbehaviourProofs++
+			evidence.Reporter = reporter
+			evidence.Offender = cheater
+			mpsEvidence = append(mpsEvidence, evidence)
 		}
 
 		return lachesis.BlockCallbacks{
@@ -150,28 +167,47 @@ func consensusCallbackBeginBlockFn(
 					for _, mp := range mps {
 						// self-contained parts of proofs are already checked by the checkers
 						if proof := mp.BlockVoteDoublesign; proof != nil {
-							reportCheater(e.Creator(), proof.Pair[0].Signed.Locator.Creator)
+							reportCheater(e.Creator(), proof.Pair[0].Signed.Locator.Creator, inter.MisbehaviourEvidence{
+								Type:  inter.BlockVoteDoublesignType,
+								Epoch: proof.Pair[0].Val.Epoch,
+								Block: proof.Block,
+								Event: e.ID(),
+							})
 						}
 						if proof := mp.EpochVoteDoublesign; proof != nil {
-							reportCheater(e.Creator(), proof.Pair[0].Signed.Locator.Creator)
+							reportCheater(e.Creator(), proof.Pair[0].Signed.Locator.Creator, inter.MisbehaviourEvidence{
+								Type:  inter.EpochVoteDoublesignType,
+								Epoch: proof.Pair[0].Val.Epoch,
+								Event: e.ID(),
+							})
 						}
 						if proof := mp.EventsDoublesign; proof != nil {
-							reportCheater(e.Creator(), proof.Pair[0].Locator.Creator)
+							reportCheater(e.Creator(), proof.Pair[0].Locator.Creator, inter.MisbehaviourEvidence{
+								Type:  inter.EventsDoublesignType,
+								Epoch: proof.Pair[0].Locator.Epoch,
+								Event: e.ID(),
+							})
 						}
 						if proof := mp.WrongBlockVote; proof != nil {
+							evidence := inter.MisbehaviourEvidence{
+								Type:  inter.WrongBlockVoteType,
+								Epoch: proof.Pals[0].Val.Epoch,
+								Block: proof.Block,
+								Event: e.ID(),
+							}
 							// all other votes are the same, see MinAccomplicesForProof
 							if proof.WrongEpoch {
 								actualBlockEpoch := store.FindBlockEpoch(proof.Block)
 								if actualBlockEpoch != 0 && actualBlockEpoch != proof.Pals[0].Val.Epoch {
 									for _, pal := range proof.Pals {
-										reportCheater(e.Creator(), pal.Signed.Locator.Creator)
+										reportCheater(e.Creator(), pal.Signed.Locator.Creator, evidence)
 									}
 								}
 							} else {
 								actualRecordHash := store.GetBlockRecordHash(proof.Block)
 								if actualRecordHash != nil && proof.GetVote(0) != *actualRecordHash {
 									for _, pal := range proof.Pals {
-										reportCheater(e.Creator(), pal.Signed.Locator.Creator)
+										reportCheater(e.Creator(), pal.Signed.Locator.Creator, evidence)
 									}
 								}
 							}
@@ -184,17 +220,35 @@ func consensusCallbackBeginBlockFn(
 								continue
 							}
 							if vote.Val.Vote != actualRecord.Hash() {
+								evidence := inter.MisbehaviourEvidence{
+									Type:  inter.WrongEpochVoteType,
+									Epoch: vote.Val.Epoch,
+									Event: e.ID(),
+								}
 								for _, pal := range proof.Pals {
-									reportCheater(e.Creator(), pal.Signed.Locator.Creator)
+									reportCheater(e.Creator(), pal.Signed.Locator.Creator, evidence)
 								}
 							}
 						}
 					}
 				}
+				counters := countersOf(e.Creator())
+				counters.Events++
+				if e.AnyBlockVotes() {
+					counters.BlockVotes += uint32(len(store.GetEventPayload(e.ID()).BlockVotes().Votes))
+				}
+				if e.AnyEpochVote() {
+					counters.EpochVotes++
+				}
 				eventProcessor.ProcessConfirmedEvent(e)
 				for _, em := range *emitters {
 					em.OnEventConfirmed(e)
 				}
+				feed.confirmedEvent.Send(inter.ConfirmedEventNotify{
+					Event:   e,
+					Atropos: cBlock.Atropos,
+					Time:    time.Now(),
+				})
 			},
 			EndBlock: func() (newValidators *pos.Validators) {
 				if atroposTime <= bs.LastBlock.Time {
@@ -230,6 +284,11 @@ func consensusCallbackBeginBlockFn(
 					})
 					bs.EpochCheaters = mergeCheaters(bs.EpochCheaters, mpsCheaters)
 				}
+				store.addValidatorEpochCounters(es.Epoch, validatorCounters)
+				for i := range mpsEvidence {
+					mpsEvidence[i].AppliedBlock = blockCtx.Idx
+				}
+				store.SetMisbehaviourEvidence(es.Epoch, mpsEvidence)
 				if skipBlock {
 					// save the latest block state even if block is skipped
 					store.SetBlockEpochState(bs, es)
@@ -262,7 +321,12 @@ func consensusCallbackBeginBlockFn(
 
 				// Providing default config
 				// In case of trace transaction node, this config is changed
-				evmProcessor := blockProc.EVMModule.Start(blockCtx, statedb, evmStateReader, onNewLogAll, es.Rules, params.DefaultVMConfig, es.Rules.EvmChainConfig(store.GetUpgradeHeights()))
+				vmConfig := params.DefaultVMConfig
+				if txTraces := store.TxTraceStore(); txTraces != nil {
+					vmConfig.Debug = true
+					vmConfig.Tracer = txtrace.NewCallTracer(txTraces)
+				}
+				evmProcessor := blockProc.EVMModule.Start(blockCtx, statedb, evmStateReader, onNewLogAll, es.Rules, vmConfig, es.Rules.EvmChainConfig(store.GetUpgradeHeights()))
 				executionStart := time.Now()
 
 				// Execute pre-internal transactions
@@ -275,8 +339,10 @@ func consensusCallbackBeginBlockFn(
 					}
 				}
 
+				blockEpoch := es.Epoch
 				// Seal epoch if requested
 				if sealing {
+					store.sealValidatorEpochMetrics(blockCtx, bs, es)
 					sealer.Update(bs, es)
 					prevUpg := es.Rules.Upgrades
 					bs, es = sealer.SealEpoch() // TODO: refactor to not mutate the bs, it is unclear
@@ -377,6 +443,8 @@ func consensusCallbackBeginBlockFn(
 						// Note: it's possible for receipts to get indexed twice by BR and block processing
 						if allReceipts.Len() != 0 {
 							store.evm.SetReceipts(blockCtx.Idx, allReceipts)
+							store.evm.SetGasPayments(blockCtx.Idx, evmProcessor.GasPayments())
+							store.evm.IndexDeployerRewards(blockCtx.Idx, blockEpoch, deployerRewards(evmBlock.Transactions, evmProcessor.GasPayments()))
 							for _, r := range allReceipts {
 								store.evm.IndexLogs(r.Logs...)
 							}
@@ -495,7 +563,8 @@ func (s *Service) ReexecuteBlocks(from, to idx.Block) {
 	}
 }
 
-func (s *Service) RecoverEVM() {
+// RecoverEVM reexecutes the blocks after the latest state found on disk, and returns the root of that state
+func (s *Service) RecoverEVM() (flushed hash.Hash) {
 	start := s.store.GetLatestBlockIndex()
 	for b := start; b >= 1 && b > start-20000; b-- {
 		block := s.store.GetBlock(b)
@@ -507,9 +576,27 @@ func (s *Service) RecoverEVM() {
 				s.Log.Warn("Reexecuting blocks after abrupt stopping", "from", b, "to", start)
 				s.ReexecuteBlocks(b, start)
 			}
-			break
+			return block.Root
+		}
+	}
+	return hash.Zero
+}
+
+// deployerRewards returns the Pay-as-You-Go rewards credited by the block transactions.
+// Gas payments are expected in the same order as the not skipped transactions.
+func deployerRewards(txs types.Transactions, payments []*evmcore.GasPayment) []evmstore.DeployerReward {
+	var rewards []evmstore.DeployerReward
+	for i, p := range payments {
+		if p == nil || !p.DeployerRewarded() || i >= len(txs) || txs[i].To() == nil {
+			continue
 		}
+		rewards = append(rewards, evmstore.DeployerReward{
+			Owner:    p.DeployerRewardRecipient,
+			Contract: *txs[i].To(),
+			Amount:   p.DeployerReward,
+		})
 	}
+	return rewards
 }
 
 // spillBlockEvents excludes first events which exceed MaxBlockGas
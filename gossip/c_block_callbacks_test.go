@@ -64,4 +64,13 @@ func TestConsensusCallback(t *testing.T) {
 		)
 	}
 
+	// check validator metrics of a sealed epoch
+	for vid := idx.ValidatorID(1); vid <= validatorsNum; vid++ {
+		m := env.store.GetValidatorEpochMetrics(2, vid)
+		require.NotNil(m, fmt.Sprintf("validator%d", vid))
+		require.True(m.Sealed)
+		require.NotZero(m.Events)
+		require.NotNil(m.Weight)
+		require.False(m.Cheater)
+	}
 }
@@ -266,6 +266,7 @@ func (s *Service) processEvent(e *inter.EventPayload) error {
 	for _, em := range s.emitters {
 		em.OnEventConnected(e)
 	}
+	s.feed.newEvent.Send(e)
 
 	if newEpoch != oldEpoch {
 		s.switchEpochTo(newEpoch)
@@ -8,9 +8,11 @@ import (
 	"github.com/artheranet/lachesis/inter/pos"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rlp"
 
 	"github.com/artheranet/arthera-node/gossip/evmstore"
 	"github.com/artheranet/arthera-node/internal/eventcheck"
+	"github.com/artheranet/arthera-node/internal/evmcore"
 	"github.com/artheranet/arthera-node/internal/inter"
 	"github.com/artheranet/arthera-node/internal/inter/iblockproc"
 	"github.com/artheranet/arthera-node/internal/inter/ibr"
@@ -85,6 +87,10 @@ func (s *Service) processBlockVotes(bvs inter.LlrSignedBlockVotes) error {
 	}
 	lBVs.Unlock()
 
+	for _, em := range s.emitters {
+		em.OnBlockVotes(bvs)
+	}
+
 	return nil
 }
 
@@ -118,6 +124,14 @@ func (s *Store) WriteFullBlockRecord(br ibr.LlrIdxFullBlockRecord) {
 		// Note: it's possible for receipts to get indexed twice by BR and block processing
 		indexRawReceipts(s, br.Receipts, br.Txs, br.Idx, br.Atropos)
 	}
+	if len(br.GasPaymentsRLP) != 0 {
+		var payments []*evmcore.GasPayment
+		if err := rlp.DecodeBytes(br.GasPaymentsRLP, &payments); err != nil || len(payments) != len(br.Txs) {
+			s.Log.Warn("Malformed gas payments of block record", "block", br.Idx, "err", err)
+		} else {
+			s.evm.SetGasPayments(br.Idx, payments)
+		}
+	}
 	for i, tx := range br.Txs {
 		s.EvmStore().SetTx(tx.Hash(), tx)
 		s.EvmStore().SetTxPosition(tx.Hash(), evmstore.TxPosition{
@@ -153,6 +167,8 @@ func (s *Service) ProcessFullBlockRecord(br ibr.LlrIdxFullBlockRecord) error {
 	if br.Hash() != *res {
 		return errors.New("block record hash mismatch")
 	}
+	// gas payments of a peer aren't covered by the record hash
+	br.GasPaymentsRLP = nil
 
 	s.store.WriteFullBlockRecord(br)
 	s.engineMu.Lock()
@@ -221,6 +237,10 @@ func (s *Service) processEpochVote(ev inter.LlrSignedEpochVote) error {
 	}
 	lEVs.Unlock()
 
+	for _, em := range s.emitters {
+		em.OnEpochVote(ev)
+	}
+
 	return nil
 }
 
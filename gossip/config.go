@@ -127,6 +127,17 @@ type (
 		LlrEpochVotesIndexes int
 	}
 
+	// HistoryConfig is a retention policy of the history, the older history is expired in background.
+	HistoryConfig struct {
+		// Number of the latest epochs whose events and LLR block votes are kept. Zero keeps all
+		Epochs idx.Epoch
+		// Number of the latest blocks whose receipts and tx lookups are kept. Zero keeps all.
+		// Receipts and tx lookups of the blocks whose events are expired are expired as well
+		Blocks idx.Block
+		// Period of the expiry job
+		Interval time.Duration
+	}
+
 	// StoreConfig is a config for store db.
 	StoreConfig struct {
 		Cache StoreCacheConfig
@@ -134,6 +145,10 @@ type (
 		EVM                 evmstore.StoreConfig
 		MaxNonFlushedSize   int
 		MaxNonFlushedPeriod time.Duration
+		// TraceTransactions enables recording of transaction call traces for the trace_ API
+		TraceTransactions bool
+		// History is the history retention policy
+		History HistoryConfig
 	}
 )
 
@@ -283,6 +298,11 @@ func DefaultStoreConfig(scale cachescale.Func) StoreConfig {
 		EVM:                 evmstore.DefaultStoreConfig(scale),
 		MaxNonFlushedSize:   21*opt.MiB + scale.I(2*opt.MiB),
 		MaxNonFlushedPeriod: 30 * time.Minute,
+		History: HistoryConfig{
+			Epochs:   0,
+			Blocks:   0,
+			Interval: time.Minute,
+		},
 	}
 }
 
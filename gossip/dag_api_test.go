@@ -0,0 +1,375 @@
+package gossip
+
+import (
+	"context"
+	"testing"
+
+	"github.com/artheranet/lachesis/hash"
+	"github.com/artheranet/lachesis/inter/idx"
+	"github.com/artheranet/lachesis/inter/pos"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/stretchr/testify/require"
+
+	"github.com/artheranet/arthera-node/api"
+	"github.com/artheranet/arthera-node/internal/inter"
+	"github.com/artheranet/arthera-node/internal/inter/iblockproc"
+	"github.com/artheranet/arthera-node/params"
+)
+
+type testDAG struct {
+	store  *Store
+	api    *api.PublicDAGChainAPI
+	events map[string]*inter.EventPayload
+}
+
+// newTestDAG builds a store with 3 epochs, the DAG of the sealed epoch 2 is:
+//
+//	a1   b1   (lamport 1)
+//	a2 ← a1, b1 (lamport 2)
+//	b2 ← b1, a2 (lamport 3)
+//	a3 ← a2, b2 (lamport 4)
+//
+// a3 is the Atropos of block 1, c1 is the single event of the current epoch 3.
+func newTestDAG() *testDAG {
+	store := NewMemStore()
+	store.SetGenesisID(hash.Hash{1})
+	dag := &testDAG{
+		store:  store,
+		events: make(map[string]*inter.EventPayload),
+	}
+
+	vv := pos.NewBuilder()
+	vv.Set(1, 1)
+	vv.Set(2, 1)
+	validators := vv.Build()
+	for epoch := idx.Epoch(1); epoch <= 3; epoch++ {
+		bs := iblockproc.BlockState{}
+		if epoch == 3 {
+			bs.LastBlock.Idx = 1
+		}
+		es := iblockproc.EpochState{
+			Epoch:      epoch,
+			EpochStart: inter.Timestamp(epoch) * 100,
+			Validators: validators,
+			Rules:      params.FakeNetRules(),
+		}
+		store.SetHistoryBlockEpochState(epoch, bs, es)
+		if epoch == 3 {
+			store.SetBlockEpochState(bs, es)
+		}
+	}
+
+	add := func(name string, epoch idx.Epoch, creator idx.ValidatorID, lamport idx.Lamport, parents ...string) {
+		me := &inter.MutableEventPayload{}
+		me.SetVersion(1)
+		me.SetEpoch(epoch)
+		me.SetCreator(creator)
+		me.SetLamport(lamport)
+		ids := make(hash.Events, len(parents))
+		for i, p := range parents {
+			ids[i] = dag.events[p].ID()
+		}
+		me.SetParents(ids)
+		me.SetPayloadHash(inter.CalcPayloadHash(me))
+		e := me.Build()
+		store.SetEvent(e)
+		dag.events[name] = e
+	}
+	add("a1", 2, 1, 1)
+	add("b1", 2, 2, 1)
+	add("a2", 2, 1, 2, "a1", "b1")
+	add("b2", 2, 2, 3, "b1", "a2")
+	add("a3", 2, 1, 4, "a2", "b2")
+	add("c1", 3, 1, 1)
+
+	atropos := dag.events["a3"].ID()
+	store.SetBlock(1, &inter.Block{
+		Time:    250,
+		Atropos: atropos,
+	})
+	store.SetBlockIndex(atropos, 1)
+
+	backend := &EthAPIBackend{
+		svc:   &Service{store: store},
+		state: NewEvmStateReader(store),
+	}
+	dag.api = api.NewPublicDAGChainAPI(backend)
+	return dag
+}
+
+func (dag *testDAG) id(name string) string {
+	return dag.events[name].ID().Hex()
+}
+
+func (dag *testDAG) names(events []map[string]interface{}) []string {
+	res := make([]string, 0, len(events))
+	for _, e := range events {
+		id := hash.BytesToEvent(e["id"].(hexutil.Bytes))
+		for name, event := range dag.events {
+			if event.ID() == id {
+				res = append(res, name)
+			}
+		}
+	}
+	return res
+}
+
+func uintPtr(v uint) *hexutil.Uint {
+	u := hexutil.Uint(v)
+	return &u
+}
+
+func TestDAGAPIEventsByEpoch(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+	dag := newTestDAG()
+	defer dag.store.Close()
+
+	// the pages follow the lamport order
+	page, err := dag.api.GetEventsByEpoch(ctx, 2, nil, nil, uintPtr(2))
+	require.NoError(err)
+	require.ElementsMatch([]string{"a1", "b1"}, dag.names(page.Events))
+	require.NotNil(page.Next)
+	require.Equal(dag.events["a2"].ID().Bytes(), []byte(*page.Next))
+
+	page, err = dag.api.GetEventsByEpoch(ctx, 2, nil, page.Next, uintPtr(2))
+	require.NoError(err)
+	require.Equal([]string{"a2", "b2"}, dag.names(page.Events))
+	require.NotNil(page.Next)
+
+	page, err = dag.api.GetEventsByEpoch(ctx, 2, nil, page.Next, uintPtr(2))
+	require.NoError(err)
+	require.Equal([]string{"a3"}, dag.names(page.Events))
+	require.Nil(page.Next)
+
+	// the default limit fits the whole epoch
+	page, err = dag.api.GetEventsByEpoch(ctx, rpc.LatestBlockNumber, nil, nil, nil)
+	require.NoError(err)
+	require.Len(page.Events, 5)
+	require.Nil(page.Next)
+
+	// the creator filter
+	page, err = dag.api.GetEventsByEpoch(ctx, 2, uintPtr(1), nil, uintPtr(2))
+	require.NoError(err)
+	require.Equal([]string{"a1", "a2"}, dag.names(page.Events))
+	require.NotNil(page.Next)
+	require.Equal(dag.events["a3"].ID().Bytes(), []byte(*page.Next))
+	page, err = dag.api.GetEventsByEpoch(ctx, 2, uintPtr(1), page.Next, uintPtr(2))
+	require.NoError(err)
+	require.Equal([]string{"a3"}, dag.names(page.Events))
+	require.Nil(page.Next)
+
+	// the current epoch
+	page, err = dag.api.GetEventsByEpoch(ctx, rpc.PendingBlockNumber, nil, nil, nil)
+	require.NoError(err)
+	require.Equal([]string{"c1"}, dag.names(page.Events))
+
+	// an epoch without events
+	page, err = dag.api.GetEventsByEpoch(ctx, 1, nil, nil, nil)
+	require.NoError(err)
+	require.Empty(page.Events)
+	require.Nil(page.Next)
+
+	// an unknown epoch
+	_, err = dag.api.GetEventsByEpoch(ctx, 4, nil, nil, nil)
+	require.Error(err)
+
+	// invalid cursors
+	short := hexutil.Bytes{1, 2, 3}
+	_, err = dag.api.GetEventsByEpoch(ctx, 2, nil, &short, nil)
+	require.Error(err)
+	other := hexutil.Bytes(dag.events["c1"].ID().Bytes())
+	_, err = dag.api.GetEventsByEpoch(ctx, 2, nil, &other, nil)
+	require.Error(err)
+}
+
+func TestDAGAPIForEachEpochEventFrom(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+	dag := newTestDAG()
+	defer dag.store.Close()
+	backend := &EthAPIBackend{svc: &Service{store: dag.store}}
+
+	collect := func(epoch rpc.BlockNumber, from hash.Event) ([]hash.Event, error) {
+		var ids []hash.Event
+		err := backend.ForEachEpochEventFrom(ctx, epoch, from, func(e *inter.EventPayload) bool {
+			ids = append(ids, e.ID())
+			return true
+		})
+		return ids, err
+	}
+
+	ids, err := collect(2, hash.Event{})
+	require.NoError(err)
+	require.Len(ids, 5)
+	for i := 1; i < len(ids); i++ {
+		require.LessOrEqual(ids[i-1].Lamport(), ids[i].Lamport())
+	}
+
+	// the start event is inclusive
+	ids, err = collect(2, dag.events["b2"].ID())
+	require.NoError(err)
+	require.Equal([]hash.Event{dag.events["b2"].ID(), dag.events["a3"].ID()}, ids)
+
+	// the start event doesn't have to exist
+	start := make([]byte, 0, len(hash.Event{}))
+	start = append(start, idx.Epoch(2).Bytes()...)
+	start = append(start, idx.Lamport(4).Bytes()...)
+	ids, err = collect(2, hash.BytesToEvent(append(start, make([]byte, cap(start)-len(start))...)))
+	require.NoError(err)
+	require.Equal([]hash.Event{dag.events["a3"].ID()}, ids)
+
+	// the iteration stops when the callback returns false
+	var n int
+	require.NoError(backend.ForEachEpochEventFrom(ctx, 2, hash.Event{}, func(*inter.EventPayload) bool {
+		n++
+		return n < 2
+	}))
+	require.Equal(2, n)
+
+	// the start event of another epoch
+	_, err = collect(2, dag.events["c1"].ID())
+	require.Error(err)
+
+	// the unknown epoch
+	_, err = collect(4, hash.Event{})
+	require.Error(err)
+}
+
+func TestDAGAPIEventParentsAndChildren(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+	dag := newTestDAG()
+	defer dag.store.Close()
+
+	parents, err := dag.api.GetEventParents(ctx, dag.id("b2"))
+	require.NoError(err)
+	require.Equal([]string{"b1", "a2"}, dag.names(parents))
+
+	parents, err = dag.api.GetEventParents(ctx, dag.id("a1"))
+	require.NoError(err)
+	require.Empty(parents)
+
+	children, err := dag.api.GetEventChildren(ctx, dag.id("b1"), nil, nil)
+	require.NoError(err)
+	require.Equal([]string{"a2", "b2"}, dag.names(children.Events))
+	require.Nil(children.Next)
+
+	// the lookup stops once the page is full
+	children, err = dag.api.GetEventChildren(ctx, dag.id("b1"), nil, uintPtr(1))
+	require.NoError(err)
+	require.Equal([]string{"a2"}, dag.names(children.Events))
+	require.NotNil(children.Next)
+	require.Equal(dag.events["b2"].ID().Bytes(), []byte(*children.Next))
+	children, err = dag.api.GetEventChildren(ctx, dag.id("b1"), children.Next, uintPtr(1))
+	require.NoError(err)
+	require.Equal([]string{"b2"}, dag.names(children.Events))
+	require.NotNil(children.Next)
+	children, err = dag.api.GetEventChildren(ctx, dag.id("b1"), children.Next, uintPtr(1))
+	require.NoError(err)
+	require.Empty(children.Events)
+	require.Nil(children.Next)
+
+	children, err = dag.api.GetEventChildren(ctx, dag.id("a3"), nil, nil)
+	require.NoError(err)
+	require.Empty(children.Events)
+
+	// invalid cursors
+	short := hexutil.Bytes{1, 2, 3}
+	_, err = dag.api.GetEventChildren(ctx, dag.id("b1"), &short, nil)
+	require.Error(err)
+	earlier := hexutil.Bytes(dag.events["a1"].ID().Bytes())
+	_, err = dag.api.GetEventChildren(ctx, dag.id("b1"), &earlier, nil)
+	require.Error(err)
+
+	// the unknown event
+	unknown := hash.Event{}
+	copy(unknown[:], idx.Epoch(2).Bytes())
+	unknown[len(unknown)-1] = 1
+	_, err = dag.api.GetEventParents(ctx, unknown.Hex())
+	require.Error(err)
+	_, err = dag.api.GetEventChildren(ctx, unknown.Hex(), nil, nil)
+	require.Error(err)
+}
+
+func TestDAGAPIAtropos(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+	dag := newTestDAG()
+	defer dag.store.Close()
+
+	res, err := dag.api.GetAtropos(ctx, 1)
+	require.NoError(err)
+	require.Equal(hexutil.Uint64(250), res["blockTime"])
+	require.Equal([]string{"a3"}, dag.names([]map[string]interface{}{res["atropos"].(map[string]interface{})}))
+
+	_, err = dag.api.GetAtropos(ctx, 0)
+	require.Error(err)
+
+	_, err = dag.api.GetAtropos(ctx, 2)
+	require.Error(err)
+}
+
+func TestDAGAPIEpochRange(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+	dag := newTestDAG()
+	defer dag.store.Close()
+
+	res, err := dag.api.GetEpochRange(ctx, 1, rpc.PendingBlockNumber)
+	require.NoError(err)
+	require.Equal([]map[string]interface{}{
+		{
+			"epoch":       hexutil.Uint64(1),
+			"start":       hexutil.Uint64(100),
+			"end":         hexutil.Uint64(200),
+			"firstBlock":  hexutil.Uint64(1),
+			"lastBlock":   hexutil.Uint64(0),
+			"validators":  hexutil.Uint64(2),
+			"totalWeight": hexutil.Uint64(2),
+			"sealed":      true,
+		},
+		{
+			"epoch":       hexutil.Uint64(2),
+			"start":       hexutil.Uint64(200),
+			"end":         hexutil.Uint64(300),
+			"firstBlock":  hexutil.Uint64(1),
+			"lastBlock":   hexutil.Uint64(1),
+			"validators":  hexutil.Uint64(2),
+			"totalWeight": hexutil.Uint64(2),
+			"sealed":      true,
+		},
+		{
+			"epoch":       hexutil.Uint64(3),
+			"start":       hexutil.Uint64(300),
+			"firstBlock":  hexutil.Uint64(2),
+			"lastBlock":   hexutil.Uint64(1),
+			"validators":  hexutil.Uint64(2),
+			"totalWeight": hexutil.Uint64(2),
+			"sealed":      false,
+		},
+	}, res)
+
+	// the latest sealed epoch
+	res, err = dag.api.GetEpochRange(ctx, rpc.LatestBlockNumber, rpc.LatestBlockNumber)
+	require.NoError(err)
+	require.Len(res, 1)
+	require.Equal(hexutil.Uint64(2), res[0]["epoch"])
+
+	// the epochs without a state are skipped
+	res, err = dag.api.GetEpochRange(ctx, 0, 1)
+	require.NoError(err)
+	require.Len(res, 1)
+	require.Equal(hexutil.Uint64(1), res[0]["epoch"])
+
+	// the unknown epochs
+	_, err = dag.api.GetEpochRange(ctx, 1, 4)
+	require.Error(err)
+	_, err = dag.api.GetEpochRange(ctx, 4, 5)
+	require.Error(err)
+
+	// the invalid range
+	_, err = dag.api.GetEpochRange(ctx, 2, 1)
+	require.Error(err)
+}
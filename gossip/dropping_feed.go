@@ -0,0 +1,78 @@
+package gossip
+
+import (
+	"reflect"
+	"sync"
+
+	notify "github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+var droppedNotifyMeter = metrics.GetOrRegisterMeter("gossip/feed/dropped", nil)
+
+// droppingFeed is a one-to-many subscription like notify.Feed, but it never blocks the sender.
+// The value is dropped for a subscriber whose channel is full, so a stalled subscriber
+// cannot stall the events processing, which sends the notifications under the engine lock.
+// The subscribers are expected to use buffered channels.
+type droppingFeed struct {
+	mu   sync.RWMutex
+	subs map[*droppingFeedSub]struct{}
+}
+
+type droppingFeedSub struct {
+	feed *droppingFeed
+	ch   reflect.Value
+	once sync.Once
+	err  chan error
+}
+
+// Subscribe adds a channel to the feed, the channel must be of the type of the sent values.
+func (f *droppingFeed) Subscribe(channel interface{}) notify.Subscription {
+	ch := reflect.ValueOf(channel)
+	if ch.Kind() != reflect.Chan || ch.Type().ChanDir()&reflect.SendDir == 0 {
+		panic("droppingFeed: Subscribe argument does not have sendable channel type")
+	}
+	sub := &droppingFeedSub{
+		feed: f,
+		ch:   ch,
+		err:  make(chan error),
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.subs == nil {
+		f.subs = make(map[*droppingFeedSub]struct{})
+	}
+	f.subs[sub] = struct{}{}
+	return sub
+}
+
+// Send delivers the value to the subscribers which have a room for it.
+// It returns the number of subscribers which received the value.
+func (f *droppingFeed) Send(value interface{}) (sent int) {
+	v := reflect.ValueOf(value)
+
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	for sub := range f.subs {
+		if sub.ch.TrySend(v) {
+			sent++
+		} else {
+			droppedNotifyMeter.Mark(1)
+		}
+	}
+	return sent
+}
+
+func (sub *droppingFeedSub) Unsubscribe() {
+	sub.once.Do(func() {
+		sub.feed.mu.Lock()
+		delete(sub.feed.subs, sub)
+		sub.feed.mu.Unlock()
+		close(sub.err)
+	})
+}
+
+func (sub *droppingFeedSub) Err() <-chan error {
+	return sub.err
+}
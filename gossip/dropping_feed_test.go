@@ -0,0 +1,67 @@
+package gossip
+
+import (
+	"testing"
+	"time"
+
+	"github.com/artheranet/lachesis/hash"
+	"github.com/stretchr/testify/require"
+
+	"github.com/artheranet/arthera-node/internal/inter"
+)
+
+func TestDroppingFeedStalledSubscriber(t *testing.T) {
+	require := require.New(t)
+
+	var f ServiceFeed
+	stalled := make(chan inter.ConfirmedEventNotify, 2)
+	stalledSub := f.SubscribeConfirmedEvent(stalled)
+	defer stalledSub.Unsubscribe()
+	active := make(chan inter.ConfirmedEventNotify, 10)
+	activeSub := f.SubscribeConfirmedEvent(active)
+	defer activeSub.Unsubscribe()
+
+	// the sender isn't blocked by the subscriber which doesn't read its channel
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 10; i++ {
+			f.confirmedEvent.Send(inter.ConfirmedEventNotify{Atropos: hash.Event{byte(i)}})
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the sender is stalled")
+	}
+
+	// the active subscriber has got all the notifications
+	require.Len(active, 10)
+	for i := 0; i < 10; i++ {
+		require.Equal(hash.Event{byte(i)}, (<-active).Atropos)
+	}
+	// the stalled subscriber has got the notifications which fit its buffer
+	require.Len(stalled, 2)
+	require.Equal(hash.Event{0}, (<-stalled).Atropos)
+	require.Equal(hash.Event{1}, (<-stalled).Atropos)
+
+	// the unsubscribed channel isn't notified
+	stalledSub.Unsubscribe()
+	_, ok := <-stalledSub.Err()
+	require.False(ok)
+	require.Equal(1, f.confirmedEvent.Send(inter.ConfirmedEventNotify{}))
+	require.Empty(stalled)
+}
+
+func TestDroppingFeedScope(t *testing.T) {
+	var f ServiceFeed
+	events := make(chan *inter.EventPayload, 1)
+	sub := f.SubscribeNewEvent(events)
+	require.Equal(t, 1, f.newEvent.Send(&inter.EventPayload{}))
+
+	// the subscriptions are closed along with the service
+	f.scope.Close()
+	_, ok := <-sub.Err()
+	require.False(t, ok)
+	require.Zero(t, f.newEvent.Send(&inter.EventPayload{}))
+}
@@ -1,6 +1,7 @@
 package gossip
 
 import (
+	"math/big"
 	"math/rand"
 	"sort"
 	"sync"
@@ -76,6 +77,11 @@ func (p *dummyTxPool) Pending(enforceTips bool) map[common.Address]types.Transac
 	return batches
 }
 
+// SubscriptionFees returns no fees, as the dummy pool doesn't track subscriptions
+func (p *dummyTxPool) SubscriptionFees() map[common.Hash]*big.Int {
+	return nil
+}
+
 func (p *dummyTxPool) SubscribeNewTxsNotify(ch chan<- evmcore.NewTxsNotify) notify.Subscription {
 	return p.txFeed.Subscribe(ch)
 }
@@ -22,6 +22,9 @@ type EmitIntervals struct {
 type ValidatorConfig struct {
 	ID     idx.ValidatorID
 	PubKey validatorpk.PubKey
+	// NextPubKey is the key the validator is rotated to. Events are signed by whichever
+	// of PubKey and NextPubKey matches the validator profile of the current epoch
+	NextPubKey validatorpk.PubKey
 }
 
 type FileConfig struct {
@@ -48,9 +51,15 @@ type Config struct {
 
 	TxsCacheInvalidation time.Duration
 
+	// ProtectionDB is the directory of the doublesign protection DB
+	ProtectionDB FileConfig
+
+	// Deprecated: the files aren't written anymore, the records are imported into ProtectionDB when it's opened first
 	PrevEmittedEventFile FileConfig
-	PrevBlockVotesFile   FileConfig
-	PrevEpochVoteFile    FileConfig
+	// Deprecated: see PrevEmittedEventFile
+	PrevBlockVotesFile FileConfig
+	// Deprecated: see PrevEmittedEventFile
+	PrevEpochVoteFile FileConfig
 }
 
 // DefaultConfig returns the default configurations for the events emitter.
@@ -75,6 +84,10 @@ func DefaultConfig() Config {
 		EmergencyThreshold:  params.DefaultEventGas * 5,
 
 		TxsCacheInvalidation: 200 * time.Millisecond,
+
+		ProtectionDB: FileConfig{
+			SyncMode: true,
+		},
 	}
 }
 
@@ -4,6 +4,9 @@ import (
 	"fmt"
 	"github.com/artheranet/arthera-node/gossip/emitter/originatedtxs"
 	"github.com/artheranet/arthera-node/internal/inter"
+	"github.com/artheranet/arthera-node/internal/inter/validatorpk"
+	"github.com/artheranet/arthera-node/internal/slashprotection"
+	"github.com/artheranet/arthera-node/internal/valkeystore"
 	"github.com/artheranet/arthera-node/logger"
 	"github.com/artheranet/arthera-node/tracing"
 	"github.com/artheranet/arthera-node/utils/rate"
@@ -12,9 +15,7 @@ import (
 	"github.com/artheranet/lachesis/inter/idx"
 	"github.com/artheranet/lachesis/inter/pos"
 	"github.com/artheranet/lachesis/utils/piecefunc"
-	"github.com/ethereum/go-ethereum/core/types"
 	"math/rand"
-	"os"
 	"strings"
 	"sync"
 	"time"
@@ -37,11 +38,14 @@ type Emitter struct {
 	prevEmittedAtBlock idx.Block
 	originatedTxs      *originatedtxs.Buffer
 	pendingGas         uint64
+	mps                *mpDetector
 
 	// note: track validators and epoch internally to avoid referring to
 	// validators of a future epoch inside OnEventConnected of last epoch event
 	validators *pos.Validators
 	epoch      idx.Epoch
+	// pubkey is the validator key which signs events of the current epoch
+	pubkey validatorpk.PubKey
 
 	// challenges is deadlines when each validator should emit an event
 	challenges map[idx.ValidatorID]time.Time
@@ -66,16 +70,14 @@ type Emitter struct {
 	maxParents idx.Event
 
 	cache struct {
-		sortedTxs *types.TransactionsByPriceAndNonce
+		sortedTxs *TxsByEffectiveFee
 		poolTime  time.Time
 		poolBlock idx.Block
 		poolCount int
 	}
 
-	emittedEventFile *os.File
-	emittedBvsFile   *os.File
-	emittedEvFile    *os.File
-	busyRate         *rate.Gauge
+	protection *slashprotection.DB
+	busyRate   *rate.Gauge
 
 	logger.Periodic
 }
@@ -94,6 +96,7 @@ func NewEmitter(
 		config:                   config,
 		world:                    world,
 		originatedTxs:            originatedtxs.New(SenderCountBufferSize),
+		mps:                      newMpDetector(world, config.Validator.ID),
 		intervals:                config.EmitIntervals,
 		globalConfirmingInterval: config.EmitIntervals.Confirming,
 		Periodic:                 logger.Periodic{Instance: logger.New()},
@@ -108,14 +111,9 @@ func (em *Emitter) init() {
 	validators, epoch := em.world.GetEpochValidators()
 	em.OnNewEpoch(validators, epoch)
 
-	if len(em.config.PrevEmittedEventFile.Path) != 0 {
-		em.emittedEventFile = openPrevActionFile(em.config.PrevEmittedEventFile.Path, em.config.PrevEmittedEventFile.SyncMode)
-	}
-	if len(em.config.PrevBlockVotesFile.Path) != 0 {
-		em.emittedBvsFile = openPrevActionFile(em.config.PrevBlockVotesFile.Path, em.config.PrevBlockVotesFile.SyncMode)
-	}
-	if len(em.config.PrevEpochVoteFile.Path) != 0 {
-		em.emittedEvFile = openPrevActionFile(em.config.PrevEpochVoteFile.Path, em.config.PrevEpochVoteFile.SyncMode)
+	if len(em.config.ProtectionDB.Path) != 0 && em.protection == nil {
+		em.protection = openProtectionDB(em.config.ProtectionDB.Path, em.config.ProtectionDB.SyncMode)
+		em.importPrevActionFiles()
 	}
 	em.busyRate = rate.NewGauge()
 }
@@ -164,6 +162,10 @@ func (em *Emitter) Stop() {
 	em.done = nil
 	em.wg.Wait()
 	em.busyRate.Stop()
+	if em.protection != nil {
+		_ = em.protection.Close()
+		em.protection = nil
+	}
 }
 
 func (em *Emitter) tick() {
@@ -192,7 +194,7 @@ func (em *Emitter) tick() {
 	}
 }
 
-func (em *Emitter) getSortedTxs() *types.TransactionsByPriceAndNonce {
+func (em *Emitter) getSortedTxs() *TxsByEffectiveFee {
 	// Short circuit if pool wasn't updated since the cache was built
 	poolCount := em.world.TxPool.Count()
 	if em.cache.sortedTxs != nil &&
@@ -209,7 +211,7 @@ func (em *Emitter) getSortedTxs() *types.TransactionsByPriceAndNonce {
 			pendingTxs[from] = txs[:em.config.MaxTxsPerAddress]
 		}
 	}
-	sortedTxs := types.NewTransactionsByPriceAndNonce(em.world.TxSigner, pendingTxs, em.world.GetRules().Economy.MinGasPrice)
+	sortedTxs := NewTxsByEffectiveFee(em.world.TxSigner, pendingTxs, em.world.TxPool.SubscriptionFees(), em.world.GetRules().Economy.MinGasPrice)
 	em.cache.sortedTxs = sortedTxs
 	em.cache.poolCount = poolCount
 	em.cache.poolBlock = em.world.GetLatestBlockIndex()
@@ -241,14 +243,8 @@ func (em *Emitter) EmitEvent() (*inter.EventPayload, error) {
 		em.Log.Error("Self-event connection failed", "err", err.Error())
 		return nil, err
 	}
-	// write event ID to avoid doublesigning in future after a crash
-	em.writeLastEmittedEventID(e.ID())
-	if e.EpochVote().Epoch != 0 {
-		em.writeLastEmittedEpochVote(e.EpochVote().Epoch)
-	}
-	if len(e.BlockVotes().Votes) != 0 {
-		em.writeLastEmittedBlockVotes(e.BlockVotes().LastBlock())
-	}
+	// record event to avoid doublesigning in future after a crash
+	em.recordEmittedEvent(e)
 	// broadcast the event
 	em.world.Broadcast(e)
 
@@ -279,10 +275,15 @@ func (em *Emitter) loadPrevEmitTime() time.Time {
 }
 
 // createEvent is not safe for concurrent use.
-func (em *Emitter) createEvent(sortedTxs *types.TransactionsByPriceAndNonce) (*inter.EventPayload, error) {
+func (em *Emitter) createEvent(sortedTxs *TxsByEffectiveFee) (*inter.EventPayload, error) {
 	if !em.isValidator() {
 		return nil, nil
 	}
+	if em.pubkey.Empty() {
+		em.Periodic.Error(5*time.Second, "None of the validator keys matches the validator profile, events emitting isn't allowed",
+			"validator", em.config.Validator.ID, "epoch", em.epoch)
+		return nil, nil
+	}
 
 	if synced := em.logSyncStatus(em.isSyncedToEmit()); !synced {
 		// I'm reindexing my old events, so don't create events until connect all the existing self-events
@@ -399,6 +400,9 @@ func (em *Emitter) createEvent(sortedTxs *types.TransactionsByPriceAndNonce) (*i
 		return nil, nil
 	}
 
+	// Add misbehaviour proofs before txs, as they take priority over the gas power
+	em.addMisbehaviourProofs(mutEvent)
+
 	// Add txs
 	em.addTxs(mutEvent, sortedTxs)
 
@@ -414,7 +418,7 @@ func (em *Emitter) createEvent(sortedTxs *types.TransactionsByPriceAndNonce) (*i
 	mutEvent.SetPayloadHash(inter.CalcPayloadHash(mutEvent))
 
 	// sign
-	bSig, err := em.world.Signer.Sign(em.config.Validator.PubKey, mutEvent.HashToSign().Bytes())
+	bSig, err := em.signEvent(mutEvent)
 	if err != nil {
 		em.Periodic.Error(time.Second, "Failed to sign event", "err", err)
 		return nil, err
@@ -427,6 +431,10 @@ func (em *Emitter) createEvent(sortedTxs *types.TransactionsByPriceAndNonce) (*i
 	event := mutEvent.Build()
 
 	// check
+	if err := em.checkEmittedEvent(event); err != nil {
+		em.Periodic.Error(time.Second, "Refused to emit event", "err", err)
+		return nil, err
+	}
 	if err := em.world.Check(event, parentHeaders); err != nil {
 		em.Periodic.Error(time.Second, "Emitted incorrect event", "err", err)
 		return nil, err
@@ -438,6 +446,14 @@ func (em *Emitter) createEvent(sortedTxs *types.TransactionsByPriceAndNonce) (*i
 	return event, nil
 }
 
+// signEvent passes the event content to signers which enforce the doublesign protection on their own
+func (em *Emitter) signEvent(e *inter.MutableEventPayload) ([]byte, error) {
+	if signer, ok := em.world.Signer.(valkeystore.EventSignerI); ok {
+		return signer.SignEvent(em.pubkey, valkeystore.NewEventSigningRequest(e))
+	}
+	return em.world.Signer.Sign(em.pubkey, e.HashToSign().Bytes())
+}
+
 func (em *Emitter) idle() bool {
 	return em.originatedTxs.Empty()
 }
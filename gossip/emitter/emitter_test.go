@@ -18,6 +18,8 @@ import (
 	"github.com/artheranet/arthera-node/genesis/fake"
 	"github.com/artheranet/arthera-node/gossip/emitter/mock"
 	"github.com/artheranet/arthera-node/internal/inter"
+	"github.com/artheranet/arthera-node/internal/inter/drivertype"
+	"github.com/artheranet/arthera-node/internal/inter/iblockproc"
 	"github.com/artheranet/arthera-node/internal/vecmt"
 )
 
@@ -32,6 +34,7 @@ func TestEmitter(t *testing.T) {
 	}
 	validators := vv.Build()
 	cfg.Validator.ID = gValidators[0].ID
+	cfg.Validator.PubKey = gValidators[0].PubKey
 
 	ctrl := gomock.NewController(t)
 	external := mock.NewMockExternal(ctrl)
@@ -72,6 +75,15 @@ func TestEmitter(t *testing.T) {
 			Return(validators, idx.Epoch(1)).
 			AnyTimes()
 
+		external.EXPECT().GetHistoryEpochState(idx.Epoch(1)).
+			Return(&iblockproc.EpochState{
+				Epoch: 1,
+				ValidatorProfiles: iblockproc.ValidatorProfiles{
+					gValidators[0].ID: drivertype.Validator{Weight: big.NewInt(1), PubKey: gValidators[0].PubKey},
+				},
+			}).
+			AnyTimes()
+
 		external.EXPECT().GetLastEvent(idx.Epoch(1), cfg.Validator.ID).
 			Return((*hash.Event)(nil)).
 			AnyTimes()
@@ -81,6 +93,7 @@ func TestEmitter(t *testing.T) {
 			AnyTimes()
 
 		em.init()
+		require.Equal(t, cfg.Validator.PubKey, em.pubkey)
 	})
 
 	t.Run("memorizeTxTimes", func(t *testing.T) {
@@ -31,10 +31,12 @@ func (em *Emitter) OnNewEpoch(newValidators *pos.Validators, newEpoch idx.Epoch)
 	}
 
 	em.validators, em.epoch = newValidators, newEpoch
+	em.mps.onNewEpoch(newEpoch)
 
 	if !em.isValidator() {
 		return
 	}
+	em.switchPubKey(newEpoch)
 	em.prevEmittedAtTime = em.loadPrevEmitTime()
 
 	em.originatedTxs.Clear()
@@ -115,6 +117,7 @@ func (em *Emitter) OnEventConnected(e inter.EventPayloadI) {
 		em.originatedTxs.Inc(addr)
 	}
 	em.pendingGas += e.GasPowerUsed()
+	em.mps.onEventConnected(e)
 	if e.Creator() == em.config.Validator.ID && em.syncStatus.prevLocalEmittedID != e.ID() {
 		// event was emitted by me on another instance
 		em.onNewExternalEvent(e)
@@ -0,0 +1,49 @@
+package emitter
+
+import (
+	"github.com/artheranet/lachesis/inter/idx"
+
+	"github.com/artheranet/arthera-node/internal/inter/validatorpk"
+)
+
+// pubkeys returns the configured validator keys
+func (em *Emitter) pubkeys() []validatorpk.PubKey {
+	pubkeys := []validatorpk.PubKey{em.config.Validator.PubKey}
+	if !em.config.Validator.NextPubKey.Empty() {
+		pubkeys = append(pubkeys, em.config.Validator.NextPubKey)
+	}
+	return pubkeys
+}
+
+// selectPubKey returns the configured key which matches the validator profile of the epoch,
+// or an empty key if none of them matches
+func (em *Emitter) selectPubKey(epoch idx.Epoch) validatorpk.PubKey {
+	es := em.world.GetHistoryEpochState(epoch)
+	if es == nil {
+		return em.config.Validator.PubKey
+	}
+	profile, ok := es.ValidatorProfiles[em.config.Validator.ID]
+	if !ok {
+		return validatorpk.PubKey{}
+	}
+	for _, pubkey := range em.pubkeys() {
+		if pubkey.Equal(profile.PubKey) {
+			return pubkey
+		}
+	}
+	return validatorpk.PubKey{}
+}
+
+// switchPubKey selects the key to sign events of the new epoch.
+// A pubkey change of the validator takes effect at the next epoch, so the key is switched at the epoch boundary.
+func (em *Emitter) switchPubKey(epoch idx.Epoch) {
+	pubkey := em.selectPubKey(epoch)
+	if pubkey.Empty() {
+		em.Log.Error("None of the validator keys matches the validator profile, events emitting isn't allowed",
+			"validator", em.config.Validator.ID, "epoch", epoch)
+	} else if !em.pubkey.Empty() && !em.pubkey.Equal(pubkey) {
+		em.Log.Info("Switched validator key", "validator", em.config.Validator.ID, "epoch", epoch,
+			"old", em.pubkey.String(), "new", pubkey.String())
+	}
+	em.pubkey = pubkey
+}
@@ -0,0 +1,97 @@
+package emitter
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/artheranet/lachesis/hash"
+	"github.com/artheranet/lachesis/inter/idx"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/artheranet/arthera-node/genesis/fake"
+	"github.com/artheranet/arthera-node/gossip/emitter/mock"
+	"github.com/artheranet/arthera-node/internal/inter"
+	"github.com/artheranet/arthera-node/internal/inter/drivertype"
+	"github.com/artheranet/arthera-node/internal/inter/iblockproc"
+	"github.com/artheranet/arthera-node/internal/inter/validatorpk"
+	"github.com/artheranet/arthera-node/internal/slashprotection"
+)
+
+func TestEmitterKeyRotation(t *testing.T) {
+	require := require.New(t)
+	gValidators := fake.GetFakeValidators(3)
+	oldKey, newKey, otherKey := gValidators[0].PubKey, gValidators[1].PubKey, gValidators[2].PubKey
+
+	cfg := DefaultConfig()
+	cfg.Validator.ID = gValidators[0].ID
+	cfg.Validator.PubKey = oldKey
+	cfg.Validator.NextPubKey = newKey
+
+	ctrl := gomock.NewController(t)
+	external := mock.NewMockExternal(ctrl)
+	em := NewEmitter(cfg, World{External: external})
+	em.protection = slashprotection.NewMem()
+	defer em.protection.Close()
+
+	setProfile := func(epoch idx.Epoch, pubkey validatorpk.PubKey) {
+		external.EXPECT().GetHistoryEpochState(epoch).
+			Return(&iblockproc.EpochState{
+				Epoch: epoch,
+				ValidatorProfiles: iblockproc.ValidatorProfiles{
+					cfg.Validator.ID: drivertype.Validator{Weight: big.NewInt(1), PubKey: pubkey},
+				},
+			})
+	}
+
+	// the old key signs until the new key takes effect
+	setProfile(1, oldKey)
+	em.switchPubKey(1)
+	require.Equal(oldKey, em.pubkey)
+
+	var id hash.Event
+	copy(id[0:4], idx.Epoch(1).Bytes())
+	copy(id[4:8], idx.Lamport(1).Bytes())
+	require.NoError(em.protection.Record(em.pubkey, slashprotection.Event{
+		ID:         id,
+		Creator:    cfg.Validator.ID,
+		Seq:        1,
+		BlockVotes: slashprotection.BlockVotes{Start: 10, Last: 12, Epoch: 1},
+	}))
+
+	// switched to the new key at the epoch boundary
+	setProfile(2, newKey)
+	em.switchPubKey(2)
+	require.Equal(newKey, em.pubkey)
+	// votes signed by the old key are still taken into account
+	require.Equal(idx.Block(12), *em.readLastBlockVotes())
+	require.Equal(id, *em.readLastEmittedEventID())
+
+	// the new key can't sign votes which overlap the votes of the old key
+	me := &inter.MutableEventPayload{}
+	me.SetVersion(1)
+	me.SetEpoch(2)
+	me.SetCreator(cfg.Validator.ID)
+	me.SetSeq(1)
+	me.SetLamport(2)
+	me.SetBlockVotes(inter.LlrBlockVotes{Start: 12, Epoch: 2, Votes: []hash.Hash{{1}}})
+	require.ErrorIs(em.checkEmittedEvent(me.Build()), slashprotection.ErrRefused)
+	me.SetBlockVotes(inter.LlrBlockVotes{Start: 13, Epoch: 2, Votes: []hash.Hash{{1}}})
+	require.NoError(em.checkEmittedEvent(me.Build()))
+
+	// the records of the old key are taken into account after it's removed from the config
+	rotated := cfg
+	rotated.Validator.PubKey = newKey
+	rotated.Validator.NextPubKey = validatorpk.PubKey{}
+	em2 := NewEmitter(rotated, World{External: external})
+	em2.protection = em.protection
+	setProfile(2, newKey)
+	em2.switchPubKey(2)
+	require.Equal(idx.Block(12), *em2.readLastBlockVotes())
+	require.Equal(id, *em2.readLastEmittedEventID())
+
+	// neither key matches
+	setProfile(3, otherKey)
+	em.switchPubKey(3)
+	require.True(em.pubkey.Empty())
+}
@@ -0,0 +1,373 @@
+package emitter
+
+import (
+	"github.com/artheranet/lachesis/hash"
+	"github.com/artheranet/lachesis/inter/idx"
+
+	"github.com/artheranet/arthera-node/internal/eventcheck/basiccheck"
+	"github.com/artheranet/arthera-node/internal/inter"
+)
+
+const (
+	// maxPendingMisbehaviourProofs limits the number of detected proofs waiting to be included into an event
+	maxPendingMisbehaviourProofs = 64
+)
+
+type (
+	eventSlot struct {
+		creator idx.ValidatorID
+		seq     idx.Event
+	}
+	blockVoteSlot struct {
+		creator idx.ValidatorID
+		block   idx.Block
+	}
+	epochVoteSlot struct {
+		creator idx.ValidatorID
+		epoch   idx.Epoch
+	}
+	wrongBlockVoteKey struct {
+		block      idx.Block
+		epoch      idx.Epoch
+		vote       hash.Hash
+		wrongEpoch bool
+	}
+
+	pendingProof struct {
+		mp        inter.MisbehaviourProof
+		offenders []idx.ValidatorID
+	}
+
+	// mpDetector builds misbehaviour proofs from the connected events and the processed LLR votes
+	mpDetector struct {
+		reader LlrReader
+		me     idx.ValidatorID
+		epoch  idx.Epoch
+
+		events          map[eventSlot]inter.SignedEventLocator
+		blockVotes      map[blockVoteSlot]*inter.LlrSignedBlockVotes
+		epochVotes      map[epochVoteSlot]inter.LlrSignedEpochVote
+		wrongBlockVotes map[wrongBlockVoteKey][]*inter.LlrSignedBlockVotes
+		wrongEpochVotes map[inter.LlrEpochVote][]inter.LlrSignedEpochVote
+
+		// reported are the offenders with a detected proof
+		reported map[idx.ValidatorID]bool
+		// included are the offenders with a proof in a connected event
+		included map[idx.ValidatorID]bool
+		pending  []pendingProof
+	}
+)
+
+func newMpDetector(reader LlrReader, me idx.ValidatorID) *mpDetector {
+	d := &mpDetector{
+		reader:          reader,
+		me:              me,
+		blockVotes:      make(map[blockVoteSlot]*inter.LlrSignedBlockVotes),
+		epochVotes:      make(map[epochVoteSlot]inter.LlrSignedEpochVote),
+		wrongBlockVotes: make(map[wrongBlockVoteKey][]*inter.LlrSignedBlockVotes),
+		wrongEpochVotes: make(map[inter.LlrEpochVote][]inter.LlrSignedEpochVote),
+	}
+	d.onNewEpoch(0)
+	return d
+}
+
+// isRecent returns true if votes of the epoch may still be observed
+func (d *mpDetector) isRecent(epoch idx.Epoch) bool {
+	return epoch+2 >= d.epoch
+}
+
+func (d *mpDetector) onNewEpoch(epoch idx.Epoch) {
+	d.epoch = epoch
+	d.events = make(map[eventSlot]inter.SignedEventLocator)
+	d.reported = make(map[idx.ValidatorID]bool)
+	d.included = make(map[idx.ValidatorID]bool)
+	// keep the pending proofs until they are too late to be included
+	pending := d.pending[:0]
+	for _, p := range d.pending {
+		if epoch > misbehaviourEpoch(p.mp)+basiccheck.MaxLiableEpochs {
+			continue
+		}
+		pending = append(pending, p)
+		for _, vid := range p.offenders {
+			d.reported[vid] = true
+		}
+	}
+	d.pending = pending
+	// forget outdated votes
+	for slot, bvs := range d.blockVotes {
+		if !d.isRecent(bvs.Val.Epoch) {
+			delete(d.blockVotes, slot)
+		}
+	}
+	for key := range d.wrongBlockVotes {
+		if !d.isRecent(key.epoch) {
+			delete(d.wrongBlockVotes, key)
+		}
+	}
+	for slot := range d.epochVotes {
+		if !d.isRecent(slot.epoch) {
+			delete(d.epochVotes, slot)
+		}
+	}
+	for key := range d.wrongEpochVotes {
+		if !d.isRecent(key.Epoch) {
+			delete(d.wrongEpochVotes, key)
+		}
+	}
+}
+
+func misbehaviourOffenders(mp inter.MisbehaviourProof) []idx.ValidatorID {
+	switch {
+	case mp.EventsDoublesign != nil:
+		return []idx.ValidatorID{mp.EventsDoublesign.Pair[0].Locator.Creator}
+	case mp.BlockVoteDoublesign != nil:
+		return []idx.ValidatorID{mp.BlockVoteDoublesign.Pair[0].Signed.Locator.Creator}
+	case mp.EpochVoteDoublesign != nil:
+		return []idx.ValidatorID{mp.EpochVoteDoublesign.Pair[0].Signed.Locator.Creator}
+	case mp.WrongBlockVote != nil:
+		offenders := make([]idx.ValidatorID, 0, len(mp.WrongBlockVote.Pals))
+		for _, pal := range mp.WrongBlockVote.Pals {
+			offenders = append(offenders, pal.Signed.Locator.Creator)
+		}
+		return offenders
+	case mp.WrongEpochVote != nil:
+		offenders := make([]idx.ValidatorID, 0, len(mp.WrongEpochVote.Pals))
+		for _, pal := range mp.WrongEpochVote.Pals {
+			offenders = append(offenders, pal.Signed.Locator.Creator)
+		}
+		return offenders
+	}
+	return nil
+}
+
+// misbehaviourEpoch returns the lowest epoch of the records in the proof, which limits the proof liability
+func misbehaviourEpoch(mp inter.MisbehaviourProof) idx.Epoch {
+	var epochs []idx.Epoch
+	switch {
+	case mp.EventsDoublesign != nil:
+		epochs = []idx.Epoch{mp.EventsDoublesign.Pair[0].Locator.Epoch}
+	case mp.BlockVoteDoublesign != nil:
+		epochs = []idx.Epoch{mp.BlockVoteDoublesign.Pair[0].Val.Epoch, mp.BlockVoteDoublesign.Pair[1].Val.Epoch}
+	case mp.EpochVoteDoublesign != nil:
+		epochs = []idx.Epoch{mp.EpochVoteDoublesign.Pair[0].Val.Epoch}
+	case mp.WrongBlockVote != nil:
+		for _, pal := range mp.WrongBlockVote.Pals {
+			epochs = append(epochs, pal.Val.Epoch)
+		}
+	case mp.WrongEpochVote != nil:
+		for _, pal := range mp.WrongEpochVote.Pals {
+			epochs = append(epochs, pal.Val.Epoch)
+		}
+	}
+	var lowest idx.Epoch
+	for i, epoch := range epochs {
+		if i == 0 || epoch < lowest {
+			lowest = epoch
+		}
+	}
+	return lowest
+}
+
+func (d *mpDetector) report(mp inter.MisbehaviourProof) {
+	offenders := misbehaviourOffenders(mp)
+	fresh := false
+	for _, vid := range offenders {
+		if vid == d.me {
+			// never report self
+			return
+		}
+		if !d.reported[vid] {
+			fresh = true
+		}
+	}
+	if !fresh || len(d.pending) >= maxPendingMisbehaviourProofs {
+		return
+	}
+	for _, vid := range offenders {
+		d.reported[vid] = true
+	}
+	d.pending = append(d.pending, pendingProof{
+		mp:        mp,
+		offenders: offenders,
+	})
+}
+
+// onEventConnected detects events doublesigns and drops pending proofs which were already included by someone
+func (d *mpDetector) onEventConnected(e inter.EventPayloadI) {
+	if len(e.MisbehaviourProofs()) != 0 {
+		for _, mp := range e.MisbehaviourProofs() {
+			for _, vid := range misbehaviourOffenders(mp) {
+				d.reported[vid] = true
+				d.included[vid] = true
+			}
+		}
+		pending := d.pending[:0]
+		for _, p := range d.pending {
+			for _, vid := range p.offenders {
+				if !d.included[vid] {
+					pending = append(pending, p)
+					break
+				}
+			}
+		}
+		d.pending = pending
+	}
+
+	if e.Epoch() != d.epoch {
+		return
+	}
+	slot := eventSlot{e.Creator(), e.Seq()}
+	locator := inter.AsSignedEventLocator(e)
+	prev, ok := d.events[slot]
+	if !ok {
+		d.events[slot] = locator
+		return
+	}
+	if prev.Locator == locator.Locator {
+		return
+	}
+	d.report(inter.MisbehaviourProof{
+		EventsDoublesign: &inter.EventsDoublesign{
+			Pair: [2]inter.SignedEventLocator{prev, locator},
+		},
+	})
+}
+
+// onBlockVotes detects block votes doublesigns and wrong block votes
+func (d *mpDetector) onBlockVotes(bvs inter.LlrSignedBlockVotes) {
+	if !d.isRecent(bvs.Val.Epoch) {
+		return
+	}
+	creator := bvs.Signed.Locator.Creator
+	for i, vote := range bvs.Val.Votes {
+		block := bvs.Val.Start + idx.Block(i)
+
+		slot := blockVoteSlot{creator, block}
+		if prev, ok := d.blockVotes[slot]; !ok {
+			d.blockVotes[slot] = &bvs
+		} else if prev.Signed.Locator != bvs.Signed.Locator {
+			prevVote := prev.Val.Votes[block-prev.Val.Start]
+			if prevVote != vote || prev.Val.Epoch != bvs.Val.Epoch {
+				d.report(inter.MisbehaviourProof{
+					BlockVoteDoublesign: &inter.BlockVoteDoublesign{
+						Block: block,
+						Pair:  [2]inter.LlrSignedBlockVotes{*prev, bvs},
+					},
+				})
+			}
+		}
+
+		// compare with the decided block
+		var key wrongBlockVoteKey
+		if actualEpoch := d.reader.GetBlockEpoch(block); actualEpoch != 0 && actualEpoch != bvs.Val.Epoch {
+			key = wrongBlockVoteKey{block: block, epoch: bvs.Val.Epoch, wrongEpoch: true}
+		} else if record := d.reader.GetBlockRecordHash(block); record != nil && *record != vote {
+			key = wrongBlockVoteKey{block: block, epoch: bvs.Val.Epoch, vote: vote}
+		} else {
+			continue
+		}
+		pals := d.wrongBlockVotes[key]
+		if len(pals) >= inter.MinAccomplicesForProof || containsBlockVotesOf(pals, creator) {
+			continue
+		}
+		pals = append(pals, &bvs)
+		d.wrongBlockVotes[key] = pals
+		if len(pals) == inter.MinAccomplicesForProof {
+			proof := &inter.WrongBlockVote{
+				Block:      block,
+				WrongEpoch: key.wrongEpoch,
+			}
+			for j, pal := range pals {
+				proof.Pals[j] = *pal
+			}
+			d.report(inter.MisbehaviourProof{WrongBlockVote: proof})
+		}
+	}
+}
+
+func containsBlockVotesOf(pals []*inter.LlrSignedBlockVotes, creator idx.ValidatorID) bool {
+	for _, pal := range pals {
+		if pal.Signed.Locator.Creator == creator {
+			return true
+		}
+	}
+	return false
+}
+
+// onEpochVote detects epoch votes doublesigns and wrong epoch votes
+func (d *mpDetector) onEpochVote(ev inter.LlrSignedEpochVote) {
+	if !d.isRecent(ev.Val.Epoch) {
+		return
+	}
+	creator := ev.Signed.Locator.Creator
+
+	slot := epochVoteSlot{creator, ev.Val.Epoch}
+	if prev, ok := d.epochVotes[slot]; !ok {
+		d.epochVotes[slot] = ev
+	} else if prev.Val.Vote != ev.Val.Vote {
+		d.report(inter.MisbehaviourProof{
+			EpochVoteDoublesign: &inter.EpochVoteDoublesign{
+				Pair: [2]inter.LlrSignedEpochVote{prev, ev},
+			},
+		})
+	}
+
+	// compare with the decided epoch
+	record := d.reader.GetEpochRecordHash(ev.Val.Epoch)
+	if record == nil || *record == ev.Val.Vote {
+		return
+	}
+	pals := d.wrongEpochVotes[ev.Val]
+	if len(pals) >= inter.MinAccomplicesForProof {
+		return
+	}
+	for _, pal := range pals {
+		if pal.Signed.Locator.Creator == creator {
+			return
+		}
+	}
+	pals = append(pals, ev)
+	d.wrongEpochVotes[ev.Val] = pals
+	if len(pals) == inter.MinAccomplicesForProof {
+		proof := &inter.WrongEpochVote{}
+		copy(proof.Pals[:], pals)
+		d.report(inter.MisbehaviourProof{WrongEpochVote: proof})
+	}
+}
+
+// OnBlockVotes tracks processed LLR block votes
+func (em *Emitter) OnBlockVotes(bvs inter.LlrSignedBlockVotes) {
+	if !em.isValidator() {
+		return
+	}
+	em.mps.onBlockVotes(bvs)
+}
+
+// OnEpochVote tracks processed LLR epoch votes
+func (em *Emitter) OnEpochVote(ev inter.LlrSignedEpochVote) {
+	if !em.isValidator() {
+		return
+	}
+	em.mps.onEpochVote(ev)
+}
+
+// addMisbehaviourProofs includes the pending proofs into the event within the gas power budget.
+// Proofs stay pending until an event with them is connected.
+func (em *Emitter) addMisbehaviourProofs(e *inter.MutableEventPayload) {
+	if e.Version() == 0 || len(em.mps.pending) == 0 {
+		return
+	}
+	gas := em.world.GetRules().Economy.Gas
+	mps := make([]inter.MisbehaviourProof, 0, len(em.mps.pending))
+	for _, p := range em.mps.pending {
+		if gas.MisbehaviourProofGas >= e.GasPowerLeft().Min() || e.GasPowerUsed()+gas.MisbehaviourProofGas > gas.MaxEventGas {
+			break
+		}
+		e.SetGasPowerUsed(e.GasPowerUsed() + gas.MisbehaviourProofGas)
+		e.SetGasPowerLeft(e.GasPowerLeft().Sub(gas.MisbehaviourProofGas))
+		mps = append(mps, p.mp)
+	}
+	if len(mps) != 0 {
+		e.SetMisbehaviourProofs(mps)
+	}
+}
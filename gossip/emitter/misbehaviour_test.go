@@ -0,0 +1,149 @@
+package emitter
+
+import (
+	"testing"
+
+	"github.com/artheranet/lachesis/hash"
+	"github.com/artheranet/lachesis/inter/idx"
+	"github.com/stretchr/testify/require"
+
+	"github.com/artheranet/arthera-node/internal/eventcheck/basiccheck"
+	"github.com/artheranet/arthera-node/internal/inter"
+)
+
+type testLlrReader struct {
+	blockRecords map[idx.Block]hash.Hash
+}
+
+func (r testLlrReader) GetLowestBlockToDecide() idx.Block       { return 1 }
+func (r testLlrReader) GetLastBV(id idx.ValidatorID) *idx.Block { return nil }
+func (r testLlrReader) GetBlockEpoch(idx.Block) idx.Epoch       { return 0 }
+func (r testLlrReader) GetLowestEpochToDecide() idx.Epoch       { return 1 }
+func (r testLlrReader) GetLastEV(id idx.ValidatorID) *idx.Epoch { return nil }
+func (r testLlrReader) GetEpochRecordHash(idx.Epoch) *hash.Hash { return nil }
+func (r testLlrReader) GetBlockRecordHash(n idx.Block) *hash.Hash {
+	if record, ok := r.blockRecords[n]; ok {
+		return &record
+	}
+	return nil
+}
+
+func testEvent(epoch idx.Epoch, creator idx.ValidatorID, seq idx.Event, lamport idx.Lamport, mps ...inter.MisbehaviourProof) *inter.EventPayload {
+	me := &inter.MutableEventPayload{}
+	me.SetVersion(1)
+	me.SetEpoch(epoch)
+	me.SetCreator(creator)
+	me.SetSeq(seq)
+	me.SetLamport(lamport)
+	me.SetMisbehaviourProofs(mps)
+	return me.Build()
+}
+
+func testBlockVotes(creator idx.ValidatorID, seq idx.Event, start idx.Block, votes ...hash.Hash) inter.LlrSignedBlockVotes {
+	return inter.LlrSignedBlockVotes{
+		Signed: inter.SignedEventLocator{
+			Locator: inter.EventLocator{Epoch: 2, Seq: seq, Creator: creator},
+		},
+		Val: inter.LlrBlockVotes{Start: start, Epoch: 2, Votes: votes},
+	}
+}
+
+func TestMpDetectorEventsDoublesign(t *testing.T) {
+	require := require.New(t)
+
+	d := newMpDetector(testLlrReader{}, 1)
+	d.onNewEpoch(2)
+
+	d.onEventConnected(testEvent(2, 2, 1, 1))
+	d.onEventConnected(testEvent(2, 2, 2, 2))
+	require.Empty(d.pending)
+
+	// fork of the second event
+	d.onEventConnected(testEvent(2, 2, 2, 3))
+	require.Len(d.pending, 1)
+	mp := d.pending[0].mp
+	require.NotNil(mp.EventsDoublesign)
+	require.Equal(idx.ValidatorID(2), mp.EventsDoublesign.Pair[0].Locator.Creator)
+	require.Equal(idx.Event(2), mp.EventsDoublesign.Pair[1].Locator.Seq)
+
+	// the offender is reported once
+	d.onEventConnected(testEvent(2, 2, 2, 4))
+	require.Len(d.pending, 1)
+
+	// self-doublesign isn't reported
+	d.onEventConnected(testEvent(2, 1, 1, 1))
+	d.onEventConnected(testEvent(2, 1, 1, 2))
+	require.Len(d.pending, 1)
+
+	// proof is dropped after it's included into a connected event
+	d.onEventConnected(testEvent(2, 3, 1, 5, mp))
+	require.Empty(d.pending)
+
+	// pending proofs are dropped once they are too late to be included
+	d.onEventConnected(testEvent(2, 3, 2, 6))
+	d.onEventConnected(testEvent(2, 3, 2, 7))
+	require.Len(d.pending, 1)
+	d.onNewEpoch(2 + basiccheck.MaxLiableEpochs)
+	require.Len(d.pending, 1)
+	d.onNewEpoch(3 + basiccheck.MaxLiableEpochs)
+	require.Empty(d.pending)
+}
+
+func TestMpDetectorEpochSwitch(t *testing.T) {
+	require := require.New(t)
+
+	d := newMpDetector(testLlrReader{}, 1)
+	d.onNewEpoch(2)
+
+	// the proof is detected at the end of the epoch
+	d.onEventConnected(testEvent(2, 2, 1, 1))
+	d.onEventConnected(testEvent(2, 2, 1, 2))
+	require.Len(d.pending, 1)
+	mp := d.pending[0].mp
+
+	// it stays pending in the next epoch, and the offender isn't reported twice
+	d.onNewEpoch(3)
+	require.Len(d.pending, 1)
+	require.Equal(mp, d.pending[0].mp)
+	d.report(mp)
+	require.Len(d.pending, 1)
+
+	// other events of the new epoch don't drop it
+	d.onEventConnected(testEvent(3, 3, 1, 3))
+	require.Len(d.pending, 1)
+
+	// the proof is dropped after it's included into an event of the new epoch
+	d.onEventConnected(testEvent(3, 4, 1, 4, mp))
+	require.Empty(d.pending)
+}
+
+func TestMpDetectorBlockVotes(t *testing.T) {
+	require := require.New(t)
+
+	d := newMpDetector(testLlrReader{
+		blockRecords: map[idx.Block]hash.Hash{10: hash.HexToHash("0x1")},
+	}, 1)
+	d.onNewEpoch(2)
+
+	// doublesign
+	d.onBlockVotes(testBlockVotes(2, 1, 20, hash.HexToHash("0x2"), hash.HexToHash("0x3")))
+	d.onBlockVotes(testBlockVotes(2, 2, 21, hash.HexToHash("0x3")))
+	require.Empty(d.pending)
+	d.onBlockVotes(testBlockVotes(2, 3, 21, hash.HexToHash("0x4")))
+	require.Len(d.pending, 1)
+	require.NotNil(d.pending[0].mp.BlockVoteDoublesign)
+	require.Equal(idx.Block(21), d.pending[0].mp.BlockVoteDoublesign.Block)
+
+	// wrong vote requires accomplices
+	d.onBlockVotes(testBlockVotes(3, 1, 10, hash.HexToHash("0x5")))
+	require.Len(d.pending, 1)
+	d.onBlockVotes(testBlockVotes(4, 1, 10, hash.HexToHash("0x6")))
+	require.Len(d.pending, 1)
+	d.onBlockVotes(testBlockVotes(5, 1, 10, hash.HexToHash("0x5")))
+	require.Len(d.pending, 2)
+	proof := d.pending[1].mp.WrongBlockVote
+	require.NotNil(proof)
+	require.Equal(idx.ValidatorID(3), proof.Pals[0].Signed.Locator.Creator)
+	require.Equal(idx.ValidatorID(5), proof.Pals[1].Signed.Locator.Creator)
+	require.Equal(proof.GetVote(0), proof.GetVote(1))
+}
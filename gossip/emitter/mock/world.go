@@ -483,6 +483,20 @@ func (mr *MockTxPoolMockRecorder) Pending(arg0 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Pending", reflect.TypeOf((*MockTxPool)(nil).Pending), arg0)
 }
 
+// SubscriptionFees mocks base method.
+func (m *MockTxPool) SubscriptionFees() map[common.Hash]*big.Int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SubscriptionFees")
+	ret0, _ := ret[0].(map[common.Hash]*big.Int)
+	return ret0
+}
+
+// SubscriptionFees indicates an expected call of SubscriptionFees.
+func (mr *MockTxPoolMockRecorder) SubscriptionFees() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SubscriptionFees", reflect.TypeOf((*MockTxPool)(nil).SubscriptionFees))
+}
+
 // MockTxSigner is a mock of TxSigner interface.
 type MockTxSigner struct {
 	ctrl     *gomock.Controller
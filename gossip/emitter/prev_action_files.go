@@ -1,105 +1,61 @@
 package emitter
 
 import (
-	"io"
+	"io/ioutil"
 	"os"
-	"path/filepath"
 
 	"github.com/artheranet/lachesis/hash"
 	"github.com/artheranet/lachesis/inter/idx"
 	"github.com/ethereum/go-ethereum/log"
+
+	"github.com/artheranet/arthera-node/internal/slashprotection"
 )
 
-func openPrevActionFile(path string, isSyncMode bool) *os.File {
-	const dirPerm = 0700
-	if err := os.MkdirAll(filepath.Dir(path), dirPerm); err != nil {
-		log.Crit("Failed to create open event file", "file", path, "err", err)
-	}
-	sync := 0
-	if isSyncMode {
-		sync = os.O_SYNC
-	}
-	fh, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|sync, 0666)
-	if err != nil {
-		log.Crit("Failed to open event file", "file", path, "err", err)
+// readPrevActionFile reads the legacy file of the last emitted action, nil if it doesn't exist
+func readPrevActionFile(path string, size int) []byte {
+	if len(path) == 0 {
+		return nil
 	}
-	return fh
-}
-
-func (em *Emitter) writeLastEmittedEventID(id hash.Event) {
-	if em.emittedEventFile == nil {
-		return
+	buf, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
 	}
-	_, err := em.emittedEventFile.WriteAt(id.Bytes(), 0)
 	if err != nil {
-		log.Crit("Failed to write event file", "file", em.config.PrevEmittedEventFile.Path, "err", err)
+		log.Crit("Failed to read legacy emitter file", "file", path, "err", err)
 	}
-}
-
-func (em *Emitter) readLastEmittedEventID() *hash.Event {
-	if em.emittedEventFile == nil {
+	if len(buf) < size {
 		return nil
 	}
-	buf := make([]byte, 32)
-	_, err := em.emittedEventFile.ReadAt(buf, 0)
-	if err != nil {
-		if err == io.EOF {
-			return nil
-		}
-		log.Crit("Failed to read event file", "file", em.config.PrevEmittedEventFile.Path, "err", err)
-	}
-	v := hash.BytesToEvent(buf)
-	return &v
+	return buf[:size]
 }
 
-func (em *Emitter) writeLastEmittedBlockVotes(b idx.Block) {
-	if em.emittedBvsFile == nil {
+// importPrevActionFiles imports the last emitted event and votes from the legacy files into the protection DB,
+// the files are written by the previous versions of the emitter
+func (em *Emitter) importPrevActionFiles() {
+	pubkey := em.config.Validator.PubKey
+	if em.protection == nil || pubkey.Empty() {
 		return
 	}
-	_, err := em.emittedBvsFile.WriteAt(b.Bytes(), 0)
-	if err != nil {
-		log.Crit("Failed to write BVs file", "file", em.config.PrevBlockVotesFile.Path, "err", err)
+	legacy := slashprotection.LegacyRecords{
+		Validator: em.config.Validator.ID,
 	}
-}
-
-func (em *Emitter) readLastBlockVotes() *idx.Block {
-	if em.emittedBvsFile == nil {
-		return nil
+	if buf := readPrevActionFile(em.config.PrevEmittedEventFile.Path, 32); buf != nil {
+		v := hash.BytesToEvent(buf)
+		legacy.LastEvent = &v
 	}
-	buf := make([]byte, 8)
-	_, err := em.emittedBvsFile.ReadAt(buf, 0)
-	if err != nil {
-		if err == io.EOF {
-			return nil
-		}
-		log.Crit("Failed to read BVs file", "file", em.config.PrevBlockVotesFile.Path, "err", err)
+	if buf := readPrevActionFile(em.config.PrevBlockVotesFile.Path, 8); buf != nil {
+		v := idx.BytesToBlock(buf)
+		legacy.LastBlockVote = &v
 	}
-	v := idx.BytesToBlock(buf)
-	return &v
-}
-
-func (em *Emitter) writeLastEmittedEpochVote(e idx.Epoch) {
-	if em.emittedEvFile == nil {
-		return
+	if buf := readPrevActionFile(em.config.PrevEpochVoteFile.Path, 4); buf != nil {
+		v := idx.BytesToEpoch(buf)
+		legacy.LastEpochVote = &v
 	}
-	_, err := em.emittedEvFile.WriteAt(e.Bytes(), 0)
+	imported, err := em.protection.ImportLegacy(pubkey, legacy)
 	if err != nil {
-		log.Crit("Failed to write BVs file", "file", em.config.PrevEpochVoteFile.Path, "err", err)
-	}
-}
-
-func (em *Emitter) readLastEpochVote() *idx.Epoch {
-	if em.emittedEvFile == nil {
-		return nil
+		log.Crit("Failed to import legacy emitter files", "path", em.config.ProtectionDB.Path, "err", err)
 	}
-	buf := make([]byte, 4)
-	_, err := em.emittedEvFile.ReadAt(buf, 0)
-	if err != nil {
-		if err == io.EOF {
-			return nil
-		}
-		log.Crit("Failed to read EV file", "file", em.config.PrevEpochVoteFile.Path, "err", err)
+	if imported && legacy.LastEvent != nil {
+		log.Info("Imported legacy emitter files into doublesign protection DB", "event", legacy.LastEvent.String())
 	}
-	v := idx.BytesToEpoch(buf)
-	return &v
 }
@@ -0,0 +1,99 @@
+package emitter
+
+import (
+	"github.com/artheranet/lachesis/hash"
+	"github.com/artheranet/lachesis/inter/idx"
+	"github.com/ethereum/go-ethereum/log"
+
+	"github.com/artheranet/arthera-node/internal/inter"
+	"github.com/artheranet/arthera-node/internal/inter/validatorpk"
+	"github.com/artheranet/arthera-node/internal/slashprotection"
+)
+
+func openProtectionDB(path string, isSyncMode bool) *slashprotection.DB {
+	db, err := slashprotection.Open(path, isSyncMode)
+	if err != nil {
+		log.Crit("Failed to open doublesign protection DB", "path", path, "err", err)
+	}
+	return db
+}
+
+// checkEmittedEvent refuses the event if it may be a doublesign of a previously emitted one,
+// including the events signed by the other keys of the validator
+func (em *Emitter) checkEmittedEvent(e inter.EventPayloadI) error {
+	if em.protection == nil {
+		return nil
+	}
+	return em.protection.Check(em.pubkey, slashprotection.EventOf(e))
+}
+
+// recordEmittedEvent saves the event to avoid doublesigning in future after a crash
+func (em *Emitter) recordEmittedEvent(e inter.EventPayloadI) {
+	if em.protection == nil {
+		return
+	}
+	err := em.protection.Record(em.pubkey, slashprotection.EventOf(e))
+	if err != nil {
+		log.Crit("Failed to write doublesign protection DB", "path", em.config.ProtectionDB.Path, "err", err)
+	}
+}
+
+// protectedPubkeys returns the configured keys along with the other keys which signed for the validator before
+func (em *Emitter) protectedPubkeys() []validatorpk.PubKey {
+	pubkeys := em.protection.ValidatorKeys(em.config.Validator.PubKey, em.config.Validator.ID)
+	if next := em.config.Validator.NextPubKey; !next.Empty() {
+		for _, pubkey := range pubkeys {
+			if pubkey.Equal(next) {
+				return pubkeys
+			}
+		}
+		pubkeys = append(pubkeys, next)
+	}
+	return pubkeys
+}
+
+// readLastEmittedEventID returns the latest event emitted by any of the validator keys
+func (em *Emitter) readLastEmittedEventID() *hash.Event {
+	if em.protection == nil {
+		return nil
+	}
+	var last *hash.Event
+	for _, pubkey := range em.protectedPubkeys() {
+		id := em.protection.LastEvent(pubkey)
+		if id != nil && (last == nil || id.Epoch() > last.Epoch() || id.Epoch() == last.Epoch() && id.Lamport() > last.Lamport()) {
+			last = id
+		}
+	}
+	return last
+}
+
+// readLastBlockVotes returns the latest block voted by any of the validator keys,
+// as votes of the validator must not overlap across a key rotation
+func (em *Emitter) readLastBlockVotes() *idx.Block {
+	if em.protection == nil {
+		return nil
+	}
+	var last *idx.Block
+	for _, pubkey := range em.protectedPubkeys() {
+		b := em.protection.LastBlockVote(pubkey)
+		if b != nil && (last == nil || *b > *last) {
+			last = b
+		}
+	}
+	return last
+}
+
+// readLastEpochVote returns the latest epoch voted by any of the validator keys
+func (em *Emitter) readLastEpochVote() *idx.Epoch {
+	if em.protection == nil {
+		return nil
+	}
+	var last *idx.Epoch
+	for _, pubkey := range em.protectedPubkeys() {
+		e := em.protection.LastEpochVote(pubkey)
+		if e != nil && (last == nil || *e > *last) {
+			last = e
+		}
+	}
+	return last
+}
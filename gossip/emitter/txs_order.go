@@ -0,0 +1,142 @@
+package emitter
+
+import (
+	"container/heap"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// txWithFee is a transaction with the fee paid per its gas unit
+type txWithFee struct {
+	tx   *types.Transaction
+	from common.Address
+	fee  *big.Int
+}
+
+// txFeeHeap is a heap of the head transactions of the senders, the highest fee first
+type txFeeHeap []*txWithFee
+
+func (h txFeeHeap) Len() int { return len(h) }
+func (h txFeeHeap) Less(i, j int) bool {
+	if cmp := h[i].fee.Cmp(h[j].fee); cmp != 0 {
+		return cmp > 0
+	}
+	return h[i].tx.Time().Before(h[j].tx.Time())
+}
+func (h txFeeHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+
+func (h *txFeeHeap) Push(x interface{}) {
+	*h = append(*h, x.(*txWithFee))
+}
+
+func (h *txFeeHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	x := old[n-1]
+	old[n-1] = nil
+	*h = old[0 : n-1]
+	return x
+}
+
+// TxsByEffectiveFee orders the transactions by the fee paid per gas unit, respecting the nonces of each sender.
+// The gas of a transaction may be paid by the sender's balance or by a subscription, in which case
+// the fee is the price of a gas unit of the subscription plan, so that the sponsored transactions
+// compete with the Pay-as-You-Go ones regardless of the payer.
+type TxsByEffectiveFee struct {
+	txs     map[common.Address]types.Transactions
+	heads   txFeeHeap
+	fees    map[common.Hash]*big.Int
+	baseFee *big.Int
+}
+
+// NewTxsByEffectiveFee creates an ordering of the given transactions, which are sorted by nonce for each sender.
+// Fees contains the price of a gas unit of the subscription-covered transactions.
+func NewTxsByEffectiveFee(signer types.Signer, txs map[common.Address]types.Transactions, fees map[common.Hash]*big.Int, baseFee *big.Int) *TxsByEffectiveFee {
+	sorted := &TxsByEffectiveFee{
+		txs:     txs,
+		heads:   make(txFeeHeap, 0, len(txs)),
+		fees:    fees,
+		baseFee: baseFee,
+	}
+	for from, accTxs := range txs {
+		if len(accTxs) == 0 {
+			delete(txs, from)
+			continue
+		}
+		// ensure the sender address is from the signer
+		if acc, _ := types.Sender(signer, accTxs[0]); acc != from {
+			delete(txs, from)
+			continue
+		}
+		sorted.heads = append(sorted.heads, sorted.withFee(from, accTxs[0]))
+		txs[from] = accTxs[1:]
+	}
+	heap.Init(&sorted.heads)
+	return sorted
+}
+
+// effectiveFee returns the fee paid per gas unit of the transaction
+func (t *TxsByEffectiveFee) effectiveFee(tx *types.Transaction) *big.Int {
+	if fee, ok := t.fees[tx.Hash()]; ok && fee != nil {
+		return fee
+	}
+	if t.baseFee == nil {
+		return tx.GasPrice()
+	}
+	fee := new(big.Int).Add(t.baseFee, tx.GasTipCap())
+	if fee.Cmp(tx.GasFeeCap()) > 0 {
+		fee.Set(tx.GasFeeCap())
+	}
+	return fee
+}
+
+func (t *TxsByEffectiveFee) withFee(from common.Address, tx *types.Transaction) *txWithFee {
+	return &txWithFee{
+		tx:   tx,
+		from: from,
+		fee:  t.effectiveFee(tx),
+	}
+}
+
+// Peek returns the next transaction by fee.
+func (t *TxsByEffectiveFee) Peek() *types.Transaction {
+	if len(t.heads) == 0 {
+		return nil
+	}
+	return t.heads[0].tx
+}
+
+// Shift replaces the current best head with the next one from the same account.
+func (t *TxsByEffectiveFee) Shift() {
+	from := t.heads[0].from
+	if txs, ok := t.txs[from]; ok && len(txs) > 0 {
+		t.heads[0], t.txs[from] = t.withFee(from, txs[0]), txs[1:]
+		heap.Fix(&t.heads, 0)
+		return
+	}
+	heap.Pop(&t.heads)
+}
+
+// Pop removes the best transaction, *not* replacing it with the next one from
+// the same account. This should be used when a transaction cannot be executed
+// and hence all subsequent ones should be discarded from the same account.
+func (t *TxsByEffectiveFee) Pop() {
+	heap.Pop(&t.heads)
+}
+
+// Copy returns a copy of the ordering, which may be consumed independently.
+func (t *TxsByEffectiveFee) Copy() *TxsByEffectiveFee {
+	cpy := &TxsByEffectiveFee{
+		txs:     make(map[common.Address]types.Transactions, len(t.txs)),
+		heads:   make(txFeeHeap, len(t.heads)),
+		fees:    t.fees,
+		baseFee: t.baseFee,
+	}
+	copy(cpy.heads, t.heads)
+	for acc, txs := range t.txs {
+		cpy.txs[acc] = txs
+	}
+	return cpy
+}
@@ -0,0 +1,57 @@
+package emitter
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTxsByEffectiveFee(t *testing.T) {
+	require := require.New(t)
+	signer := types.LatestSignerForChainID(big.NewInt(1))
+
+	newTx := func(nonce uint64, tip, feeCap int64) (*types.Transaction, common.Address) {
+		key, err := crypto.GenerateKey()
+		require.NoError(err)
+		tx, err := types.SignNewTx(key, signer, &types.DynamicFeeTx{
+			ChainID:   big.NewInt(1),
+			Nonce:     nonce,
+			GasTipCap: big.NewInt(tip),
+			GasFeeCap: big.NewInt(feeCap),
+			Gas:       21000,
+		})
+		require.NoError(err)
+		return tx, crypto.PubkeyToAddress(key.PublicKey)
+	}
+
+	payg, paygFrom := newTx(0, 5, 200)
+	capped, cappedFrom := newTx(0, 50, 120)
+	sponsored, sponsoredFrom := newTx(0, 0, 100)
+
+	txs := map[common.Address]types.Transactions{
+		paygFrom:      {payg},
+		cappedFrom:    {capped},
+		sponsoredFrom: {sponsored},
+	}
+	fees := map[common.Hash]*big.Int{
+		sponsored.Hash(): big.NewInt(130),
+	}
+	sorted := NewTxsByEffectiveFee(signer, txs, fees, big.NewInt(100))
+
+	// the sponsored tx pays 130 per gas through the subscription, the capped tx pays 120, and the other one pays 105
+	cpy := sorted.Copy()
+	require.Equal(sponsored.Hash(), sorted.Peek().Hash())
+	sorted.Shift()
+	require.Equal(capped.Hash(), sorted.Peek().Hash())
+	sorted.Pop()
+	require.Equal(payg.Hash(), sorted.Peek().Hash())
+	sorted.Shift()
+	require.Nil(sorted.Peek())
+
+	// the copy isn't affected
+	require.Equal(sponsored.Hash(), cpy.Peek().Hash())
+}
@@ -5,6 +5,7 @@ import (
 	"github.com/artheranet/arthera-node/internal/inter/iblockproc"
 	"github.com/artheranet/arthera-node/params"
 	"github.com/ethereum/go-ethereum/core/state"
+	"math/big"
 	"sync"
 
 	"github.com/artheranet/arthera-node/internal/inter"
@@ -88,4 +89,8 @@ type TxPool interface {
 
 	// Count returns the total number of transactions
 	Count() int
+
+	// SubscriptionFees returns the price of a gas unit for the transactions
+	// whose gas is covered by a subscription.
+	SubscriptionFees() map[common.Hash]*big.Int
 }
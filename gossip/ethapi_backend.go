@@ -23,6 +23,7 @@ import (
 	"github.com/pkg/errors"
 
 	"github.com/artheranet/arthera-node/gossip/evmstore"
+	"github.com/artheranet/arthera-node/gossip/txtrace"
 	"github.com/artheranet/arthera-node/internal/evmcore"
 	"github.com/artheranet/arthera-node/internal/inter"
 	"github.com/artheranet/arthera-node/internal/inter/iblockproc"
@@ -105,12 +106,26 @@ func (b *EthAPIBackend) BlockByNumber(ctx context.Context, number rpc.BlockNumbe
 		blk = b.state.CurrentBlock()
 	} else {
 		n := uint64(number.Int64())
+		if b.isBlockTxsPruned(idx.Block(n)) {
+			return nil, evmstore.ErrPrunedHistory
+		}
 		blk = b.state.GetBlock(common.Hash{}, n)
 	}
 
 	return blk, nil
 }
 
+// isBlockTxsPruned returns true if the block exists, but its transactions are expired
+func (b *EthAPIBackend) isBlockTxsPruned(n idx.Block) bool {
+	block := b.svc.store.GetBlock(n)
+	return block != nil && b.svc.store.IsBlockTxsPruned(block)
+}
+
+// IsBlockPruned returns true if the receipts and the tx lookups of the block are expired.
+func (b *EthAPIBackend) IsBlockPruned(n idx.Block) bool {
+	return b.svc.store.IsBlockPruned(n)
+}
+
 // StateAndHeaderByNumberOrHash returns evm state and block header by block number or block hash, err if not exists.
 func (b *EthAPIBackend) StateAndHeaderByNumberOrHash(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (*state.StateDB, *evmcore.EvmHeader, error) {
 	var header *evmcore.EvmHeader
@@ -160,13 +175,20 @@ func (b *EthAPIBackend) GetFullEventID(shortEventID string) (hash.Event, error)
 	s := strings.Split(shortEventID, ":")
 	if len(s) == 1 {
 		// it's a full hash
-		return hash.HexToEventHash(shortEventID), nil
+		id := hash.HexToEventHash(shortEventID)
+		if b.svc.store.IsEpochPruned(id.Epoch()) {
+			return hash.Event{}, evmstore.ErrPrunedHistory
+		}
+		return id, nil
 	}
 	// short hash
 	epoch, lamport, prefix, err := decodeShortEventID(s)
 	if err != nil {
 		return hash.Event{}, err
 	}
+	if b.svc.store.IsEpochPruned(epoch) {
+		return hash.Event{}, evmstore.ErrPrunedHistory
+	}
 
 	options := b.svc.store.FindEventHashes(epoch, lamport, prefix)
 	if len(options) == 0 {
@@ -245,11 +267,37 @@ func (b *EthAPIBackend) ForEachEpochEvent(ctx context.Context, epoch rpc.BlockNu
 	if err != nil {
 		return err
 	}
+	if b.svc.store.IsEpochPruned(requested) {
+		return evmstore.ErrPrunedHistory
+	}
 
 	b.svc.store.ForEachEpochEvent(requested, onEvent)
 	return nil
 }
 
+// ForEachEpochEventFrom iterates the epoch events in the lamport order, starting from the given event (inclusive).
+// The event doesn't have to exist, the iteration starts from the first event which follows it.
+// The iteration starts from the first event of the epoch if from is zero.
+func (b *EthAPIBackend) ForEachEpochEventFrom(ctx context.Context, epoch rpc.BlockNumber, from hash.Event, onEvent func(event *inter.EventPayload) bool) error {
+	requested, err := b.epochWithDefault(ctx, epoch)
+	if err != nil {
+		return err
+	}
+	if b.svc.store.IsEpochPruned(requested) {
+		return evmstore.ErrPrunedHistory
+	}
+
+	var start []byte
+	if from != (hash.Event{}) {
+		if from.Epoch() != requested {
+			return errors.New("event doesn't belong to the epoch")
+		}
+		start = from.Bytes()[4:]
+	}
+	b.svc.store.ForEachEpochEventFrom(requested, start, onEvent)
+	return nil
+}
+
 func (b *EthAPIBackend) BlockByHash(ctx context.Context, h common.Hash) (*evmcore.EvmBlock, error) {
 	index := b.svc.store.GetBlockIndex(hash.Event(h))
 	if index == nil {
@@ -265,6 +313,9 @@ func (b *EthAPIBackend) BlockByHash(ctx context.Context, h common.Hash) (*evmcor
 		blk = b.state.CurrentBlock()
 	} else {
 		n := uint64(*index)
+		if b.isBlockTxsPruned(idx.Block(n)) {
+			return nil, evmstore.ErrPrunedHistory
+		}
 		blk = b.state.GetBlock(common.Hash{}, n)
 	}
 
@@ -284,12 +335,89 @@ func (b *EthAPIBackend) GetReceiptsByNumber(ctx context.Context, number rpc.Bloc
 		header := b.state.CurrentHeader()
 		number = rpc.BlockNumber(header.Number.Uint64())
 	}
+	if b.svc.store.IsBlockPruned(idx.Block(number)) {
+		return nil, evmstore.ErrPrunedHistory
+	}
 
 	block := b.state.GetBlock(common.Hash{}, uint64(number))
 	receipts := b.svc.store.evm.GetReceipts(idx.Block(number), b.signer, block.Hash, block.Transactions)
 	return receipts, nil
 }
 
+// GetGasPaymentsByNumber returns gas payments of block transactions by block number.
+func (b *EthAPIBackend) GetGasPaymentsByNumber(ctx context.Context, number rpc.BlockNumber) ([]*evmcore.GasPayment, error) {
+	if !b.svc.config.TxIndex {
+		return nil, errors.New("transactions index is disabled (enable TxIndex and re-process the DAGs)")
+	}
+
+	if number == rpc.PendingBlockNumber {
+		number = rpc.LatestBlockNumber
+	}
+	if number == rpc.LatestBlockNumber {
+		header := b.state.CurrentHeader()
+		number = rpc.BlockNumber(header.Number.Uint64())
+	}
+	if b.svc.store.IsBlockPruned(idx.Block(number)) {
+		return nil, evmstore.ErrPrunedHistory
+	}
+
+	return b.svc.store.evm.GetGasPayments(idx.Block(number)), nil
+}
+
+// TxTraceByHash returns the recorded call traces of a transaction.
+func (b *EthAPIBackend) TxTraceByHash(ctx context.Context, h common.Hash) ([]txtrace.ActionTrace, error) {
+	store := b.svc.store.TxTraceStore()
+	if store == nil {
+		return nil, errors.New("transaction tracing is disabled (enable --tracenode and re-process the DAGs)")
+	}
+	return store.GetTxTraces(h)
+}
+
+func (b *EthAPIBackend) deployerRewards(ctx context.Context, byOwner bool, addr common.Address, from, to idx.Block, limit int) ([]evmstore.DeployerReward, error) {
+	if !b.svc.config.TxIndex {
+		return nil, errors.New("transactions index is disabled (enable TxIndex and re-process the DAGs)")
+	}
+	var (
+		rewards []evmstore.DeployerReward
+		err     error
+	)
+	onReward := func(r evmstore.DeployerReward) bool {
+		if len(rewards) >= limit {
+			err = fmt.Errorf("too many rewards records, max %d, narrow the blocks range", limit)
+			return false
+		}
+		if err = ctx.Err(); err != nil {
+			return false
+		}
+		rewards = append(rewards, r)
+		return true
+	}
+	if byOwner {
+		b.svc.store.evm.ForEachOwnerDeployerReward(addr, from, to, onReward)
+	} else {
+		b.svc.store.evm.ForEachContractDeployerReward(addr, from, to, onReward)
+	}
+	return rewards, err
+}
+
+// OwnerDeployerRewards returns the indexed Pay-as-You-Go rewards credited to the owner within the blocks range.
+func (b *EthAPIBackend) OwnerDeployerRewards(ctx context.Context, owner common.Address, from, to idx.Block, limit int) ([]evmstore.DeployerReward, error) {
+	return b.deployerRewards(ctx, true, owner, from, to, limit)
+}
+
+// ContractDeployerRewards returns the indexed Pay-as-You-Go rewards earned by the contract within the blocks range.
+func (b *EthAPIBackend) ContractDeployerRewards(ctx context.Context, contract common.Address, from, to idx.Block, limit int) ([]evmstore.DeployerReward, error) {
+	return b.deployerRewards(ctx, false, contract, from, to, limit)
+}
+
+// OwnerDeployerRewardsTotal returns the total amount of the indexed rewards credited to the owner.
+func (b *EthAPIBackend) OwnerDeployerRewardsTotal(ctx context.Context, owner common.Address) (*big.Int, error) {
+	if !b.svc.config.TxIndex {
+		return nil, errors.New("transactions index is disabled (enable TxIndex and re-process the DAGs)")
+	}
+	return b.svc.store.evm.GetOwnerDeployerRewardsTotal(owner), nil
+}
+
 // GetReceipts retrieves the receipts for all transactions in a given block.
 func (b *EthAPIBackend) GetReceipts(ctx context.Context, block common.Hash) (types.Receipts, error) {
 	number := b.svc.store.GetBlockIndex(hash.Event(block))
@@ -320,16 +448,27 @@ func (b *EthAPIBackend) GetEVM(ctx context.Context, msg evmcore.Message, state *
 	if vmConfig == nil {
 		vmConfig = &params.DefaultVMConfig
 	}
+	// meter the precompiled contracts according to the rules of the requested block
+	cfg := b.BlockVMConfig(idx.Block(header.Number.Uint64()), *vmConfig)
 	txContext := evmcore.NewEVMTxContext(msg)
 	context := evmcore.NewEVMBlockContext(header, b.state, nil)
 	config := b.ChainConfig()
-	return vm.NewEVM(context, txContext, state, config, *vmConfig), state.Error, nil
+	return vm.NewEVM(context, txContext, state, config, cfg), state.Error, nil
 }
 
 func (b *EthAPIBackend) GetBlockContext(header *evmcore.EvmHeader) vm.BlockContext {
 	return evmcore.NewEVMBlockContext(header, b.state, nil)
 }
 
+// BlockVMConfig returns a copy of the VM config with the precompiled contracts metered by the rules of the block's epoch
+func (b *EthAPIBackend) BlockVMConfig(n idx.Block, vmConfig vm.Config) vm.Config {
+	rules := b.svc.store.GetRules()
+	if es := b.svc.store.GetHistoryEpochState(b.svc.store.FindBlockEpoch(n)); es != nil {
+		rules = es.Rules
+	}
+	return rules.VMConfig(vmConfig)
+}
+
 func (b *EthAPIBackend) SendTx(ctx context.Context, signedTx *types.Transaction) error {
 	err := b.svc.txpool.AddLocal(signedTx)
 	if err == nil {
@@ -347,6 +486,18 @@ func (b *EthAPIBackend) SubscribeNewBlockNotify(ch chan<- evmcore.ChainHeadNotif
 	return b.svc.feed.SubscribeNewBlock(ch)
 }
 
+func (b *EthAPIBackend) SubscribeNewEventsNotify(ch chan<- *inter.EventPayload) notify.Subscription {
+	return b.svc.feed.SubscribeNewEvent(ch)
+}
+
+func (b *EthAPIBackend) SubscribeConfirmedEventsNotify(ch chan<- inter.ConfirmedEventNotify) notify.Subscription {
+	return b.svc.feed.SubscribeConfirmedEvent(ch)
+}
+
+func (b *EthAPIBackend) SubscribeNewEpochNotify(ch chan<- idx.Epoch) notify.Subscription {
+	return b.svc.feed.SubscribeNewEpoch(ch)
+}
+
 func (b *EthAPIBackend) SubscribeNewTxsNotify(ch chan<- evmcore.NewTxsNotify) notify.Subscription {
 	return b.svc.txpool.SubscribeNewTxsNotify(ch)
 }
@@ -377,6 +528,10 @@ func (b *EthAPIBackend) GetTransaction(ctx context.Context, txHash common.Hash)
 	if position == nil {
 		return nil, 0, 0, nil
 	}
+	if b.svc.store.IsBlockPruned(position.Block) {
+		// the tx lookup is being expired
+		return nil, 0, 0, evmstore.ErrPrunedHistory
+	}
 
 	var tx *types.Transaction
 	if position.Event.IsZero() {
@@ -441,6 +596,10 @@ func (b *EthAPIBackend) ChainDb() ethdb.Database {
 	return b.svc.store.evm.EvmDb
 }
 
+func (b *EthAPIBackend) PruneStatus() evmstore.PruneStatus {
+	return b.svc.store.evm.PruneStatus()
+}
+
 func (b *EthAPIBackend) AccountManager() *accounts.Manager {
 	return b.svc.AccountManager()
 }
@@ -503,6 +662,38 @@ func (b *EthAPIBackend) GetOriginatedFee(ctx context.Context, vid idx.ValidatorI
 	return bs.GetValidatorState(vid, es.Validators).Originated, nil
 }
 
+// GetValidatorEpochMetrics returns the performance metrics of the validator in the epoch.
+// Metrics of the current epoch contain only the counters collected so far.
+func (b *EthAPIBackend) GetValidatorEpochMetrics(ctx context.Context, epoch idx.Epoch, vid idx.ValidatorID) (*iblockproc.ValidatorEpochMetrics, error) {
+	return b.svc.store.GetValidatorEpochMetrics(epoch, vid), nil
+}
+
+// GetEpochCheaters returns the validators which were reported as cheaters in the epoch.
+func (b *EthAPIBackend) GetEpochCheaters(ctx context.Context, epoch idx.Epoch) ([]idx.ValidatorID, error) {
+	if epoch == b.svc.store.GetEpoch() {
+		cheaters := b.svc.store.GetBlockState().EpochCheaters
+		return append(make([]idx.ValidatorID, 0, len(cheaters)), cheaters...), nil
+	}
+	var cheaters []idx.ValidatorID
+	b.svc.store.ForEachValidatorEpochMetrics(epoch, func(vid idx.ValidatorID, m *iblockproc.ValidatorEpochMetrics) bool {
+		if m.Cheater {
+			cheaters = append(cheaters, vid)
+		}
+		return true
+	})
+	return cheaters, nil
+}
+
+// GetMisbehaviourEvidence returns the evidence of misbehaviour proofs applied in the epoch.
+func (b *EthAPIBackend) GetMisbehaviourEvidence(ctx context.Context, epoch idx.Epoch) ([]inter.MisbehaviourEvidence, error) {
+	var evidence []inter.MisbehaviourEvidence
+	b.svc.store.ForEachMisbehaviourEvidence(epoch, func(ev inter.MisbehaviourEvidence) bool {
+		evidence = append(evidence, ev)
+		return true
+	})
+	return evidence, nil
+}
+
 func (b *EthAPIBackend) GetDowntime(ctx context.Context, vid idx.ValidatorID) (idx.Block, inter.Timestamp, error) {
 	// Note: loads bs and es atomically to avoid a race condition
 	bs, es := b.svc.store.GetBlockEpochState()
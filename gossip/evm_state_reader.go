@@ -95,6 +95,10 @@ func (r *EvmStateReader) getBlock(h hash.Event, n idx.Block, readTxs bool) *evmc
 	if (h != hash.Event{}) && (h != block.Atropos) {
 		return nil
 	}
+	if readTxs && r.store.IsBlockTxsPruned(block) {
+		// block events are expired
+		return nil
+	}
 	if readTxs {
 		if cached := r.store.EvmStore().GetCachedEvmBlock(n); cached != nil {
 			return cached
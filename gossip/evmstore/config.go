@@ -1,6 +1,8 @@
 package evmstore
 
 import (
+	"time"
+
 	"github.com/artheranet/lachesis/utils/cachescale"
 	"github.com/syndtr/goleveldb/leveldb/opt"
 )
@@ -31,11 +33,24 @@ type (
 		// Whether to enable greedy gc mode
 		GreedyGC bool
 	}
+	// PruningConfig is a config of the online state pruning.
+	PruningConfig struct {
+		// Number of the latest block states to keep. Zero disables the online pruning
+		Blocks uint64
+		// Minimum time between the pruning runs
+		Interval time.Duration
+		// Pause between the deletion batches, which throttles the pruning
+		BatchPause time.Duration
+		// Size (MB) of the bloom filter of the reachable trie nodes
+		BloomSize uint64
+	}
 	// StoreConfig is a config for store db.
 	StoreConfig struct {
 		Cache StoreCacheConfig
 		// Enables tracking of SHA3 preimages in the VM
 		EnablePreimageRecording bool
+		// Online state pruning
+		Pruning PruningConfig
 	}
 )
 
@@ -55,6 +70,12 @@ func DefaultStoreConfig(scale cachescale.Func) StoreConfig {
 			TrieDirtyLimit:    scale.U(256 * opt.MiB),
 		},
 		EnablePreimageRecording: true,
+		Pruning: PruningConfig{
+			Blocks:     0,
+			Interval:   time.Hour,
+			BatchPause: 10 * time.Millisecond,
+			BloomSize:  512,
+		},
 	}
 }
 
@@ -38,6 +38,11 @@ type Store struct {
 		Receipts    kvdb.Store `table:"r"`
 		TxPositions kvdb.Store `table:"x"`
 		Txs         kvdb.Store `table:"X"`
+		GasPayments kvdb.Store `table:"p"`
+		// Pay-as-You-Go deployer rewards index
+		DeployerRewardsByOwner    kvdb.Store `table:"d"`
+		DeployerRewardsByContract kvdb.Store `table:"k"`
+		DeployerRewardTotals      kvdb.Store `table:"w"` // by owner
 	}
 
 	EvmDb    ethdb.Database
@@ -55,6 +60,8 @@ type Store struct {
 
 	triegc *prque.Prque // Priority queue mapping block numbers to tries to gc
 
+	pruner *statePruner
+
 	logger.Instance
 }
 
@@ -70,6 +77,10 @@ func NewStore(dbs kvdb.DBProducer, cfg StoreConfig) *Store {
 		rlp:      rlpstore.Helper{logger.New("rlp")},
 		triegc:   prque.New(nil),
 	}
+	s.pruner = &statePruner{
+		store: s,
+		cfg:   cfg.Pruning,
+	}
 
 	err := table.OpenTables(&s.table, dbs, "evm")
 	if err != nil {
@@ -105,7 +116,7 @@ func (s *Store) initEVMDB() {
 	s.EvmDb = rawdb.NewDatabase(
 		kvdb2ethdb.Wrap(
 			nokeyiserr.Wrap(
-				s.table.Evm)))
+				&pruneTrackedStore{s.table.Evm, s.pruner})))
 	s.EvmState = state.NewDatabaseWithConfig(s.EvmDb, &trie.Config{
 		Cache:     s.cfg.Cache.EvmDatabase / opt.MiB,
 		Journal:   s.cfg.Cache.TrieCleanJournal,
@@ -171,6 +182,8 @@ func (s *Store) CleanCommit(block iblockproc.BlockState) error {
 	err := triedb.Commit(stateRoot, false, nil)
 	if err != nil {
 		s.Log.Error("Failed to flush trie DB into main DB", "err", err)
+	} else {
+		s.pruner.setFlushed(stateRoot)
 	}
 	return err
 }
@@ -192,6 +205,8 @@ func (s *Store) Commit(block idx.Block, root hash.Hash, flush bool) error {
 		err := triedb.Commit(stateRoot, false, nil)
 		if err != nil {
 			s.Log.Error("Failed to flush trie DB into main DB", "err", err)
+		} else {
+			s.pruner.setFlushed(stateRoot)
 		}
 		return err
 	} else {
@@ -237,6 +252,8 @@ func (s *Store) Flush(block iblockproc.BlockState) {
 			s.Log.Info("Writing cached state to disk", "block", number, "root", block.FinalizedStateRoot)
 			if err := triedb.Commit(common.Hash(block.FinalizedStateRoot), true, nil); err != nil {
 				s.Log.Error("Failed to commit recent state trie", "err", err)
+			} else {
+				s.pruner.setFlushed(common.Hash(block.FinalizedStateRoot))
 			}
 		}
 		if snapBase != (common.Hash{}) {
@@ -0,0 +1,129 @@
+package evmstore
+
+import (
+	"math/big"
+
+	"github.com/artheranet/lachesis/common/bigendian"
+	"github.com/artheranet/lachesis/inter/idx"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// DeployerReward is a Pay-as-You-Go reward credited to a contract owner within a block
+type DeployerReward struct {
+	Owner    common.Address
+	Contract common.Address
+	Block    idx.Block
+	Epoch    idx.Epoch
+	Amount   *big.Int
+}
+
+type deployerRewardRLP struct {
+	Epoch  idx.Epoch
+	Amount *big.Int
+}
+
+func deployerRewardKey(first common.Address, n idx.Block, second common.Address) []byte {
+	key := make([]byte, 0, common.AddressLength*2+8)
+	key = append(key, first.Bytes()...)
+	key = append(key, n.Bytes()...)
+	return append(key, second.Bytes()...)
+}
+
+// IndexDeployerRewards stores the deployer rewards of a block, aggregated per owner and contract.
+func (s *Store) IndexDeployerRewards(n idx.Block, epoch idx.Epoch, rewards []DeployerReward) {
+	type ownedContract struct {
+		owner    common.Address
+		contract common.Address
+	}
+	aggregated := make(map[ownedContract]*big.Int)
+	order := make([]ownedContract, 0, len(rewards))
+	for _, r := range rewards {
+		if r.Amount == nil || r.Amount.Sign() <= 0 {
+			continue
+		}
+		k := ownedContract{r.Owner, r.Contract}
+		if aggregated[k] == nil {
+			aggregated[k] = new(big.Int)
+			order = append(order, k)
+		}
+		aggregated[k].Add(aggregated[k], r.Amount)
+	}
+
+	for _, k := range order {
+		key := deployerRewardKey(k.contract, n, k.owner)
+		total := s.GetOwnerDeployerRewardsTotal(k.owner)
+		// the block is indexed again if it's re-executed, so the previous record is replaced rather than counted twice
+		if prev, _ := s.rlp.Get(s.table.DeployerRewardsByContract, key, &deployerRewardRLP{}).(*deployerRewardRLP); prev != nil {
+			total.Sub(total, prev.Amount)
+		}
+		val := &deployerRewardRLP{
+			Epoch:  epoch,
+			Amount: aggregated[k],
+		}
+		s.rlp.Set(s.table.DeployerRewardsByOwner, deployerRewardKey(k.owner, n, k.contract), val)
+		s.rlp.Set(s.table.DeployerRewardsByContract, key, val)
+
+		total.Add(total, aggregated[k])
+		if err := s.table.DeployerRewardTotals.Put(k.owner.Bytes(), total.Bytes()); err != nil {
+			s.Log.Crit("Failed to put key-value", "err", err)
+		}
+	}
+}
+
+// GetOwnerDeployerRewardsTotal returns the total amount of the indexed rewards credited to the owner.
+// The PayAsYouGoGasRewards contract credits the rewards to the owner, so the totals are kept per owner as well.
+func (s *Store) GetOwnerDeployerRewardsTotal(owner common.Address) *big.Int {
+	buf, err := s.table.DeployerRewardTotals.Get(owner.Bytes())
+	if err != nil {
+		s.Log.Crit("Failed to get key-value", "err", err)
+	}
+	return new(big.Int).SetBytes(buf)
+}
+
+func (s *Store) forEachDeployerReward(byOwner bool, addr common.Address, from, to idx.Block, onReward func(DeployerReward) bool) {
+	tbl := s.table.DeployerRewardsByContract
+	if byOwner {
+		tbl = s.table.DeployerRewardsByOwner
+	}
+	it := tbl.NewIterator(addr.Bytes(), from.Bytes())
+	defer it.Release()
+	for it.Next() {
+		key := it.Key()
+		if len(key) != common.AddressLength*2+8 {
+			s.Log.Crit("Invalid deployer reward key", "key", key)
+		}
+		n := idx.Block(bigendian.BytesToUint64(key[common.AddressLength : common.AddressLength+8]))
+		if n > to {
+			break
+		}
+		var val deployerRewardRLP
+		if err := rlp.DecodeBytes(it.Value(), &val); err != nil {
+			s.Log.Crit("Failed to decode rlp", "err", err)
+		}
+		r := DeployerReward{
+			Block:  n,
+			Epoch:  val.Epoch,
+			Amount: val.Amount,
+		}
+		other := common.BytesToAddress(key[common.AddressLength+8:])
+		if byOwner {
+			r.Owner, r.Contract = addr, other
+		} else {
+			r.Owner, r.Contract = other, addr
+		}
+		if !onReward(r) {
+			break
+		}
+	}
+}
+
+// ForEachOwnerDeployerReward iterates over the rewards credited to the owner within the blocks range.
+func (s *Store) ForEachOwnerDeployerReward(owner common.Address, from, to idx.Block, onReward func(DeployerReward) bool) {
+	s.forEachDeployerReward(true, owner, from, to, onReward)
+}
+
+// ForEachContractDeployerReward iterates over the rewards earned by the contract within the blocks range.
+func (s *Store) ForEachContractDeployerReward(contract common.Address, from, to idx.Block, onReward func(DeployerReward) bool) {
+	s.forEachDeployerReward(false, contract, from, to, onReward)
+}
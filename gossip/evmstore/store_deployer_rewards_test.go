@@ -0,0 +1,70 @@
+package evmstore
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/artheranet/lachesis/inter/idx"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStoreDeployerRewards(t *testing.T) {
+	require := require.New(t)
+	store := cachedStore()
+
+	owner1 := common.HexToAddress("0x01")
+	owner2 := common.HexToAddress("0x02")
+	contractA := common.HexToAddress("0x0a")
+	contractB := common.HexToAddress("0x0b")
+
+	store.IndexDeployerRewards(10, 1, []DeployerReward{
+		{Owner: owner1, Contract: contractA, Amount: big.NewInt(5)},
+		{Owner: owner1, Contract: contractA, Amount: big.NewInt(7)},
+		{Owner: owner2, Contract: contractB, Amount: big.NewInt(1)},
+	})
+	store.IndexDeployerRewards(20, 2, []DeployerReward{
+		{Owner: owner1, Contract: contractB, Amount: big.NewInt(3)},
+	})
+
+	collect := func(forEach func(common.Address, idx.Block, idx.Block, func(DeployerReward) bool), addr common.Address, from, to idx.Block) []DeployerReward {
+		var res []DeployerReward
+		forEach(addr, from, to, func(r DeployerReward) bool {
+			res = append(res, r)
+			return true
+		})
+		return res
+	}
+
+	got := collect(store.ForEachOwnerDeployerReward, owner1, 0, 100)
+	require.Len(got, 2)
+	require.Equal(contractA, got[0].Contract)
+	require.Equal(idx.Block(10), got[0].Block)
+	require.Equal(idx.Epoch(1), got[0].Epoch)
+	require.Equal(int64(12), got[0].Amount.Int64(), "aggregated within block")
+	require.Equal(contractB, got[1].Contract)
+	require.Equal(idx.Block(20), got[1].Block)
+
+	got = collect(store.ForEachOwnerDeployerReward, owner1, 11, 100)
+	require.Len(got, 1)
+	got = collect(store.ForEachOwnerDeployerReward, owner1, 0, 19)
+	require.Len(got, 1)
+
+	got = collect(store.ForEachContractDeployerReward, contractB, 0, 100)
+	require.Len(got, 2)
+	require.Equal(owner2, got[0].Owner)
+	require.Equal(owner1, got[1].Owner)
+
+	require.Equal(int64(15), store.GetOwnerDeployerRewardsTotal(owner1).Int64())
+	require.Equal(int64(1), store.GetOwnerDeployerRewardsTotal(owner2).Int64())
+	require.Equal(int64(0), store.GetOwnerDeployerRewardsTotal(contractA).Int64())
+
+	// a re-executed block is indexed again without double counting
+	store.IndexDeployerRewards(20, 2, []DeployerReward{
+		{Owner: owner1, Contract: contractB, Amount: big.NewInt(3)},
+	})
+	require.Equal(int64(15), store.GetOwnerDeployerRewardsTotal(owner1).Int64())
+	got = collect(store.ForEachContractDeployerReward, contractB, 0, 100)
+	require.Len(got, 2)
+	require.Equal(int64(3), got[1].Amount.Int64())
+}
@@ -0,0 +1,38 @@
+package evmstore
+
+import (
+	"github.com/artheranet/lachesis/inter/idx"
+	"github.com/ethereum/go-ethereum/rlp"
+
+	"github.com/artheranet/arthera-node/internal/evmcore"
+)
+
+// SetGasPayments stores gas payments of block transactions, in the same order as receipts.
+func (s *Store) SetGasPayments(n idx.Block, payments []*evmcore.GasPayment) {
+	s.rlp.Set(s.table.GasPayments, n.Bytes(), payments)
+}
+
+// DelGasPayments deletes gas payments of block transactions.
+func (s *Store) DelGasPayments(n idx.Block) {
+	if err := s.table.GasPayments.Delete(n.Bytes()); err != nil {
+		s.Log.Crit("Failed to delete key", "err", err)
+	}
+}
+
+// GetGasPayments returns stored gas payments of block transactions.
+func (s *Store) GetGasPayments(n idx.Block) []*evmcore.GasPayment {
+	payments, _ := s.rlp.Get(s.table.GasPayments, n.Bytes(), &[]*evmcore.GasPayment{}).(*[]*evmcore.GasPayment)
+	if payments == nil {
+		return nil
+	}
+	return *payments
+}
+
+// GetGasPaymentsRLP returns stored gas payments of block transactions in the RLP encoding.
+func (s *Store) GetGasPaymentsRLP(n idx.Block) rlp.RawValue {
+	buf, err := s.table.GasPayments.Get(n.Bytes())
+	if err != nil {
+		s.Log.Crit("Failed to get key-value", "err", err)
+	}
+	return buf
+}
@@ -0,0 +1,46 @@
+package evmstore
+
+import (
+	"errors"
+
+	"github.com/artheranet/lachesis/inter/idx"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// ErrPrunedHistory is returned when the requested data is older than the retained history
+var ErrPrunedHistory = errors.New("pruned history")
+
+// ExpireReceipts deletes the receipts, the gas payments and the tx positions of up to limit oldest blocks below the given one.
+// txs returns the transactions of a block, which are required to find the tx positions.
+// It returns the number of expired blocks.
+func (s *Store) ExpireReceipts(before idx.Block, limit int, txs func(n idx.Block) types.Transactions) int {
+	blocks := make([]idx.Block, 0, limit)
+	it := s.table.Receipts.NewIterator(nil, nil)
+	for it.Next() && len(blocks) < limit {
+		n := idx.BytesToBlock(it.Key())
+		if n >= before {
+			break
+		}
+		blocks = append(blocks, n)
+	}
+	it.Release()
+
+	for _, n := range blocks {
+		for _, tx := range txs(n) {
+			s.DelTxPosition(tx.Hash())
+		}
+		s.DelGasPayments(n)
+		s.DelReceipts(n)
+	}
+	return len(blocks)
+}
+
+// ExpireLogs deletes up to limit oldest log records of the blocks below the given one.
+// It returns the number of deleted records.
+func (s *Store) ExpireLogs(before idx.Block, limit int) int {
+	deleted, err := s.EvmLogs.DeleteBefore(before, limit)
+	if err != nil {
+		s.Log.Crit("Failed to delete logs", "err", err)
+	}
+	return deleted
+}
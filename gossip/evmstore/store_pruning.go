@@ -0,0 +1,580 @@
+package evmstore
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/artheranet/lachesis/kvdb"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state/snapshot"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie"
+	bloomfilter "github.com/holiman/bloomfilter/v2"
+)
+
+const (
+	// pruneSweepStep is the maximum number of keys scanned by a sweep step
+	pruneSweepStep = 10000
+	// pruneDeleteChunk is the maximum number of keys checked and deleted while the state writes are blocked
+	pruneDeleteChunk = 500
+)
+
+var (
+	pruneRunningGauge   = metrics.GetOrRegisterGauge("evm/prune/running", nil)
+	pruneMarkedGauge    = metrics.GetOrRegisterGauge("evm/prune/marked", nil)
+	pruneScannedGauge   = metrics.GetOrRegisterGauge("evm/prune/scanned", nil)
+	pruneDeletedMeter   = metrics.GetOrRegisterMeter("evm/prune/deleted", nil)
+	pruneDeletedSizeMtr = metrics.GetOrRegisterMeter("evm/prune/deletedsize", nil)
+	pruneRunsCounter    = metrics.GetOrRegisterCounter("evm/prune/runs", nil)
+
+	errPruningStopped = errors.New("pruning stopped")
+)
+
+// PruneStatus is the progress of the online state pruning
+type PruneStatus struct {
+	Enabled bool   `json:"enabled"`
+	Running bool   `json:"running"`
+	Phase   string `json:"phase"`
+	// KeptBlocks is the number of the latest block states kept by a run
+	KeptBlocks uint64    `json:"keptBlocks"`
+	Started    time.Time `json:"started"`
+	// Marked is the number of trie nodes and codes marked as reachable by the current run
+	Marked uint64 `json:"marked"`
+	// Scanned is the number of trie nodes and codes checked by the current run
+	Scanned     uint64    `json:"scanned"`
+	Deleted     uint64    `json:"deleted"`
+	DeletedSize uint64    `json:"deletedSize"`
+	Runs        uint64    `json:"runs"`
+	LastRun     time.Time `json:"lastRun"`
+	LastError   string    `json:"lastError,omitempty"`
+}
+
+// statePruner deletes the trie nodes which aren't reachable from the latest block states, while blocks keep being processed.
+// A run marks the reachable nodes in a bloom filter and then deletes the unmarked nodes in throttled batches.
+// The nodes written during a run are marked as well, so a node which becomes reachable again is never deleted.
+type statePruner struct {
+	store *Store
+	cfg   PruningConfig
+	// roots returns the state roots of the latest blocks, newest first
+	roots func(n uint64) []common.Hash
+	// genesis is the genesis state root, which is always kept
+	genesis common.Hash
+
+	// mu guards the keep filter and the flushed root. It's held while a deletion chunk is checked and written,
+	// which blocks the state writes for a moment
+	mu   sync.Mutex
+	keep *bloomfilter.Filter
+	// flushed is the latest state root committed to disk, the node restarts from it after a crash
+	flushed common.Hash
+
+	// marked is the number of nodes marked by the current run
+	marked   uint64
+	statusMu sync.Mutex
+	status   PruneStatus
+
+	quit chan struct{}
+	wg   sync.WaitGroup
+}
+
+// pruneBloomHasher converts a trie node hash or a code hash into a 64 bit mini hash.
+type pruneBloomHasher []byte
+
+func (f pruneBloomHasher) Write(p []byte) (n int, err error) { panic("not implemented") }
+func (f pruneBloomHasher) Sum(b []byte) []byte               { panic("not implemented") }
+func (f pruneBloomHasher) Reset()                            { panic("not implemented") }
+func (f pruneBloomHasher) BlockSize() int                    { panic("not implemented") }
+func (f pruneBloomHasher) Size() int                         { return 8 }
+func (f pruneBloomHasher) Sum64() uint64                     { return binary.BigEndian.Uint64(f) }
+
+// pruneKeyHash returns the hash of a trie node or code key
+func pruneKeyHash(key []byte) []byte {
+	if isCode, codeKey := rawdb.IsCodeKey(key); isCode {
+		return codeKey
+	}
+	return key
+}
+
+// onWrite marks the written trie node or code if a run is in progress
+func (p *statePruner) onWrite(key []byte) {
+	if !IsMptKey(key) {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.keep != nil {
+		p.keep.Add(pruneBloomHasher(pruneKeyHash(key)))
+	}
+}
+
+// setFlushed remembers the state root committed to disk
+func (p *statePruner) setFlushed(root common.Hash) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.flushed = root
+}
+
+// pinnedRoots returns the state roots which are kept regardless of the number of kept blocks:
+// the latest flushed state, the base state of the snapshot on disk and the genesis state
+func (p *statePruner) pinnedRoots() []common.Hash {
+	p.mu.Lock()
+	flushed := p.flushed
+	p.mu.Unlock()
+	roots := make([]common.Hash, 0, 3)
+	for _, root := range []common.Hash{flushed, rawdb.ReadSnapshotRoot(p.store.EvmDb), p.genesis} {
+		if root != (common.Hash{}) {
+			roots = append(roots, root)
+		}
+	}
+	return roots
+}
+
+// mark adds the trie node or code hash into the keep filter
+func (p *statePruner) mark(h []byte) {
+	p.mu.Lock()
+	p.keep.Add(pruneBloomHasher(h))
+	p.mu.Unlock()
+	if marked := atomic.AddUint64(&p.marked, 1); marked%10000 == 0 {
+		pruneMarkedGauge.Update(int64(marked))
+	}
+}
+
+func (p *statePruner) stopped() bool {
+	select {
+	case <-p.quit:
+		return true
+	default:
+		return false
+	}
+}
+
+func (p *statePruner) updateStatus(fn func(s *PruneStatus)) {
+	p.statusMu.Lock()
+	defer p.statusMu.Unlock()
+	fn(&p.status)
+}
+
+func (p *statePruner) Status() PruneStatus {
+	p.statusMu.Lock()
+	defer p.statusMu.Unlock()
+	status := p.status
+	status.Marked = atomic.LoadUint64(&p.marked)
+	return status
+}
+
+func (p *statePruner) Start() {
+	p.updateStatus(func(s *PruneStatus) {
+		s.Enabled = true
+		s.Phase = "idle"
+		s.KeptBlocks = p.cfg.Blocks
+	})
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		for {
+			p.run()
+			select {
+			case <-time.After(p.cfg.Interval):
+			case <-p.quit:
+				return
+			}
+		}
+	}()
+}
+
+func (p *statePruner) Stop() {
+	close(p.quit)
+	p.wg.Wait()
+}
+
+// run performs a single pruning run
+func (p *statePruner) run() {
+	start := time.Now()
+	p.updateStatus(func(s *PruneStatus) {
+		s.Running = true
+		s.Phase = "marking"
+		s.Started = start
+		s.Scanned, s.Deleted, s.DeletedSize = 0, 0, 0
+	})
+	atomic.StoreUint64(&p.marked, 0)
+	pruneRunningGauge.Update(1)
+	pruneScannedGauge.Update(0)
+
+	err := p.prune()
+
+	p.mu.Lock()
+	p.keep = nil
+	p.mu.Unlock()
+	pruneRunningGauge.Update(0)
+	pruneRunsCounter.Inc(1)
+	p.updateStatus(func(s *PruneStatus) {
+		s.Running = false
+		s.Phase = "idle"
+		s.Runs++
+		s.LastRun = time.Now()
+		s.LastError = ""
+		if err != nil {
+			s.LastError = err.Error()
+		}
+	})
+	status := p.Status()
+	pruneMarkedGauge.Update(int64(status.Marked))
+	if err == errPruningStopped {
+		p.store.Log.Info("State pruning interrupted", "elapsed", common.PrettyDuration(time.Since(start)))
+	} else if err != nil {
+		p.store.Log.Warn("State pruning failed", "err", err, "elapsed", common.PrettyDuration(time.Since(start)))
+	} else {
+		p.store.Log.Info("State pruning finished", "marked", status.Marked, "deleted", status.Deleted,
+			"size", common.StorageSize(status.DeletedSize), "elapsed", common.PrettyDuration(time.Since(start)))
+	}
+}
+
+func (p *statePruner) prune() error {
+	keep, err := bloomfilter.New(p.cfg.BloomSize*1024*1024*8, 4)
+	if err != nil {
+		return err
+	}
+	// start tracking the state writes before the roots are read
+	p.mu.Lock()
+	p.keep = keep
+	p.mu.Unlock()
+
+	marked := make(map[common.Hash]bool)
+	var last common.Hash
+	for i, root := range p.roots(p.cfg.Blocks) {
+		if marked[root] {
+			continue
+		}
+		if i == 0 {
+			err = p.markLatest(root)
+		} else {
+			// older states differ from the newer ones only by a few nodes
+			err = p.markState(root, last)
+		}
+		if err == errPruningStopped || err != nil && i == 0 {
+			// the latest state must be kept completely
+			return err
+		}
+		if err != nil {
+			// state isn't available, e.g. it was never flushed to disk
+			p.store.Log.Debug("Skipped state during pruning", "root", root, "err", err)
+			continue
+		}
+		marked[root], last = true, root
+	}
+	if len(marked) == 0 {
+		return errors.New("no state to keep")
+	}
+	for _, root := range p.pinnedRoots() {
+		if marked[root] {
+			continue
+		}
+		err = p.markState(root, last)
+		if err == errPruningStopped {
+			return err
+		}
+		if err != nil {
+			// state isn't available, e.g. genesis state was pruned offline
+			p.store.Log.Debug("Skipped pinned state during pruning", "root", root, "err", err)
+			continue
+		}
+		marked[root] = true
+	}
+	// catch up with the blocks processed during the marking
+	latest := p.roots(1)
+	if len(latest) != 0 && !marked[latest[0]] {
+		if err := p.markState(latest[0], last); err != nil {
+			return err
+		}
+	}
+
+	p.updateStatus(func(s *PruneStatus) {
+		s.Phase = "sweeping"
+	})
+	return p.sweep()
+}
+
+// markLatest marks the latest state, regenerating it from the snapshot if possible
+func (p *statePruner) markLatest(root common.Hash) error {
+	if snaps := p.store.Snaps; snaps != nil && snaps.Snapshot(root) != nil {
+		err := snapshot.GenerateTrie(snaps, root, p.store.EvmDb, &pruneMarker{p})
+		if err == nil || p.stopped() {
+			return err
+		}
+		// snapshot layers may become stale while blocks are processed
+		p.store.Log.Debug("Failed to regenerate state from snapshot, iterating state trie", "root", root, "err", err)
+	}
+	return p.markState(root, common.Hash{})
+}
+
+// markState marks the nodes of the state which aren't in the base state. Empty base means the whole state
+func (p *statePruner) markState(root, base common.Hash) error {
+	baseTrie, err := trie.New(base, p.store.EvmState.TrieDB())
+	if err != nil {
+		return err
+	}
+	return p.markTrie(root, base, func(key, blob []byte) error {
+		var acc types.StateAccount
+		if err := rlp.DecodeBytes(blob, &acc); err != nil {
+			return err
+		}
+		baseStorage := common.Hash{}
+		if baseBlob, _ := baseTrie.TryGet(key); len(baseBlob) != 0 {
+			var baseAcc types.StateAccount
+			if err := rlp.DecodeBytes(baseBlob, &baseAcc); err == nil {
+				baseStorage = baseAcc.Root
+			}
+		}
+		if acc.Root != types.EmptyRootHash && acc.Root != baseStorage {
+			if err := p.markTrie(acc.Root, baseStorage, nil); err != nil {
+				return err
+			}
+		}
+		if !bytes.Equal(acc.CodeHash, EmptyCode) {
+			p.mark(acc.CodeHash)
+		}
+		return nil
+	})
+}
+
+// markTrie marks the nodes of the trie which aren't in the base trie
+func (p *statePruner) markTrie(root, base common.Hash, onLeaf func(key, blob []byte) error) error {
+	triedb := p.store.EvmState.TrieDB()
+	t, err := trie.New(root, triedb)
+	if err != nil {
+		return err
+	}
+	baseTrie, err := trie.New(base, triedb)
+	if err != nil {
+		return err
+	}
+	it, _ := trie.NewDifferenceIterator(baseTrie.NodeIterator(nil), t.NodeIterator(nil))
+	for it.Next(true) {
+		if p.stopped() {
+			return errPruningStopped
+		}
+		// embedded nodes don't have hash
+		if h := it.Hash(); h != (common.Hash{}) {
+			p.mark(h.Bytes())
+		}
+		if it.Leaf() && onLeaf != nil {
+			if err := onLeaf(it.LeafKey(), it.LeafBlob()); err != nil {
+				return err
+			}
+		}
+	}
+	return it.Error()
+}
+
+// sweep deletes the trie nodes and codes which aren't marked
+func (p *statePruner) sweep() error {
+	var (
+		start  []byte
+		logged = time.Now()
+	)
+	for {
+		next, done, err := p.sweepStep(start)
+		if err != nil || done {
+			return err
+		}
+		start = next
+		if time.Since(logged) > 8*time.Second {
+			status := p.Status()
+			p.store.Log.Info("Pruning state data", "scanned", status.Scanned, "deleted", status.Deleted,
+				"size", common.StorageSize(status.DeletedSize), "elapsed", common.PrettyDuration(time.Since(status.Started)))
+			logged = time.Now()
+		}
+		select {
+		case <-time.After(p.cfg.BatchPause):
+		case <-p.quit:
+			return errPruningStopped
+		}
+	}
+}
+
+// sweepStep scans a range of keys starting from the key, deletes the unmarked ones and returns the key to continue from.
+// The keys are scanned without blocking the state writes, and deleted in small chunks
+func (p *statePruner) sweepStep(start []byte) (next []byte, done bool, err error) {
+	var (
+		keys    [][]byte
+		sizes   []uint64
+		scanned uint64
+	)
+	it := p.store.table.Evm.NewIterator(nil, start)
+	for i := 0; i < pruneSweepStep; i++ {
+		if !it.Next() {
+			done = true
+			break
+		}
+		key := it.Key()
+		if !IsMptKey(key) {
+			continue
+		}
+		scanned++
+		p.mu.Lock()
+		marked := p.keep.Contains(pruneBloomHasher(pruneKeyHash(key)))
+		p.mu.Unlock()
+		if marked {
+			continue
+		}
+		keys = append(keys, common.CopyBytes(key))
+		sizes = append(sizes, uint64(len(key)+len(it.Value())))
+	}
+	if !done {
+		if !it.Next() {
+			done = true
+		} else {
+			next = common.CopyBytes(it.Key())
+		}
+	}
+	err = it.Error()
+	it.Release()
+	if err != nil {
+		return nil, false, err
+	}
+	pruneScannedGauge.Inc(int64(scanned))
+	p.updateStatus(func(s *PruneStatus) {
+		s.Scanned += scanned
+	})
+
+	for len(keys) != 0 {
+		if p.stopped() {
+			return nil, false, errPruningStopped
+		}
+		n := len(keys)
+		if n > pruneDeleteChunk {
+			n = pruneDeleteChunk
+		}
+		if err := p.deleteUnmarked(keys[:n], sizes[:n]); err != nil {
+			return nil, false, err
+		}
+		keys, sizes = keys[n:], sizes[n:]
+	}
+	return next, done, nil
+}
+
+// deleteUnmarked deletes the keys which are still unmarked.
+// The check and the deletion must be atomic relatively to the state writes, which mark a node before writing it
+func (p *statePruner) deleteUnmarked(keys [][]byte, sizes []uint64) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	batch := p.store.table.Evm.NewBatch()
+	defer batch.Reset()
+	var deleted, size uint64
+	for i, key := range keys {
+		// the node may have been written again since it was scanned
+		if p.keep.Contains(pruneBloomHasher(pruneKeyHash(key))) {
+			continue
+		}
+		if err := batch.Delete(key); err != nil {
+			return err
+		}
+		deleted++
+		size += sizes[i]
+	}
+	if err := batch.Write(); err != nil {
+		return err
+	}
+
+	pruneDeletedMeter.Mark(int64(deleted))
+	pruneDeletedSizeMtr.Mark(int64(size))
+	p.updateStatus(func(s *PruneStatus) {
+		s.Deleted += deleted
+		s.DeletedSize += size
+	})
+	return nil
+}
+
+// pruneMarker marks the trie nodes and codes regenerated from the snapshot
+type pruneMarker struct {
+	p *statePruner
+}
+
+func (m *pruneMarker) Put(key []byte, value []byte) error {
+	if m.p.stopped() {
+		return errPruningStopped
+	}
+	if !IsMptKey(key) {
+		return errors.New("invalid entry")
+	}
+	m.p.mark(pruneKeyHash(key))
+	return nil
+}
+
+func (m *pruneMarker) Delete(key []byte) error {
+	panic("not supported")
+}
+
+// pruneTrackedStore marks the trie nodes and codes written during a pruning run
+type pruneTrackedStore struct {
+	kvdb.Store
+	p *statePruner
+}
+
+type pruneTrackedBatch struct {
+	kvdb.Batch
+	p *statePruner
+}
+
+func (s *pruneTrackedStore) Put(key []byte, value []byte) error {
+	s.p.onWrite(key)
+	return s.Store.Put(key, value)
+}
+
+func (s *pruneTrackedStore) NewBatch() kvdb.Batch {
+	batch := s.Store.NewBatch()
+	if batch == nil {
+		return nil
+	}
+	return &pruneTrackedBatch{
+		Batch: batch,
+		p:     s.p,
+	}
+}
+
+func (b *pruneTrackedBatch) Put(key []byte, value []byte) error {
+	b.p.onWrite(key)
+	return b.Batch.Put(key, value)
+}
+
+// StartPruning starts the online state pruning in background if it's enabled.
+// roots returns the state roots of the latest n blocks, newest first.
+// The genesis state and the flushed state, which the node restarts from, are kept as well.
+func (s *Store) StartPruning(roots func(n uint64) []common.Hash, genesis, flushed common.Hash) {
+	if s.cfg.Pruning.Blocks == 0 || s.pruner.quit != nil {
+		return
+	}
+	if s.cfg.Pruning.Blocks < TriesInMemory {
+		s.Log.Warn("Sanitizing number of kept block states", "provided", s.cfg.Pruning.Blocks, "updated", TriesInMemory)
+		s.pruner.cfg.Blocks = TriesInMemory
+	}
+	if s.cfg.Pruning.BloomSize < 256 {
+		s.Log.Warn("Sanitizing bloomfilter size", "provided(MB)", s.cfg.Pruning.BloomSize, "updated(MB)", 256)
+		s.pruner.cfg.BloomSize = 256
+	}
+	s.pruner.roots = roots
+	s.pruner.genesis = genesis
+	s.pruner.setFlushed(flushed)
+	s.pruner.quit = make(chan struct{})
+	s.pruner.Start()
+}
+
+// StopPruning stops the online state pruning, interrupting the current run
+func (s *Store) StopPruning() {
+	if s.pruner.quit == nil {
+		return
+	}
+	s.pruner.Stop()
+}
+
+// PruneStatus returns the progress of the online state pruning
+func (s *Store) PruneStatus() PruneStatus {
+	return s.pruner.Status()
+}
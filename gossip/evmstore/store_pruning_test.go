@@ -0,0 +1,127 @@
+package evmstore
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/artheranet/lachesis/hash"
+	"github.com/artheranet/lachesis/kvdb"
+	"github.com/artheranet/lachesis/kvdb/leveldb"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/trie"
+	"github.com/stretchr/testify/require"
+	"github.com/syndtr/goleveldb/leveldb/opt"
+
+	"github.com/artheranet/arthera-node/internal/inter/iblockproc"
+	"github.com/artheranet/arthera-node/logger"
+)
+
+var pruneTestContract = common.Address{0xff}
+
+// writePruneTestStates writes the states of n blocks to disk, each block adds an account and a storage slot
+func writePruneTestStates(t *testing.T, store *Store, n int) []common.Hash {
+	var roots []common.Hash
+	root := common.Hash{}
+	for i := 0; i < n; i++ {
+		statedb, err := state.New(root, store.EvmState, nil)
+		require.NoError(t, err)
+		statedb.SetBalance(common.Address{byte(i)}, big.NewInt(int64(i+1)))
+		statedb.SetState(pruneTestContract, common.Hash{byte(i)}, common.Hash{byte(i + 1)})
+		statedb.SetCode(pruneTestContract, []byte{byte(i)})
+		root, err = statedb.Commit(true)
+		require.NoError(t, err)
+		require.NoError(t, store.EvmState.TrieDB().Commit(root, false, nil))
+		roots = append(roots, root)
+	}
+	return roots
+}
+
+// checkPruneTestState checks the state of the block i written by writePruneTestStates
+func checkPruneTestState(t *testing.T, evmState state.Database, roots []common.Hash, i int) {
+	statedb, err := state.New(roots[i], evmState, nil)
+	require.NoError(t, err)
+	for j := 0; j <= i; j++ {
+		require.Equal(t, big.NewInt(int64(j+1)), statedb.GetBalance(common.Address{byte(j)}))
+		require.Equal(t, common.Hash{byte(j + 1)}, statedb.GetState(pruneTestContract, common.Hash{byte(j)}))
+	}
+	require.Equal(t, []byte{byte(i)}, statedb.GetCode(pruneTestContract))
+	require.NoError(t, statedb.Error())
+}
+
+// runPruneTest runs the pruning once, keeping the latest states of the given number of blocks
+func runPruneTest(store *Store, roots []common.Hash, blocks uint64) {
+	store.pruner.cfg.Blocks = blocks
+	store.pruner.cfg.BloomSize = 1
+	store.pruner.roots = func(n uint64) []common.Hash {
+		var res []common.Hash
+		for i := len(roots) - 1; i >= 0 && uint64(len(res)) < n; i-- {
+			res = append(res, roots[i])
+		}
+		return res
+	}
+	store.pruner.quit = make(chan struct{})
+	store.pruner.run()
+}
+
+func TestStatePruning(t *testing.T) {
+	logger.SetTestMode(t)
+	require := require.New(t)
+
+	store := cachedStore()
+	roots := writePruneTestStates(t, store, 5)
+	runPruneTest(store, roots, 2)
+
+	status := store.PruneStatus()
+	require.Empty(status.LastError)
+	require.NotZero(status.Marked)
+	require.NotZero(status.Deleted)
+
+	// read from disk, bypassing the caches
+	evmState := state.NewDatabase(store.EvmDb)
+	for i := 3; i < 5; i++ {
+		checkPruneTestState(t, evmState, roots, i)
+	}
+	// states which aren't kept are pruned
+	for i := 0; i < 3; i++ {
+		_, err := trie.New(roots[i], trie.NewDatabase(store.EvmDb))
+		require.Error(err)
+	}
+}
+
+func TestStatePruningKeepsFlushedState(t *testing.T) {
+	logger.SetTestMode(t)
+	require := require.New(t)
+
+	dir := t.TempDir()
+	producer := func() kvdb.IterableDBProducer {
+		return leveldb.NewProducer(dir, func(string) (int, int) {
+			return 16 * opt.MiB, 64
+		})
+	}
+	store := NewStore(producer(), LiteStoreConfig())
+	roots := writePruneTestStates(t, store, 8)
+
+	// the node restarts from the flushed state, which is older than the kept blocks
+	store.pruner.genesis = roots[0]
+	require.NoError(store.CleanCommit(iblockproc.BlockState{
+		LastBlock:          iblockproc.BlockCtx{Idx: 2},
+		FinalizedStateRoot: hash.Hash(roots[1]),
+	}))
+	runPruneTest(store, roots, 2)
+	status := store.PruneStatus()
+	require.Empty(status.LastError)
+	require.NotZero(status.Deleted)
+	store.Close()
+
+	store = NewStore(producer(), LiteStoreConfig())
+	defer store.Close()
+	evmState := state.NewDatabase(store.EvmDb)
+	for _, i := range []int{0, 1, 6, 7} {
+		checkPruneTestState(t, evmState, roots, i)
+	}
+	for i := 2; i < 6; i++ {
+		_, err := trie.New(roots[i], trie.NewDatabase(store.EvmDb))
+		require.Error(err)
+	}
+}
@@ -25,6 +25,16 @@ func (s *Store) SetTxPosition(txid common.Hash, position TxPosition) {
 	s.cache.TxPositions.Add(txid.String(), &position, nominalSize)
 }
 
+// DelTxPosition deletes transaction block and position.
+func (s *Store) DelTxPosition(txid common.Hash) {
+	if err := s.table.TxPositions.Delete(txid.Bytes()); err != nil {
+		s.Log.Crit("Failed to delete key", "err", err)
+	}
+
+	// Remove from LRU cache.
+	s.cache.TxPositions.Remove(txid.String())
+}
+
 // GetTxPosition returns stored transaction block and position.
 func (s *Store) GetTxPosition(txid common.Hash) *TxPosition {
 	// Get data from LRU cache first.
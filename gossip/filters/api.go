@@ -55,12 +55,18 @@ type Config struct {
 	IndexedLogsBlockRangeLimit idx.Block
 	// Block range limit for logs search (unindexed).
 	UnindexedLogsBlockRangeLimit idx.Block
+	// Max number of logs in a page of the paged logs search.
+	LogsPageLimit int
+	// Max number of logs emitted by a logs stream.
+	LogsStreamLimit int
 }
 
 func DefaultConfig() Config {
 	return Config{
 		IndexedLogsBlockRangeLimit:   999999999999999999,
 		UnindexedLogsBlockRangeLimit: 100,
+		LogsPageLimit:                10000,
+		LogsStreamLimit:              1000000,
 	}
 }
 
@@ -44,6 +44,7 @@ type Backend interface {
 	GetReceiptsByNumber(ctx context.Context, number rpc.BlockNumber) (types.Receipts, error)
 	GetLogs(ctx context.Context, blockHash common.Hash) ([][]*types.Log, error)
 	GetTxPosition(txid common.Hash) *evmstore.TxPosition
+	IsBlockPruned(n idx.Block) bool
 
 	SubscribeNewBlockNotify(ch chan<- evmcore.ChainHeadNotify) notify.Subscription
 	SubscribeNewTxsNotify(chan<- evmcore.NewTxsNotify) notify.Subscription
@@ -134,6 +135,9 @@ func (f *Filter) Logs(ctx context.Context) ([]*types.Log, error) {
 	if begin > end {
 		return []*types.Log{}, nil
 	}
+	if f.backend.IsBlockPruned(begin) {
+		return nil, evmstore.ErrPrunedHistory
+	}
 
 	if isEmpty(f.topics) && len(f.addresses) == 0 {
 		return f.unindexedLogs(ctx, begin, end)
@@ -23,6 +23,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/artheranet/lachesis/inter/idx"
 	"github.com/artheranet/lachesis/kvdb/memorydb"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/rawdb"
@@ -44,6 +45,8 @@ type testBackend struct {
 	blocksFeed *notify.Feed
 	txsFeed    *notify.Feed
 	logsFeed   *notify.Feed
+
+	prunedBelow idx.Block
 }
 
 func newTestBackend() *testBackend {
@@ -141,6 +144,10 @@ func (b *testBackend) GetTxPosition(txid common.Hash) *evmstore.TxPosition {
 	return nil
 }
 
+func (b *testBackend) IsBlockPruned(n idx.Block) bool {
+	return n < b.prunedBelow
+}
+
 func (b *testBackend) CalcBlockExtApi() bool {
 	return true
 }
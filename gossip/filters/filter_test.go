@@ -41,6 +41,8 @@ func testConfig() Config {
 	return Config{
 		IndexedLogsBlockRangeLimit:   1000,
 		UnindexedLogsBlockRangeLimit: 1000,
+		LogsPageLimit:                1000,
+		LogsStreamLimit:              1000,
 	}
 }
 
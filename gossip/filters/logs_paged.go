@@ -0,0 +1,288 @@
+package filters
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/artheranet/lachesis/inter/idx"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rpc"
+
+	"github.com/artheranet/arthera-node/gossip/evmstore"
+	"github.com/artheranet/arthera-node/internal/topicsdb"
+)
+
+var (
+	errUnindexedCriteria = errors.New("either addresses or topics must be specified")
+	errInvalidCursor     = errors.New("invalid cursor")
+)
+
+// LogsPage is a page of the logs search.
+type LogsPage struct {
+	Logs []*types.Log `json:"logs"`
+	// Cursor to request the next page with, it's nil if there are no more logs
+	Cursor *hexutil.Bytes `json:"cursor"`
+}
+
+// LogsStreamEnd is the last notification of a logs stream.
+type LogsStreamEnd struct {
+	Done bool `json:"done"`
+	// Cursor to continue the search with, if the stream was cut by the logs limit
+	Cursor *hexutil.Bytes `json:"cursor,omitempty"`
+	Error  string         `json:"error,omitempty"`
+}
+
+// PublicLogsAPI offers the logs search over wide block ranges, which is split into pages or streamed.
+type PublicLogsAPI struct {
+	config  Config
+	backend Backend
+}
+
+// NewPublicLogsAPI returns a new PublicLogsAPI instance.
+func NewPublicLogsAPI(backend Backend, cfg Config) *PublicLogsAPI {
+	return &PublicLogsAPI{
+		config:  cfg,
+		backend: backend,
+	}
+}
+
+// GetLogsPaged returns a page of the logs matching the given criteria, which follow the cursor.
+// The logs are ordered by block number, transaction hash and log index.
+// Either addresses or topics must be specified, as the search is performed over the logs index.
+func (api *PublicLogsAPI) GetLogsPaged(ctx context.Context, crit FilterCriteria, cursor *hexutil.Bytes, limit *hexutil.Uint) (*LogsPage, error) {
+	after, err := decodeLogsCursor(cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &LogsPage{
+		Logs: []*types.Log{},
+	}
+	last, err := api.forEachLog(ctx, crit, after, pageLimit(limit, api.config.LogsPageLimit), func(l *types.Log) {
+		page.Logs = append(page.Logs, l)
+	})
+	if err != nil {
+		return nil, err
+	}
+	page.Cursor = encodeLogsCursor(last)
+
+	return page, nil
+}
+
+// StreamLogs creates a subscription which emits the logs matching the given criteria as they are found,
+// in the same order as GetLogsPaged does. The last notification is LogsStreamEnd.
+// The search stops when the subscription is cancelled or after the logs limit is reached.
+func (api *PublicLogsAPI) StreamLogs(ctx context.Context, crit FilterCriteria, cursor *hexutil.Bytes, limit *hexutil.Uint) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+	after, err := decodeLogsCursor(cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	rpcSub := notifier.CreateSubscription()
+
+	searchCtx, cancel := context.WithCancel(context.Background())
+	go func() {
+		select {
+		case <-rpcSub.Err(): // client send an unsubscribe request
+		case <-notifier.Closed(): // connection dropped
+		case <-searchCtx.Done():
+		}
+		cancel()
+	}()
+
+	go func() {
+		defer cancel()
+
+		last, err := api.forEachLog(searchCtx, crit, after, pageLimit(limit, api.config.LogsStreamLimit), func(l *types.Log) {
+			_ = notifier.Notify(rpcSub.ID, l)
+		})
+		if searchCtx.Err() != nil {
+			return
+		}
+		end := &LogsStreamEnd{
+			Done:   true,
+			Cursor: encodeLogsCursor(last),
+		}
+		if err != nil {
+			end.Error = err.Error()
+		}
+		_ = notifier.Notify(rpcSub.ID, end)
+	}()
+
+	return rpcSub, nil
+}
+
+// logsRange resolves the blocks range of the criteria
+func (api *PublicLogsAPI) logsRange(ctx context.Context, crit FilterCriteria) (begin, end idx.Block, err error) {
+	if crit.BlockHash != nil {
+		header, err := api.backend.HeaderByHash(ctx, *crit.BlockHash)
+		if err != nil {
+			return 0, 0, err
+		}
+		if header == nil {
+			return 0, 0, errors.New("unknown block")
+		}
+		begin = idx.Block(header.Number.Uint64())
+		return begin, begin, nil
+	}
+
+	header, err := api.backend.HeaderByNumber(ctx, rpc.LatestBlockNumber)
+	if err != nil {
+		return 0, 0, err
+	}
+	if header == nil {
+		return 0, 0, errors.New("unknown latest block")
+	}
+	head := idx.Block(header.Number.Uint64())
+
+	begin, end = head, head
+	if crit.FromBlock != nil && crit.FromBlock.Sign() >= 0 {
+		begin = idx.Block(crit.FromBlock.Uint64())
+	}
+	if crit.ToBlock != nil && crit.ToBlock.Sign() >= 0 {
+		end = idx.Block(crit.ToBlock.Uint64())
+	}
+	return begin, end, nil
+}
+
+// forEachLog calls onLog for the logs matching the criteria which follow the cursor, in the order of their IDs.
+// It returns the ID of the last passed log if the limit is reached before the end of the search.
+func (api *PublicLogsAPI) forEachLog(ctx context.Context, crit FilterCriteria, after *topicsdb.ID, limit int, onLog func(*types.Log)) (last *topicsdb.ID, err error) {
+	if len(crit.Addresses) == 0 && isEmpty(crit.Topics) {
+		return nil, errUnindexedCriteria
+	}
+	begin, end, err := api.logsRange(ctx, crit)
+	if err != nil {
+		return nil, err
+	}
+	// the expired ranges are rejected up front rather than scanned without the txs index
+	if api.backend.IsBlockPruned(begin) {
+		return nil, evmstore.ErrPrunedHistory
+	}
+	if after != nil {
+		cursorBlock := idx.Block(after.BlockNumber())
+		if api.backend.IsBlockPruned(cursorBlock) {
+			return nil, evmstore.ErrPrunedHistory
+		}
+		if cursorBlock > begin {
+			begin = cursorBlock
+		}
+	}
+	if begin > end {
+		return nil, nil
+	}
+
+	var (
+		count int
+		block []*types.Log
+	)
+	// logs of a block are passed at once, sorted by their IDs,
+	// so the cursor is a position in the index regardless of the order of the index scanning
+	flush := func() (gonext bool) {
+		sort.Slice(block, func(i, j int) bool {
+			a, b := logID(block[i]), logID(block[j])
+			return bytes.Compare(a.Bytes(), b.Bytes()) < 0
+		})
+		for _, l := range block {
+			id := logID(l)
+			if after != nil && bytes.Compare(id.Bytes(), after.Bytes()) <= 0 {
+				continue
+			}
+			if count >= limit {
+				return false
+			}
+			api.fillTxIndex(l)
+			onLog(l)
+			count++
+			last = &id
+		}
+		block = block[:0]
+		return true
+	}
+
+	complete := true
+	err = api.backend.EvmLogIndex().ForEachInBlocks(ctx, begin, end, logsPattern(crit), func(l *types.Log) bool {
+		if l.BlockNumber > uint64(end) {
+			// the index treats the zero end as an unbounded range
+			return false
+		}
+		if len(block) > 0 && block[0].BlockNumber != l.BlockNumber {
+			complete = flush()
+			if !complete {
+				return false
+			}
+		}
+		block = append(block, l)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	if complete && flush() {
+		// no more logs
+		return nil, nil
+	}
+	return last, nil
+}
+
+func (api *PublicLogsAPI) fillTxIndex(l *types.Log) {
+	pos := api.backend.GetTxPosition(l.TxHash)
+	if pos != nil {
+		l.TxIndex = uint(pos.BlockOffset)
+	} else {
+		log.Debug("tx index empty", "hash", l.TxHash)
+	}
+}
+
+func logsPattern(crit FilterCriteria) [][]common.Hash {
+	addresses := make([]common.Hash, len(crit.Addresses))
+	for i, addr := range crit.Addresses {
+		addresses[i] = addr.Hash()
+	}
+
+	pattern := make([][]common.Hash, 1, len(crit.Topics)+1)
+	pattern[0] = addresses
+	pattern = append(pattern, crit.Topics...)
+	return pattern
+}
+
+func logID(l *types.Log) topicsdb.ID {
+	return topicsdb.NewID(l.BlockNumber, l.TxHash, l.Index)
+}
+
+func pageLimit(limit *hexutil.Uint, max int) int {
+	if limit == nil || int(*limit) > max || *limit == 0 {
+		return max
+	}
+	return int(*limit)
+}
+
+func decodeLogsCursor(cursor *hexutil.Bytes) (*topicsdb.ID, error) {
+	if cursor == nil {
+		return nil, nil
+	}
+	var id topicsdb.ID
+	if len(*cursor) != len(id) {
+		return nil, fmt.Errorf("%w: wrong length %d", errInvalidCursor, len(*cursor))
+	}
+	copy(id[:], *cursor)
+	return &id, nil
+}
+
+func encodeLogsCursor(id *topicsdb.ID) *hexutil.Bytes {
+	if id == nil {
+		return nil
+	}
+	cursor := hexutil.Bytes(common.CopyBytes(id.Bytes()))
+	return &cursor
+}
@@ -0,0 +1,123 @@
+package filters
+
+import (
+	"bytes"
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/stretchr/testify/require"
+
+	"github.com/artheranet/arthera-node/gossip/evmstore"
+)
+
+func TestGetLogsPaged(t *testing.T) {
+	require := require.New(t)
+
+	var (
+		backend = newTestBackend()
+		addr1   = common.BytesToAddress([]byte("addr1"))
+		addr2   = common.BytesToAddress([]byte("addr2"))
+		topic   = common.BytesToHash([]byte("topic"))
+	)
+
+	genesis := core.GenesisBlockForTesting(backend.db, addr1, big.NewInt(1000000))
+	chain, _ := core.GenerateChain(params.TestChainConfig, genesis, ethash.NewFaker(), backend.db, 20, func(i int, gen *core.BlockGen) {})
+	for _, block := range chain {
+		rawdb.WriteBlock(backend.db, block)
+		rawdb.WriteCanonicalHash(backend.db, block.Hash(), block.NumberU64())
+		rawdb.WriteHeadBlockHash(backend.db, block.Hash())
+	}
+
+	var expect []*types.Log
+	for n := uint64(1); n <= 10; n++ {
+		for i := 0; i < 3; i++ {
+			l := &types.Log{
+				BlockNumber: n,
+				BlockHash:   chain[n-1].Hash(),
+				TxHash:      common.BytesToHash([]byte{byte(n), byte(3 - i)}),
+				Index:       uint(i),
+				Address:     addr1,
+				Topics:      []common.Hash{topic},
+				Data:        []byte{},
+			}
+			if i == 1 {
+				l.Address = addr2
+			}
+			require.NoError(backend.logIndex.Push(l))
+			expect = append(expect, l)
+		}
+	}
+
+	api := NewPublicLogsAPI(backend, testConfig())
+	readAll := func(crit FilterCriteria, limit uint) (got []*types.Log) {
+		var (
+			cursor *hexutil.Bytes
+			pages  int
+		)
+		for {
+			page, err := api.GetLogsPaged(context.Background(), crit, cursor, (*hexutil.Uint)(&limit))
+			require.NoError(err)
+			require.LessOrEqual(len(page.Logs), int(limit))
+			got = append(got, page.Logs...)
+			pages++
+			require.Less(pages, 100)
+			if page.Cursor == nil {
+				return
+			}
+			cursor = page.Cursor
+		}
+	}
+
+	for _, limit := range []uint{1, 2, 4, 30, 100} {
+		got := readAll(FilterCriteria{
+			FromBlock: big.NewInt(0),
+			Topics:    [][]common.Hash{{topic}},
+		}, limit)
+		require.Equal(len(expect), len(got), limit)
+		for i := 1; i < len(got); i++ {
+			prev, cur := logID(got[i-1]), logID(got[i])
+			require.Equal(-1, bytes.Compare(prev.Bytes(), cur.Bytes()), limit)
+		}
+		require.ElementsMatch(expect, got, limit)
+	}
+
+	got := readAll(FilterCriteria{
+		FromBlock: big.NewInt(3),
+		ToBlock:   big.NewInt(5),
+		Addresses: []common.Address{addr2},
+	}, 2)
+	require.Equal(3, len(got))
+	for _, l := range got {
+		require.Equal(addr2, l.Address)
+	}
+
+	_, err := api.GetLogsPaged(context.Background(), FilterCriteria{FromBlock: big.NewInt(0)}, nil, nil)
+	require.Equal(errUnindexedCriteria, err)
+
+	cursor := hexutil.Bytes{1, 2, 3}
+	_, err = api.GetLogsPaged(context.Background(), FilterCriteria{Addresses: []common.Address{addr1}}, &cursor, nil)
+	require.ErrorIs(err, errInvalidCursor)
+
+	// the expired ranges are rejected by the begin of the range and by the cursor
+	limit := hexutil.Uint(1)
+	crit := FilterCriteria{FromBlock: big.NewInt(1), Addresses: []common.Address{addr1}}
+	page, err := api.GetLogsPaged(context.Background(), crit, nil, &limit)
+	require.NoError(err)
+	require.NotNil(page.Cursor)
+	backend.prunedBelow = 2
+	_, err = api.GetLogsPaged(context.Background(), crit, page.Cursor, &limit)
+	require.ErrorIs(err, evmstore.ErrPrunedHistory)
+	crit.FromBlock = big.NewInt(3)
+	_, err = api.GetLogsPaged(context.Background(), crit, page.Cursor, &limit)
+	require.ErrorIs(err, evmstore.ErrPrunedHistory)
+	_, err = api.GetLogsPaged(context.Background(), crit, nil, &limit)
+	require.NoError(err)
+}
@@ -0,0 +1,656 @@
+package filters
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/log"
+	ethparams "github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
+
+	"github.com/artheranet/arthera-node/contracts"
+	"github.com/artheranet/arthera-node/contracts/abis"
+	"github.com/artheranet/arthera-node/contracts/subscriber"
+	"github.com/artheranet/arthera-node/internal/evmcore"
+	"github.com/artheranet/arthera-node/internal/evmcore/vmcontext"
+	"github.com/artheranet/arthera-node/params"
+	"github.com/artheranet/arthera-node/utils/signers/gsignercache"
+)
+
+// Subscription event types
+const (
+	// SubscriptionCreated is emitted by the Subscribers contract when a subscription is bought
+	SubscriptionCreated = "newSubscription"
+	// SubscriptionRenewed is emitted by the Subscribers contract when a subscription is renewed
+	SubscriptionRenewed = "renewSubscription"
+	// SubscriptionPlanSwitched is emitted by the Subscribers contract when a subscription changes its plan
+	SubscriptionPlanSwitched = "switchPlan"
+	// SubscriptionTerminated is emitted by the Subscribers contract when a subscription is terminated
+	SubscriptionTerminated = "terminateSubscription"
+	// SubscriptionDebited reports the gas units debited from a subscription when buying gas
+	SubscriptionDebited = "debit"
+	// SubscriptionCredited reports the gas units refunded to a subscription after the execution
+	SubscriptionCredited = "credit"
+	// SubscriptionPayAsYouGoFallback reports a transaction whose gas wasn't fully covered by the subscription
+	// expected to pay for it, the rest was paid by the sender under Pay-as-You-Go
+	SubscriptionPayAsYouGoFallback = "payAsYouGoFallback"
+	// SubscriptionLowBalance reports a watched subscription whose balance dropped below the threshold
+	SubscriptionLowBalance = "lowBalance"
+	// SubscriptionCapReset reports a watched subscription whose cap window was reset
+	SubscriptionCapReset = "capReset"
+	// SubscriptionExpired reports a watched subscription which reached its end time
+	SubscriptionExpired = "expired"
+)
+
+const (
+	// maxWatchedSubscribers limits the number of subscribers whose state is tracked by a single feed
+	maxWatchedSubscribers = 100
+	// subscriptionStatesCacheSize is the number of recent blocks whose Subscribers contract calls are cached
+	subscriptionStatesCacheSize = 4
+)
+
+var subscriptionEventTypes = map[string]bool{
+	SubscriptionCreated:            true,
+	SubscriptionRenewed:            true,
+	SubscriptionPlanSwitched:       true,
+	SubscriptionTerminated:         true,
+	SubscriptionDebited:            true,
+	SubscriptionCredited:           true,
+	SubscriptionPayAsYouGoFallback: true,
+	SubscriptionLowBalance:         true,
+	SubscriptionCapReset:           true,
+	SubscriptionExpired:            true,
+}
+
+// subscribersLogTypes maps the Subscribers contract events to the subscription event types
+var subscribersLogTypes = map[common.Hash]string{
+	abis.Subscribers.Events["NewSubscription"].ID:       SubscriptionCreated,
+	abis.Subscribers.Events["RenewSubscription"].ID:     SubscriptionRenewed,
+	abis.Subscribers.Events["SwitchPlan"].ID:            SubscriptionPlanSwitched,
+	abis.Subscribers.Events["TerminateSubscription"].ID: SubscriptionTerminated,
+}
+
+// subscriptionTransferID is the ERC-721 transfer of a subscription token, which is emitted when
+// a subscription is minted, transferred or burnt
+var subscriptionTransferID = abis.Subscribers.Events["Transfer"].ID
+
+// SubscriptionBackend is the backend of the subscription events feed.
+// In addition to the filters backend, it provides access to the gas payments
+// and to the state of the Subscribers contract.
+type SubscriptionBackend interface {
+	Backend
+	ChainConfig() *ethparams.ChainConfig
+	GetGasPaymentsByNumber(ctx context.Context, number rpc.BlockNumber) ([]*evmcore.GasPayment, error)
+	StateAndHeaderByNumberOrHash(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (*state.StateDB, *evmcore.EvmHeader, error)
+	GetEVM(ctx context.Context, msg evmcore.Message, state *state.StateDB, header *evmcore.EvmHeader, vmConfig *vm.Config) (*vm.EVM, func() error, error)
+}
+
+// SubscriptionEventsCriteria selects the subscription events delivered by the feed.
+type SubscriptionEventsCriteria struct {
+	// Subscribers restricts the events to the given accounts and contracts.
+	// The state of these subscriptions is tracked to report low balance, cap resets and expiration.
+	Subscribers []common.Address `json:"subscribers"`
+	// Types restricts the events to the given types, all types are delivered if empty
+	Types []string `json:"types"`
+	// BalanceThreshold is the balance (in gas units) below which a lowBalance event is reported
+	BalanceThreshold *hexutil.Big `json:"balanceThreshold"`
+}
+
+// SubscriptionEvent is a notification of the subscription events feed.
+type SubscriptionEvent struct {
+	Type        string          `json:"type"`
+	Subscriber  common.Address  `json:"subscriber"`
+	ContractSub bool            `json:"contractSub"`
+	Id          *hexutil.Big    `json:"id,omitempty"`
+	PlanId      *hexutil.Big    `json:"planId,omitempty"`
+	OldPlanId   *hexutil.Big    `json:"oldPlanId,omitempty"`
+	StartTime   *hexutil.Uint64 `json:"startTime,omitempty"`
+	EndTime     *hexutil.Uint64 `json:"endTime,omitempty"`
+	Balance     *hexutil.Big    `json:"balance,omitempty"`
+	Units       *hexutil.Uint64 `json:"units,omitempty"`
+	BlockNumber hexutil.Uint64  `json:"blockNumber"`
+	BlockHash   common.Hash     `json:"blockHash"`
+	TxHash      *common.Hash    `json:"transactionHash,omitempty"`
+}
+
+// PublicSubscriptionEventsAPI offers the subscription lifecycle notifications.
+type PublicSubscriptionEventsAPI struct {
+	backend SubscriptionBackend
+	states  *subscriptionStateCache
+}
+
+// NewPublicSubscriptionEventsAPI returns a new PublicSubscriptionEventsAPI instance.
+func NewPublicSubscriptionEventsAPI(backend SubscriptionBackend) *PublicSubscriptionEventsAPI {
+	return &PublicSubscriptionEventsAPI{
+		backend: backend,
+		states:  newSubscriptionStateCache(backend),
+	}
+}
+
+// SubscriptionEvents creates a subscription that fires for the events of the Subscribers contract
+// and for the subscriptions debits and credits performed by the node when paying for gas.
+func (api *PublicSubscriptionEventsAPI) SubscriptionEvents(ctx context.Context, crit SubscriptionEventsCriteria) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+	signer := gsignercache.Wrap(types.LatestSignerForChainID(api.backend.ChainConfig().ChainID))
+	w, err := newSubscriptionWatcher(signer, api.states.at, crit)
+	if err != nil {
+		return nil, err
+	}
+
+	rpcSub := notifier.CreateSubscription()
+
+	go func() {
+		blocks := make(chan evmcore.ChainHeadNotify, blocksChanSize)
+		blocksSub := api.backend.SubscribeNewBlockNotify(blocks)
+		defer blocksSub.Unsubscribe()
+
+		for {
+			select {
+			case ev := <-blocks:
+				for _, e := range api.blockEvents(w, ev.Block) {
+					_ = notifier.Notify(rpcSub.ID, e)
+				}
+			case <-rpcSub.Err():
+				return
+			case <-notifier.Closed():
+				return
+			}
+		}
+	}()
+
+	return rpcSub, nil
+}
+
+// blockEvents returns the subscription events of the block delivered by the watcher
+func (api *PublicSubscriptionEventsAPI) blockEvents(w *subscriptionWatcher, block *evmcore.EvmBlock) []*SubscriptionEvent {
+	ctx := context.Background()
+	number := rpc.BlockNumber(block.NumberU64())
+
+	st, err := api.states.at(number)
+	if err != nil {
+		log.Debug("Subscription events skipped, state is unavailable", "block", number, "err", err)
+		return nil
+	}
+	receipts, err := api.backend.GetReceiptsByNumber(ctx, number)
+	if err != nil {
+		log.Debug("Subscription events skipped, receipts are unavailable", "block", number, "err", err)
+		return nil
+	}
+	// gas payments are available only if the transactions index is enabled
+	payments, _ := api.backend.GetGasPaymentsByNumber(ctx, number)
+
+	return w.processBlock(block, receipts, payments, st)
+}
+
+// subscriptionState is the state of the Subscribers contract after a block
+type subscriptionState interface {
+	Time() *big.Int
+	IsContract(addr common.Address) bool
+	SubscriberById(id *big.Int) (common.Address, error)
+	SubscriptionData(addr common.Address, contractSub bool) (*subscriber.Subscription, error)
+	IsWhitelisted(contract common.Address, account common.Address) (bool, error)
+}
+
+type subscriptionDataKey struct {
+	addr        common.Address
+	contractSub bool
+}
+
+type whitelistKey struct {
+	contract common.Address
+	account  common.Address
+}
+
+type subscriberResult struct {
+	addr common.Address
+	err  error
+}
+
+type subscriptionDataResult struct {
+	sub *subscriber.Subscription
+	err error
+}
+
+type whitelistResult struct {
+	whitelisted bool
+	err         error
+}
+
+// evmSubscriptionState calls the Subscribers contract at a block.
+// The results are cached, as the same calls are made by every feed.
+type evmSubscriptionState struct {
+	mu      sync.Mutex
+	statedb *state.StateDB
+	runner  vmcontext.EVMRunner
+	time    *big.Int
+
+	subscribers map[string]subscriberResult
+	data        map[subscriptionDataKey]subscriptionDataResult
+	whitelisted map[whitelistKey]whitelistResult
+}
+
+func (s *evmSubscriptionState) Time() *big.Int {
+	return s.time
+}
+
+func (s *evmSubscriptionState) IsContract(addr common.Address) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.statedb.GetCodeSize(addr) > 0
+}
+
+func (s *evmSubscriptionState) SubscriberById(id *big.Int) (common.Address, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := id.String()
+	res, ok := s.subscribers[key]
+	if !ok {
+		res.addr, res.err = subscriber.GetSubscriberById(s.runner, id)
+		s.subscribers[key] = res
+	}
+	return res.addr, res.err
+}
+
+func (s *evmSubscriptionState) SubscriptionData(addr common.Address, contractSub bool) (*subscriber.Subscription, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := subscriptionDataKey{addr, contractSub}
+	res, ok := s.data[key]
+	if !ok {
+		res.sub, res.err = subscriber.GetSubscriptionData(s.runner, addr, contractSub)
+		s.data[key] = res
+	}
+	return res.sub, res.err
+}
+
+func (s *evmSubscriptionState) IsWhitelisted(contract common.Address, account common.Address) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := whitelistKey{contract, account}
+	res, ok := s.whitelisted[key]
+	if !ok {
+		res.whitelisted, res.err = subscriber.IsWhitelistedForContract(s.runner, contract, account)
+		s.whitelisted[key] = res
+	}
+	return res.whitelisted, res.err
+}
+
+// subscriptionStateCache shares the states of the recent blocks between the feeds
+type subscriptionStateCache struct {
+	backend SubscriptionBackend
+
+	mu     sync.Mutex
+	states map[rpc.BlockNumber]*evmSubscriptionState
+	order  []rpc.BlockNumber
+}
+
+func newSubscriptionStateCache(backend SubscriptionBackend) *subscriptionStateCache {
+	return &subscriptionStateCache{
+		backend: backend,
+		states:  make(map[rpc.BlockNumber]*evmSubscriptionState),
+	}
+}
+
+// at returns the state of the Subscribers contract after the block
+func (c *subscriptionStateCache) at(number rpc.BlockNumber) (subscriptionState, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if st, ok := c.states[number]; ok {
+		return st, nil
+	}
+
+	ctx := context.Background()
+	statedb, header, err := c.backend.StateAndHeaderByNumberOrHash(ctx, rpc.BlockNumberOrHashWithNumber(number))
+	if err != nil {
+		return nil, err
+	}
+	if statedb == nil {
+		return nil, errors.New("state is unavailable")
+	}
+	vmConfig := params.DefaultVMConfig
+	msg := types.NewMessage(params.ZeroAddress, nil, 0, new(big.Int), 0, new(big.Int), new(big.Int), new(big.Int), []byte{}, nil, true)
+	evm, _, err := c.backend.GetEVM(ctx, msg, statedb, header, &vmConfig)
+	if err != nil {
+		return nil, err
+	}
+	st := &evmSubscriptionState{
+		statedb:     statedb,
+		runner:      &vmcontext.SharedEVMRunner{EVM: evm},
+		time:        evm.Context.Time,
+		subscribers: make(map[string]subscriberResult),
+		data:        make(map[subscriptionDataKey]subscriptionDataResult),
+		whitelisted: make(map[whitelistKey]whitelistResult),
+	}
+	c.states[number] = st
+	c.order = append(c.order, number)
+	if len(c.order) > subscriptionStatesCacheSize {
+		delete(c.states, c.order[0])
+		c.order = c.order[1:]
+	}
+	return st, nil
+}
+
+// subscriptionWatcher turns the processed blocks into subscription events
+type subscriptionWatcher struct {
+	signer    types.Signer
+	states    func(rpc.BlockNumber) (subscriptionState, error)
+	types     map[string]bool
+	watched   map[common.Address]bool
+	threshold *big.Int
+
+	// last observed state of the watched subscriptions
+	snapshots map[common.Address]*subscriber.Subscription
+	prevTime  *big.Int
+}
+
+func newSubscriptionWatcher(signer types.Signer, states func(rpc.BlockNumber) (subscriptionState, error), crit SubscriptionEventsCriteria) (*subscriptionWatcher, error) {
+	if len(crit.Subscribers) > maxWatchedSubscribers {
+		return nil, fmt.Errorf("too many subscribers, max %d", maxWatchedSubscribers)
+	}
+	w := &subscriptionWatcher{
+		signer:    signer,
+		states:    states,
+		snapshots: make(map[common.Address]*subscriber.Subscription),
+	}
+	if len(crit.Types) != 0 {
+		w.types = make(map[string]bool, len(crit.Types))
+		for _, typ := range crit.Types {
+			if !subscriptionEventTypes[typ] {
+				return nil, fmt.Errorf("unknown subscription event type %q", typ)
+			}
+			w.types[typ] = true
+		}
+	}
+	if len(crit.Subscribers) != 0 {
+		w.watched = make(map[common.Address]bool, len(crit.Subscribers))
+		for _, addr := range crit.Subscribers {
+			w.watched[addr] = true
+		}
+	}
+	if crit.BalanceThreshold != nil {
+		if len(crit.Subscribers) == 0 {
+			return nil, errors.New("balanceThreshold requires the subscribers to be specified")
+		}
+		w.threshold = crit.BalanceThreshold.ToInt()
+	}
+	return w, nil
+}
+
+func (w *subscriptionWatcher) wanted(e *SubscriptionEvent) bool {
+	if w.types != nil && !w.types[e.Type] {
+		return false
+	}
+	return w.watched == nil || w.watched[e.Subscriber]
+}
+
+func hexUint64(v uint64) *hexutil.Uint64 {
+	return (*hexutil.Uint64)(&v)
+}
+
+func hexUint64FromBig(v *big.Int) *hexutil.Uint64 {
+	if v == nil {
+		return nil
+	}
+	return hexUint64(v.Uint64())
+}
+
+// processBlock returns the subscription events of the block, in the order of the transactions.
+// The events derived from the state of the watched subscriptions follow the transactions events.
+func (w *subscriptionWatcher) processBlock(block *evmcore.EvmBlock, receipts types.Receipts, payments []*evmcore.GasPayment, st subscriptionState) []*SubscriptionEvent {
+	number := rpc.BlockNumber(block.NumberU64())
+
+	var events []*SubscriptionEvent
+	emit := func(e *SubscriptionEvent) {
+		e.BlockNumber = hexutil.Uint64(number)
+		e.BlockHash = block.Hash
+		if w.wanted(e) {
+			events = append(events, e)
+		}
+	}
+
+	for i, tx := range block.Transactions {
+		txHash := tx.Hash()
+		if i < len(receipts) {
+			for _, l := range receipts[i].Logs {
+				if e := w.decodeSubscribersLog(l, receipts[i].Logs, number, st); e != nil {
+					e.TxHash = &txHash
+					emit(e)
+				}
+			}
+		}
+		if i < len(payments) && payments[i] != nil {
+			for _, e := range w.paymentEvents(tx, payments[i], st) {
+				e.TxHash = &txHash
+				emit(e)
+			}
+		}
+	}
+
+	for _, e := range w.stateEvents(st) {
+		emit(e)
+	}
+	return events
+}
+
+// decodeSubscribersLog converts a Subscribers contract log into a subscription event,
+// returns nil for the logs which aren't related to the subscriptions lifecycle
+func (w *subscriptionWatcher) decodeSubscribersLog(l *types.Log, txLogs []*types.Log, number rpc.BlockNumber, st subscriptionState) *SubscriptionEvent {
+	if l.Address != contracts.SubscribersSmartContractAddress || len(l.Topics) == 0 {
+		return nil
+	}
+	typ, ok := subscribersLogTypes[l.Topics[0]]
+	if !ok {
+		return nil
+	}
+	ev, _ := abis.Subscribers.EventByID(l.Topics[0])
+	values, err := abis.Subscribers.Unpack(ev.Name, l.Data)
+	if err != nil || len(values) == 0 {
+		log.Debug("Failed to unpack Subscribers log", "event", ev.Name, "err", err)
+		return nil
+	}
+	id, _ := values[0].(*big.Int)
+	if id == nil {
+		return nil
+	}
+	e := &SubscriptionEvent{
+		Type: typ,
+		Id:   (*hexutil.Big)(id),
+	}
+	switch typ {
+	case SubscriptionCreated, SubscriptionRenewed:
+		if len(values) == 5 {
+			planId, _ := values[1].(*big.Int)
+			startTime, _ := values[2].(*big.Int)
+			endTime, _ := values[3].(*big.Int)
+			balance, _ := values[4].(*big.Int)
+			e.PlanId = (*hexutil.Big)(planId)
+			e.StartTime = hexUint64FromBig(startTime)
+			e.EndTime = hexUint64FromBig(endTime)
+			e.Balance = (*hexutil.Big)(balance)
+		}
+	case SubscriptionPlanSwitched:
+		if len(values) == 3 {
+			oldPlanId, _ := values[1].(*big.Int)
+			newPlanId, _ := values[2].(*big.Int)
+			e.OldPlanId = (*hexutil.Big)(oldPlanId)
+			e.PlanId = (*hexutil.Big)(newPlanId)
+		}
+	}
+
+	var resolved bool
+	e.Subscriber, resolved = subscriberFromLogs(txLogs, id)
+	if !resolved {
+		e.Subscriber, err = w.subscriberById(id, typ, number, st)
+		if err != nil {
+			log.Debug("Failed to resolve the subscriber", "id", id, "err", err)
+		}
+	}
+	e.ContractSub = st.IsContract(e.Subscriber)
+	return e
+}
+
+// subscriberFromLogs returns the holder of the subscription token transferred by the transaction
+func subscriberFromLogs(txLogs []*types.Log, id *big.Int) (common.Address, bool) {
+	for _, l := range txLogs {
+		if l.Address != contracts.SubscribersSmartContractAddress || len(l.Topics) != 4 || l.Topics[0] != subscriptionTransferID {
+			continue
+		}
+		if l.Topics[3].Big().Cmp(id) != 0 {
+			continue
+		}
+		from := common.BytesToAddress(l.Topics[1].Bytes())
+		to := common.BytesToAddress(l.Topics[2].Bytes())
+		if to != (common.Address{}) {
+			return to, true
+		}
+		// the token is burnt
+		return from, true
+	}
+	return common.Address{}, false
+}
+
+// subscriberById resolves the subscriber from the state. The subscription doesn't exist anymore
+// after it's terminated, so the subscriber of a terminated subscription is taken from the previous block.
+func (w *subscriptionWatcher) subscriberById(id *big.Int, typ string, number rpc.BlockNumber, st subscriptionState) (common.Address, error) {
+	if typ == SubscriptionTerminated {
+		if number == 0 {
+			return common.Address{}, errors.New("no state before the genesis block")
+		}
+		prev, err := w.states(number - 1)
+		if err != nil {
+			return common.Address{}, err
+		}
+		st = prev
+	}
+	return st.SubscriberById(id)
+}
+
+// paymentEvents converts the gas payment of a transaction into the debit/credit events
+func (w *subscriptionWatcher) paymentEvents(tx *types.Transaction, p *evmcore.GasPayment, st subscriptionState) []*SubscriptionEvent {
+	from, err := types.Sender(w.signer, tx)
+	if err != nil {
+		return nil
+	}
+
+	var events []*SubscriptionEvent
+	payer := func(addr common.Address, contractSub bool, gas, refund uint64) {
+		if gas != 0 {
+			events = append(events, &SubscriptionEvent{
+				Type:        SubscriptionDebited,
+				Subscriber:  addr,
+				ContractSub: contractSub,
+				Units:       hexUint64(gas),
+			})
+		}
+		if refund != 0 {
+			events = append(events, &SubscriptionEvent{
+				Type:        SubscriptionCredited,
+				Subscriber:  addr,
+				ContractSub: contractSub,
+				Units:       hexUint64(refund),
+			})
+		}
+	}
+	fallback := func(addr common.Address, contractSub bool) {
+		events = append(events, &SubscriptionEvent{
+			Type:        SubscriptionPayAsYouGoFallback,
+			Subscriber:  addr,
+			ContractSub: contractSub,
+			Units:       hexUint64(p.PayAsYouGoGas),
+		})
+	}
+
+	if tx.To() != nil {
+		payer(*tx.To(), true, p.ReceiverSubscriptionGas, p.ReceiverSubscriptionRefund)
+	}
+	payer(from, false, p.SenderSubscriptionGas, p.SenderSubscriptionRefund)
+
+	if p.PayAsYouGoGas == 0 {
+		return events
+	}
+	switch {
+	case p.ReceiverSubscriptionGas != 0:
+		// the sponsor's subscription covered the gas only partially
+		fallback(*tx.To(), true)
+	case p.SenderSubscriptionGas != 0:
+		fallback(from, false)
+	case tx.To() != nil && w.wasActive(*tx.To()):
+		// the sponsor's subscription was active but didn't pay anything, e.g. its cap is exhausted
+		whitelisted, err := st.IsWhitelisted(*tx.To(), from)
+		if err == nil && whitelisted {
+			fallback(*tx.To(), true)
+		}
+	case w.wasActive(from) && (tx.To() == nil || !st.IsContract(*tx.To()) || !w.contractSubscribed(*tx.To(), st)):
+		// the sender's subscription was active but didn't pay anything
+		fallback(from, false)
+	}
+	return events
+}
+
+// wasActive returns true if the watched subscription was active at the previous block
+func (w *subscriptionWatcher) wasActive(addr common.Address) bool {
+	sub := w.snapshots[addr]
+	return sub != nil && w.prevTime != nil && evmcore.SubscriptionDataActive(sub, w.prevTime)
+}
+
+func (w *subscriptionWatcher) contractSubscribed(contract common.Address, st subscriptionState) bool {
+	sub, err := st.SubscriptionData(contract, true)
+	return err == nil && evmcore.SubscriptionDataValid(sub)
+}
+
+// stateEvents compares the state of the watched subscriptions with the previous block
+func (w *subscriptionWatcher) stateEvents(st subscriptionState) []*SubscriptionEvent {
+	blockTime := st.Time()
+	var events []*SubscriptionEvent
+	for addr := range w.watched {
+		contractSub := st.IsContract(addr)
+		sub, err := st.SubscriptionData(addr, contractSub)
+		if err != nil {
+			log.Debug("Failed to get the subscription data", "subscriber", addr, "err", err)
+			continue
+		}
+		if !evmcore.SubscriptionDataValid(sub) {
+			delete(w.snapshots, addr)
+			continue
+		}
+		prev := w.snapshots[addr]
+		w.snapshots[addr] = sub
+		if prev == nil || prev.Id.Cmp(sub.Id) != 0 {
+			continue
+		}
+
+		newEvent := func(typ string) *SubscriptionEvent {
+			return &SubscriptionEvent{
+				Type:        typ,
+				Subscriber:  addr,
+				ContractSub: contractSub,
+				Id:          (*hexutil.Big)(sub.Id),
+				PlanId:      (*hexutil.Big)(sub.PlanId),
+				EndTime:     hexUint64FromBig(sub.EndTime),
+				Balance:     (*hexutil.Big)(sub.Balance),
+			}
+		}
+		if sub.LastCapReset != nil && prev.LastCapReset != nil && sub.LastCapReset.Cmp(prev.LastCapReset) > 0 {
+			events = append(events, newEvent(SubscriptionCapReset))
+		}
+		if w.threshold != nil && prev.Balance.Cmp(w.threshold) >= 0 && sub.Balance.Cmp(w.threshold) < 0 {
+			events = append(events, newEvent(SubscriptionLowBalance))
+		}
+		if w.prevTime != nil && prev.EndTime.Cmp(w.prevTime) >= 0 && sub.EndTime.Cmp(blockTime) < 0 {
+			events = append(events, newEvent(SubscriptionExpired))
+		}
+	}
+	w.prevTime = blockTime
+	return events
+}
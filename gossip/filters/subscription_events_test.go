@@ -0,0 +1,385 @@
+package filters
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/stretchr/testify/require"
+
+	"github.com/artheranet/arthera-node/contracts"
+	"github.com/artheranet/arthera-node/contracts/abis"
+	"github.com/artheranet/arthera-node/contracts/subscriber"
+	"github.com/artheranet/arthera-node/internal/evmcore"
+)
+
+var (
+	testSubKey, _    = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+	testSubAccount   = crypto.PubkeyToAddress(testSubKey.PublicKey)
+	testSubContract  = common.HexToAddress("0x2000000000000000000000000000000000000002")
+	testSubRecipient = common.HexToAddress("0x3000000000000000000000000000000000000003")
+	testSubSigner    = types.LatestSignerForChainID(big.NewInt(1))
+)
+
+type testSubKeyData struct {
+	addr        common.Address
+	contractSub bool
+}
+
+// testSubscriptionState is the state of the Subscribers contract at a block
+type testSubscriptionState struct {
+	time        *big.Int
+	contracts   map[common.Address]bool
+	subscribers map[string]common.Address
+	subs        map[testSubKeyData]*subscriber.Subscription
+	whitelisted map[common.Address]common.Address
+	calls       int
+}
+
+func newTestSubscriptionState(time int64) *testSubscriptionState {
+	return &testSubscriptionState{
+		time:        big.NewInt(time),
+		contracts:   map[common.Address]bool{testSubContract: true},
+		subscribers: make(map[string]common.Address),
+		subs:        make(map[testSubKeyData]*subscriber.Subscription),
+		whitelisted: make(map[common.Address]common.Address),
+	}
+}
+
+func (s *testSubscriptionState) subscribe(addr common.Address, id int64, balance int64) *subscriber.Subscription {
+	sub := &subscriber.Subscription{
+		Id:           big.NewInt(id),
+		PlanId:       big.NewInt(1),
+		Balance:      big.NewInt(balance),
+		StartTime:    big.NewInt(1),
+		EndTime:      big.NewInt(1000),
+		LastCapReset: big.NewInt(1),
+		PeriodUsage:  new(big.Int),
+	}
+	s.subs[testSubKeyData{addr, s.contracts[addr]}] = sub
+	s.subscribers[sub.Id.String()] = addr
+	return sub
+}
+
+func (s *testSubscriptionState) Time() *big.Int {
+	return s.time
+}
+
+func (s *testSubscriptionState) IsContract(addr common.Address) bool {
+	return s.contracts[addr]
+}
+
+func (s *testSubscriptionState) SubscriberById(id *big.Int) (common.Address, error) {
+	s.calls++
+	return s.subscribers[id.String()], nil
+}
+
+func (s *testSubscriptionState) SubscriptionData(addr common.Address, contractSub bool) (*subscriber.Subscription, error) {
+	s.calls++
+	sub := s.subs[testSubKeyData{addr, contractSub}]
+	if sub == nil {
+		return &subscriber.Subscription{}, nil
+	}
+	cp := *sub
+	return &cp, nil
+}
+
+func (s *testSubscriptionState) IsWhitelisted(contract common.Address, account common.Address) (bool, error) {
+	s.calls++
+	return s.whitelisted[contract] == account, nil
+}
+
+// testSubscriptionChain holds the states of the Subscribers contract by block
+type testSubscriptionChain map[rpc.BlockNumber]*testSubscriptionState
+
+func (c testSubscriptionChain) at(number rpc.BlockNumber) (subscriptionState, error) {
+	st, ok := c[number]
+	if !ok {
+		return nil, errors.New("unknown block")
+	}
+	return st, nil
+}
+
+func subscribersLog(t *testing.T, event string, args ...interface{}) *types.Log {
+	data, err := abis.Subscribers.Events[event].Inputs.Pack(args...)
+	require.NoError(t, err)
+	return &types.Log{
+		Address: contracts.SubscribersSmartContractAddress,
+		Topics:  []common.Hash{abis.Subscribers.Events[event].ID},
+		Data:    data,
+	}
+}
+
+func subscriptionTransferLog(from, to common.Address, id int64) *types.Log {
+	return &types.Log{
+		Address: contracts.SubscribersSmartContractAddress,
+		Topics: []common.Hash{
+			subscriptionTransferID,
+			common.BytesToHash(from.Bytes()),
+			common.BytesToHash(to.Bytes()),
+			common.BigToHash(big.NewInt(id)),
+		},
+	}
+}
+
+func testSubscriptionTx(t *testing.T, nonce uint64, to *common.Address) *types.Transaction {
+	var tx *types.Transaction
+	if to == nil {
+		tx = types.NewContractCreation(nonce, new(big.Int), 100000, big.NewInt(1), nil)
+	} else {
+		tx = types.NewTransaction(nonce, *to, new(big.Int), 100000, big.NewInt(1), nil)
+	}
+	tx, err := types.SignTx(tx, testSubSigner, testSubKey)
+	require.NoError(t, err)
+	return tx
+}
+
+func testSubscriptionBlock(number int64, txs ...*types.Transaction) *evmcore.EvmBlock {
+	return evmcore.NewEvmBlock(&evmcore.EvmHeader{
+		Number: big.NewInt(number),
+		Hash:   common.BigToHash(big.NewInt(number)),
+	}, txs)
+}
+
+func newTestSubscriptionWatcher(t *testing.T, chain testSubscriptionChain, crit SubscriptionEventsCriteria) *subscriptionWatcher {
+	w, err := newSubscriptionWatcher(testSubSigner, chain.at, crit)
+	require.NoError(t, err)
+	return w
+}
+
+func TestSubscriptionLogEvents(t *testing.T) {
+	require := require.New(t)
+
+	chain := testSubscriptionChain{
+		1: newTestSubscriptionState(10),
+		2: newTestSubscriptionState(20),
+	}
+	chain[1].subscribe(testSubRecipient, 3, 100)
+	chain[2].subscribe(testSubAccount, 1, 100)
+	chain[2].subscribe(testSubContract, 2, 100)
+	w := newTestSubscriptionWatcher(t, chain, SubscriptionEventsCriteria{})
+
+	txs := []*types.Transaction{
+		testSubscriptionTx(t, 0, &contracts.SubscribersSmartContractAddress),
+		testSubscriptionTx(t, 1, &contracts.SubscribersSmartContractAddress),
+		testSubscriptionTx(t, 2, &contracts.SubscribersSmartContractAddress),
+		testSubscriptionTx(t, 3, &contracts.SubscribersSmartContractAddress),
+	}
+	receipts := types.Receipts{
+		{Logs: []*types.Log{
+			subscriptionTransferLog(common.Address{}, testSubAccount, 1),
+			subscribersLog(t, "NewSubscription", big.NewInt(1), big.NewInt(5), big.NewInt(11), big.NewInt(111), big.NewInt(1000)),
+		}},
+		{Logs: []*types.Log{
+			subscribersLog(t, "RenewSubscription", big.NewInt(2), big.NewInt(6), big.NewInt(12), big.NewInt(112), big.NewInt(2000)),
+		}},
+		{Logs: []*types.Log{
+			subscribersLog(t, "SwitchPlan", big.NewInt(1), big.NewInt(5), big.NewInt(7)),
+		}},
+		{Logs: []*types.Log{
+			// the subscription doesn't exist after the block, the subscriber is taken from the previous block
+			subscribersLog(t, "TerminateSubscription", big.NewInt(3)),
+			// unrelated logs are skipped
+			subscribersLog(t, "PlanActivated", big.NewInt(5)),
+		}},
+	}
+
+	events := w.processBlock(testSubscriptionBlock(2, txs...), receipts, nil, chain[2])
+	require.Len(events, 4)
+	for i, e := range events {
+		require.Equal(hexutil.Uint64(2), e.BlockNumber)
+		require.Equal(common.BigToHash(big.NewInt(2)), e.BlockHash)
+		require.Equal(txs[i].Hash(), *e.TxHash)
+	}
+
+	created := events[0]
+	require.Equal(SubscriptionCreated, created.Type)
+	require.Equal(testSubAccount, created.Subscriber)
+	require.False(created.ContractSub)
+	require.Equal(uint64(1), created.Id.ToInt().Uint64())
+	require.Equal(uint64(5), created.PlanId.ToInt().Uint64())
+	require.Equal(hexutil.Uint64(11), *created.StartTime)
+	require.Equal(hexutil.Uint64(111), *created.EndTime)
+	require.Equal(uint64(1000), created.Balance.ToInt().Uint64())
+
+	renewed := events[1]
+	require.Equal(SubscriptionRenewed, renewed.Type)
+	require.Equal(testSubContract, renewed.Subscriber)
+	require.True(renewed.ContractSub)
+	require.Equal(uint64(6), renewed.PlanId.ToInt().Uint64())
+	require.Equal(hexutil.Uint64(112), *renewed.EndTime)
+
+	switched := events[2]
+	require.Equal(SubscriptionPlanSwitched, switched.Type)
+	require.Equal(testSubAccount, switched.Subscriber)
+	require.Equal(uint64(5), switched.OldPlanId.ToInt().Uint64())
+	require.Equal(uint64(7), switched.PlanId.ToInt().Uint64())
+
+	terminated := events[3]
+	require.Equal(SubscriptionTerminated, terminated.Type)
+	require.Equal(testSubRecipient, terminated.Subscriber)
+	require.Equal(uint64(3), terminated.Id.ToInt().Uint64())
+}
+
+func TestSubscriptionTerminatedFromLogs(t *testing.T) {
+	require := require.New(t)
+
+	chain := testSubscriptionChain{
+		2: newTestSubscriptionState(20),
+	}
+	w := newTestSubscriptionWatcher(t, chain, SubscriptionEventsCriteria{})
+
+	// the burnt token identifies the subscriber, the state isn't needed
+	tx := testSubscriptionTx(t, 0, &contracts.SubscribersSmartContractAddress)
+	receipts := types.Receipts{{Logs: []*types.Log{
+		subscribersLog(t, "TerminateSubscription", big.NewInt(4)),
+		subscriptionTransferLog(testSubRecipient, common.Address{}, 4),
+	}}}
+	events := w.processBlock(testSubscriptionBlock(2, tx), receipts, nil, chain[2])
+	require.Len(events, 1)
+	require.Equal(SubscriptionTerminated, events[0].Type)
+	require.Equal(testSubRecipient, events[0].Subscriber)
+	require.Zero(chain[2].calls)
+
+	// the subscriber is unknown if neither the logs nor the previous block have it
+	events = w.processBlock(testSubscriptionBlock(2, tx), types.Receipts{{Logs: receipts[0].Logs[:1]}}, nil, chain[2])
+	require.Len(events, 1)
+	require.Equal(common.Address{}, events[0].Subscriber)
+}
+
+func TestSubscriptionPaymentEvents(t *testing.T) {
+	require := require.New(t)
+
+	chain := testSubscriptionChain{
+		1: newTestSubscriptionState(10),
+		2: newTestSubscriptionState(20),
+	}
+	for _, st := range chain {
+		st.subscribe(testSubAccount, 1, 100)
+		st.subscribe(testSubContract, 2, 100)
+		st.whitelisted[testSubContract] = testSubAccount
+	}
+	w := newTestSubscriptionWatcher(t, chain, SubscriptionEventsCriteria{
+		Subscribers: []common.Address{testSubAccount, testSubContract},
+	})
+	// the first block records the state of the watched subscriptions
+	require.Empty(w.processBlock(testSubscriptionBlock(1), nil, nil, chain[1]))
+
+	txs := []*types.Transaction{
+		testSubscriptionTx(t, 0, &testSubContract),
+		testSubscriptionTx(t, 1, &testSubRecipient),
+		testSubscriptionTx(t, 2, &testSubContract),
+		testSubscriptionTx(t, 3, &testSubRecipient),
+		testSubscriptionTx(t, 4, nil),
+	}
+	payments := []*evmcore.GasPayment{
+		// the sponsor pays
+		{ReceiverSubscriptionGas: 50, ReceiverSubscriptionRefund: 10},
+		// the sender's subscription pays partially
+		{SenderSubscriptionGas: 30, SenderSubscriptionRefund: 5, PayAsYouGoGas: 20},
+		// the sponsor's subscription is active but didn't pay
+		{PayAsYouGoGas: 40},
+		// the sender's subscription is active but didn't pay
+		{PayAsYouGoGas: 60},
+		// no payment is recorded
+		nil,
+	}
+
+	events := w.processBlock(testSubscriptionBlock(2, txs...), make(types.Receipts, len(txs)), payments, chain[2])
+	type expected struct {
+		typ         string
+		subscriber  common.Address
+		contractSub bool
+		units       uint64
+		tx          int
+	}
+	exp := []expected{
+		{SubscriptionDebited, testSubContract, true, 50, 0},
+		{SubscriptionCredited, testSubContract, true, 10, 0},
+		{SubscriptionDebited, testSubAccount, false, 30, 1},
+		{SubscriptionCredited, testSubAccount, false, 5, 1},
+		{SubscriptionPayAsYouGoFallback, testSubAccount, false, 20, 1},
+		{SubscriptionPayAsYouGoFallback, testSubContract, true, 40, 2},
+		{SubscriptionPayAsYouGoFallback, testSubAccount, false, 60, 3},
+	}
+	require.Len(events, len(exp))
+	for i, e := range exp {
+		require.Equal(e.typ, events[i].Type, i)
+		require.Equal(e.subscriber, events[i].Subscriber, i)
+		require.Equal(e.contractSub, events[i].ContractSub, i)
+		require.Equal(hexutil.Uint64(e.units), *events[i].Units, i)
+		require.Equal(txs[e.tx].Hash(), *events[i].TxHash, i)
+	}
+
+	// the filters are applied
+	w = newTestSubscriptionWatcher(t, chain, SubscriptionEventsCriteria{
+		Subscribers: []common.Address{testSubContract},
+		Types:       []string{SubscriptionCredited},
+	})
+	require.Empty(w.processBlock(testSubscriptionBlock(1), nil, nil, chain[1]))
+	events = w.processBlock(testSubscriptionBlock(2, txs...), make(types.Receipts, len(txs)), payments, chain[2])
+	require.Len(events, 1)
+	require.Equal(SubscriptionCredited, events[0].Type)
+	require.Equal(testSubContract, events[0].Subscriber)
+}
+
+func TestSubscriptionStateEvents(t *testing.T) {
+	require := require.New(t)
+
+	chain := testSubscriptionChain{
+		1: newTestSubscriptionState(10),
+		2: newTestSubscriptionState(1001),
+	}
+	chain[1].subscribe(testSubAccount, 1, 100)
+	chain[1].subscribe(testSubContract, 2, 100)
+	chain[1].subscribe(testSubRecipient, 3, 100)
+	// the cap window is reset
+	reset := chain[2].subscribe(testSubAccount, 1, 100)
+	reset.LastCapReset = big.NewInt(500)
+	reset.EndTime = big.NewInt(2000)
+	// the balance drops below the threshold
+	chain[2].subscribe(testSubContract, 2, 40).EndTime = big.NewInt(2000)
+	// the end time is reached
+	chain[2].subscribe(testSubRecipient, 3, 100)
+
+	w := newTestSubscriptionWatcher(t, chain, SubscriptionEventsCriteria{
+		Subscribers:      []common.Address{testSubAccount, testSubContract, testSubRecipient},
+		BalanceThreshold: (*hexutil.Big)(big.NewInt(50)),
+	})
+	require.Empty(w.processBlock(testSubscriptionBlock(1), nil, nil, chain[1]))
+
+	events := w.processBlock(testSubscriptionBlock(2), nil, nil, chain[2])
+	require.Len(events, 3)
+	byType := make(map[string]*SubscriptionEvent)
+	for _, e := range events {
+		require.Nil(e.TxHash)
+		require.Equal(hexutil.Uint64(2), e.BlockNumber)
+		byType[e.Type] = e
+	}
+	require.Len(byType, 3)
+	require.Equal(testSubAccount, byType[SubscriptionCapReset].Subscriber)
+	require.Equal(testSubContract, byType[SubscriptionLowBalance].Subscriber)
+	require.True(byType[SubscriptionLowBalance].ContractSub)
+	require.Equal(uint64(40), byType[SubscriptionLowBalance].Balance.ToInt().Uint64())
+	require.Equal(testSubRecipient, byType[SubscriptionExpired].Subscriber)
+	require.Equal(hexutil.Uint64(1000), *byType[SubscriptionExpired].EndTime)
+
+	// the events aren't repeated
+	chain[3] = chain[2]
+	require.Empty(w.processBlock(testSubscriptionBlock(3), nil, nil, chain[3]))
+}
+
+func TestSubscriptionCriteria(t *testing.T) {
+	chain := testSubscriptionChain{}
+	_, err := newSubscriptionWatcher(testSubSigner, chain.at, SubscriptionEventsCriteria{Types: []string{"unknown"}})
+	require.Error(t, err)
+	_, err = newSubscriptionWatcher(testSubSigner, chain.at, SubscriptionEventsCriteria{BalanceThreshold: (*hexutil.Big)(big.NewInt(1))})
+	require.Error(t, err)
+	_, err = newSubscriptionWatcher(testSubSigner, chain.at, SubscriptionEventsCriteria{Subscribers: make([]common.Address, maxWatchedSubscribers+1)})
+	require.Error(t, err)
+}
@@ -0,0 +1,49 @@
+package gossip
+
+import (
+	"sync"
+	"time"
+)
+
+// historyExpiryBatch is the maximum number of records deleted at once
+const historyExpiryBatch = 1000
+
+// HistoryExpirer periodically deletes the history which is older than the retention policy allows
+type HistoryExpirer struct {
+	period time.Duration
+	// expire deletes a batch of the expired history, and returns true if there may be more
+	expire func() bool
+
+	wg   sync.WaitGroup
+	quit chan struct{}
+}
+
+func (e *HistoryExpirer) loop() {
+	defer e.wg.Done()
+	ticker := time.NewTicker(e.period)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			for e.expire() {
+				select {
+				case <-e.quit:
+					return
+				default:
+				}
+			}
+		case <-e.quit:
+			return
+		}
+	}
+}
+
+func (e *HistoryExpirer) Start() {
+	e.wg.Add(1)
+	go e.loop()
+}
+
+func (e *HistoryExpirer) Stop() {
+	close(e.quit)
+	e.wg.Wait()
+}
@@ -146,10 +146,23 @@ func TestMisbehaviourProofsEventsDoublesign(t *testing.T) {
 	err = env.ApplyMPs(nextEpoch, wrongAuthEpochMp)
 	require.ErrorIs(err, heavycheck.ErrUnknownEpochEventLocator)
 
+	appliedEpoch := env.store.GetEpoch()
 	err = env.ApplyMPs(nextEpoch, correctMp)
 	require.NoError(err)
 	require.Equal(idx.Validator(2), env.store.GetValidators().Len())
 	require.False(env.store.GetValidators().Exists(1))
+
+	// check the evidence
+	var evidence []inter.MisbehaviourEvidence
+	env.store.ForEachMisbehaviourEvidence(appliedEpoch, func(ev inter.MisbehaviourEvidence) bool {
+		evidence = append(evidence, ev)
+		return true
+	})
+	require.Len(evidence, 1)
+	require.Equal(inter.EventsDoublesignType, evidence[0].Type)
+	require.Equal(idx.ValidatorID(1), evidence[0].Offender)
+	require.Equal(startEpoch, evidence[0].Epoch)
+	require.True(env.store.GetValidatorEpochMetrics(appliedEpoch, 1).Cheater)
 }
 
 func TestMisbehaviourProofsBlockVoteDoublesign(t *testing.T) {
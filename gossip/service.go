@@ -61,6 +61,8 @@ type ServiceFeed struct {
 
 	newEpoch        notify.Feed
 	newEmittedEvent notify.Feed
+	newEvent        droppingFeed
+	confirmedEvent  droppingFeed
 	newBlock        notify.Feed
 	newLogs         notify.Feed
 }
@@ -73,6 +75,14 @@ func (f *ServiceFeed) SubscribeNewEmitted(ch chan<- *inter.EventPayload) notify.
 	return f.scope.Track(f.newEmittedEvent.Subscribe(ch))
 }
 
+func (f *ServiceFeed) SubscribeNewEvent(ch chan<- *inter.EventPayload) notify.Subscription {
+	return f.scope.Track(f.newEvent.Subscribe(ch))
+}
+
+func (f *ServiceFeed) SubscribeConfirmedEvent(ch chan<- inter.ConfirmedEventNotify) notify.Subscription {
+	return f.scope.Track(f.confirmedEvent.Subscribe(ch))
+}
+
 func (f *ServiceFeed) SubscribeNewBlock(ch chan<- evmcore.ChainHeadNotify) notify.Subscription {
 	return f.scope.Track(f.newBlock.Subscribe(ch))
 }
@@ -155,6 +165,8 @@ type Service struct {
 
 	tflusher PeriodicFlusher
 
+	historyExpirer *HistoryExpirer
+
 	bootstrapping bool
 
 	logger.Instance
@@ -279,6 +291,7 @@ func newService(config Config, store *Store, blockProc BlockProc, engine lachesi
 
 	svc.verWatcher = verwatcher.New(netVerStore)
 	svc.tflusher = svc.makePeriodicFlusher()
+	svc.historyExpirer = svc.makeHistoryExpirer()
 
 	return svc, nil
 }
@@ -331,6 +344,34 @@ func (s *Service) makePeriodicFlusher() PeriodicFlusher {
 	}
 }
 
+// makeHistoryExpirer makes HistoryExpirer, or returns nil if the whole history is kept
+func (s *Service) makeHistoryExpirer() *HistoryExpirer {
+	cfg := s.store.cfg.History
+	if cfg.Epochs == 0 && cfg.Blocks == 0 {
+		return nil
+	}
+	if cfg.Epochs != 0 && cfg.Epochs < minHistoryEpochs {
+		s.Log.Warn("Sanitizing number of kept history epochs", "provided", cfg.Epochs, "updated", minHistoryEpochs)
+		cfg.Epochs = minHistoryEpochs
+	}
+	if cfg.Blocks != 0 && cfg.Blocks < minHistoryBlocks {
+		s.Log.Warn("Sanitizing number of kept history blocks", "provided", cfg.Blocks, "updated", minHistoryBlocks)
+		cfg.Blocks = minHistoryBlocks
+	}
+	if cfg.Interval <= 0 {
+		s.Log.Warn("Sanitizing history expiry interval", "provided", cfg.Interval, "updated", time.Minute)
+		cfg.Interval = time.Minute
+	}
+	return &HistoryExpirer{
+		period: cfg.Interval,
+		// expired records are never written again, so the expiry doesn't block the events processing
+		expire: func() bool {
+			return s.store.ExpireHistory(cfg, historyExpiryBatch)
+		},
+		quit: make(chan struct{}),
+	}
+}
+
 func (s *Service) EmitterWorld(signer valkeystore.SignerI) emitter.World {
 	return emitter.World{
 		External: &emitterWorld{
@@ -414,6 +455,16 @@ func (s *Service) APIs() []rpc.API {
 			Version:   "1.0",
 			Service:   filters.NewPublicFilterAPI(s.EthAPI, s.config.FilterAPI),
 			Public:    true,
+		}, {
+			Namespace: "art",
+			Version:   "1.0",
+			Service:   filters.NewPublicSubscriptionEventsAPI(s.EthAPI),
+			Public:    true,
+		}, {
+			Namespace: "art",
+			Version:   "1.0",
+			Service:   filters.NewPublicLogsAPI(s.EthAPI, s.config.FilterAPI),
+			Public:    true,
 		}, {
 			Namespace: "eth",
 			Version:   "1.0",
@@ -439,7 +490,7 @@ func (s *Service) Start() error {
 	if s.store.evm.IsEvmSnapshotPaused() && !s.config.AllowSnapsync {
 		return errors.New("cannot halt snapsync and start fullsync")
 	}
-	s.RecoverEVM()
+	flushed := s.RecoverEVM()
 	root := s.store.GetBlockState().FinalizedStateRoot
 	if !s.store.evm.HasStateDB(root) {
 		if !s.config.AllowSnapsync {
@@ -448,6 +499,12 @@ func (s *Service) Start() error {
 		root = hash.Zero
 	}
 	_ = s.store.GenerateSnapshotAt(common.Hash(root), true)
+	// start online state pruning (if enabled)
+	s.store.evm.StartPruning(s.store.GetLatestStateRoots, s.store.GetGenesisStateRoot(), common.Hash(flushed))
+	// start history expiry (if enabled)
+	if s.historyExpirer != nil {
+		s.historyExpirer.Start()
+	}
 
 	// start blocks processor
 	s.blockProcTasks.Start(1)
@@ -492,6 +549,10 @@ func (s *Service) Stop() error {
 	s.feed.scope.Close()
 	s.eventMux.Stop()
 	s.gpo.Stop()
+	s.store.evm.StopPruning()
+	if s.historyExpirer != nil {
+		s.historyExpirer.Stop()
+	}
 	// it's safe to stop tflusher only before locking engineMu
 	s.tflusher.Stop()
 
@@ -65,6 +65,13 @@ type Store struct {
 		LlrEpochVoteIndex  kvdb.Store `table:"I"`
 		LlrLastBlockVotes  kvdb.Store `table:"G"`
 		LlrLastEpochVote   kvdb.Store `table:"F"`
+
+		// Retained history window
+		History kvdb.Store `table:"H"`
+
+		// Validator analytics
+		ValidatorMetrics     kvdb.Store `table:"M"`
+		MisbehaviourEvidence kvdb.Store `table:"Y"`
 	}
 
 	prevFlushTime time.Time
@@ -88,6 +95,7 @@ type Store struct {
 		KvdbEvmSnap            atomic.Value // store by pointer
 		UpgradeHeights         atomic.Value // store by pointer
 		Genesis                atomic.Value // store by value
+		HistoryWindow          atomic.Value // store by value
 		LlrBlockVotesIndex     *VotesCache  // store by pointer
 		LlrEpochVoteIndex      *VotesCache  // store by pointer
 	}
@@ -127,6 +135,9 @@ func NewStore(dbs kvdb.FlushableDBProducer, cfg StoreConfig) *Store {
 
 	s.initCache()
 	s.evm = evmstore.NewStore(dbs, cfg.EVM)
+	if cfg.TraceTransactions {
+		s.txtrace = txtrace.NewStore(s.table.TransactionTraces)
+	}
 
 	if err := s.migrateData(); err != nil {
 		s.Log.Crit("Failed to migrate Gossip DB", "err", err)
@@ -5,6 +5,7 @@ import (
 
 	"github.com/artheranet/lachesis/hash"
 	"github.com/artheranet/lachesis/inter/idx"
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/rlp"
@@ -78,6 +79,37 @@ func (s *Store) GetBlock(n idx.Block) *inter.Block {
 	return block
 }
 
+// GetLatestStateRoots returns at most n distinct state roots of the latest blocks, starting from the newest one.
+// Consecutive blocks with the same state root are counted once.
+func (s *Store) GetLatestStateRoots(n uint64) []common.Hash {
+	roots := make([]common.Hash, 0, n)
+	for i := s.GetLatestBlockIndex(); i > 0 && uint64(len(roots)) < n; i-- {
+		block := s.GetBlock(i)
+		if block == nil {
+			break
+		}
+		root := common.Hash(block.Root)
+		if len(roots) != 0 && roots[len(roots)-1] == root {
+			continue
+		}
+		roots = append(roots, root)
+	}
+	return roots
+}
+
+// GetGenesisStateRoot returns the state root of the genesis block, zero if genesis isn't applied
+func (s *Store) GetGenesisStateRoot() common.Hash {
+	n := s.GetGenesisBlockIndex()
+	if n == nil {
+		return common.Hash{}
+	}
+	block := s.GetBlock(*n)
+	if block == nil {
+		return common.Hash{}
+	}
+	return common.Hash(block.Root)
+}
+
 func (s *Store) HasBlock(n idx.Block) bool {
 	has, _ := s.table.Blocks.Has(n.Bytes())
 	return has
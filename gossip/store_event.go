@@ -110,6 +110,14 @@ func (s *Store) ForEachEpochEvent(epoch idx.Epoch, onEvent func(event *inter.Eve
 	s.forEachEvent(it, onEvent)
 }
 
+// ForEachEpochEventFrom iterates the events of the epoch in the lamport order.
+// The start is the key suffix following the epoch prefix, i.e. the lamport and the hash of the first event.
+func (s *Store) ForEachEpochEventFrom(epoch idx.Epoch, start []byte, onEvent func(event *inter.EventPayload) bool) {
+	it := s.table.Events.NewIterator(epoch.Bytes(), start)
+	defer it.Release()
+	s.forEachEvent(it, onEvent)
+}
+
 func (s *Store) ForEachEvent(start idx.Epoch, onEvent func(event *inter.EventPayload) bool) {
 	it := s.table.Events.NewIterator(nil, start.Bytes())
 	defer it.Release()
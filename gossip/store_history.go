@@ -0,0 +1,138 @@
+package gossip
+
+import (
+	"github.com/artheranet/lachesis/hash"
+	"github.com/artheranet/lachesis/inter/idx"
+	"github.com/artheranet/lachesis/kvdb"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"github.com/artheranet/arthera-node/internal/inter"
+)
+
+const (
+	// minHistoryEpochs is the minimum number of kept epochs, the current and the latest sealed epochs are always kept
+	minHistoryEpochs = 2
+	// minHistoryBlocks is the minimum number of blocks with kept receipts
+	minHistoryBlocks = 1024
+)
+
+// HistoryWindow is the range of the retained history
+type HistoryWindow struct {
+	// FirstEpoch is the first epoch with retained events
+	FirstEpoch idx.Epoch
+	// FirstBlock is the first block with retained receipts and tx lookups
+	FirstBlock idx.Block
+}
+
+// GetHistoryWindow returns the range of the retained history. The history below it is either deleted or being deleted.
+func (s *Store) GetHistoryWindow() HistoryWindow {
+	if v := s.cache.HistoryWindow.Load(); v != nil {
+		return v.(HistoryWindow)
+	}
+	w, _ := s.rlp.Get(s.table.History, []byte("w"), &HistoryWindow{}).(*HistoryWindow)
+	if w == nil {
+		w = &HistoryWindow{}
+	}
+	s.cache.HistoryWindow.Store(*w)
+	return *w
+}
+
+func (s *Store) setHistoryWindow(w HistoryWindow) {
+	s.rlp.Set(s.table.History, []byte("w"), &w)
+	s.cache.HistoryWindow.Store(w)
+}
+
+// IsEpochPruned returns true if the events of the epoch are expired
+func (s *Store) IsEpochPruned(epoch idx.Epoch) bool {
+	return epoch < s.GetHistoryWindow().FirstEpoch
+}
+
+// IsBlockPruned returns true if the receipts and the tx lookups of the block are expired
+func (s *Store) IsBlockPruned(n idx.Block) bool {
+	return n < s.GetHistoryWindow().FirstBlock
+}
+
+// IsBlockTxsPruned returns true if the block transactions cannot be read because the block events are expired
+func (s *Store) IsBlockTxsPruned(block *inter.Block) bool {
+	return len(block.Events) != 0 && s.IsEpochPruned(block.Atropos.Epoch())
+}
+
+// advanceHistoryWindow moves the retained history window forward according to the retention policy
+func (s *Store) advanceHistoryWindow(cfg HistoryConfig) HistoryWindow {
+	w := s.GetHistoryWindow()
+	target := w
+	if epoch := s.GetEpoch(); cfg.Epochs != 0 && epoch > cfg.Epochs {
+		if first := epoch - cfg.Epochs + 1; first > target.FirstEpoch {
+			if bs, _ := s.GetHistoryBlockEpochState(first); bs != nil {
+				target.FirstEpoch = first
+				// receipts cannot be read without the block transactions, which are found through the events
+				if firstBlock := bs.LastBlock.Idx + 1; firstBlock > target.FirstBlock {
+					target.FirstBlock = firstBlock
+				}
+			}
+		}
+	}
+	if latest := s.GetLatestBlockIndex(); cfg.Blocks != 0 && latest > cfg.Blocks {
+		if first := latest - cfg.Blocks + 1; first > target.FirstBlock {
+			target.FirstBlock = first
+		}
+	}
+	if target != w {
+		s.setHistoryWindow(target)
+		s.Log.Debug("Advanced history window", "epoch", target.FirstEpoch, "block", target.FirstBlock)
+	}
+	return target
+}
+
+// expireByEpoch deletes up to limit oldest records of the epoch-prefixed table which belong to the epochs below the given one.
+// It returns the number of deleted records.
+func expireByEpoch(t kvdb.Store, before idx.Epoch, limit int, del func(key []byte)) int {
+	keys := make([][]byte, 0, limit)
+	it := t.NewIterator(nil, nil)
+	for len(keys) < limit && it.Next() {
+		if idx.BytesToEpoch(it.Key()[:4]) >= before {
+			break
+		}
+		keys = append(keys, common.CopyBytes(it.Key()))
+	}
+	it.Release()
+
+	for _, key := range keys {
+		del(key)
+	}
+	return len(keys)
+}
+
+// ExpireHistory advances the retained history window according to the retention policy
+// and deletes up to limit records of the expired history.
+// It returns true if there may be more expired history to delete.
+func (s *Store) ExpireHistory(cfg HistoryConfig, limit int) bool {
+	w := s.advanceHistoryWindow(cfg)
+
+	// receipts are expired first, because the events are required to find the tx positions
+	expired := s.evm.ExpireReceipts(w.FirstBlock, limit, func(n idx.Block) types.Transactions {
+		block := s.GetBlock(n)
+		if block == nil {
+			return nil
+		}
+		return s.GetBlockTxs(n, block)
+	})
+	if expired == limit {
+		return true
+	}
+	if s.evm.ExpireLogs(w.FirstBlock, limit) == limit {
+		return true
+	}
+
+	expired = expireByEpoch(s.table.Events, w.FirstEpoch, limit, func(key []byte) {
+		s.DelEvent(hash.BytesToEvent(key))
+	})
+	// epoch votes are small and are kept to serve the epoch packs
+	expired += expireByEpoch(s.table.LlrBlockVotes, w.FirstEpoch, limit-expired, func(key []byte) {
+		if err := s.table.LlrBlockVotes.Delete(key); err != nil {
+			s.Log.Crit("Failed to delete key", "err", err)
+		}
+	})
+	return expired == limit
+}
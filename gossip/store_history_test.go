@@ -0,0 +1,115 @@
+package gossip
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/artheranet/lachesis/hash"
+	"github.com/artheranet/lachesis/inter/idx"
+	"github.com/artheranet/lachesis/inter/pos"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/require"
+
+	"github.com/artheranet/arthera-node/gossip/evmstore"
+	"github.com/artheranet/arthera-node/internal/inter"
+	"github.com/artheranet/arthera-node/internal/inter/iblockproc"
+	"github.com/artheranet/arthera-node/params"
+)
+
+func TestStoreExpireHistory(t *testing.T) {
+	require := require.New(t)
+	const (
+		epochs         = 5
+		blocksPerEpoch = 10
+	)
+	store := NewMemStore()
+
+	vv := pos.NewBuilder()
+	vv.Set(1, 1)
+	epochState := func(epoch idx.Epoch) iblockproc.EpochState {
+		return iblockproc.EpochState{
+			Epoch:      epoch,
+			Validators: vv.Build(),
+			Rules:      params.FakeNetRules(),
+		}
+	}
+	blockState := func(last idx.Block) iblockproc.BlockState {
+		return iblockproc.BlockState{LastBlock: iblockproc.BlockCtx{Idx: last}}
+	}
+
+	var (
+		events = make(map[idx.Block]hash.Event)
+		txs    = make(map[idx.Block]common.Hash)
+		votes  = make(map[idx.Block]inter.LlrSignedBlockVotes)
+	)
+	for epoch := idx.Epoch(1); epoch <= epochs; epoch++ {
+		store.SetHistoryBlockEpochState(epoch, blockState(idx.Block(epoch-1)*blocksPerEpoch), epochState(epoch))
+		for i := idx.Block(1); i <= blocksPerEpoch; i++ {
+			n := idx.Block(epoch-1)*blocksPerEpoch + i
+			tx := types.NewTransaction(uint64(n), common.Address{1}, big.NewInt(0), 21000, big.NewInt(0), nil)
+
+			me := &inter.MutableEventPayload{}
+			me.SetVersion(1)
+			me.SetEpoch(epoch)
+			me.SetLamport(idx.Lamport(n))
+			me.SetCreator(1)
+			me.SetTxs(types.Transactions{tx})
+			me.SetPayloadHash(inter.CalcPayloadHash(me))
+			e := me.Build()
+			store.SetEvent(e)
+			store.SetBlock(n, &inter.Block{
+				Atropos: e.ID(),
+				Events:  hash.Events{e.ID()},
+			})
+			store.evm.SetReceipts(n, types.Receipts{{Status: types.ReceiptStatusSuccessful, Logs: []*types.Log{}}})
+			store.evm.SetTxPosition(tx.Hash(), evmstore.TxPosition{Block: n, Event: e.ID()})
+			require.NoError(store.evm.EvmLogs.Push(&types.Log{
+				Address:     common.Address{1},
+				Topics:      []common.Hash{{byte(n)}},
+				BlockNumber: uint64(n),
+				TxHash:      tx.Hash(),
+			}))
+
+			bvs := inter.LlrSignedBlockVotes{
+				Signed: inter.SignedEventLocator{Locator: inter.EventLocator{Epoch: epoch, Lamport: idx.Lamport(n), Creator: 1}},
+				Val:    inter.LlrBlockVotes{Start: n, Epoch: epoch, Votes: []hash.Hash{{1}}},
+			}
+			store.SetBlockVotes(bvs)
+
+			events[n], txs[n], votes[n] = e.ID(), tx.Hash(), bvs
+		}
+	}
+	store.SetBlockEpochState(blockState(epochs*blocksPerEpoch), epochState(epochs))
+
+	// nothing is expired by default
+	require.False(store.ExpireHistory(HistoryConfig{}, 7))
+	require.Equal(HistoryWindow{}, store.GetHistoryWindow())
+
+	cfg := HistoryConfig{Epochs: 2, Blocks: 10}
+	for store.ExpireHistory(cfg, 7) {
+	}
+	w := store.GetHistoryWindow()
+	require.Equal(HistoryWindow{FirstEpoch: 4, FirstBlock: 41}, w)
+
+	for n := idx.Block(1); n <= epochs*blocksPerEpoch; n++ {
+		block := store.GetBlock(n)
+		epochKept := block.Atropos.Epoch() >= w.FirstEpoch
+		blockKept := n >= w.FirstBlock
+
+		require.Equal(!epochKept, store.IsBlockTxsPruned(block), n)
+		require.Equal(epochKept, store.GetEventPayload(events[n]) != nil, n)
+		bvs := votes[n]
+		require.Equal(epochKept, store.HasBlockVotes(bvs.Val.Epoch, bvs.Val.LastBlock(), bvs.Signed.Locator.ID()), n)
+
+		require.Equal(!blockKept, store.IsBlockPruned(n), n)
+		receipts, _ := store.evm.GetRawReceipts(n)
+		require.Equal(blockKept, receipts != nil, n)
+		require.Equal(blockKept, store.evm.GetTxPosition(txs[n]) != nil, n)
+		require.Equal(blockKept, store.GetFullBlockRecord(n) != nil, n)
+		logs, err := store.evm.EvmLogs.FindInBlocks(context.Background(), n, n, [][]common.Hash{{}, {{byte(n)}}})
+		require.NoError(err)
+		require.Equal(blockKept, len(logs) == 1, n)
+	}
+}
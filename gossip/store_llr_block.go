@@ -117,6 +117,9 @@ func (s *Store) GetLlrBlockResult(block idx.Block) *hash.Hash {
 }
 
 func (s *Store) GetFullBlockRecord(n idx.Block) *ibr.LlrFullBlockRecord {
+	if s.IsBlockPruned(n) {
+		return nil
+	}
 	block := s.GetBlock(n)
 	if block == nil {
 		return nil
@@ -182,6 +185,10 @@ type LlrIdxFullBlockRecordRLP struct {
 var emptyReceiptsRLP, _ = rlp.EncodeToBytes([]*types.ReceiptForStorage{})
 
 func (s *Store) IterateFullBlockRecordsRLP(start idx.Block, f func(b idx.Block, br rlp.RawValue) bool) {
+	if s.IsBlockPruned(start) {
+		// the records are expired
+		return
+	}
 	it := s.table.Blocks.NewIterator(nil, start.Bytes())
 	defer it.Release()
 	for it.Next() {
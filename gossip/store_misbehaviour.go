@@ -0,0 +1,41 @@
+package gossip
+
+import (
+	"github.com/artheranet/lachesis/inter/idx"
+	"github.com/ethereum/go-ethereum/rlp"
+
+	"github.com/artheranet/arthera-node/internal/inter"
+)
+
+// misbehaviourEvidenceKey is epoch+event+type+block+offender,
+// an event may carry several proofs of the same type against the same offender for different blocks
+func misbehaviourEvidenceKey(epoch idx.Epoch, ev *inter.MisbehaviourEvidence) []byte {
+	key := make([]byte, 0, 4+32+1+8+4)
+	key = append(key, epoch.Bytes()...)
+	key = append(key, ev.Event.Bytes()...)
+	key = append(key, byte(ev.Type))
+	key = append(key, ev.Block.Bytes()...)
+	return append(key, ev.Offender.Bytes()...)
+}
+
+// SetMisbehaviourEvidence stores the evidence of misbehaviour proofs applied in the epoch.
+func (s *Store) SetMisbehaviourEvidence(epoch idx.Epoch, evidence []inter.MisbehaviourEvidence) {
+	for i := range evidence {
+		s.rlp.Set(s.table.MisbehaviourEvidence, misbehaviourEvidenceKey(epoch, &evidence[i]), &evidence[i])
+	}
+}
+
+// ForEachMisbehaviourEvidence iterates over the evidence of misbehaviour proofs applied in the epoch.
+func (s *Store) ForEachMisbehaviourEvidence(epoch idx.Epoch, onEvidence func(inter.MisbehaviourEvidence) bool) {
+	it := s.table.MisbehaviourEvidence.NewIterator(epoch.Bytes(), nil)
+	defer it.Release()
+	for it.Next() {
+		var ev inter.MisbehaviourEvidence
+		if err := rlp.DecodeBytes(it.Value(), &ev); err != nil {
+			s.Log.Crit("Failed to decode misbehaviour evidence", "err", err)
+		}
+		if !onEvidence(ev) {
+			break
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package gossip
+
+import (
+	"testing"
+
+	"github.com/artheranet/lachesis/hash"
+	"github.com/artheranet/lachesis/inter/idx"
+	"github.com/stretchr/testify/require"
+
+	"github.com/artheranet/arthera-node/internal/inter"
+)
+
+func TestStoreMisbehaviourEvidence(t *testing.T) {
+	require := require.New(t)
+	store := NewMemStore()
+	defer store.Close()
+
+	event := hash.Event{1}
+	evidence := []inter.MisbehaviourEvidence{
+		// the proofs of the same event against the same offender for different blocks
+		{Type: inter.WrongBlockVoteType, Reporter: 1, Offender: 2, Epoch: 3, Block: 10, Event: event, AppliedBlock: 20},
+		{Type: inter.WrongBlockVoteType, Reporter: 1, Offender: 2, Epoch: 3, Block: 11, Event: event, AppliedBlock: 20},
+		{Type: inter.BlockVoteDoublesignType, Reporter: 1, Offender: 2, Epoch: 3, Block: 10, Event: event, AppliedBlock: 20},
+		{Type: inter.WrongBlockVoteType, Reporter: 1, Offender: 3, Epoch: 3, Block: 10, Event: event, AppliedBlock: 20},
+	}
+	store.SetMisbehaviourEvidence(4, evidence)
+	store.SetMisbehaviourEvidence(5, evidence[:1])
+
+	var got []inter.MisbehaviourEvidence
+	store.ForEachMisbehaviourEvidence(4, func(ev inter.MisbehaviourEvidence) bool {
+		got = append(got, ev)
+		return true
+	})
+	require.ElementsMatch(evidence, got)
+
+	got = nil
+	store.ForEachMisbehaviourEvidence(5, func(ev inter.MisbehaviourEvidence) bool {
+		got = append(got, ev)
+		return true
+	})
+	require.Equal(evidence[:1], got)
+
+	store.ForEachMisbehaviourEvidence(idx.Epoch(6), func(ev inter.MisbehaviourEvidence) bool {
+		require.Fail("unexpected evidence", ev)
+		return true
+	})
+}
@@ -0,0 +1,92 @@
+package gossip
+
+import (
+	"math/big"
+
+	"github.com/artheranet/lachesis/inter/idx"
+	"github.com/ethereum/go-ethereum/rlp"
+
+	"github.com/artheranet/arthera-node/internal/inter"
+	"github.com/artheranet/arthera-node/internal/inter/iblockproc"
+)
+
+func validatorMetricsKey(epoch idx.Epoch, vid idx.ValidatorID) []byte {
+	return append(epoch.Bytes(), vid.Bytes()...)
+}
+
+// GetValidatorEpochMetrics returns the metrics of the validator in the epoch, or nil if none are recorded.
+func (s *Store) GetValidatorEpochMetrics(epoch idx.Epoch, vid idx.ValidatorID) *iblockproc.ValidatorEpochMetrics {
+	m, _ := s.rlp.Get(s.table.ValidatorMetrics, validatorMetricsKey(epoch, vid), &iblockproc.ValidatorEpochMetrics{}).(*iblockproc.ValidatorEpochMetrics)
+	return m
+}
+
+// SetValidatorEpochMetrics stores the metrics of the validator in the epoch.
+func (s *Store) SetValidatorEpochMetrics(epoch idx.Epoch, vid idx.ValidatorID, m *iblockproc.ValidatorEpochMetrics) {
+	s.rlp.Set(s.table.ValidatorMetrics, validatorMetricsKey(epoch, vid), m)
+}
+
+// addValidatorEpochCounters merges the counters collected in a block into the epoch metrics.
+func (s *Store) addValidatorEpochCounters(epoch idx.Epoch, counters map[idx.ValidatorID]*iblockproc.ValidatorEpochMetrics) {
+	for vid, c := range counters {
+		m := s.GetValidatorEpochMetrics(epoch, vid)
+		if m == nil {
+			m = &iblockproc.ValidatorEpochMetrics{}
+		}
+		m.Events += c.Events
+		m.BlockVotes += c.BlockVotes
+		m.EpochVotes += c.EpochVotes
+		m.MisbehaviourProofs += c.MisbehaviourProofs
+		s.SetValidatorEpochMetrics(epoch, vid, m)
+	}
+}
+
+// sealValidatorEpochMetrics finalizes the metrics of the epoch validators.
+// Downtime and uptime are calculated the same way as they are reported to the driver contract.
+func (s *Store) sealValidatorEpochMetrics(block iblockproc.BlockCtx, bs iblockproc.BlockState, es iblockproc.EpochState) {
+	cheaters := make(map[idx.ValidatorID]bool, len(bs.EpochCheaters))
+	for _, vid := range bs.EpochCheaters {
+		cheaters[vid] = true
+	}
+	for i := idx.Validator(0); i < es.Validators.Len(); i++ {
+		vid := es.Validators.GetID(i)
+		info := bs.ValidatorStates[i]
+		m := s.GetValidatorEpochMetrics(es.Epoch, vid)
+		if m == nil {
+			m = &iblockproc.ValidatorEpochMetrics{}
+		}
+		m.Sealed = true
+		m.Weight = new(big.Int).Set(es.ValidatorProfiles[vid].Weight)
+		m.MissedBlocks = 0
+		if block.Idx > info.LastBlock {
+			m.MissedBlocks = block.Idx - info.LastBlock
+		}
+		m.MissedTime = inter.MaxTimestamp(block.Time, info.LastOnlineTime) - info.LastOnlineTime
+		m.Uptime = info.Uptime
+		m.DowntimeForgiven = m.MissedBlocks <= es.Rules.Economy.BlockMissedSlack
+		if m.DowntimeForgiven {
+			prevOnlineTime := inter.MaxTimestamp(info.LastOnlineTime, es.EpochStart)
+			m.Uptime += inter.MaxTimestamp(block.Time, prevOnlineTime) - prevOnlineTime
+		}
+		m.OriginatedFee = new(big.Int)
+		if info.Originated != nil {
+			m.OriginatedFee.Set(info.Originated)
+		}
+		m.Cheater = cheaters[vid]
+		s.SetValidatorEpochMetrics(es.Epoch, vid, m)
+	}
+}
+
+// ForEachValidatorEpochMetrics iterates over the metrics of all validators recorded in the epoch.
+func (s *Store) ForEachValidatorEpochMetrics(epoch idx.Epoch, onMetrics func(idx.ValidatorID, *iblockproc.ValidatorEpochMetrics) bool) {
+	it := s.table.ValidatorMetrics.NewIterator(epoch.Bytes(), nil)
+	defer it.Release()
+	for it.Next() {
+		m := &iblockproc.ValidatorEpochMetrics{}
+		if err := rlp.DecodeBytes(it.Value(), m); err != nil {
+			s.Log.Crit("Failed to decode validator metrics", "err", err)
+		}
+		if !onMetrics(idx.BytesToValidatorID(it.Key()[4:]), m) {
+			break
+		}
+	}
+}
@@ -0,0 +1,246 @@
+package txtrace
+
+import (
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// ActionTrace is a single flat call trace in the Parity/OpenEthereum format
+type ActionTrace struct {
+	Action              TraceAction  `json:"action"`
+	BlockHash           common.Hash  `json:"blockHash"`
+	BlockNumber         uint64       `json:"blockNumber"`
+	Result              *TraceResult `json:"result,omitempty"`
+	Error               string       `json:"error,omitempty"`
+	Subtraces           uint64       `json:"subtraces"`
+	TraceAddress        []uint64     `json:"traceAddress"`
+	TransactionHash     common.Hash  `json:"transactionHash"`
+	TransactionPosition uint64       `json:"transactionPosition"`
+	Type                string       `json:"type"`
+}
+
+// TraceAction is the input of a traced call, contract creation or self-destruct
+type TraceAction struct {
+	CallType      string          `json:"callType,omitempty"`
+	From          *common.Address `json:"from,omitempty"`
+	To            *common.Address `json:"to,omitempty"`
+	Gas           hexutil.Uint64  `json:"gas"`
+	Input         hexutil.Bytes   `json:"input,omitempty"`
+	Init          hexutil.Bytes   `json:"init,omitempty"`
+	Value         *hexutil.Big    `json:"value,omitempty"`
+	Address       *common.Address `json:"address,omitempty"`
+	RefundAddress *common.Address `json:"refundAddress,omitempty"`
+	Balance       *hexutil.Big    `json:"balance,omitempty"`
+}
+
+// TraceResult is the output of a traced call or contract creation
+type TraceResult struct {
+	GasUsed hexutil.Uint64  `json:"gasUsed"`
+	Output  hexutil.Bytes   `json:"output,omitempty"`
+	Address *common.Address `json:"address,omitempty"`
+	Code    hexutil.Bytes   `json:"code,omitempty"`
+}
+
+// Sender returns the address which initiated the action.
+func (t *ActionTrace) Sender() common.Address {
+	if t.Action.From != nil {
+		return *t.Action.From
+	}
+	if t.Action.Address != nil {
+		return *t.Action.Address
+	}
+	return common.Address{}
+}
+
+// Receiver returns the address targeted by the action.
+func (t *ActionTrace) Receiver() common.Address {
+	if t.Action.To != nil {
+		return *t.Action.To
+	}
+	if t.Action.RefundAddress != nil {
+		return *t.Action.RefundAddress
+	}
+	if t.Result != nil && t.Result.Address != nil {
+		return *t.Result.Address
+	}
+	return common.Address{}
+}
+
+type txTraces struct {
+	receipt *types.Receipt
+	traces  []ActionTrace
+}
+
+// CallTracer is an EVM logger which records flat call traces of the applied transactions
+// and writes them into the Store.
+type CallTracer struct {
+	store *Store
+
+	traces []ActionTrace
+	stack  []int // indexes of the not finished traces
+
+	pending []txTraces
+}
+
+// NewCallTracer creates a call tracer writing into the store.
+func NewCallTracer(store *Store) *CallTracer {
+	return &CallTracer{
+		store: store,
+	}
+}
+
+// StartTx is called before a transaction is applied.
+func (t *CallTracer) StartTx(tx *types.Transaction) {
+	t.traces = nil
+	t.stack = t.stack[:0]
+}
+
+// FinishTx is called after a transaction is applied. Traces of skipped transactions are dropped.
+func (t *CallTracer) FinishTx(receipt *types.Receipt, err error) {
+	if err == nil && receipt != nil && len(t.traces) != 0 {
+		t.pending = append(t.pending, txTraces{
+			receipt: receipt,
+			traces:  t.traces,
+		})
+	}
+	t.traces = nil
+	t.stack = t.stack[:0]
+}
+
+// Flush writes the traces of the finished transactions into the store.
+// Block position fields are taken from the receipts, so it must be called once receipts are finalized.
+func (t *CallTracer) Flush() {
+	for _, p := range t.pending {
+		for i := range p.traces {
+			p.traces[i].BlockHash = p.receipt.BlockHash
+			if p.receipt.BlockNumber != nil {
+				p.traces[i].BlockNumber = p.receipt.BlockNumber.Uint64()
+			}
+			p.traces[i].TransactionHash = p.receipt.TxHash
+			p.traces[i].TransactionPosition = uint64(p.receipt.TransactionIndex)
+		}
+		if err := t.store.SetTxTraces(p.receipt.TxHash, p.traces); err != nil {
+			log.Error("Failed to store tx traces", "tx", p.receipt.TxHash, "err", err)
+		}
+	}
+	t.pending = nil
+}
+
+func (t *CallTracer) push(trace ActionTrace) {
+	if len(t.stack) != 0 {
+		parent := &t.traces[t.stack[len(t.stack)-1]]
+		trace.TraceAddress = append(append(make([]uint64, 0, len(parent.TraceAddress)+1), parent.TraceAddress...), parent.Subtraces)
+		parent.Subtraces++
+	} else {
+		trace.TraceAddress = []uint64{}
+	}
+	t.stack = append(t.stack, len(t.traces))
+	t.traces = append(t.traces, trace)
+}
+
+func (t *CallTracer) pop(output []byte, gasUsed uint64, err error) {
+	if len(t.stack) == 0 {
+		return
+	}
+	trace := &t.traces[t.stack[len(t.stack)-1]]
+	t.stack = t.stack[:len(t.stack)-1]
+
+	if trace.Type == "suicide" {
+		return
+	}
+	if err != nil {
+		trace.Error = err.Error()
+		if err == vm.ErrExecutionReverted {
+			trace.Error = "Reverted"
+		}
+		trace.Result = nil
+		return
+	}
+	trace.Result = &TraceResult{
+		GasUsed: hexutil.Uint64(gasUsed),
+	}
+	if trace.Type == "create" {
+		trace.Result.Code = common.CopyBytes(output)
+		trace.Result.Address = trace.Action.To
+		trace.Action.To = nil
+	} else {
+		trace.Result.Output = common.CopyBytes(output)
+	}
+}
+
+func newActionTrace(typ vm.OpCode, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) ActionTrace {
+	fromCopy, toCopy := from, to
+	trace := ActionTrace{
+		Action: TraceAction{
+			From:  &fromCopy,
+			To:    &toCopy,
+			Gas:   hexutil.Uint64(gas),
+			Value: (*hexutil.Big)(new(big.Int)),
+		},
+	}
+	if value != nil {
+		trace.Action.Value = (*hexutil.Big)(new(big.Int).Set(value))
+	}
+	switch typ {
+	case vm.CREATE, vm.CREATE2:
+		trace.Type = "create"
+		trace.Action.Init = common.CopyBytes(input)
+	case vm.SELFDESTRUCT:
+		trace.Type = "suicide"
+		trace.Action = TraceAction{
+			Address:       &fromCopy,
+			RefundAddress: &toCopy,
+			Balance:       trace.Action.Value,
+		}
+	default:
+		trace.Type = "call"
+		trace.Action.CallType = strings.ToLower(typ.String())
+		trace.Action.Input = common.CopyBytes(input)
+	}
+	return trace
+}
+
+// CaptureTxStart implements the EVMLogger interface.
+func (t *CallTracer) CaptureTxStart(gasLimit uint64) {}
+
+// CaptureTxEnd implements the EVMLogger interface.
+func (t *CallTracer) CaptureTxEnd(restGas uint64) {}
+
+// CaptureStart implements the EVMLogger interface to initialize the tracing operation.
+func (t *CallTracer) CaptureStart(env *vm.EVM, from common.Address, to common.Address, create bool, input []byte, gas uint64, value *big.Int) {
+	typ := vm.CALL
+	if create {
+		typ = vm.CREATE
+	}
+	t.push(newActionTrace(typ, from, to, input, gas, value))
+}
+
+// CaptureEnd is called after the call finishes to finalize the tracing.
+func (t *CallTracer) CaptureEnd(output []byte, gasUsed uint64, err error) {
+	t.pop(output, gasUsed, err)
+}
+
+// CaptureEnter is called when EVM enters a new scope (via call, create or selfdestruct).
+func (t *CallTracer) CaptureEnter(typ vm.OpCode, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) {
+	t.push(newActionTrace(typ, from, to, input, gas, value))
+}
+
+// CaptureExit is called when EVM exits a scope, even if the scope didn't
+// execute any code.
+func (t *CallTracer) CaptureExit(output []byte, gasUsed uint64, err error) {
+	t.pop(output, gasUsed, err)
+}
+
+// CaptureState implements the EVMLogger interface to trace a single step of VM execution.
+func (t *CallTracer) CaptureState(pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, rData []byte, depth int, err error) {
+}
+
+// CaptureFault implements the EVMLogger interface to trace an execution fault.
+func (t *CallTracer) CaptureFault(pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, depth int, err error) {
+}
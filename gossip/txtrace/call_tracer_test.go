@@ -0,0 +1,189 @@
+package txtrace
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/artheranet/lachesis/kvdb/memorydb"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	testSender   = common.HexToAddress("0x1000000000000000000000000000000000000001")
+	testContract = common.HexToAddress("0x2000000000000000000000000000000000000002")
+	testCallee   = common.HexToAddress("0x3000000000000000000000000000000000000003")
+	testCreated  = common.HexToAddress("0x4000000000000000000000000000000000000004")
+)
+
+func testReceipt(tx *types.Transaction, pos uint) *types.Receipt {
+	return &types.Receipt{
+		TxHash:           tx.Hash(),
+		BlockHash:        common.HexToHash("0xb1"),
+		BlockNumber:      big.NewInt(7),
+		TransactionIndex: pos,
+	}
+}
+
+func TestCallTracerCallTree(t *testing.T) {
+	require := require.New(t)
+	store := NewStore(memorydb.New())
+	tracer := NewCallTracer(store)
+	tx := types.NewTransaction(0, testContract, big.NewInt(1), 100000, big.NewInt(1), []byte{1})
+
+	// sender -> contract -> (callee static call, create, callee delegate call -> self-destruct)
+	tracer.StartTx(tx)
+	tracer.CaptureTxStart(100000)
+	tracer.CaptureStart(nil, testSender, testContract, false, []byte{1}, 79000, big.NewInt(1))
+	tracer.CaptureEnter(vm.STATICCALL, testContract, testCallee, []byte{2}, 10000, nil)
+	tracer.CaptureExit([]byte{3}, 500, nil)
+	tracer.CaptureEnter(vm.CREATE2, testContract, testCreated, []byte{4}, 20000, big.NewInt(2))
+	tracer.CaptureExit([]byte{5}, 1000, nil)
+	tracer.CaptureEnter(vm.DELEGATECALL, testContract, testCallee, []byte{6}, 30000, nil)
+	tracer.CaptureEnter(vm.SELFDESTRUCT, testCallee, testSender, nil, 0, big.NewInt(3))
+	tracer.CaptureExit(nil, 0, nil)
+	tracer.CaptureExit(nil, 2000, nil)
+	tracer.CaptureEnd([]byte{7}, 5000, nil)
+	tracer.CaptureTxEnd(20000)
+	tracer.FinishTx(testReceipt(tx, 3), nil)
+
+	// nothing is written before the flush
+	traces, err := store.GetTxTraces(tx.Hash())
+	require.NoError(err)
+	require.Nil(traces)
+
+	tracer.Flush()
+	traces, err = store.GetTxTraces(tx.Hash())
+	require.NoError(err)
+	require.Len(traces, 5)
+
+	for _, trace := range traces {
+		require.Equal(common.HexToHash("0xb1"), trace.BlockHash)
+		require.Equal(uint64(7), trace.BlockNumber)
+		require.Equal(tx.Hash(), trace.TransactionHash)
+		require.Equal(uint64(3), trace.TransactionPosition)
+		require.Empty(trace.Error)
+	}
+
+	root := traces[0]
+	require.Equal("call", root.Type)
+	require.Equal("call", root.Action.CallType)
+	require.Equal([]uint64{}, root.TraceAddress)
+	require.Equal(uint64(3), root.Subtraces)
+	require.Equal(testSender, root.Sender())
+	require.Equal(testContract, root.Receiver())
+	require.Equal(uint64(1), root.Action.Value.ToInt().Uint64())
+	require.Equal(hexutil.Bytes{1}, root.Action.Input)
+	require.Equal(hexutil.Uint64(5000), root.Result.GasUsed)
+	require.Equal(hexutil.Bytes{7}, root.Result.Output)
+
+	static := traces[1]
+	require.Equal("staticcall", static.Action.CallType)
+	require.Equal([]uint64{0}, static.TraceAddress)
+	require.Zero(static.Subtraces)
+	require.Zero(static.Action.Value.ToInt().Sign())
+	require.Equal(hexutil.Bytes{3}, static.Result.Output)
+
+	create := traces[2]
+	require.Equal("create", create.Type)
+	require.Empty(create.Action.CallType)
+	require.Equal([]uint64{1}, create.TraceAddress)
+	require.Equal(hexutil.Bytes{4}, create.Action.Init)
+	require.Nil(create.Action.To)
+	require.Equal(testCreated, *create.Result.Address)
+	require.Equal(testCreated, create.Receiver())
+	require.Equal(hexutil.Bytes{5}, create.Result.Code)
+	require.Nil(create.Result.Output)
+
+	delegate := traces[3]
+	require.Equal("delegatecall", delegate.Action.CallType)
+	require.Equal([]uint64{2}, delegate.TraceAddress)
+	require.Equal(uint64(1), delegate.Subtraces)
+
+	suicide := traces[4]
+	require.Equal("suicide", suicide.Type)
+	require.Equal([]uint64{2, 0}, suicide.TraceAddress)
+	require.Nil(suicide.Result)
+	require.Equal(testCallee, suicide.Sender())
+	require.Equal(testSender, suicide.Receiver())
+	require.Equal(uint64(3), suicide.Action.Balance.ToInt().Uint64())
+
+	// the pending traces are written only once
+	require.NoError(store.RemoveTxTrace(tx.Hash()))
+	tracer.Flush()
+	traces, err = store.GetTxTraces(tx.Hash())
+	require.NoError(err)
+	require.Nil(traces)
+}
+
+func TestCallTracerErrors(t *testing.T) {
+	require := require.New(t)
+	store := NewStore(memorydb.New())
+	tracer := NewCallTracer(store)
+
+	// a reverted inner call of a transaction which fails
+	reverted := types.NewTransaction(0, testContract, nil, 100000, big.NewInt(1), nil)
+	tracer.StartTx(reverted)
+	tracer.CaptureStart(nil, testSender, testContract, false, nil, 79000, nil)
+	tracer.CaptureEnter(vm.CALL, testContract, testCallee, nil, 10000, big.NewInt(1))
+	tracer.CaptureExit([]byte{1}, 10000, vm.ErrExecutionReverted)
+	tracer.CaptureEnter(vm.CREATE, testContract, testCreated, []byte{2}, 20000, nil)
+	tracer.CaptureExit(nil, 20000, vm.ErrOutOfGas)
+	tracer.CaptureEnd(nil, 79000, vm.ErrWriteProtection)
+	tracer.FinishTx(testReceipt(reverted, 0), nil)
+
+	// a skipped transaction
+	skipped := types.NewTransaction(1, testContract, nil, 100000, big.NewInt(1), nil)
+	tracer.StartTx(skipped)
+	tracer.CaptureStart(nil, testSender, testContract, false, nil, 79000, nil)
+	tracer.CaptureEnd(nil, 0, nil)
+	tracer.FinishTx(nil, errors.New("skipped"))
+
+	// a transaction which is interrupted before the traces are finished doesn't affect the next one
+	interrupted := types.NewTransaction(2, testContract, nil, 100000, big.NewInt(1), nil)
+	tracer.StartTx(interrupted)
+	tracer.CaptureStart(nil, testSender, testContract, false, nil, 79000, nil)
+	tracer.CaptureEnter(vm.CALL, testContract, testCallee, nil, 10000, nil)
+	next := types.NewTransaction(3, testCallee, nil, 100000, big.NewInt(1), nil)
+	tracer.StartTx(next)
+	tracer.CaptureStart(nil, testSender, testCallee, false, nil, 79000, nil)
+	tracer.CaptureEnd(nil, 100, nil)
+	tracer.FinishTx(testReceipt(next, 1), nil)
+
+	tracer.Flush()
+
+	traces, err := store.GetTxTraces(reverted.Hash())
+	require.NoError(err)
+	require.Len(traces, 3)
+	require.Equal(vm.ErrWriteProtection.Error(), traces[0].Error)
+	require.Nil(traces[0].Result)
+	require.Equal(uint64(2), traces[0].Subtraces)
+	require.Equal("Reverted", traces[1].Error)
+	require.Nil(traces[1].Result)
+	require.Equal([]uint64{0}, traces[1].TraceAddress)
+	require.Equal(vm.ErrOutOfGas.Error(), traces[2].Error)
+	require.Nil(traces[2].Result)
+	require.Equal([]uint64{1}, traces[2].TraceAddress)
+	// the address of a failed contract creation isn't reported as created
+	require.Equal(testCreated, *traces[2].Action.To)
+
+	traces, err = store.GetTxTraces(skipped.Hash())
+	require.NoError(err)
+	require.Nil(traces)
+
+	traces, err = store.GetTxTraces(interrupted.Hash())
+	require.NoError(err)
+	require.Nil(traces)
+
+	traces, err = store.GetTxTraces(next.Hash())
+	require.NoError(err)
+	require.Len(traces, 1)
+	require.Equal([]uint64{}, traces[0].TraceAddress)
+	require.Zero(traces[0].Subtraces)
+	require.Equal(hexutil.Uint64(100), traces[0].Result.GasUsed)
+	require.Equal(uint64(1), traces[0].TransactionPosition)
+}
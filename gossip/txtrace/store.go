@@ -1,6 +1,8 @@
 package txtrace
 
 import (
+	"encoding/json"
+
 	"github.com/artheranet/arthera-node/logger"
 	"github.com/artheranet/lachesis/kvdb"
 	"github.com/ethereum/go-ethereum/common"
@@ -64,3 +66,23 @@ func (s *Store) ForEachTxtrace(onEvent func(key common.Hash, traces []byte) bool
 		}
 	}
 }
+
+// SetTxTraces stores JSON representation of the transaction call traces.
+func (s *Store) SetTxTraces(txID common.Hash, traces []ActionTrace) error {
+	buf, err := json.Marshal(traces)
+	if err != nil {
+		return err
+	}
+	return s.SetTxTrace(txID, buf)
+}
+
+// GetTxTraces returns decoded transaction call traces, or nil if not found.
+func (s *Store) GetTxTraces(txID common.Hash) ([]ActionTrace, error) {
+	buf := s.GetTx(txID)
+	if buf == nil {
+		return nil, nil
+	}
+	var traces []ActionTrace
+	err := json.Unmarshal(buf, &traces)
+	return traces, err
+}
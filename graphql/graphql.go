@@ -0,0 +1,930 @@
+// Package graphql provides a GraphQL interface to the node data, extended with the Lachesis DAG,
+// epochs and validators, and the subscriptions to the Subscribers contract.
+package graphql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"sync"
+
+	"github.com/artheranet/lachesis/hash"
+	"github.com/artheranet/lachesis/inter/idx"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+
+	"github.com/artheranet/arthera-node/api"
+	"github.com/artheranet/arthera-node/gossip/filters"
+	"github.com/artheranet/arthera-node/gossip/gasprice"
+	"github.com/artheranet/arthera-node/internal/evmcore"
+	"github.com/artheranet/arthera-node/internal/inter"
+	"github.com/artheranet/arthera-node/utils/signers/gsignercache"
+	"github.com/artheranet/arthera-node/utils/signers/internaltx"
+)
+
+var (
+	errBlockInvariant = errors.New("block objects must be instantiated with at least one of num or hash")
+	errTooManyBlocks  = errors.New("requested block range is too wide")
+)
+
+// maxBlocksRange is the maximum number of blocks returned by a single blocks query
+const maxBlocksRange = 1000
+
+// Backend is the data source of the GraphQL service
+type Backend interface {
+	api.Backend
+	filters.Backend
+}
+
+// Long is a 64 bit unsigned integer
+type Long uint64
+
+// ImplementsGraphQLType returns true if Long implements the provided GraphQL type.
+func (b Long) ImplementsGraphQLType(name string) bool { return name == "Long" }
+
+// UnmarshalGraphQL unmarshals the provided GraphQL query data.
+func (b *Long) UnmarshalGraphQL(input interface{}) error {
+	var err error
+	switch input := input.(type) {
+	case string:
+		if len(input) > 2 && input[:2] == "0x" {
+			var value hexutil.Uint64
+			err = value.UnmarshalText([]byte(input))
+			*b = Long(value)
+		} else {
+			var value uint64
+			value, err = strconv.ParseUint(input, 10, 64)
+			*b = Long(value)
+		}
+	case int32:
+		*b = Long(input)
+	case int64:
+		*b = Long(input)
+	case float64:
+		*b = Long(input)
+	default:
+		err = fmt.Errorf("unexpected type %T for Long", input)
+	}
+	return err
+}
+
+// MarshalJSON encodes Long as a hexadecimal string
+func (b Long) MarshalJSON() ([]byte, error) {
+	return hexutil.Uint64(b).MarshalText()
+}
+
+func longPtr(v uint64) *Long {
+	l := Long(v)
+	return &l
+}
+
+func blockNumberOrLatest(number *Long) rpc.BlockNumberOrHash {
+	if number == nil {
+		return rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)
+	}
+	return rpc.BlockNumberOrHashWithNumber(rpc.BlockNumber(*number))
+}
+
+// Account represents an account at a specific block.
+type Account struct {
+	r             *Resolver
+	address       common.Address
+	blockNrOrHash rpc.BlockNumberOrHash
+}
+
+func (a *Account) Address(ctx context.Context) (common.Address, error) {
+	return a.address, nil
+}
+
+func (a *Account) Balance(ctx context.Context) (hexutil.Big, error) {
+	statedb, _, err := a.r.backend.StateAndHeaderByNumberOrHash(ctx, a.blockNrOrHash)
+	if statedb == nil || err != nil {
+		return hexutil.Big{}, err
+	}
+	return hexutil.Big(*statedb.GetBalance(a.address)), statedb.Error()
+}
+
+func (a *Account) TransactionCount(ctx context.Context) (Long, error) {
+	statedb, _, err := a.r.backend.StateAndHeaderByNumberOrHash(ctx, a.blockNrOrHash)
+	if statedb == nil || err != nil {
+		return 0, err
+	}
+	return Long(statedb.GetNonce(a.address)), statedb.Error()
+}
+
+func (a *Account) Code(ctx context.Context) (hexutil.Bytes, error) {
+	statedb, _, err := a.r.backend.StateAndHeaderByNumberOrHash(ctx, a.blockNrOrHash)
+	if statedb == nil || err != nil {
+		return hexutil.Bytes{}, err
+	}
+	return statedb.GetCode(a.address), statedb.Error()
+}
+
+func (a *Account) Storage(ctx context.Context, args struct{ Slot common.Hash }) (common.Hash, error) {
+	statedb, _, err := a.r.backend.StateAndHeaderByNumberOrHash(ctx, a.blockNrOrHash)
+	if statedb == nil || err != nil {
+		return common.Hash{}, err
+	}
+	return statedb.GetState(a.address, args.Slot), statedb.Error()
+}
+
+func (a *Account) SubscriptionState(ctx context.Context) (*SubscriptionState, error) {
+	sub, err := a.r.art.GetSubscription(ctx, a.address, a.blockNrOrHash, nil)
+	if sub == nil || err != nil {
+		return nil, err
+	}
+	return &SubscriptionState{sub}, nil
+}
+
+func (a *Account) Whitelisted(ctx context.Context, args struct{ Account common.Address }) (bool, error) {
+	return a.r.art.IsWhitelisted(ctx, a.address, args.Account, a.blockNrOrHash)
+}
+
+// SubscriptionState represents a subscription to the Subscribers contract
+type SubscriptionState struct {
+	sub *api.SubscriptionResult
+}
+
+func (s *SubscriptionState) Id() hexutil.Big            { return *s.sub.Id }
+func (s *SubscriptionState) PlanId() hexutil.Big        { return *s.sub.PlanId }
+func (s *SubscriptionState) ContractSub() bool          { return s.sub.ContractSub }
+func (s *SubscriptionState) Active() bool               { return s.sub.Active }
+func (s *SubscriptionState) StartTime() Long            { return Long(s.sub.StartTime) }
+func (s *SubscriptionState) EndTime() Long              { return Long(s.sub.EndTime) }
+func (s *SubscriptionState) Balance() hexutil.Big       { return *s.sub.Balance }
+func (s *SubscriptionState) CapFrequency() *hexutil.Big { return s.sub.CapType }
+func (s *SubscriptionState) CapUnits() *hexutil.Big     { return s.sub.CapUnits }
+func (s *SubscriptionState) CapWindow() Long            { return Long(s.sub.CapWindow) }
+func (s *SubscriptionState) CapRemaining() hexutil.Big  { return *s.sub.CapRemaining }
+func (s *SubscriptionState) PeriodUsage() hexutil.Big   { return *s.sub.PeriodUsage }
+func (s *SubscriptionState) LastCapReset() Long         { return Long(s.sub.LastCapReset) }
+
+// GasPayment represents the breakdown of who paid for the gas of a transaction
+type GasPayment struct {
+	p *evmcore.GasPayment
+}
+
+func (g *GasPayment) ReceiverSubscriptionGas() Long    { return Long(g.p.ReceiverSubscriptionGas) }
+func (g *GasPayment) ReceiverSubscriptionRefund() Long { return Long(g.p.ReceiverSubscriptionRefund) }
+func (g *GasPayment) SenderSubscriptionGas() Long      { return Long(g.p.SenderSubscriptionGas) }
+func (g *GasPayment) SenderSubscriptionRefund() Long   { return Long(g.p.SenderSubscriptionRefund) }
+func (g *GasPayment) PayAsYouGoGas() Long              { return Long(g.p.PayAsYouGoGas) }
+func (g *GasPayment) PayAsYouGoRefund() Long           { return Long(g.p.PayAsYouGoRefund) }
+func (g *GasPayment) PayAsYouGoCost() hexutil.Big      { return hexutil.Big(*g.p.PayAsYouGoCost()) }
+
+func (g *GasPayment) DeployerRewardRecipient() *common.Address {
+	if !g.p.DeployerRewarded() {
+		return nil
+	}
+	return &g.p.DeployerRewardRecipient
+}
+
+func (g *GasPayment) DeployerReward() *hexutil.Big {
+	if !g.p.DeployerRewarded() {
+		return nil
+	}
+	return (*hexutil.Big)(g.p.DeployerReward)
+}
+
+// Log represents an individual log message. All arguments are mandatory.
+type Log struct {
+	r           *Resolver
+	transaction *Transaction
+	log         *types.Log
+}
+
+func (l *Log) Transaction(ctx context.Context) *Transaction {
+	return l.transaction
+}
+
+func (l *Log) Account(ctx context.Context, args struct{ Block *Long }) *Account {
+	return &Account{
+		r:             l.r,
+		address:       l.log.Address,
+		blockNrOrHash: blockNumberOrLatest(args.Block),
+	}
+}
+
+func (l *Log) Index(ctx context.Context) int32 {
+	return int32(l.log.Index)
+}
+
+func (l *Log) Topics(ctx context.Context) []common.Hash {
+	return l.log.Topics
+}
+
+func (l *Log) Data(ctx context.Context) hexutil.Bytes {
+	return l.log.Data
+}
+
+// Transaction represents an Ethereum transaction.
+// The block and the index are set if the transaction is mined.
+type Transaction struct {
+	r     *Resolver
+	hash  common.Hash
+	tx    *types.Transaction
+	block *Block
+	index uint64
+}
+
+func (t *Transaction) mined() bool {
+	return t.block != nil
+}
+
+// receipt returns the receipt of the transaction, nil if the transaction isn't mined
+func (t *Transaction) receipt(ctx context.Context) (*types.Receipt, error) {
+	if !t.mined() {
+		return nil, nil
+	}
+	receipts, err := t.block.resolveReceipts(ctx)
+	if err != nil || uint64(len(receipts)) <= t.index {
+		return nil, err
+	}
+	return receipts[t.index], nil
+}
+
+func (t *Transaction) Hash(ctx context.Context) common.Hash {
+	return t.hash
+}
+
+func (t *Transaction) InputData(ctx context.Context) hexutil.Bytes {
+	return t.tx.Data()
+}
+
+func (t *Transaction) Gas(ctx context.Context) Long {
+	return Long(t.tx.Gas())
+}
+
+func (t *Transaction) GasPrice(ctx context.Context) hexutil.Big {
+	return hexutil.Big(*t.tx.GasPrice())
+}
+
+func (t *Transaction) MaxFeePerGas(ctx context.Context) *hexutil.Big {
+	if t.tx.Type() != types.DynamicFeeTxType {
+		return nil
+	}
+	return (*hexutil.Big)(t.tx.GasFeeCap())
+}
+
+func (t *Transaction) MaxPriorityFeePerGas(ctx context.Context) *hexutil.Big {
+	if t.tx.Type() != types.DynamicFeeTxType {
+		return nil
+	}
+	return (*hexutil.Big)(t.tx.GasTipCap())
+}
+
+func (t *Transaction) EffectiveGasPrice(ctx context.Context) *hexutil.Big {
+	if !t.mined() {
+		return nil
+	}
+	baseFee := t.block.block.BaseFee
+	if baseFee == nil {
+		return (*hexutil.Big)(t.tx.GasPrice())
+	}
+	price := new(big.Int).Add(baseFee, t.tx.GasTipCap())
+	if price.Cmp(t.tx.GasFeeCap()) > 0 {
+		price.Set(t.tx.GasFeeCap())
+	}
+	return (*hexutil.Big)(price)
+}
+
+func (t *Transaction) Value(ctx context.Context) hexutil.Big {
+	return hexutil.Big(*t.tx.Value())
+}
+
+func (t *Transaction) Nonce(ctx context.Context) Long {
+	return Long(t.tx.Nonce())
+}
+
+func (t *Transaction) To(ctx context.Context, args struct{ Block *Long }) *Account {
+	to := t.tx.To()
+	if to == nil {
+		return nil
+	}
+	return &Account{
+		r:             t.r,
+		address:       *to,
+		blockNrOrHash: blockNumberOrLatest(args.Block),
+	}
+}
+
+func (t *Transaction) From(ctx context.Context, args struct{ Block *Long }) *Account {
+	signer := gsignercache.Wrap(types.LatestSignerForChainID(t.r.backend.ChainConfig().ChainID))
+	from, _ := internaltx.Sender(signer, t.tx)
+	return &Account{
+		r:             t.r,
+		address:       from,
+		blockNrOrHash: blockNumberOrLatest(args.Block),
+	}
+}
+
+func (t *Transaction) Block(ctx context.Context) *Block {
+	return t.block
+}
+
+func (t *Transaction) Index(ctx context.Context) *Long {
+	if !t.mined() {
+		return nil
+	}
+	return longPtr(t.index)
+}
+
+func (t *Transaction) Status(ctx context.Context) (*Long, error) {
+	receipt, err := t.receipt(ctx)
+	if receipt == nil || err != nil {
+		return nil, err
+	}
+	return longPtr(receipt.Status), nil
+}
+
+func (t *Transaction) GasUsed(ctx context.Context) (*Long, error) {
+	receipt, err := t.receipt(ctx)
+	if receipt == nil || err != nil {
+		return nil, err
+	}
+	return longPtr(receipt.GasUsed), nil
+}
+
+func (t *Transaction) CumulativeGasUsed(ctx context.Context) (*Long, error) {
+	receipt, err := t.receipt(ctx)
+	if receipt == nil || err != nil {
+		return nil, err
+	}
+	return longPtr(receipt.CumulativeGasUsed), nil
+}
+
+func (t *Transaction) CreatedContract(ctx context.Context, args struct{ Block *Long }) (*Account, error) {
+	receipt, err := t.receipt(ctx)
+	if receipt == nil || err != nil || receipt.ContractAddress == (common.Address{}) {
+		return nil, err
+	}
+	return &Account{
+		r:             t.r,
+		address:       receipt.ContractAddress,
+		blockNrOrHash: blockNumberOrLatest(args.Block),
+	}, nil
+}
+
+func (t *Transaction) Logs(ctx context.Context) (*[]*Log, error) {
+	receipt, err := t.receipt(ctx)
+	if receipt == nil || err != nil {
+		return nil, err
+	}
+	ret := make([]*Log, 0, len(receipt.Logs))
+	for _, log := range receipt.Logs {
+		ret = append(ret, &Log{
+			r:           t.r,
+			transaction: t,
+			log:         log,
+		})
+	}
+	return &ret, nil
+}
+
+func (t *Transaction) Type(ctx context.Context) *Long {
+	return longPtr(uint64(t.tx.Type()))
+}
+
+func (t *Transaction) GasPayment(ctx context.Context) (*GasPayment, error) {
+	if !t.mined() {
+		return nil, nil
+	}
+	payments, err := t.r.backend.GetGasPaymentsByNumber(ctx, rpc.BlockNumber(t.block.block.NumberU64()))
+	if err != nil || uint64(len(payments)) <= t.index || payments[t.index] == nil {
+		return nil, err
+	}
+	return &GasPayment{payments[t.index]}, nil
+}
+
+// Block represents an Ethereum block.
+type Block struct {
+	r     *Resolver
+	block *evmcore.EvmBlock
+
+	mu       sync.Mutex
+	receipts types.Receipts
+}
+
+// resolveReceipts returns the receipts of the block, fetching them on the first call
+func (b *Block) resolveReceipts(ctx context.Context) (types.Receipts, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.receipts != nil {
+		return b.receipts, nil
+	}
+	receipts, err := b.r.backend.GetReceiptsByNumber(ctx, rpc.BlockNumber(b.block.NumberU64()))
+	if err != nil {
+		return nil, err
+	}
+	b.receipts = receipts
+	return receipts, nil
+}
+
+func (b *Block) Number(ctx context.Context) Long {
+	return Long(b.block.NumberU64())
+}
+
+func (b *Block) Hash(ctx context.Context) common.Hash {
+	return b.block.Hash
+}
+
+func (b *Block) Parent(ctx context.Context) (*Block, error) {
+	if b.block.NumberU64() == 0 {
+		return nil, nil
+	}
+	return b.r.blockByNumberOrHash(ctx, rpc.BlockNumberOrHashWithHash(b.block.ParentHash, false))
+}
+
+func (b *Block) StateRoot(ctx context.Context) common.Hash {
+	return b.block.Root
+}
+
+func (b *Block) TransactionsRoot(ctx context.Context) common.Hash {
+	return b.block.TxHash
+}
+
+func (b *Block) GasLimit(ctx context.Context) Long {
+	return Long(b.block.GasLimit)
+}
+
+func (b *Block) GasUsed(ctx context.Context) Long {
+	return Long(b.block.GasUsed)
+}
+
+func (b *Block) BaseFeePerGas(ctx context.Context) *hexutil.Big {
+	return (*hexutil.Big)(b.block.BaseFee)
+}
+
+func (b *Block) Timestamp(ctx context.Context) Long {
+	return Long(b.block.Time.Unix())
+}
+
+func (b *Block) TransactionCount(ctx context.Context) *int32 {
+	count := int32(len(b.block.Transactions))
+	return &count
+}
+
+func (b *Block) Transactions(ctx context.Context) *[]*Transaction {
+	ret := make([]*Transaction, 0, len(b.block.Transactions))
+	for i, tx := range b.block.Transactions {
+		ret = append(ret, &Transaction{
+			r:     b.r,
+			hash:  tx.Hash(),
+			tx:    tx,
+			block: b,
+			index: uint64(i),
+		})
+	}
+	return &ret
+}
+
+func (b *Block) TransactionAt(ctx context.Context, args struct{ Index int32 }) *Transaction {
+	if args.Index < 0 || int(args.Index) >= len(b.block.Transactions) {
+		return nil
+	}
+	tx := b.block.Transactions[args.Index]
+	return &Transaction{
+		r:     b.r,
+		hash:  tx.Hash(),
+		tx:    tx,
+		block: b,
+		index: uint64(args.Index),
+	}
+}
+
+// BlockFilterCriteria encapsulates criteria passed to a `logs` accessor inside a block.
+type BlockFilterCriteria struct {
+	Addresses *[]common.Address // restricts matches to events created by specific contracts
+
+	// The Topic list restricts matches to particular event topics. Each event has a list
+	// of topics. Topics matches a prefix of that list. An empty element slice matches any
+	// topic. Non-empty elements represent an alternative that matches any of the
+	// contained topics.
+	//
+	// Examples:
+	// {} or nil          matches any topic list
+	// {{A}}              matches topic A in first position
+	// {{}, {B}}          matches any topic in first position, B in second position
+	// {{A}, {B}}         matches topic A in first position, B in second position
+	// {{A, B}}, {C, D}}  matches topic (A OR B) in first position, (C OR D) in second position
+	Topics *[][]common.Hash
+}
+
+func unpackCriteria(addresses *[]common.Address, topics *[][]common.Hash) ([]common.Address, [][]common.Hash) {
+	var addrs []common.Address
+	if addresses != nil {
+		addrs = *addresses
+	}
+	var tps [][]common.Hash
+	if topics != nil {
+		tps = *topics
+	}
+	return addrs, tps
+}
+
+func (b *Block) Logs(ctx context.Context, args struct{ Filter BlockFilterCriteria }) ([]*Log, error) {
+	addresses, topics := unpackCriteria(args.Filter.Addresses, args.Filter.Topics)
+	filter := filters.NewBlockFilter(b.r.backend, b.r.filterConfig, b.block.Hash, addresses, topics)
+	return b.r.runFilter(ctx, filter)
+}
+
+func (b *Block) Account(ctx context.Context, args struct{ Address common.Address }) *Account {
+	return &Account{
+		r:             b.r,
+		address:       args.Address,
+		blockNrOrHash: rpc.BlockNumberOrHashWithNumber(rpc.BlockNumber(b.block.NumberU64())),
+	}
+}
+
+func (b *Block) Atropos(ctx context.Context) (*Event, error) {
+	if b.block.NumberU64() == 0 {
+		return nil, nil
+	}
+	return b.r.eventByID(ctx, b.block.Hash.Hex())
+}
+
+// Event represents a Lachesis DAG event.
+type Event struct {
+	r     *Resolver
+	event *inter.EventPayload
+}
+
+func (e *Event) Id() common.Hash          { return common.Hash(e.event.ID()) }
+func (e *Event) Epoch() Long              { return Long(e.event.Epoch()) }
+func (e *Event) Seq() Long                { return Long(e.event.Seq()) }
+func (e *Event) Frame() Long              { return Long(e.event.Frame()) }
+func (e *Event) Creator() Long            { return Long(e.event.Creator()) }
+func (e *Event) Lamport() Long            { return Long(e.event.Lamport()) }
+func (e *Event) CreationTime() Long       { return Long(e.event.CreationTime()) }
+func (e *Event) MedianTime() Long         { return Long(e.event.MedianTime()) }
+func (e *Event) GasPowerUsed() Long       { return Long(e.event.GasPowerUsed()) }
+func (e *Event) ExtraData() hexutil.Bytes { return e.event.Extra() }
+func (e *Event) TransactionCount() int32  { return int32(len(e.event.Txs())) }
+
+func (e *Event) Parents() []common.Hash {
+	return eventIDsToHashes(e.event.Parents())
+}
+
+func (e *Event) ParentEvents(ctx context.Context) ([]*Event, error) {
+	parents := make([]*Event, 0, len(e.event.Parents()))
+	for _, id := range e.event.Parents() {
+		parent, err := e.r.eventByID(ctx, common.Hash(id).Hex())
+		if err != nil {
+			return nil, err
+		}
+		if parent != nil {
+			parents = append(parents, parent)
+		}
+	}
+	return parents, nil
+}
+
+func (e *Event) TransactionHashes() []common.Hash {
+	hashes := make([]common.Hash, 0, len(e.event.Txs()))
+	for _, tx := range e.event.Txs() {
+		hashes = append(hashes, tx.Hash())
+	}
+	return hashes
+}
+
+func eventIDsToHashes(ids hash.Events) []common.Hash {
+	res := make([]common.Hash, len(ids))
+	for i, id := range ids {
+		res[i] = common.Hash(id)
+	}
+	return res
+}
+
+// Validator represents a validator of an epoch
+type Validator struct {
+	r       *Resolver
+	id      idx.ValidatorID
+	weight  *big.Int
+	pubkey  []byte
+	current bool
+}
+
+func (v *Validator) Id() Long              { return Long(v.id) }
+func (v *Validator) Weight() hexutil.Big   { return hexutil.Big(*v.weight) }
+func (v *Validator) PubKey() hexutil.Bytes { return v.pubkey }
+
+func (v *Validator) OfflineBlocks(ctx context.Context) (*Long, error) {
+	if !v.current {
+		return nil, nil
+	}
+	blocks, _, err := v.r.backend.GetDowntime(ctx, v.id)
+	if err != nil {
+		return nil, err
+	}
+	return longPtr(uint64(blocks)), nil
+}
+
+func (v *Validator) OfflineTime(ctx context.Context) (*Long, error) {
+	if !v.current {
+		return nil, nil
+	}
+	_, period, err := v.r.backend.GetDowntime(ctx, v.id)
+	if err != nil {
+		return nil, err
+	}
+	return longPtr(uint64(period)), nil
+}
+
+func (v *Validator) Uptime(ctx context.Context) (*Long, error) {
+	if !v.current {
+		return nil, nil
+	}
+	uptime, err := v.r.backend.GetUptime(ctx, v.id)
+	if uptime == nil || err != nil {
+		return nil, err
+	}
+	return longPtr(uptime.Uint64()), nil
+}
+
+func (v *Validator) OriginatedFee(ctx context.Context) (*hexutil.Big, error) {
+	if !v.current {
+		return nil, nil
+	}
+	fee, err := v.r.backend.GetOriginatedFee(ctx, v.id)
+	if err != nil {
+		return nil, err
+	}
+	return (*hexutil.Big)(fee), nil
+}
+
+// Epoch represents a Lachesis epoch
+type Epoch struct {
+	r          *Resolver
+	number     idx.Epoch
+	start      inter.Timestamp
+	prevStart  inter.Timestamp
+	lastBlock  idx.Block
+	validators []*Validator
+}
+
+func (e *Epoch) Number() Long             { return Long(e.number) }
+func (e *Epoch) Start() Long              { return Long(e.start) }
+func (e *Epoch) PrevStart() Long          { return Long(e.prevStart) }
+func (e *Epoch) Validators() []*Validator { return e.validators }
+
+func (e *Epoch) LastBlock(ctx context.Context) (*Block, error) {
+	return e.r.blockByNumberOrHash(ctx, rpc.BlockNumberOrHashWithNumber(rpc.BlockNumber(e.lastBlock)))
+}
+
+func (e *Epoch) Heads(ctx context.Context) ([]common.Hash, error) {
+	heads, err := e.r.backend.GetHeads(ctx, rpc.BlockNumber(e.number))
+	if err != nil {
+		return nil, err
+	}
+	return eventIDsToHashes(heads), nil
+}
+
+// Resolver is the top-level object in the GraphQL hierarchy.
+type Resolver struct {
+	backend      Backend
+	filterConfig filters.Config
+	art          *api.PublicArtheraAPI
+}
+
+func newResolver(backend Backend, filterConfig filters.Config) *Resolver {
+	return &Resolver{
+		backend:      backend,
+		filterConfig: filterConfig,
+		art:          api.NewPublicArtheraAPI(backend),
+	}
+}
+
+func (r *Resolver) blockByNumberOrHash(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (*Block, error) {
+	var (
+		block *evmcore.EvmBlock
+		err   error
+	)
+	if h, ok := blockNrOrHash.Hash(); ok {
+		block, err = r.backend.BlockByHash(ctx, h)
+	} else if number, ok := blockNrOrHash.Number(); ok {
+		block, err = r.backend.BlockByNumber(ctx, number)
+	} else {
+		return nil, errBlockInvariant
+	}
+	if block == nil || err != nil {
+		return nil, err
+	}
+	return &Block{r: r, block: block}, nil
+}
+
+func (r *Resolver) eventByID(ctx context.Context, id string) (*Event, error) {
+	event, err := r.backend.GetEventPayload(ctx, id)
+	if event == nil || err != nil {
+		return nil, err
+	}
+	return &Event{r: r, event: event}, nil
+}
+
+// runFilter runs a filter and converts its results to the Log objects, sharing the blocks of the same logs
+func (r *Resolver) runFilter(ctx context.Context, filter *filters.Filter) ([]*Log, error) {
+	logs, err := filter.Logs(ctx)
+	if err != nil || logs == nil {
+		return nil, err
+	}
+	blocks := make(map[common.Hash]*Block)
+	ret := make([]*Log, 0, len(logs))
+	for _, log := range logs {
+		block, ok := blocks[log.BlockHash]
+		if !ok {
+			block, err = r.blockByNumberOrHash(ctx, rpc.BlockNumberOrHashWithNumber(rpc.BlockNumber(log.BlockNumber)))
+			if err != nil {
+				return nil, err
+			}
+			blocks[log.BlockHash] = block
+		}
+		tx := &Transaction{
+			r:     r,
+			hash:  log.TxHash,
+			block: block,
+			index: uint64(log.TxIndex),
+		}
+		if block != nil && int(log.TxIndex) < len(block.block.Transactions) {
+			tx.tx = block.block.Transactions[log.TxIndex]
+		} else {
+			tx.tx, _, _, err = r.backend.GetTransaction(ctx, log.TxHash)
+			if err != nil {
+				return nil, err
+			}
+			if tx.tx == nil {
+				return nil, fmt.Errorf("transaction %s not found", log.TxHash.Hex())
+			}
+		}
+		ret = append(ret, &Log{
+			r:           r,
+			transaction: tx,
+			log:         log,
+		})
+	}
+	return ret, nil
+}
+
+func (r *Resolver) Block(ctx context.Context, args struct {
+	Number *Long
+	Hash   *common.Hash
+}) (*Block, error) {
+	if args.Hash != nil {
+		return r.blockByNumberOrHash(ctx, rpc.BlockNumberOrHashWithHash(*args.Hash, false))
+	}
+	return r.blockByNumberOrHash(ctx, blockNumberOrLatest(args.Number))
+}
+
+func (r *Resolver) Blocks(ctx context.Context, args struct {
+	From *Long
+	To   *Long
+}) ([]*Block, error) {
+	latest := Long(r.backend.CurrentBlock().NumberU64())
+	from := Long(0)
+	if args.From != nil {
+		from = *args.From
+	}
+	to := latest
+	if args.To != nil && *args.To < latest {
+		to = *args.To
+	}
+	if to < from {
+		return []*Block{}, nil
+	}
+	if to-from >= maxBlocksRange {
+		return nil, errTooManyBlocks
+	}
+	ret := make([]*Block, 0, to-from+1)
+	for i := from; i <= to; i++ {
+		block, err := r.blockByNumberOrHash(ctx, rpc.BlockNumberOrHashWithNumber(rpc.BlockNumber(i)))
+		if err != nil {
+			return nil, err
+		}
+		if block == nil {
+			break
+		}
+		ret = append(ret, block)
+	}
+	return ret, nil
+}
+
+func (r *Resolver) Transaction(ctx context.Context, args struct{ Hash common.Hash }) (*Transaction, error) {
+	tx, blockNumber, index, err := r.backend.GetTransaction(ctx, args.Hash)
+	if err != nil {
+		return nil, err
+	}
+	if tx != nil {
+		block, err := r.blockByNumberOrHash(ctx, rpc.BlockNumberOrHashWithNumber(rpc.BlockNumber(blockNumber)))
+		if err != nil {
+			return nil, err
+		}
+		return &Transaction{
+			r:     r,
+			hash:  args.Hash,
+			tx:    tx,
+			block: block,
+			index: index,
+		}, nil
+	}
+	// no mined transaction with this hash, look it up in the pool
+	tx = r.backend.GetPoolTransaction(args.Hash)
+	if tx == nil {
+		return nil, nil
+	}
+	return &Transaction{
+		r:    r,
+		hash: args.Hash,
+		tx:   tx,
+	}, nil
+}
+
+// FilterCriteria encapsulates the arguments to `logs` on the root resolver object.
+type FilterCriteria struct {
+	FromBlock *Long             // beginning of the queried range, nil means latest block
+	ToBlock   *Long             // end of the range, nil means latest block
+	Addresses *[]common.Address // restricts matches to events created by specific contracts
+
+	// The Topic list restricts matches to particular event topics. Each event has a list
+	// of topics. Topics matches a prefix of that list. An empty element slice matches any
+	// topic. Non-empty elements represent an alternative that matches any of the
+	// contained topics.
+	Topics *[][]common.Hash
+}
+
+func (r *Resolver) Logs(ctx context.Context, args struct{ Filter FilterCriteria }) ([]*Log, error) {
+	begin := rpc.LatestBlockNumber.Int64()
+	if args.Filter.FromBlock != nil {
+		begin = int64(*args.Filter.FromBlock)
+	}
+	end := rpc.LatestBlockNumber.Int64()
+	if args.Filter.ToBlock != nil {
+		end = int64(*args.Filter.ToBlock)
+	}
+	addresses, topics := unpackCriteria(args.Filter.Addresses, args.Filter.Topics)
+	filter := filters.NewRangeFilter(r.backend, r.filterConfig, begin, end, addresses, topics)
+	return r.runFilter(ctx, filter)
+}
+
+func (r *Resolver) Account(ctx context.Context, args struct {
+	Address common.Address
+	Block   *Long
+}) *Account {
+	return &Account{
+		r:             r,
+		address:       args.Address,
+		blockNrOrHash: blockNumberOrLatest(args.Block),
+	}
+}
+
+func (r *Resolver) Epoch(ctx context.Context, args struct{ Number *Long }) (*Epoch, error) {
+	epoch := rpc.PendingBlockNumber
+	if args.Number != nil {
+		epoch = rpc.BlockNumber(*args.Number)
+	}
+	bs, es, err := r.backend.GetEpochBlockState(ctx, epoch)
+	if es == nil || err != nil {
+		return nil, err
+	}
+	current := es.Epoch == r.backend.CurrentEpoch(ctx)
+	res := &Epoch{
+		r:          r,
+		number:     es.Epoch,
+		start:      es.EpochStart,
+		prevStart:  es.PrevEpochStart,
+		validators: make([]*Validator, 0, es.Validators.Len()),
+	}
+	if bs != nil {
+		res.lastBlock = bs.LastBlock.Idx
+	}
+	for _, vid := range es.Validators.IDs() {
+		profile := es.ValidatorProfiles[vid]
+		weight := profile.Weight
+		if weight == nil {
+			weight = new(big.Int)
+		}
+		res.validators = append(res.validators, &Validator{
+			r:       r,
+			id:      vid,
+			weight:  weight,
+			pubkey:  profile.PubKey.Bytes(),
+			current: current,
+		})
+	}
+	return res, nil
+}
+
+func (r *Resolver) Event(ctx context.Context, args struct{ Id string }) (*Event, error) {
+	return r.eventByID(ctx, args.Id)
+}
+
+func (r *Resolver) GasPrice(ctx context.Context) (hexutil.Big, error) {
+	tipcap := r.backend.SuggestGasTipCap(ctx, gasprice.AsDefaultCertainty)
+	tipcap.Add(tipcap, r.backend.MinGasPrice())
+	return hexutil.Big(*tipcap), nil
+}
+
+func (r *Resolver) ChainID(ctx context.Context) (hexutil.Big, error) {
+	return hexutil.Big(*r.backend.ChainConfig().ChainID), nil
+}
@@ -0,0 +1,396 @@
+package graphql
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/artheranet/lachesis/inter/idx"
+	"github.com/artheranet/lachesis/kvdb/memorydb"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/graph-gophers/graphql-go"
+	"github.com/stretchr/testify/require"
+
+	"github.com/artheranet/arthera-node/gossip/evmstore"
+	"github.com/artheranet/arthera-node/gossip/filters"
+	"github.com/artheranet/arthera-node/internal/evmcore"
+	"github.com/artheranet/arthera-node/internal/inter"
+	"github.com/artheranet/arthera-node/internal/topicsdb"
+)
+
+func TestSchemaMatchesResolvers(t *testing.T) {
+	_, err := graphql.ParseSchema(schema, &Resolver{})
+	require.NoError(t, err)
+}
+
+func TestLongUnmarshal(t *testing.T) {
+	require := require.New(t)
+
+	for input, expected := range map[interface{}]Long{
+		"0x10":      16,
+		"16":        16,
+		int32(16):   16,
+		int64(16):   16,
+		float64(16): 16,
+	} {
+		var l Long
+		require.NoError(l.UnmarshalGraphQL(input))
+		require.Equal(expected, l)
+	}
+	var l Long
+	require.Error(l.UnmarshalGraphQL("0xzz"))
+	require.Error(l.UnmarshalGraphQL(true))
+}
+
+const testHead = 1500
+
+var (
+	testKey, _   = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+	testAddr     = crypto.PubkeyToAddress(testKey.PublicKey)
+	testContract = common.HexToAddress("0x2000000000000000000000000000000000000002")
+	testTopic    = common.HexToHash("0x7f")
+	testSigner   = types.LatestSignerForChainID(params.TestChainConfig.ChainID)
+)
+
+// testBackend is a chain of testHead empty blocks, except the first blocks, each of them
+// having a transaction which emits a log. The methods which aren't used by the tests panic.
+type testBackend struct {
+	Backend
+
+	blocks   map[uint64]*evmcore.EvmBlock
+	receipts map[uint64]types.Receipts
+	txs      map[common.Hash]*evmstore.TxPosition
+	pool     map[common.Hash]*types.Transaction
+	logIndex topicsdb.Index
+}
+
+func testBlockHash(n uint64) common.Hash {
+	return common.BigToHash(new(big.Int).SetUint64(n + 1))
+}
+
+func newTestBackend(t *testing.T, withTxs uint64) *testBackend {
+	b := &testBackend{
+		blocks:   make(map[uint64]*evmcore.EvmBlock),
+		receipts: make(map[uint64]types.Receipts),
+		txs:      make(map[common.Hash]*evmstore.TxPosition),
+		pool:     make(map[common.Hash]*types.Transaction),
+		logIndex: topicsdb.New(memorydb.NewProducer("")),
+	}
+	for n := uint64(1); n <= withTxs; n++ {
+		tx, err := types.SignTx(types.NewTransaction(n-1, testContract, big.NewInt(int64(n)), 50000, big.NewInt(1e9), []byte{byte(n)}), testSigner, testKey)
+		require.NoError(t, err)
+		block := b.emptyBlock(n)
+		block.Transactions = types.Transactions{tx}
+		block.GasUsed = 30000
+		b.blocks[n] = block
+		l := &types.Log{
+			Address:     testContract,
+			Topics:      []common.Hash{testTopic, common.BigToHash(new(big.Int).SetUint64(n))},
+			Data:        []byte{byte(n)},
+			BlockNumber: n,
+			TxHash:      tx.Hash(),
+			TxIndex:     0,
+			BlockHash:   block.Hash,
+			Index:       0,
+		}
+		b.receipts[n] = types.Receipts{{
+			Status:            types.ReceiptStatusSuccessful,
+			CumulativeGasUsed: 30000,
+			GasUsed:           30000,
+			TxHash:            tx.Hash(),
+			Logs:              []*types.Log{l},
+		}}
+		require.NoError(t, b.logIndex.Push(l))
+		b.txs[tx.Hash()] = &evmstore.TxPosition{Block: idx.Block(n)}
+	}
+	return b
+}
+
+func (b *testBackend) emptyBlock(n uint64) *evmcore.EvmBlock {
+	header := &evmcore.EvmHeader{
+		Number:   new(big.Int).SetUint64(n),
+		Hash:     testBlockHash(n),
+		Time:     inter.FromUnix(int64(1000 + n)),
+		GasLimit: 1000000,
+		BaseFee:  big.NewInt(1e9),
+	}
+	if n > 0 {
+		header.ParentHash = testBlockHash(n - 1)
+	}
+	return evmcore.NewEvmBlock(header, nil)
+}
+
+func (b *testBackend) block(n uint64) *evmcore.EvmBlock {
+	if n > testHead {
+		return nil
+	}
+	if block, ok := b.blocks[n]; ok {
+		return block
+	}
+	return b.emptyBlock(n)
+}
+
+func (b *testBackend) number(number rpc.BlockNumber) uint64 {
+	if number < 0 {
+		return testHead
+	}
+	return uint64(number)
+}
+
+func (b *testBackend) numberOf(hash common.Hash) (uint64, bool) {
+	n := new(big.Int).SetBytes(hash.Bytes()).Uint64()
+	if n == 0 || n > testHead+1 || hash != testBlockHash(n-1) {
+		return 0, false
+	}
+	return n - 1, true
+}
+
+func (b *testBackend) ChainConfig() *params.ChainConfig {
+	return params.TestChainConfig
+}
+
+func (b *testBackend) CurrentBlock() *evmcore.EvmBlock {
+	return b.block(testHead)
+}
+
+func (b *testBackend) BlockByNumber(ctx context.Context, number rpc.BlockNumber) (*evmcore.EvmBlock, error) {
+	return b.block(b.number(number)), nil
+}
+
+func (b *testBackend) BlockByHash(ctx context.Context, hash common.Hash) (*evmcore.EvmBlock, error) {
+	n, ok := b.numberOf(hash)
+	if !ok {
+		return nil, nil
+	}
+	return b.block(n), nil
+}
+
+func (b *testBackend) HeaderByNumber(ctx context.Context, number rpc.BlockNumber) (*evmcore.EvmHeader, error) {
+	block := b.block(b.number(number))
+	if block == nil {
+		return nil, nil
+	}
+	return block.Header(), nil
+}
+
+func (b *testBackend) HeaderByHash(ctx context.Context, hash common.Hash) (*evmcore.EvmHeader, error) {
+	n, ok := b.numberOf(hash)
+	if !ok {
+		return nil, nil
+	}
+	return b.block(n).Header(), nil
+}
+
+func (b *testBackend) GetReceiptsByNumber(ctx context.Context, number rpc.BlockNumber) (types.Receipts, error) {
+	return b.receipts[b.number(number)], nil
+}
+
+func (b *testBackend) GetReceipts(ctx context.Context, hash common.Hash) (types.Receipts, error) {
+	n, _ := b.numberOf(hash)
+	return b.receipts[n], nil
+}
+
+func (b *testBackend) GetLogs(ctx context.Context, hash common.Hash) ([][]*types.Log, error) {
+	receipts, _ := b.GetReceipts(ctx, hash)
+	logs := make([][]*types.Log, len(receipts))
+	for i, r := range receipts {
+		logs[i] = r.Logs
+	}
+	return logs, nil
+}
+
+func (b *testBackend) GetTransaction(ctx context.Context, hash common.Hash) (*types.Transaction, uint64, uint64, error) {
+	pos := b.txs[hash]
+	if pos == nil {
+		return nil, 0, 0, nil
+	}
+	return b.blocks[uint64(pos.Block)].Transactions[pos.BlockOffset], uint64(pos.Block), uint64(pos.BlockOffset), nil
+}
+
+func (b *testBackend) GetPoolTransaction(hash common.Hash) *types.Transaction {
+	return b.pool[hash]
+}
+
+func (b *testBackend) GetTxPosition(hash common.Hash) *evmstore.TxPosition {
+	return b.txs[hash]
+}
+
+func (b *testBackend) EvmLogIndex() topicsdb.Index {
+	return b.logIndex
+}
+
+func (b *testBackend) IsBlockPruned(n idx.Block) bool {
+	return false
+}
+
+func newTestHandler(t *testing.T, backend *testBackend) http.Handler {
+	s, err := graphql.ParseSchema(schema, newResolver(backend, filters.DefaultConfig()))
+	require.NoError(t, err)
+	return handler{Schema: s}
+}
+
+func execQuery(t *testing.T, h http.Handler, query string) (int, string) {
+	body := fmt.Sprintf(`{"query": %q}`, query)
+	req := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(body))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec.Code, strings.TrimSpace(rec.Body.String())
+}
+
+func TestGraphQLBlockSerialization(t *testing.T) {
+	backend := newTestBackend(t, 3)
+	h := newTestHandler(t, backend)
+
+	for i, tt := range []struct {
+		body string
+		want string
+		code int
+	}{
+		{
+			body: `{block{number}}`,
+			want: `{"data":{"block":{"number":"0x5dc"}}}`,
+			code: 200,
+		},
+		{
+			body: `{block(number:1){number,hash,parent{number},gasUsed,gasLimit,baseFeePerGas,timestamp,transactionCount}}`,
+			want: fmt.Sprintf(`{"data":{"block":{"number":"0x1","hash":"%s","parent":{"number":"0x0"},"gasUsed":"0x7530","gasLimit":"0xf4240","baseFeePerGas":"0x3b9aca00","timestamp":"0x3e9","transactionCount":1}}}`, testBlockHash(1).Hex()),
+			code: 200,
+		},
+		{
+			body: fmt.Sprintf(`{block(hash:"%s"){number}}`, testBlockHash(2).Hex()),
+			want: `{"data":{"block":{"number":"0x2"}}}`,
+			code: 200,
+		},
+		{
+			body: `{block(number:"0x3"){number}}`,
+			want: `{"data":{"block":{"number":"0x3"}}}`,
+			code: 200,
+		},
+		{
+			body: `{block(number:0){parent{number}}}`,
+			want: `{"data":{"block":{"parent":null}}}`,
+			code: 200,
+		},
+		{
+			body: `{block(number:1501){number}}`,
+			want: `{"data":{"block":null}}`,
+			code: 200,
+		},
+		{
+			body: fmt.Sprintf(`{block(hash:"%s"){number}}`, common.HexToHash("0xdead").Hex()),
+			want: `{"data":{"block":null}}`,
+			code: 200,
+		},
+		{
+			body: `{block(number:"0xzz"){number}}`,
+			code: 400,
+		},
+	} {
+		code, resp := execQuery(t, h, tt.body)
+		require.Equal(t, tt.code, code, "testcase %d %s", i, tt.body)
+		if tt.want != "" {
+			require.JSONEq(t, tt.want, resp, "testcase %d %s", i, tt.body)
+		}
+	}
+}
+
+func TestGraphQLBlocksRange(t *testing.T) {
+	backend := newTestBackend(t, 3)
+	h := newTestHandler(t, backend)
+
+	code, resp := execQuery(t, h, `{blocks(from:1,to:3){number,transactionCount}}`)
+	require.Equal(t, 200, code)
+	require.JSONEq(t, `{"data":{"blocks":[{"number":"0x1","transactionCount":1},{"number":"0x2","transactionCount":1},{"number":"0x3","transactionCount":1}]}}`, resp)
+
+	// the range is capped by the latest block
+	code, resp = execQuery(t, h, `{blocks(from:1499,to:2000){number}}`)
+	require.Equal(t, 200, code)
+	require.JSONEq(t, `{"data":{"blocks":[{"number":"0x5db"},{"number":"0x5dc"}]}}`, resp)
+
+	code, resp = execQuery(t, h, `{blocks(from:3,to:1){number}}`)
+	require.Equal(t, 200, code)
+	require.JSONEq(t, `{"data":{"blocks":[]}}`, resp)
+
+	// maxBlocksRange blocks at most
+	code, _ = execQuery(t, h, fmt.Sprintf(`{blocks(from:0,to:%d){number}}`, maxBlocksRange-1))
+	require.Equal(t, 200, code)
+	code, resp = execQuery(t, h, fmt.Sprintf(`{blocks(from:0,to:%d){number}}`, maxBlocksRange))
+	require.Equal(t, 400, code)
+	require.Contains(t, resp, errTooManyBlocks.Error())
+	code, resp = execQuery(t, h, `{blocks{number}}`)
+	require.Equal(t, 400, code)
+	require.Contains(t, resp, errTooManyBlocks.Error())
+}
+
+func TestGraphQLTransaction(t *testing.T) {
+	backend := newTestBackend(t, 3)
+	h := newTestHandler(t, backend)
+	tx := backend.blocks[2].Transactions[0]
+
+	code, resp := execQuery(t, h, fmt.Sprintf(`{transaction(hash:"%s"){hash,nonce,index,from{address},to{address},value,gas,gasPrice,effectiveGasPrice,inputData,type,block{number},status,gasUsed,cumulativeGasUsed,logs{index,topics,data,account{address}}}}`, tx.Hash().Hex()))
+	require.Equal(t, 200, code)
+	require.JSONEq(t, fmt.Sprintf(`{"data":{"transaction":{"hash":"%s","nonce":"0x1","index":"0x0","from":{"address":"%s"},"to":{"address":"%s"},"value":"0x2","gas":"0xc350","gasPrice":"0x3b9aca00","effectiveGasPrice":"0x3b9aca00","inputData":"0x02","type":"0x0","block":{"number":"0x2"},"status":"0x1","gasUsed":"0x7530","cumulativeGasUsed":"0x7530","logs":[{"index":0,"topics":["%s","%s"],"data":"0x02","account":{"address":"%s"}}]}}}`,
+		tx.Hash().Hex(), strings.ToLower(testAddr.Hex()), strings.ToLower(testContract.Hex()), testTopic.Hex(), common.BigToHash(big.NewInt(2)).Hex(), strings.ToLower(testContract.Hex())), resp)
+
+	// the transactions of a block
+	code, resp = execQuery(t, h, `{block(number:3){transactions{hash,index},transactionAt(index:0){nonce},missing:transactionAt(index:1){nonce}}}`)
+	require.Equal(t, 200, code)
+	require.JSONEq(t, fmt.Sprintf(`{"data":{"block":{"transactions":[{"hash":"%s","index":"0x0"}],"transactionAt":{"nonce":"0x2"},"missing":null}}}`, backend.blocks[3].Transactions[0].Hash().Hex()), resp)
+
+	// a pending transaction isn't mined
+	pending, err := types.SignTx(types.NewTransaction(3, testContract, new(big.Int), 50000, big.NewInt(1e9), nil), testSigner, testKey)
+	require.NoError(t, err)
+	backend.pool[pending.Hash()] = pending
+	code, resp = execQuery(t, h, fmt.Sprintf(`{transaction(hash:"%s"){nonce,index,block{number},status,gasUsed,logs{index}}}`, pending.Hash().Hex()))
+	require.Equal(t, 200, code)
+	require.JSONEq(t, `{"data":{"transaction":{"nonce":"0x3","index":null,"block":null,"status":null,"gasUsed":null,"logs":null}}}`, resp)
+
+	// an unknown transaction
+	code, resp = execQuery(t, h, fmt.Sprintf(`{transaction(hash:"%s"){nonce}}`, common.HexToHash("0xdead").Hex()))
+	require.Equal(t, 200, code)
+	require.JSONEq(t, `{"data":{"transaction":null}}`, resp)
+}
+
+func TestGraphQLLogs(t *testing.T) {
+	backend := newTestBackend(t, 3)
+	h := newTestHandler(t, backend)
+	txHash := func(n uint64) string {
+		return backend.blocks[n].Transactions[0].Hash().Hex()
+	}
+
+	// unindexed search
+	code, resp := execQuery(t, h, `{logs(filter:{fromBlock:1,toBlock:3}){data,transaction{hash,block{number}}}}`)
+	require.Equal(t, 200, code)
+	require.JSONEq(t, fmt.Sprintf(`{"data":{"logs":[{"data":"0x01","transaction":{"hash":"%s","block":{"number":"0x1"}}},{"data":"0x02","transaction":{"hash":"%s","block":{"number":"0x2"}}},{"data":"0x03","transaction":{"hash":"%s","block":{"number":"0x3"}}}]}}`, txHash(1), txHash(2), txHash(3)), resp)
+
+	// indexed search
+	code, resp = execQuery(t, h, fmt.Sprintf(`{logs(filter:{fromBlock:1,toBlock:3,addresses:["%s"],topics:[[],["%s"]]}){data,transaction{hash}}}`, testContract.Hex(), common.BigToHash(big.NewInt(2)).Hex()))
+	require.Equal(t, 200, code)
+	require.JSONEq(t, fmt.Sprintf(`{"data":{"logs":[{"data":"0x02","transaction":{"hash":"%s"}}]}}`, txHash(2)), resp)
+
+	code, resp = execQuery(t, h, fmt.Sprintf(`{logs(filter:{fromBlock:1,toBlock:3,addresses:["%s"]}){data}}`, testAddr.Hex()))
+	require.Equal(t, 200, code)
+	require.JSONEq(t, `{"data":{"logs":[]}}`, resp)
+
+	// the latest block has no logs
+	code, resp = execQuery(t, h, `{logs(filter:{}){data}}`)
+	require.Equal(t, 200, code)
+	require.JSONEq(t, `{"data":{"logs":[]}}`, resp)
+
+	// the unindexed range limit
+	code, _ = execQuery(t, h, `{logs(filter:{fromBlock:1,toBlock:500}){data}}`)
+	require.Equal(t, 400, code)
+
+	// the logs of a block
+	code, resp = execQuery(t, h, fmt.Sprintf(`{block(number:3){logs(filter:{topics:[["%s"]]}){data,transaction{index}},none:logs(filter:{topics:[["%s"]]}){data}}}`, testTopic.Hex(), common.HexToHash("0xdead").Hex()))
+	require.Equal(t, 200, code)
+	require.JSONEq(t, `{"data":{"block":{"logs":[{"data":"0x03","transaction":{"index":"0x0"}}],"none":[]}}}`, resp)
+}
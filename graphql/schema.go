@@ -0,0 +1,281 @@
+package graphql
+
+const schema string = `
+    # Bytes32 is a 32 byte binary string, represented as 0x-prefixed hexadecimal.
+    scalar Bytes32
+    # Address is a 20 byte Ethereum address, represented as 0x-prefixed hexadecimal.
+    scalar Address
+    # Bytes is an arbitrary length binary string, represented as 0x-prefixed hexadecimal.
+    # An empty byte string is represented as '0x'. Byte strings must have an even number of hexadecimal nybbles.
+    scalar Bytes
+    # BigInt is a large integer. Input is accepted as either a JSON number or as a string.
+    # Strings may be either decimal or 0x-prefixed hexadecimal. Output values are all
+    # 0x-prefixed hexadecimal.
+    scalar BigInt
+    # Long is a 64 bit unsigned integer. Input is accepted as either a JSON number or as a string.
+    # Strings may be either decimal or 0x-prefixed hexadecimal. Output values are all
+    # 0x-prefixed hexadecimal.
+    scalar Long
+
+    schema {
+        query: Query
+    }
+
+    # Account is an account at a particular block.
+    type Account {
+        # Address is the address owning the account.
+        address: Address!
+        # Balance is the balance of the account, in wei.
+        balance: BigInt!
+        # TransactionCount is the number of transactions sent from this account,
+        # or in the case of a contract, the number of contracts created. Otherwise
+        # known as the nonce.
+        transactionCount: Long!
+        # Code contains the smart contract code for this account, if the account
+        # is a (non-self-destructed) contract.
+        code: Bytes!
+        # Storage provides access to the storage of a contract account, indexed
+        # by its 32 byte slot identifier.
+        storage(slot: Bytes32!): Bytes32!
+        # SubscriptionState is the state of the account's subscription to the Subscribers contract,
+        # a contract subscription if the account holds code. Null if the account has no subscription.
+        subscriptionState: SubscriptionState
+        # Whitelisted reports whether the given account is allowed to use the subscription of this contract.
+        whitelisted(account: Address!): Boolean!
+    }
+
+    # SubscriptionState is the state of a subscription to the Subscribers contract.
+    type SubscriptionState {
+        id: BigInt!
+        planId: BigInt!
+        contractSub: Boolean!
+        active: Boolean!
+        startTime: Long!
+        endTime: Long!
+        # Balance is the number of gas units left in the subscription.
+        balance: BigInt!
+        capFrequency: BigInt
+        capUnits: BigInt
+        capWindow: Long!
+        capRemaining: BigInt!
+        periodUsage: BigInt!
+        lastCapReset: Long!
+    }
+
+    # GasPayment is the breakdown of who paid for the gas of a transaction.
+    type GasPayment {
+        receiverSubscriptionGas: Long!
+        receiverSubscriptionRefund: Long!
+        senderSubscriptionGas: Long!
+        senderSubscriptionRefund: Long!
+        payAsYouGoGas: Long!
+        payAsYouGoRefund: Long!
+        # PayAsYouGoCost is the amount of wei debited from the sender's balance, net of refunds.
+        payAsYouGoCost: BigInt!
+        deployerRewardRecipient: Address
+        deployerReward: BigInt
+    }
+
+    # Log is an Ethereum event log.
+    type Log {
+        # Index is the index of this log in the block.
+        index: Int!
+        # Account is the account which generated this log - this will always
+        # be a contract account.
+        account(block: Long): Account!
+        # Topics is a list of 0-4 indexed topics for the log.
+        topics: [Bytes32!]!
+        # Data is unindexed data for this log.
+        data: Bytes!
+        # Transaction is the transaction that generated this log entry.
+        transaction: Transaction!
+    }
+
+    # Transaction is an Ethereum transaction.
+    type Transaction {
+        # Hash is the hash of this transaction.
+        hash: Bytes32!
+        # Nonce is the nonce of the account this transaction was generated with.
+        nonce: Long!
+        # Index is the index of this transaction in the parent block. This will
+        # be null if the transaction has not yet been mined.
+        index: Long
+        # From is the account that sent this transaction - this will always be
+        # an externally owned account.
+        from(block: Long): Account!
+        # To is the account the transaction was sent to. This is null for
+        # contract-creating transactions.
+        to(block: Long): Account
+        # Value is the value, in wei, sent along with this transaction.
+        value: BigInt!
+        # GasPrice is the price offered to miners for gas, in wei per unit.
+        gasPrice: BigInt!
+        # MaxFeePerGas is the maximum fee per gas offered to include a transaction, in wei.
+        maxFeePerGas: BigInt
+        # MaxPriorityFeePerGas is the maximum miner tip per gas offered to include a transaction, in wei.
+        maxPriorityFeePerGas: BigInt
+        # EffectiveGasPrice is actual value per gas deducted from the sender's account.
+        effectiveGasPrice: BigInt
+        # Gas is the maximum amount of gas this transaction can consume.
+        gas: Long!
+        # InputData is the data supplied to the target of the transaction.
+        inputData: Bytes!
+        # Block is the block this transaction was mined in. This will be null if
+        # the transaction has not yet been mined.
+        block: Block
+        # Status is the return status of the transaction. This will be 1 if the
+        # transaction succeeded, or 0 if it failed (due to a revert, or due to
+        # running out of gas). If the transaction has not yet been mined, this
+        # field will be null.
+        status: Long
+        # GasUsed is the amount of gas that was used processing this transaction.
+        # If the transaction has not yet been mined, this field will be null.
+        gasUsed: Long
+        # CumulativeGasUsed is the total gas used in the block up to and including
+        # this transaction. If the transaction has not yet been mined, this field
+        # will be null.
+        cumulativeGasUsed: Long
+        # CreatedContract is the account that was created by a contract creation
+        # transaction. If the transaction was not a contract creation transaction,
+        # or it has not yet been mined, this field will be null.
+        createdContract(block: Long): Account
+        # Logs is a list of log entries emitted by this transaction. If the
+        # transaction has not yet been mined, this field will be null.
+        logs: [Log!]
+        # Type is the transaction type.
+        type: Long
+        # GasPayment is the breakdown of who paid for the gas of this transaction.
+        # Null if the transaction has not yet been mined or its payment wasn't recorded.
+        gasPayment: GasPayment
+    }
+
+    # BlockFilterCriteria encapsulates log filter criteria for a filter applied
+    # to a single block.
+    input BlockFilterCriteria {
+        # Addresses is list of addresses that are of interest. If this list is
+        # empty, results will not be filtered by address.
+        addresses: [Address!]
+        # Topics list restricts matches to particular event topics.
+        topics: [[Bytes32!]!]
+    }
+
+    # Block is an Ethereum block.
+    type Block {
+        # Number is the number of this block, starting at 0 for the genesis block.
+        number: Long!
+        # Hash is the block hash of this block, which is the ID of its Atropos event.
+        hash: Bytes32!
+        # Parent is the parent block of this block.
+        parent: Block
+        # StateRoot is the hash of the state trie after this block was processed.
+        stateRoot: Bytes32!
+        # TransactionsRoot is the hash of the root of the trie of transactions in this block.
+        transactionsRoot: Bytes32!
+        # GasLimit is the maximum amount of gas that was available to transactions in this block.
+        gasLimit: Long!
+        # GasUsed is the amount of gas that was used executing transactions in this block.
+        gasUsed: Long!
+        # BaseFeePerGas is the fee per unit of gas burned by the protocol in this block.
+        baseFeePerGas: BigInt
+        # Timestamp is the unix timestamp at which this block was mined.
+        timestamp: Long!
+        # TransactionCount is the number of transactions in this block.
+        transactionCount: Int
+        # Transactions is a list of transactions associated with this block.
+        transactions: [Transaction!]
+        # TransactionAt returns the transaction at the specified index.
+        transactionAt(index: Int!): Transaction
+        # Logs returns a filtered set of logs from this block.
+        logs(filter: BlockFilterCriteria!): [Log!]!
+        # Account fetches an Ethereum account at the current block's state.
+        account(address: Address!): Account!
+        # Atropos is the DAG event which decided this block.
+        atropos: Event
+    }
+
+    # FilterCriteria encapsulates log filter criteria for searching log entries.
+    input FilterCriteria {
+        # FromBlock is the block at which to start searching, inclusive. Defaults
+        # to the latest block if not supplied.
+        fromBlock: Long
+        # ToBlock is the block at which to stop searching, inclusive. Defaults
+        # to the latest block if not supplied.
+        toBlock: Long
+        # Addresses is a list of addresses that are of interest. If this list is
+        # empty, results will not be filtered by address.
+        addresses: [Address!]
+        # Topics list restricts matches to particular event topics.
+        topics: [[Bytes32!]!]
+    }
+
+    # Event is a Lachesis DAG event.
+    type Event {
+        id: Bytes32!
+        epoch: Long!
+        seq: Long!
+        frame: Long!
+        creator: Long!
+        lamport: Long!
+        creationTime: Long!
+        medianTime: Long!
+        parents: [Bytes32!]!
+        # Parent events, which may be fetched recursively.
+        parentEvents: [Event!]!
+        gasPowerUsed: Long!
+        extraData: Bytes!
+        # TransactionCount is the number of transactions in the event payload.
+        transactionCount: Int!
+        # TransactionHashes are the hashes of the transactions in the event payload.
+        transactionHashes: [Bytes32!]!
+    }
+
+    # Validator is a validator of an epoch.
+    type Validator {
+        id: Long!
+        weight: BigInt!
+        pubKey: Bytes!
+        # Downtime and uptime are available for the current epoch only.
+        offlineBlocks: Long
+        offlineTime: Long
+        uptime: Long
+        originatedFee: BigInt
+    }
+
+    # Epoch is a Lachesis epoch.
+    type Epoch {
+        number: Long!
+        # Start is the time the epoch started at, in nanoseconds.
+        start: Long!
+        # PrevStart is the time the previous epoch started at, in nanoseconds.
+        prevStart: Long!
+        # LastBlock is the last block of the epoch, or the latest block if the epoch isn't sealed yet.
+        lastBlock: Block
+        validators: [Validator!]!
+        # Heads are the IDs of the epoch events with no descendants.
+        heads: [Bytes32!]!
+    }
+
+    type Query {
+        # Block fetches an Ethereum block by number or by hash. If neither is
+        # supplied, the most recent known block is returned.
+        block(number: Long, hash: Bytes32): Block
+        # Blocks returns all the blocks between two numbers, inclusive. If
+        # to is not supplied, it defaults to the most recent known block.
+        blocks(from: Long, to: Long): [Block!]!
+        # Transaction returns a transaction specified by its hash.
+        transaction(hash: Bytes32!): Transaction
+        # Logs returns log entries matching the provided filter.
+        logs(filter: FilterCriteria!): [Log!]!
+        # Account fetches an account at the given block, the latest block by default.
+        account(address: Address!, block: Long): Account!
+        # Epoch fetches an epoch by number. If not supplied, the current epoch is returned.
+        epoch(number: Long): Epoch
+        # Event fetches a DAG event by ID or short ID.
+        event(id: String!): Event
+        # GasPrice returns the node's estimate of a gas price sufficient to
+        # ensure a transaction is mined in a timely fashion.
+        gasPrice: BigInt!
+        # ChainID returns the current chain ID for transaction replay protection.
+        chainID: BigInt!
+    }
+`
@@ -0,0 +1,103 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/node"
+	"github.com/graph-gophers/graphql-go"
+	gqlErrors "github.com/graph-gophers/graphql-go/errors"
+
+	"github.com/artheranet/arthera-node/gossip/filters"
+)
+
+type handler struct {
+	Schema  *graphql.Schema
+	Timeout time.Duration
+}
+
+func (h handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var params struct {
+		Query         string                 `json:"query"`
+		OperationName string                 `json:"operationName"`
+		Variables     map[string]interface{} `json:"variables"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+	if h.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, h.Timeout)
+		defer cancel()
+	}
+
+	var (
+		responded sync.Once
+		respond   = func(body []byte) {
+			responded.Do(func() {
+				w.Header().Set("content-type", "application/json")
+				w.Write(body)
+			})
+		}
+	)
+
+	if h.Timeout > 0 {
+		timer := time.AfterFunc(h.Timeout, func() {
+			// Send a response to the client before the server closes the connection
+			response := &graphql.Response{
+				Errors: []*gqlErrors.QueryError{{Message: "request timed out"}},
+			}
+			responseJSON, err := json.Marshal(response)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			// Setting this disables gzip compression in package node.
+			w.Header().Set("transfer-encoding", "identity")
+			// Flush the response. Since we are writing close to the response timeout,
+			// chunked transfer encoding must be disabled by setting content-length.
+			w.Header().Set("content-length", strconv.Itoa(len(responseJSON)))
+			respond(responseJSON)
+			if flush, ok := w.(http.Flusher); ok {
+				flush.Flush()
+			}
+		})
+		defer timer.Stop()
+	}
+
+	response := h.Schema.Exec(ctx, params.Query, params.OperationName, params.Variables)
+	responseJSON, err := json.Marshal(response)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if len(response.Errors) > 0 {
+		w.WriteHeader(http.StatusBadRequest)
+	}
+	respond(responseJSON)
+}
+
+// New constructs a new GraphQL service instance and mounts it on the HTTP server of the node.
+func New(stack *node.Node, backend Backend, filterConfig filters.Config, cors, vhosts []string) error {
+	s, err := graphql.ParseSchema(schema, newResolver(backend, filterConfig))
+	if err != nil {
+		return err
+	}
+	h := handler{
+		Schema:  s,
+		Timeout: stack.Config().HTTPTimeouts.WriteTimeout,
+	}
+	httpHandler := node.NewHTTPHandlerStack(h, cors, vhosts, nil)
+
+	stack.RegisterHandler("GraphQL", "/graphql", httpHandler)
+	stack.RegisterHandler("GraphQL", "/graphql/", httpHandler)
+
+	return nil
+}
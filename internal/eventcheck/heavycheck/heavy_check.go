@@ -9,12 +9,12 @@ import (
 	"github.com/artheranet/lachesis/hash"
 	"github.com/artheranet/lachesis/inter/idx"
 	"github.com/ethereum/go-ethereum/core/types"
-	"github.com/ethereum/go-ethereum/crypto"
 
 	"github.com/artheranet/arthera-node/internal/eventcheck/basiccheck"
 	"github.com/artheranet/arthera-node/internal/eventcheck/epochcheck"
 	"github.com/artheranet/arthera-node/internal/inter"
 	"github.com/artheranet/arthera-node/internal/inter/validatorpk"
+	"github.com/artheranet/arthera-node/internal/valsig"
 )
 
 var (
@@ -141,10 +141,7 @@ func (v *Checker) EnqueueEV(ev inter.LlrSignedEpochVote, onValidated func(error)
 
 // verifySignature checks the signature against e.Creator.
 func verifySignature(signedHash hash.Hash, sig inter.Signature, pubkey validatorpk.PubKey) bool {
-	if pubkey.Type != validatorpk.Types.Secp256k1 {
-		return false
-	}
-	return crypto.VerifySignature(pubkey.Raw, signedHash.Bytes(), sig.Bytes())
+	return valsig.Verify(pubkey, signedHash.Bytes(), sig.Bytes())
 }
 
 func (v *Checker) ValidateEventLocator(e inter.SignedEventLocator, authEpoch idx.Epoch, authErr error, checkPayload func() bool) error {
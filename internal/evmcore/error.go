@@ -25,6 +25,9 @@ import (
 var (
 	// ErrNoGenesis is returned when there is no Genesis Block.
 	ErrNoGenesis = errors.New("genesis not found in chain")
+
+	// ErrTxSkipped is passed to the TxTracer when a transaction is skipped during block processing.
+	ErrTxSkipped = errors.New("transaction skipped")
 )
 
 // List of evm-call-message pre-checking errors. All state transition messages will
@@ -0,0 +1,44 @@
+package evmcore
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// GasPayment describes who paid for the gas of a transaction.
+// The gas units bought for a transaction are split between the receiver's contract subscription,
+// the sender's subscription and the sender's balance (Pay-as-You-Go).
+// Refunds are the gas units given back to each of the payers after the execution.
+// The gas of a subscription which isn't active after the execution is refunded to the sender's balance,
+// so it's accounted as Pay-as-You-Go gas.
+type GasPayment struct {
+	GasPrice *big.Int
+
+	ReceiverSubscriptionGas    uint64
+	ReceiverSubscriptionRefund uint64
+	SenderSubscriptionGas      uint64
+	SenderSubscriptionRefund   uint64
+	PayAsYouGoGas              uint64
+	PayAsYouGoRefund           uint64
+
+	// DeployerReward is the amount (in wei) credited to the owner of the receiver contract
+	// through the PayAsYouGoGasRewards contract
+	DeployerRewardRecipient common.Address
+	DeployerReward          *big.Int
+}
+
+// PayAsYouGoCost returns the amount of wei debited from the sender's balance for gas, net of refunds.
+func (p *GasPayment) PayAsYouGoCost() *big.Int {
+	if p.GasPrice == nil {
+		return new(big.Int)
+	}
+	charged := new(big.Int).Mul(new(big.Int).SetUint64(p.PayAsYouGoGas), p.GasPrice)
+	refunded := new(big.Int).Mul(new(big.Int).SetUint64(p.PayAsYouGoRefund), p.GasPrice)
+	return charged.Sub(charged, refunded)
+}
+
+// DeployerRewarded returns true if the owner of the receiver contract was credited a reward.
+func (p *GasPayment) DeployerRewarded() bool {
+	return p.DeployerReward != nil && p.DeployerReward.Sign() > 0
+}
@@ -51,22 +51,33 @@ func NewStateProcessor(config *params.ChainConfig, bc DummyChain) *StateProcesso
 	}
 }
 
+// TxTracer is an EVM logger which is notified about the boundaries of the applied transactions.
+// Skipped or failed transactions are finished with a non-nil error.
+// Flush is called once the receipts of the processed transactions are finalized.
+type TxTracer interface {
+	vm.EVMLogger
+	StartTx(tx *types.Transaction)
+	FinishTx(receipt *types.Receipt, err error)
+	Flush()
+}
+
 // Process processes the state changes according to the Ethereum rules by running
 // the transaction messages using the statedb and applying any rewards to both
 // the processor (coinbase) and any included uncles.
 //
-// Process returns the receipts and logs accumulated during the process and
+// Process returns the receipts, gas payments and logs accumulated during the process and
 // returns the amount of gas that was used in the process. If any of the
 // transactions failed to execute due to insufficient gas it will return an error.
 func (p *StateProcessor) Process(
 	block *EvmBlock, statedb *state.StateDB, cfg vm.Config, usedGas *uint64, onNewLog func(*types.Log, *state.StateDB),
 ) (
-	receipts types.Receipts, allLogs []*types.Log, skipped []uint32, err error,
+	receipts types.Receipts, payments []*GasPayment, allLogs []*types.Log, skipped []uint32, err error,
 ) {
 	skipped = make([]uint32, 0, len(block.Transactions))
 	var (
 		gp           = new(GasPool).AddGas(block.GasLimit)
 		receipt      *types.Receipt
+		payment      *GasPayment
 		skip         bool
 		header       = block.Header()
 		blockContext = NewEVMBlockContext(header, p.bc, nil)
@@ -75,24 +86,39 @@ func (p *StateProcessor) Process(
 		blockNumber  = block.Number
 		signer       = gsignercache.Wrap(types.MakeSigner(p.config, header.Number))
 	)
+	txTracer, _ := cfg.Tracer.(TxTracer)
+	if !cfg.Debug {
+		txTracer = nil
+	}
 	// Iterate over and process the individual transactions
 	for i, tx := range block.Transactions {
 		msg, err := TxAsMessage(tx, signer, header.BaseFee)
 		if err != nil {
-			return nil, nil, nil, fmt.Errorf("could not apply tx %d [%v]: %w", i, tx.Hash().Hex(), err)
+			return nil, nil, nil, nil, fmt.Errorf("could not apply tx %d [%v]: %w", i, tx.Hash().Hex(), err)
 		}
 
 		statedb.Prepare(tx.Hash(), i)
-		receipt, _, skip, err = applyTransaction(msg, p.config, gp, statedb, blockNumber, blockHash, tx, usedGas, vmenv, cfg, onNewLog)
+		if txTracer != nil {
+			txTracer.StartTx(tx)
+		}
+		receipt, payment, _, skip, err = applyTransaction(msg, p.config, gp, statedb, blockNumber, blockHash, tx, usedGas, vmenv, cfg, onNewLog)
+		if txTracer != nil {
+			if skip {
+				txTracer.FinishTx(nil, ErrTxSkipped)
+			} else {
+				txTracer.FinishTx(receipt, err)
+			}
+		}
 		if skip {
 			skipped = append(skipped, uint32(i))
 			err = nil
 			continue
 		}
 		if err != nil {
-			return nil, nil, nil, fmt.Errorf("could not apply tx %d [%v]: %w", i, tx.Hash().Hex(), err)
+			return nil, nil, nil, nil, fmt.Errorf("could not apply tx %d [%v]: %w", i, tx.Hash().Hex(), err)
 		}
 		receipts = append(receipts, receipt)
+		payments = append(payments, payment)
 		allLogs = append(allLogs, receipt.Logs...)
 	}
 	return
@@ -112,6 +138,7 @@ func applyTransaction(
 	onNewLog func(*types.Log, *state.StateDB),
 ) (
 	*types.Receipt,
+	*GasPayment,
 	uint64,
 	bool,
 	error,
@@ -123,7 +150,7 @@ func applyTransaction(
 	// Apply the transaction to the current state (included in the env).
 	result, err := ApplyMessage(evm, msg, gp)
 	if err != nil {
-		return nil, 0, result == nil, err
+		return nil, nil, 0, result == nil, err
 	}
 	// Notify about logs with potential state changes
 	logs := statedb.GetLogs(tx.Hash(), blockHash)
@@ -173,7 +200,7 @@ func applyTransaction(
 	receipt.BlockHash = blockHash
 	receipt.BlockNumber = blockNumber
 	receipt.TransactionIndex = uint(statedb.TxIndex())
-	return receipt, result.UsedGas, false, err
+	return receipt, result.Payment, result.UsedGas, false, err
 }
 
 func TxAsMessage(tx *types.Transaction, signer types.Signer, baseFee *big.Int) (types.Message, error) {
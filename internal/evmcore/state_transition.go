@@ -61,6 +61,7 @@ type StateTransition struct {
 	senderSpentGas   uint64
 	receiverSpentGas uint64
 	pyagSpentGas     uint64
+	payment          GasPayment
 	gasPrice         *big.Int
 	initialGas       uint64
 	value            *big.Int
@@ -90,9 +91,10 @@ type Message interface {
 // ExecutionResult includes all output after executing given evm
 // message no matter the execution itself is successful or not.
 type ExecutionResult struct {
-	UsedGas    uint64 // Total used gas but include the refunded gas
-	Err        error  // Any error encountered during the execution(listed in core/vm/errors.go)
-	ReturnData []byte // Returned data from evm(function result or data supplied with revert opcode)
+	UsedGas    uint64      // Total used gas but include the refunded gas
+	Err        error       // Any error encountered during the execution(listed in core/vm/errors.go)
+	ReturnData []byte      // Returned data from evm(function result or data supplied with revert opcode)
+	Payment    *GasPayment // Breakdown of who paid for the gas
 }
 
 // Unwrap returns the internal evm error which allows us for further
@@ -225,42 +227,38 @@ func (st *StateTransition) buyGas(senderSub *subscriber.Subscription, receiverSu
 		log.Trace("Buying gas", "units", pyagGasUnits)
 	}
 
-	if st.gasPrice.BitLen() > 0 {
-		// first check to see if the target is a contract account and it can pay for all gas units from its subscription
-		if SubscriptionDataValid(receiverSub) {
-			if st.hasActiveSubscription(receiverSub) {
-				log.Trace("Receiver has an active subscription")
-				// if the sender is whitelisted with the contract account
-				if IsWhitelistedForContract(*st.msg.To(), st.msg.From(), &st.evmRunner) {
-					// if the contract account has an active subscription, pay gas from its balance
-					subBalance := GetCappedBalance(receiverSub, *st.msg.To(), true, &st.evmRunner)
-					if subBalance.Cmp(pyagGasUnits) < 0 {
-						// receiver's subscription balance is not enough
-						// the overflowed value needs to be covered from the sender
-						pyagGasUnits = pyagGasUnits.Sub(pyagGasUnits, subBalance)
-						log.Trace("Receiver's subscription balance overflows", "balance", subBalance, "overflow", pyagGasUnits)
-					} else {
-						// the subscription has enough balance to cover the gas, nothing left to pay
-						pyagGasUnits = big.NewInt(0)
-						log.Trace("Receiver's subscription balance is enough", "balance", subBalance)
-					}
-				}
+	payer := selectGasPayer(st.msg, st.gasPrice, senderSub, receiverSub, st.evm.Context.Time, &st.evmRunner)
+
+	// first check to see if the gas units can be paid from a subscription
+	// the sender's subscription which pays instead of the receiver's one isn't taken into account
+	if !payer.unchecked {
+		switch payer.payer {
+		case receiverSubscriptionPayer:
+			log.Trace("Receiver has an active subscription")
+			// if the contract account has an active subscription, pay gas from its balance
+			subBalance := GetCappedBalance(receiverSub, *st.msg.To(), true, &st.evmRunner)
+			if subBalance.Cmp(pyagGasUnits) < 0 {
+				// receiver's subscription balance is not enough
+				// the overflowed value needs to be covered from the sender
+				pyagGasUnits = pyagGasUnits.Sub(pyagGasUnits, subBalance)
+				log.Trace("Receiver's subscription balance overflows", "balance", subBalance, "overflow", pyagGasUnits)
+			} else {
+				// the subscription has enough balance to cover the gas, nothing left to pay
+				pyagGasUnits = big.NewInt(0)
+				log.Trace("Receiver's subscription balance is enough", "balance", subBalance)
 			}
-		} else {
-			// the contract account does not have a subscription, take fees from the user's subscription
-			if st.hasActiveSubscription(senderSub) {
-				log.Trace("Sender has an active subscription")
-				subBalance := GetCappedBalance(senderSub, st.msg.From(), false, &st.evmRunner)
-				if subBalance.Cmp(pyagGasUnits) < 0 {
-					// the subscription balance is not enough
-					// the overflowed value needs to be covered from Pay-as-You-Go
-					pyagGasUnits = pyagGasUnits.Sub(pyagGasUnits, subBalance)
-					log.Trace("Sender's subscription balance overflows", "balance", subBalance, "overflow", pyagGasUnits)
-				} else {
-					// the subscription has enough balance to cover the gas, nothing left to pay
-					pyagGasUnits = big.NewInt(0)
-					log.Trace("Sender's subscription balance is enough", "balance", subBalance)
-				}
+		case senderSubscriptionPayer:
+			log.Trace("Sender has an active subscription")
+			subBalance := GetCappedBalance(senderSub, st.msg.From(), false, &st.evmRunner)
+			if subBalance.Cmp(pyagGasUnits) < 0 {
+				// the subscription balance is not enough
+				// the overflowed value needs to be covered from Pay-as-You-Go
+				pyagGasUnits = pyagGasUnits.Sub(pyagGasUnits, subBalance)
+				log.Trace("Sender's subscription balance overflows", "balance", subBalance, "overflow", pyagGasUnits)
+			} else {
+				// the subscription has enough balance to cover the gas, nothing left to pay
+				pyagGasUnits = big.NewInt(0)
+				log.Trace("Sender's subscription balance is enough", "balance", subBalance)
 			}
 		}
 	}
@@ -291,25 +289,22 @@ func (st *StateTransition) buyGas(senderSub *subscriber.Subscription, receiverSu
 	// copy it to st.initialGas to keep the initial gas value specified in the message
 	st.initialGas = st.msg.Gas()
 
-	// debit the gas, redo the same logic as above
+	// debit the gas from the selected subscription
 	pyagGasUnits = new(big.Int).SetUint64(st.msg.Gas())
 
-	if st.gasPrice.BitLen() > 0 {
-		if SubscriptionDataValid(receiverSub) && st.hasActiveSubscription(receiverSub) {
-			if IsWhitelistedForContract(*st.msg.To(), st.msg.From(), &st.evmRunner) {
-				// receiver pays from his subscription the entire cost is the caller is whitelisted
-				// the caps are handled by the subscribers contract
-				log.Trace("Debit from receiver's subscription", "units", pyagGasUnits)
-				pyagGasUnits = DebitSubscription(*st.msg.To(), pyagGasUnits, true, &st.evmRunner)
-				st.receiverSpentGas = st.msg.Gas() - pyagGasUnits.Uint64()
-			}
-		} else if st.hasActiveSubscription(senderSub) {
-			// sender pays the rest from his subscription
-			// the caps are handled by the subscribers contract
-			log.Trace("Debit from sender's subscription", "units", pyagGasUnits)
-			pyagGasUnits = DebitSubscription(st.msg.From(), pyagGasUnits, false, &st.evmRunner)
-			st.senderSpentGas = st.msg.Gas() - pyagGasUnits.Uint64()
-		}
+	switch payer.payer {
+	case receiverSubscriptionPayer:
+		// receiver pays from his subscription the entire cost is the caller is whitelisted
+		// the caps are handled by the subscribers contract
+		log.Trace("Debit from receiver's subscription", "units", pyagGasUnits)
+		pyagGasUnits = DebitSubscription(*st.msg.To(), pyagGasUnits, true, &st.evmRunner)
+		st.receiverSpentGas = st.msg.Gas() - pyagGasUnits.Uint64()
+	case senderSubscriptionPayer:
+		// sender pays the rest from his subscription
+		// the caps are handled by the subscribers contract
+		log.Trace("Debit from sender's subscription", "units", pyagGasUnits)
+		pyagGasUnits = DebitSubscription(st.msg.From(), pyagGasUnits, false, &st.evmRunner)
+		st.senderSpentGas = st.msg.Gas() - pyagGasUnits.Uint64()
 	}
 
 	// if there's anything else to pay not covered by subscriptions, do a standard (Pay-as-You-Go) payment
@@ -476,15 +471,24 @@ func (st *StateTransition) TransitionDb() (*ExecutionResult, error) {
 				err := pyag.AddReward(&st.evmRunner, owner, refund)
 				if err == nil {
 					st.state.AddBalance(contracts.PayAsYouGoGasRewardsContractAddress, refund)
+					st.payment.DeployerRewardRecipient = owner
+					st.payment.DeployerReward = refund
 				}
 			}
 		}
 	}
 
+	// the refund may have moved the gas of subscriptions to Pay-as-You-Go, so the breakdown is known only now
+	st.payment.GasPrice = st.gasPrice
+	st.payment.ReceiverSubscriptionGas = st.receiverSpentGas
+	st.payment.SenderSubscriptionGas = st.senderSpentGas
+	st.payment.PayAsYouGoGas = st.pyagSpentGas
+
 	return &ExecutionResult{
 		UsedGas:    st.gasUsed(),
 		Err:        vmerr,
 		ReturnData: ret,
+		Payment:    &st.payment,
 	}, nil
 }
 
@@ -513,10 +517,12 @@ func (st *StateTransition) refundGas(
 				if receiverGasRefund > 0 {
 					log.Trace("Credit receiver subscription", "refund (units)", receiverGasRefund)
 					CreditSubscription(st.to(), new(big.Int).SetUint64(receiverGasRefund), true, &st.evmRunner)
+					st.payment.ReceiverSubscriptionRefund = receiverGasRefund
 				}
 			} else {
 				// if the receiver does hot have an active subscription, give the refund to PYAG
 				st.pyagSpentGas += st.receiverSpentGas
+				st.receiverSpentGas = 0
 			}
 		}
 
@@ -527,6 +533,7 @@ func (st *StateTransition) refundGas(
 			if st.hasActiveSubscription(senderSubscription) {
 				log.Trace("Credit sender subscription", "refund (units)", senderGasRefund)
 				CreditSubscription(st.msg.From(), new(big.Int).SetUint64(senderGasRefund), false, &st.evmRunner)
+				st.payment.SenderSubscriptionRefund = senderGasRefund
 			} else {
 				if st.isSubscribersCall() && senderHadActiveSubscription && !st.hasActiveSubscription(senderSubscription) {
 					// the sender had an active subscription and no longer has it
@@ -535,6 +542,7 @@ func (st *StateTransition) refundGas(
 					if newSubscriber != params2.ZeroAddress {
 						// if yes, give the refund to the subscription
 						CreditSubscription(newSubscriber, new(big.Int).SetUint64(senderGasRefund), false, &st.evmRunner)
+						st.payment.SenderSubscriptionRefund = senderGasRefund
 					} else {
 						// subscription was terminated, don't give a refund
 					}
@@ -542,6 +550,7 @@ func (st *StateTransition) refundGas(
 					// if the sender does hot have an active subscription, give the refund to PYAG
 					log.Trace("Refunding Sender", "senderSpentGas", st.senderSpentGas)
 					st.pyagSpentGas += st.senderSpentGas
+					st.senderSpentGas = 0
 				}
 			}
 		}
@@ -551,6 +560,7 @@ func (st *StateTransition) refundGas(
 			pyagRefund := new(big.Int).Mul(new(big.Int).SetUint64(pyagGasRefund), st.gasPrice)
 			log.Trace("Credit Pay-as-You-Go", "refund (units)", pyagGasRefund, "refund (wei)", pyagRefund.String())
 			st.state.AddBalance(st.msg.From(), pyagRefund)
+			st.payment.PayAsYouGoRefund = pyagGasRefund
 		}
 	} else {
 		remaining := new(big.Int).Mul(new(big.Int).SetUint64(st.gas), st.gasPrice)
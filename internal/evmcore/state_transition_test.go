@@ -0,0 +1,326 @@
+package evmcore
+
+import (
+	"math"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/stretchr/testify/require"
+
+	"github.com/artheranet/arthera-node/contracts"
+	"github.com/artheranet/arthera-node/contracts/abis"
+	"github.com/artheranet/arthera-node/contracts/subscriber"
+)
+
+type testSubKey struct {
+	addr        common.Address
+	contractSub bool
+}
+
+// testSubscribers is a stand-in for the Subscribers contract
+type testSubscribers struct {
+	subs        map[testSubKey]*subscriber.Subscription
+	caps        map[testSubKey]*big.Int
+	whitelisted map[common.Address]map[common.Address]bool
+}
+
+func newTestSubscribers() *testSubscribers {
+	return &testSubscribers{
+		subs:        map[testSubKey]*subscriber.Subscription{},
+		caps:        map[testSubKey]*big.Int{},
+		whitelisted: map[common.Address]map[common.Address]bool{},
+	}
+}
+
+func (c *testSubscribers) subscribe(addr common.Address, contractSub bool, id int64, balance uint64) *subscriber.Subscription {
+	sub := &subscriber.Subscription{
+		Id:           big.NewInt(id),
+		PlanId:       big.NewInt(1),
+		Balance:      new(big.Int).SetUint64(balance),
+		StartTime:    big.NewInt(1),
+		EndTime:      big.NewInt(2000),
+		LastCapReset: big.NewInt(1),
+		PeriodUsage:  new(big.Int),
+	}
+	c.subs[testSubKey{addr, contractSub}] = sub
+	return sub
+}
+
+func (c *testSubscribers) whitelist(contract, account common.Address) {
+	if c.whitelisted[contract] == nil {
+		c.whitelisted[contract] = map[common.Address]bool{}
+	}
+	c.whitelisted[contract][account] = true
+}
+
+func (c *testSubscribers) get(addr common.Address, contractSub bool) subscriber.Subscription {
+	if sub := c.subs[testSubKey{addr, contractSub}]; sub != nil {
+		return *sub
+	}
+	return subscriber.Subscription{
+		Id:           new(big.Int),
+		PlanId:       new(big.Int),
+		Balance:      new(big.Int),
+		StartTime:    new(big.Int),
+		EndTime:      new(big.Int),
+		LastCapReset: new(big.Int),
+		PeriodUsage:  new(big.Int),
+	}
+}
+
+func (c *testSubscribers) Run(evm *vm.EVM, caller common.Address, input []byte, suppliedGas uint64) ([]byte, uint64, error) {
+	if len(input) < 4 {
+		return nil, 0, vm.ErrExecutionReverted
+	}
+	method, err := abis.Subscribers.MethodById(input[:4])
+	if err != nil {
+		return nil, 0, vm.ErrExecutionReverted
+	}
+	args, err := method.Inputs.Unpack(input[4:])
+	if err != nil {
+		return nil, 0, vm.ErrExecutionReverted
+	}
+
+	var out []interface{}
+	switch method.Name {
+	case "getSubscriptionData":
+		out = []interface{}{c.get(args[0].(common.Address), args[1].(bool))}
+	case "getCapRemaining":
+		capRemaining := c.caps[testSubKey{args[0].(common.Address), args[1].(bool)}]
+		if capRemaining == nil {
+			capRemaining = InfiniteCap
+		}
+		out = []interface{}{capRemaining}
+	case "hasActiveSubscription":
+		sub := c.get(args[0].(common.Address), args[1].(bool))
+		out = []interface{}{sub.EndTime.Cmp(evm.Context.Time) >= 0 && sub.Balance.Sign() > 0}
+	case "getPlan":
+		// every plan sells 1000 units for 5000 wei
+		out = []interface{}{args[0].(*big.Int), "", "", big.NewInt(1000), big.NewInt(1000), big.NewInt(5000), new(big.Int), new(big.Int), false, true}
+	case "isWhitelistedForContract":
+		out = []interface{}{c.whitelisted[args[0].(common.Address)][args[1].(common.Address)]}
+	case "subscribersById":
+		res := common.Address{}
+		for key, sub := range c.subs {
+			if sub.Id.Cmp(args[0].(*big.Int)) == 0 {
+				res = key.addr
+			}
+		}
+		out = []interface{}{res}
+	case "debit":
+		units := new(big.Int).Set(args[1].(*big.Int))
+		key := testSubKey{args[0].(common.Address), args[2].(bool)}
+		if sub := c.subs[key]; sub != nil {
+			covered := sub.Balance
+			if capRemaining := c.caps[key]; capRemaining != nil && covered.Cmp(capRemaining) > 0 {
+				covered = capRemaining
+			}
+			if covered.Cmp(units) > 0 {
+				covered = units
+			}
+			units.Sub(units, covered)
+			sub.Balance = new(big.Int).Sub(sub.Balance, covered)
+		}
+		out = []interface{}{units}
+	case "credit":
+		if sub := c.subs[testSubKey{args[0].(common.Address), args[2].(bool)}]; sub != nil {
+			sub.Balance = new(big.Int).Add(sub.Balance, args[1].(*big.Int))
+		}
+	default:
+		return nil, 0, vm.ErrExecutionReverted
+	}
+	ret, err := method.Outputs.Pack(out...)
+	if err != nil {
+		return nil, 0, vm.ErrExecutionReverted
+	}
+	return ret, suppliedGas, nil
+}
+
+var (
+	testGasPrice   = big.NewInt(1e9)
+	testSender     = common.HexToAddress("0x1000000000000000000000000000000000000001")
+	testAccount    = common.HexToAddress("0x1000000000000000000000000000000000000002")
+	testContract   = common.HexToAddress("0x1000000000000000000000000000000000000003")
+	testInitialBal = new(big.Int).Mul(big.NewInt(1e18), big.NewInt(10))
+)
+
+func newTestTransitionState() *state.StateDB {
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	statedb.SetCode(contracts.SubscribersSmartContractAddress, []byte{0})
+	statedb.SetCode(testContract, []byte{0}) // STOP
+	statedb.SetBalance(testSender, testInitialBal)
+	return statedb
+}
+
+func newTestEVM(subs *testSubscribers, statedb *state.StateDB, msg Message) *vm.EVM {
+	blockCtx := vm.BlockContext{
+		CanTransfer: CanTransfer,
+		Transfer:    Transfer,
+		GetHash:     func(uint64) common.Hash { return common.Hash{} },
+		BlockNumber: big.NewInt(1),
+		Time:        big.NewInt(1000),
+		Difficulty:  big.NewInt(1),
+		BaseFee:     big.NewInt(0),
+		GasLimit:    math.MaxUint64,
+	}
+	return vm.NewEVM(blockCtx, NewEVMTxContext(msg), statedb, params.TestChainConfig, vm.Config{
+		StatePrecompiles: map[common.Address]vm.PrecompiledStateContract{
+			contracts.SubscribersSmartContractAddress: subs,
+		},
+	})
+}
+
+func newTestMessage(to *common.Address, gas uint64, gasPrice *big.Int) Message {
+	return types.NewMessage(testSender, to, 0, new(big.Int), gas, gasPrice, gasPrice, gasPrice, nil, nil, false)
+}
+
+func applyTestMessage(t *testing.T, subs *testSubscribers, statedb *state.StateDB, to common.Address, gas uint64) *ExecutionResult {
+	msg := newTestMessage(&to, gas, testGasPrice)
+	res, err := ApplyMessage(newTestEVM(subs, statedb, msg), msg, new(GasPool).AddGas(math.MaxUint64))
+	require.NoError(t, err)
+	require.NoError(t, res.Err)
+	return res
+}
+
+func gasCost(gas uint64) *big.Int {
+	return new(big.Int).Mul(new(big.Int).SetUint64(gas), testGasPrice)
+}
+
+func TestStateTransitionGasPayment(t *testing.T) {
+	const gas = 100000
+
+	checkConsistency := func(t *testing.T, p *GasPayment) {
+		// every bought gas unit is paid by someone, and nobody gets back more than it paid
+		require.Equal(t, uint64(gas), p.ReceiverSubscriptionGas+p.SenderSubscriptionGas+p.PayAsYouGoGas)
+		require.LessOrEqual(t, p.ReceiverSubscriptionRefund, p.ReceiverSubscriptionGas)
+		require.LessOrEqual(t, p.SenderSubscriptionRefund, p.SenderSubscriptionGas)
+		require.LessOrEqual(t, p.PayAsYouGoRefund, p.PayAsYouGoGas)
+		require.True(t, p.PayAsYouGoCost().Sign() >= 0)
+		require.Equal(t, testGasPrice, p.GasPrice)
+	}
+
+	t.Run("pay-as-you-go", func(t *testing.T) {
+		require := require.New(t)
+		subs := newTestSubscribers()
+		statedb := newTestTransitionState()
+
+		res := applyTestMessage(t, subs, statedb, testAccount, gas)
+		p := res.Payment
+		checkConsistency(t, p)
+		require.Equal(uint64(gas), p.PayAsYouGoGas)
+		require.Equal(gas-res.UsedGas, p.PayAsYouGoRefund)
+		require.Equal(gasCost(res.UsedGas), p.PayAsYouGoCost())
+		require.Equal(new(big.Int).Sub(testInitialBal, gasCost(res.UsedGas)), statedb.GetBalance(testSender))
+	})
+
+	t.Run("sender subscription", func(t *testing.T) {
+		require := require.New(t)
+		subs := newTestSubscribers()
+		sub := subs.subscribe(testSender, false, 1, 1000000)
+		statedb := newTestTransitionState()
+
+		res := applyTestMessage(t, subs, statedb, testAccount, gas)
+		p := res.Payment
+		checkConsistency(t, p)
+		require.Equal(uint64(gas), p.SenderSubscriptionGas)
+		require.Equal(gas-res.UsedGas, p.SenderSubscriptionRefund)
+		require.Zero(p.PayAsYouGoGas)
+		require.Zero(p.PayAsYouGoCost().Sign())
+		require.Equal(testInitialBal, statedb.GetBalance(testSender))
+		require.Equal(uint64(1000000)-res.UsedGas, sub.Balance.Uint64())
+	})
+
+	t.Run("sender subscription overflow", func(t *testing.T) {
+		require := require.New(t)
+		subs := newTestSubscribers()
+		subs.subscribe(testSender, false, 1, 40000)
+		statedb := newTestTransitionState()
+
+		res := applyTestMessage(t, subs, statedb, testAccount, gas)
+		p := res.Payment
+		checkConsistency(t, p)
+		// the exhausted subscription isn't active anymore, so its gas is refunded to the balance
+		require.Zero(p.SenderSubscriptionGas)
+		require.Zero(p.SenderSubscriptionRefund)
+		require.Equal(uint64(gas), p.PayAsYouGoGas)
+		require.Equal(gas-res.UsedGas, p.PayAsYouGoRefund)
+		require.Equal(new(big.Int).Add(new(big.Int).Sub(testInitialBal, gasCost(gas-40000)), gasCost(gas-res.UsedGas)), statedb.GetBalance(testSender))
+	})
+
+	t.Run("sender subscription with a cap", func(t *testing.T) {
+		require := require.New(t)
+		subs := newTestSubscribers()
+		subs.subscribe(testSender, false, 1, 1000000)
+		subs.caps[testSubKey{testSender, false}] = big.NewInt(60000)
+		statedb := newTestTransitionState()
+
+		res := applyTestMessage(t, subs, statedb, testAccount, gas)
+		p := res.Payment
+		checkConsistency(t, p)
+		// the gas over the cap is paid from the balance, the refund is split proportionally
+		require.Equal(uint64(60000), p.SenderSubscriptionGas)
+		require.Equal((gas-res.UsedGas)*60000/gas, p.SenderSubscriptionRefund)
+		require.Equal(uint64(40000), p.PayAsYouGoGas)
+		require.Equal((gas-res.UsedGas)*40000/gas, p.PayAsYouGoRefund)
+	})
+
+	t.Run("receiver subscription", func(t *testing.T) {
+		require := require.New(t)
+		subs := newTestSubscribers()
+		sub := subs.subscribe(testContract, true, 1, 1000000)
+		subs.subscribe(testSender, false, 2, 1000000)
+		subs.whitelist(testContract, testSender)
+		statedb := newTestTransitionState()
+
+		res := applyTestMessage(t, subs, statedb, testContract, gas)
+		p := res.Payment
+		checkConsistency(t, p)
+		require.Equal(uint64(gas), p.ReceiverSubscriptionGas)
+		require.Equal(gas-res.UsedGas, p.ReceiverSubscriptionRefund)
+		require.Zero(p.SenderSubscriptionGas)
+		require.Zero(p.PayAsYouGoGas)
+		require.Equal(testInitialBal, statedb.GetBalance(testSender))
+		require.Equal(uint64(1000000)-res.UsedGas, sub.Balance.Uint64())
+	})
+
+	t.Run("receiver subscription exhausted", func(t *testing.T) {
+		require := require.New(t)
+		subs := newTestSubscribers()
+		subs.subscribe(testContract, true, 1, gas)
+		subs.whitelist(testContract, testSender)
+		statedb := newTestTransitionState()
+
+		res := applyTestMessage(t, subs, statedb, testContract, gas)
+		p := res.Payment
+		checkConsistency(t, p)
+		// the subscription isn't active after the debit, so the refund goes to the sender's balance
+		require.Zero(p.ReceiverSubscriptionGas)
+		require.Zero(p.ReceiverSubscriptionRefund)
+		require.Equal(uint64(gas), p.PayAsYouGoGas)
+		require.Equal(gas-res.UsedGas, p.PayAsYouGoRefund)
+		require.Equal(new(big.Int).Add(testInitialBal, gasCost(gas-res.UsedGas)), statedb.GetBalance(testSender))
+	})
+
+	t.Run("receiver subscription not whitelisted", func(t *testing.T) {
+		require := require.New(t)
+		subs := newTestSubscribers()
+		subs.subscribe(testContract, true, 1, 1000000)
+		subs.subscribe(testSender, false, 2, 1000000)
+		statedb := newTestTransitionState()
+
+		res := applyTestMessage(t, subs, statedb, testContract, gas)
+		p := res.Payment
+		checkConsistency(t, p)
+		// the sender's subscription isn't used either, as the receiver has a subscription
+		require.Zero(p.ReceiverSubscriptionGas)
+		require.Zero(p.SenderSubscriptionGas)
+		require.Equal(uint64(gas), p.PayAsYouGoGas)
+		require.Equal(gasCost(res.UsedGas), p.PayAsYouGoCost())
+	})
+}
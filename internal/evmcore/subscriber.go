@@ -184,3 +184,127 @@ func GetSubscriberById(subId *big.Int, vmRunner vmcontext.EVMRunner) common.Addr
 	}
 	return sub
 }
+
+type gasPayer uint8
+
+const (
+	payAsYouGoPayer gasPayer = iota
+	receiverSubscriptionPayer
+	senderSubscriptionPayer
+)
+
+// gasPayerChoice is the subscription selected to pay for the gas of a message
+type gasPayerChoice struct {
+	payer gasPayer
+	// unchecked is true if the sender's subscription pays instead of the inactive subscription of the receiver.
+	// Such subscription isn't taken into account when the sender's balance is checked before buying gas
+	unchecked bool
+	// reason explains why no subscription pays, empty if a subscription pays
+	reason string
+}
+
+// selectGasPayer selects the subscription which pays for the gas of a message.
+// It's used both to buy gas and to preview the payment, so that the preview matches the execution.
+func selectGasPayer(msg Message, gasPrice *big.Int, senderSub, receiverSub *subscriber.Subscription, blockTime *big.Int, vmRunner vmcontext.EVMRunner) gasPayerChoice {
+	if gasPrice.Sign() == 0 {
+		// this is from eth_call or eth_estimateGas, so subscriptions are not applied
+		return gasPayerChoice{reason: "zero gas price, subscriptions are not used"}
+	}
+	receiverSubValid := SubscriptionDataValid(receiverSub)
+	if receiverSubValid && SubscriptionDataActive(receiverSub, blockTime) {
+		// a contract with an active subscription pays only for the whitelisted callers
+		if !IsWhitelistedForContract(*msg.To(), msg.From(), vmRunner) {
+			return gasPayerChoice{reason: "sender is not whitelisted for the receiver subscription"}
+		}
+		return gasPayerChoice{payer: receiverSubscriptionPayer}
+	}
+	if SubscriptionDataActive(senderSub, blockTime) {
+		return gasPayerChoice{
+			payer:     senderSubscriptionPayer,
+			unchecked: receiverSubValid,
+		}
+	}
+	if receiverSubValid {
+		return gasPayerChoice{reason: "receiver subscription is not active"}
+	}
+	return gasPayerChoice{reason: "no active subscription"}
+}
+
+// GasPaymentPreview describes how the gas of a message would be paid for at the moment of buying gas.
+type GasPaymentPreview struct {
+	Gas                     uint64
+	GasPrice                *big.Int
+	ReceiverSubscriptionGas uint64
+	SenderSubscriptionGas   uint64
+	PayAsYouGoGas           uint64
+	PayAsYouGoCost          *big.Int
+	// Affordable is true if the sender's balance covers both the Pay-as-You-Go cost and the transferred value
+	Affordable bool
+	// CapResetTime is the end of the current cap window of the paying subscription, if the cap was hit
+	CapResetTime inter.Timestamp
+	// Reason explains why the subscriptions don't cover the entire gas, empty if they do
+	Reason string
+}
+
+// PreviewGasPayment splits the gas of a message between the receiver's subscription, the sender's subscription
+// and Pay-as-You-Go, selecting the payer in the same way as StateTransition.buyGas does.
+// The state isn't modified.
+func PreviewGasPayment(msg Message, state *state.StateDB, blockTime *big.Int, vmRunner vmcontext.EVMRunner) *GasPaymentPreview {
+	from := msg.From()
+	preview := &GasPaymentPreview{
+		Gas:      msg.Gas(),
+		GasPrice: msg.GasPrice(),
+	}
+	covered := uint64(0)
+
+	var senderSub, receiverSub *subscriber.Subscription
+	if msg.GasPrice().Sign() != 0 {
+		senderSub = GetSubscriptionData(from, false, vmRunner)
+		if msg.To() != nil && state.GetCodeSize(*msg.To()) > 0 {
+			receiverSub = GetSubscriptionData(*msg.To(), true, vmRunner)
+		}
+	}
+	payer := selectGasPayer(msg, msg.GasPrice(), senderSub, receiverSub, blockTime, vmRunner)
+	preview.Reason = payer.reason
+	switch payer.payer {
+	case receiverSubscriptionPayer:
+		covered = previewSubscription(preview, receiverSub, *msg.To(), true, "receiver", vmRunner)
+		preview.ReceiverSubscriptionGas = covered
+	case senderSubscriptionPayer:
+		covered = previewSubscription(preview, senderSub, from, false, "sender", vmRunner)
+		preview.SenderSubscriptionGas = covered
+	}
+
+	preview.PayAsYouGoGas = msg.Gas() - covered
+	preview.PayAsYouGoCost = new(big.Int).Mul(new(big.Int).SetUint64(preview.PayAsYouGoGas), msg.GasPrice())
+	// the balance check before buying gas doesn't take an unchecked subscription into account
+	checked := preview.PayAsYouGoCost
+	if payer.unchecked {
+		checked = new(big.Int).Mul(new(big.Int).SetUint64(msg.Gas()), msg.GasPrice())
+	}
+	balance := state.GetBalance(from)
+	preview.Affordable = balance.Cmp(checked) >= 0 && balance.Cmp(new(big.Int).Add(preview.PayAsYouGoCost, msg.Value())) >= 0
+	return preview
+}
+
+// previewSubscription returns the number of gas units the subscription would cover and fills the reason
+// if the subscription doesn't cover the entire gas.
+func previewSubscription(preview *GasPaymentPreview, sub *subscriber.Subscription, address common.Address, contractSub bool, who string, vmRunner vmcontext.EVMRunner) uint64 {
+	gas := new(big.Int).SetUint64(preview.Gas)
+	capRemaining := GetCapRemaining(address, contractSub, vmRunner)
+	subBalance := GetCappedBalance(sub, address, contractSub, vmRunner)
+	if subBalance.Cmp(gas) >= 0 {
+		return preview.Gas
+	}
+	if capRemaining.Cmp(InfiniteCap) != 0 && capRemaining.Cmp(sub.Balance) < 0 {
+		preview.CapResetTime = GetCapWindow(address, contractSub, vmRunner)
+		if capRemaining.Sign() == 0 {
+			preview.Reason = who + " subscription cap window is exhausted"
+		} else {
+			preview.Reason = who + " subscription cap window remaining is insufficient"
+		}
+	} else {
+		preview.Reason = who + " subscription balance is insufficient"
+	}
+	return subBalance.Uint64()
+}
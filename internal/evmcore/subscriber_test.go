@@ -0,0 +1,173 @@
+package evmcore
+
+import (
+	"math"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+
+	"github.com/artheranet/arthera-node/internal/evmcore/vmcontext"
+)
+
+func TestSelectGasPayer(t *testing.T) {
+	const gas = 100000
+
+	expire := func(c *testSubscribers, addr common.Address, contractSub bool) {
+		c.subs[testSubKey{addr, contractSub}].EndTime = big.NewInt(500)
+	}
+
+	for _, tt := range []struct {
+		name     string
+		to       *common.Address
+		gasPrice *big.Int
+		setup    func(c *testSubscribers)
+		expect   gasPayerChoice
+	}{
+		{
+			name:     "zero gas price",
+			to:       &testAccount,
+			gasPrice: new(big.Int),
+			setup: func(c *testSubscribers) {
+				c.subscribe(testSender, false, 1, 1000000)
+			},
+			expect: gasPayerChoice{reason: "zero gas price, subscriptions are not used"},
+		},
+		{
+			name:   "no subscriptions",
+			to:     &testAccount,
+			setup:  func(c *testSubscribers) {},
+			expect: gasPayerChoice{reason: "no active subscription"},
+		},
+		{
+			name: "sender subscription",
+			to:   &testAccount,
+			setup: func(c *testSubscribers) {
+				c.subscribe(testSender, false, 1, 1000000)
+			},
+			expect: gasPayerChoice{payer: senderSubscriptionPayer},
+		},
+		{
+			name: "sender subscription of a contract creation",
+			to:   nil,
+			setup: func(c *testSubscribers) {
+				c.subscribe(testSender, false, 1, 1000000)
+			},
+			expect: gasPayerChoice{payer: senderSubscriptionPayer},
+		},
+		{
+			name: "expired sender subscription",
+			to:   &testAccount,
+			setup: func(c *testSubscribers) {
+				c.subscribe(testSender, false, 1, 1000000)
+				expire(c, testSender, false)
+			},
+			expect: gasPayerChoice{reason: "no active subscription"},
+		},
+		{
+			name: "receiver subscription",
+			to:   &testContract,
+			setup: func(c *testSubscribers) {
+				c.subscribe(testContract, true, 1, 1000000)
+				c.subscribe(testSender, false, 2, 1000000)
+				c.whitelist(testContract, testSender)
+			},
+			expect: gasPayerChoice{payer: receiverSubscriptionPayer},
+		},
+		{
+			name: "receiver subscription of a non-whitelisted sender",
+			to:   &testContract,
+			setup: func(c *testSubscribers) {
+				c.subscribe(testContract, true, 1, 1000000)
+				c.subscribe(testSender, false, 2, 1000000)
+			},
+			expect: gasPayerChoice{reason: "sender is not whitelisted for the receiver subscription"},
+		},
+		{
+			name: "expired receiver subscription",
+			to:   &testContract,
+			setup: func(c *testSubscribers) {
+				c.subscribe(testContract, true, 1, 1000000)
+				c.whitelist(testContract, testSender)
+				expire(c, testContract, true)
+			},
+			expect: gasPayerChoice{reason: "receiver subscription is not active"},
+		},
+		{
+			name: "expired receiver subscription falls back to the sender subscription",
+			to:   &testContract,
+			setup: func(c *testSubscribers) {
+				c.subscribe(testContract, true, 1, 1000000)
+				c.subscribe(testSender, false, 2, 1000000)
+				c.whitelist(testContract, testSender)
+				expire(c, testContract, true)
+			},
+			expect: gasPayerChoice{payer: senderSubscriptionPayer, unchecked: true},
+		},
+		{
+			name: "contract without a subscription",
+			to:   &testContract,
+			setup: func(c *testSubscribers) {
+				c.subscribe(testSender, false, 1, 1000000)
+			},
+			expect: gasPayerChoice{payer: senderSubscriptionPayer},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			require := require.New(t)
+			gasPrice := tt.gasPrice
+			if gasPrice == nil {
+				gasPrice = testGasPrice
+			}
+			subs := newTestSubscribers()
+			tt.setup(subs)
+			statedb := newTestTransitionState()
+			msg := newTestMessage(tt.to, gas, gasPrice)
+			evm := newTestEVM(subs, statedb, msg)
+			runner := &vmcontext.SharedEVMRunner{EVM: evm}
+
+			senderSub := GetSubscriptionData(testSender, false, runner)
+			receiverSub := GetSubscriptionData(testContract, true, runner)
+			if tt.to == nil || *tt.to != testContract {
+				receiverSub = nil
+			}
+			require.Equal(tt.expect, selectGasPayer(msg, gasPrice, senderSub, receiverSub, evm.Context.Time, runner))
+
+			// the preview has to match the execution
+			preview := PreviewGasPayment(msg, statedb, evm.Context.Time, runner)
+			require.Equal(tt.expect.reason, preview.Reason)
+			require.True(preview.Affordable)
+			res, err := ApplyMessage(evm, msg, new(GasPool).AddGas(math.MaxUint64))
+			require.NoError(err)
+			if gasPrice.Sign() == 0 {
+				return
+			}
+			require.Equal(preview.ReceiverSubscriptionGas, res.Payment.ReceiverSubscriptionGas)
+			require.Equal(preview.SenderSubscriptionGas, res.Payment.SenderSubscriptionGas)
+			require.Equal(preview.PayAsYouGoGas, res.Payment.PayAsYouGoGas)
+		})
+	}
+}
+
+func TestPreviewGasPaymentUncheckedBalance(t *testing.T) {
+	require := require.New(t)
+	const gas = 100000
+
+	subs := newTestSubscribers()
+	subs.subscribe(testContract, true, 1, 1000000).EndTime = big.NewInt(500)
+	subs.subscribe(testSender, false, 2, 1000000)
+	statedb := newTestTransitionState()
+	// the balance doesn't cover the gas, which is checked before the sender's subscription is debited
+	statedb.SetBalance(testSender, new(big.Int).Sub(gasCost(gas), big.NewInt(1)))
+
+	msg := newTestMessage(&testContract, gas, testGasPrice)
+	evm := newTestEVM(subs, statedb, msg)
+	preview := PreviewGasPayment(msg, statedb, evm.Context.Time, &vmcontext.SharedEVMRunner{EVM: evm})
+	require.Equal(uint64(gas), preview.SenderSubscriptionGas)
+	require.Zero(preview.PayAsYouGoGas)
+	require.False(preview.Affordable)
+
+	_, err := ApplyMessage(evm, msg, new(GasPool).AddGas(math.MaxUint64))
+	require.ErrorIs(err, ErrInsufficientFunds)
+}
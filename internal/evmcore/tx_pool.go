@@ -90,6 +90,10 @@ var (
 	// than some meaningful limit a user might use. This is not a consensus error
 	// making the transaction invalid, rather a DOS protection.
 	ErrOversizedData = errors.New("oversized data")
+
+	// ErrSponsorSlotsExceeded is returned if the contract paying for the gas of a
+	// transaction already sponsors the maximum number of pooled transactions.
+	ErrSponsorSlotsExceeded = errors.New("sponsor slots exceeded")
 )
 
 var (
@@ -171,6 +175,7 @@ type TxPoolConfig struct {
 	GlobalSlots  uint64 // Maximum number of executable transaction slots for all accounts
 	AccountQueue uint64 // Maximum number of non-executable transaction slots permitted per account
 	GlobalQueue  uint64 // Maximum number of non-executable transaction slots for all accounts
+	SponsorSlots uint64 // Maximum number of transaction slots sponsored by a single contract subscription
 
 	Lifetime time.Duration // Maximum amount of time non-executable transaction are queued
 }
@@ -188,6 +193,7 @@ var DefaultTxPoolConfig = TxPoolConfig{
 	GlobalSlots:  4096 + 1024, // urgent + floating queue capacity with 4:1 ratio
 	AccountQueue: 64,
 	GlobalQueue:  1024,
+	SponsorSlots: 1024,
 
 	Lifetime: 3 * time.Hour,
 }
@@ -220,6 +226,10 @@ func (config *TxPoolConfig) sanitize() TxPoolConfig {
 		log.Warn("Sanitizing invalid txpool global queue", "provided", conf.GlobalQueue, "updated", DefaultTxPoolConfig.GlobalQueue)
 		conf.GlobalQueue = DefaultTxPoolConfig.GlobalQueue
 	}
+	if conf.SponsorSlots < 1 {
+		log.Warn("Sanitizing invalid txpool sponsor slots", "provided", conf.SponsorSlots, "updated", DefaultTxPoolConfig.SponsorSlots)
+		conf.SponsorSlots = DefaultTxPoolConfig.SponsorSlots
+	}
 	if conf.Lifetime < 1 {
 		log.Warn("Sanitizing invalid txpool lifetime", "provided", conf.Lifetime, "updated", DefaultTxPoolConfig.Lifetime)
 		conf.Lifetime = DefaultTxPoolConfig.Lifetime
@@ -253,6 +263,8 @@ type TxPool struct {
 	pendingNonces   *txNoncer // Pending state tracking virtual nonces
 	currentMaxGas   uint64    // Current gas limit for transaction caps
 
+	subscriptions atomic.Value // *subscriptionCache of the Subscribers contract calls at the current head
+
 	locals  *accountSet // Set of local transaction to exempt from eviction rules
 	journal *txJournal  // Journal of local transaction to back up to disk
 
@@ -261,6 +273,7 @@ type TxPool struct {
 	beats   map[common.Address]time.Time // Last heartbeat from each known account
 	all     *txLookup                    // All transactions to allow lookups
 	priced  *txPricedList                // All transactions sorted by price
+	payers  *txPayers                    // Payers of the pooled transactions, tracking the sponsored ones
 
 	chainHeadCh     chan ChainHeadNotify
 	chainHeadSub    notify.Subscription
@@ -295,6 +308,7 @@ func NewTxPool(config TxPoolConfig, chainconfig *params.ChainConfig, chain State
 		queue:           make(map[common.Address]*txList),
 		beats:           make(map[common.Address]time.Time),
 		all:             newTxLookup(),
+		payers:          newTxPayers(),
 		chainHeadCh:     make(chan ChainHeadNotify, chainHeadChanSize),
 		reqResetCh:      make(chan *txpoolResetRequest),
 		reqPromoteCh:    make(chan *accountSet),
@@ -698,6 +712,14 @@ func (pool *TxPool) add(tx *types.Transaction, local bool) (replaced bool, err e
 		invalidTxMeter.Mark(1)
 		return false, err
 	}
+	// Resolve who pays for the gas and stop a single sponsor from flooding the pool
+	from, _ := types.Sender(pool.signer, tx) // already validated
+	payer := pool.subscriptionCache().txPayerOf(from, tx)
+	if !isLocal && payer.sponsor != (common.Address{}) && pool.sponsorSlotsUsed(payer.sponsor) >= pool.config.SponsorSlots {
+		log.Trace("Discarding transaction exceeding the sponsor slots", "hash", hash, "sponsor", payer.sponsor)
+		sponsorRateLimitMeter.Mark(1)
+		return false, ErrSponsorSlotsExceeded
+	}
 	// If the transaction pool is full, discard underpriced transactions
 	if uint64(pool.all.Slots()+numSlots(tx)) > pool.config.GlobalSlots+pool.config.GlobalQueue {
 		// If the new transaction is underpriced, don't accept it
@@ -736,7 +758,6 @@ func (pool *TxPool) add(tx *types.Transaction, local bool) (replaced bool, err e
 		}
 	}
 	// Try to replace an existing transaction in the pending pool
-	from, _ := types.Sender(pool.signer, tx) // already validated
 	if list := pool.pending[from]; list != nil && list.Overlaps(tx) {
 		// Nonce already pending, check if required price bump is met
 		inserted, old := list.Add(tx, pool.config.PriceBump)
@@ -752,6 +773,7 @@ func (pool *TxPool) add(tx *types.Transaction, local bool) (replaced bool, err e
 		}
 		pool.all.Add(tx, isLocal)
 		pool.priced.Put(tx, isLocal)
+		pool.payers.put(hash, payer)
 		pool.journalTx(from, tx)
 		pool.queueTxEvent(tx)
 		log.Trace("Pooled new executable transaction", "hash", hash, "from", from, "to", tx.To())
@@ -765,6 +787,7 @@ func (pool *TxPool) add(tx *types.Transaction, local bool) (replaced bool, err e
 	if err != nil {
 		return false, err
 	}
+	pool.payers.put(hash, payer)
 	// Mark local addresses and journal local transactions
 	if local && !pool.locals.contains(from) {
 		log.Trace("Setting new local account", "address", from)
@@ -942,6 +965,13 @@ func (pool *TxPool) addTxs(txs []*types.Transaction, local, sync bool) []error {
 	if len(news) == 0 {
 		return errs
 	}
+	// Resolve the payers before obtaining the lock, so the Subscribers
+	// contract calls are made outside of it and found memoized later
+	subscriptions := pool.subscriptionCache()
+	for _, tx := range news {
+		from, _ := types.Sender(pool.signer, tx) // already validated
+		subscriptions.txPayerOf(from, tx)
+	}
 
 	// Process all the new transaction and merge any errors into the original slice
 	pool.mu.Lock()
@@ -1243,8 +1273,24 @@ func (pool *TxPool) runReorg(done chan struct{}, reset *txpoolResetRequest, dirt
 	pool.changesSinceReorg = 0 // Reset change counter
 	pool.mu.Unlock()
 
+	// Re-validate the sponsored transactions against the new state. The sponsors subscriptions
+	// are loaded before obtaining the lock again, as they need the Subscribers contract calls
+	if reset != nil {
+		subscriptions := pool.subscriptionCache()
+		for _, sponsor := range pool.sponsoredContracts() {
+			subscriptions.sponsor(sponsor)
+		}
+		pool.mu.Lock()
+		pool.revalidateSponsored(subscriptions)
+		pool.mu.Unlock()
+	}
+
 	// Notify subsystems for newly added transactions
 	for _, tx := range promoted {
+		if pool.all.Get(tx.Hash()) == nil {
+			// evicted by the re-validation
+			continue
+		}
 		addr, _ := types.Sender(pool.signer, tx)
 		if _, ok := events[addr]; !ok {
 			events[addr] = newTxSortedMap()
@@ -1346,6 +1392,8 @@ func (pool *TxPool) reset(oldHead, newHead *EvmHeader) {
 	}
 	pool.currentState = statedb
 	pool.currentVMRunner = NewEVMRunner(pool.chain, pool.chainconfig, newHead, statedb)
+	subscriptionsState := statedb.Copy()
+	pool.subscriptions.Store(newSubscriptionCache(subscriptionsState, NewEVMRunner(pool.chain, pool.chainconfig, newHead, subscriptionsState)))
 	pool.pendingNonces = newTxNoncer(statedb)
 	pool.currentMaxGas = pool.chain.MaxGasLimit()
 
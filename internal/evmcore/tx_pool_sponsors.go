@@ -0,0 +1,266 @@
+package evmcore
+
+import (
+	"bytes"
+	"math/big"
+	"sort"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+
+	"github.com/artheranet/arthera-node/contracts/subscriber"
+	"github.com/artheranet/arthera-node/internal/evmcore/vmcontext"
+)
+
+var (
+	sponsorRateLimitMeter = metrics.GetOrRegisterMeter("txpool/sponsored/ratelimit", nil) // Rejected due to the sponsor's slots limit
+	sponsorNofundsMeter   = metrics.GetOrRegisterMeter("txpool/sponsored/nofunds", nil)   // Dropped due to the sponsor's exhausted cap
+)
+
+// txPayer describes who pays for the gas of a pooled transaction
+type txPayer struct {
+	// sponsor is the contract which pays for the gas from its subscription, zero if not sponsored
+	sponsor common.Address
+	// feePerGas is the price of a gas unit of the subscription which covers the gas,
+	// nil if the gas is paid under Pay-as-You-Go
+	feePerGas *big.Int
+}
+
+// subscriptionState is the state of a subscription observed at a block
+type subscriptionState struct {
+	valid     bool
+	active    bool
+	capped    *big.Int // balance available within the current cap window
+	feePerGas *big.Int
+}
+
+func (s subscriptionState) equal(other subscriptionState) bool {
+	return s.active == other.active && s.capped.Cmp(other.capped) == 0
+}
+
+type subscriptionKey struct {
+	addr        common.Address
+	contractSub bool
+}
+
+type whitelistKey struct {
+	contract common.Address
+	account  common.Address
+}
+
+// subscriptionCache memoizes the Subscribers contract calls of the pool policy at a block.
+// The calls run on a copy of the state, so the cache is used without holding the pool lock.
+type subscriptionCache struct {
+	mu     sync.Mutex
+	state  *state.StateDB
+	runner vmcontext.EVMRunner
+
+	subs        map[subscriptionKey]subscriptionState
+	whitelisted map[whitelistKey]bool
+	planFees    map[string]*big.Int // price of a gas unit per plan
+}
+
+func newSubscriptionCache(statedb *state.StateDB, runner vmcontext.EVMRunner) *subscriptionCache {
+	return &subscriptionCache{
+		state:       statedb,
+		runner:      runner,
+		subs:        make(map[subscriptionKey]subscriptionState),
+		whitelisted: make(map[whitelistKey]bool),
+		planFees:    make(map[string]*big.Int),
+	}
+}
+
+// planFeePerGas returns the price of a gas unit of the subscription plan
+func (c *subscriptionCache) planFeePerGas(planId *big.Int) *big.Int {
+	if fee, ok := c.planFees[planId.String()]; ok {
+		return fee
+	}
+	var fee *big.Int
+	plan, err := subscriber.GetPlan(c.runner, planId)
+	if err != nil {
+		log.Debug("Subscribers::getPlan() failed", "error", err.Error())
+	} else if plan != nil && plan.Units != nil && plan.Units.Sign() > 0 && plan.Price != nil {
+		fee = new(big.Int).Div(plan.Price, plan.Units)
+	}
+	c.planFees[planId.String()] = fee
+	return fee
+}
+
+// subscription reads the state of the subscription
+func (c *subscriptionCache) subscription(addr common.Address, contractSub bool) subscriptionState {
+	key := subscriptionKey{addr, contractSub}
+	if st, ok := c.subs[key]; ok {
+		return st
+	}
+	st := subscriptionState{
+		capped: new(big.Int),
+	}
+	sub := GetSubscriptionData(addr, contractSub, c.runner)
+	st.valid = SubscriptionDataValid(sub)
+	if st.valid && HasActiveSubscription(addr, contractSub, c.runner) {
+		st.active = true
+		st.capped = GetCappedBalance(sub, addr, contractSub, c.runner)
+		st.feePerGas = c.planFeePerGas(sub.PlanId)
+	}
+	c.subs[key] = st
+	return st
+}
+
+func (c *subscriptionCache) isWhitelisted(contract common.Address, account common.Address) bool {
+	key := whitelistKey{contract, account}
+	if whitelisted, ok := c.whitelisted[key]; ok {
+		return whitelisted
+	}
+	whitelisted := IsWhitelistedForContract(contract, account, c.runner)
+	c.whitelisted[key] = whitelisted
+	return whitelisted
+}
+
+// sponsor returns the state of the contract's subscription
+func (c *subscriptionCache) sponsor(contract common.Address) subscriptionState {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.subscription(contract, true)
+}
+
+// txPayerOf resolves who pays for the gas of the transaction at the block,
+// following the same rules as the gas purchase of the state transition.
+func (c *subscriptionCache) txPayerOf(from common.Address, tx *types.Transaction) txPayer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	gas := new(big.Int).SetUint64(tx.Gas())
+	if tx.To() != nil && c.state.GetCodeSize(*tx.To()) > 0 {
+		contract := *tx.To()
+		if sponsor := c.subscription(contract, true); sponsor.valid {
+			if !c.isWhitelisted(contract, from) || !sponsor.active {
+				return txPayer{}
+			}
+			payer := txPayer{sponsor: contract}
+			if sponsor.capped.Cmp(gas) >= 0 {
+				payer.feePerGas = sponsor.feePerGas
+			}
+			return payer
+		}
+	}
+	sub := c.subscription(from, false)
+	if !sub.active || sub.capped.Cmp(gas) < 0 {
+		return txPayer{}
+	}
+	return txPayer{feePerGas: sub.feePerGas}
+}
+
+// subscriptionCache returns the cache of the Subscribers contract calls at the current head
+func (pool *TxPool) subscriptionCache() *subscriptionCache {
+	return pool.subscriptions.Load().(*subscriptionCache)
+}
+
+// sponsorSlotsUsed returns the number of pooled transactions sponsored by the contract
+func (pool *TxPool) sponsorSlotsUsed(sponsor common.Address) uint64 {
+	return uint64(pool.payers.pruneSponsor(sponsor, pool.all))
+}
+
+// sponsoredContracts returns the contracts sponsoring the pooled transactions
+func (pool *TxPool) sponsoredContracts() []common.Address {
+	pool.mu.RLock()
+	defer pool.mu.RUnlock()
+
+	sponsors := make([]common.Address, 0, len(pool.payers.bySponsor))
+	for sponsor := range pool.payers.bySponsor {
+		sponsors = append(sponsors, sponsor)
+	}
+	return sponsors
+}
+
+// revalidateSponsored re-validates the sponsored transactions of every sponsor whose
+// subscription balance or cap window changed since the previous reset.
+// The sponsor's available balance is distributed among its transactions in the nonce order
+// of every sender, the transactions which are no longer covered and whose senders can't pay
+// for the rest are evicted.
+// The sponsors subscriptions are expected to be loaded into the cache beforehand.
+//
+// Note, this method assumes the pool lock is held!
+func (pool *TxPool) revalidateSponsored(cache *subscriptionCache) {
+	pool.payers.prune(pool.all)
+
+	for sponsor, hashes := range pool.payers.bySponsor {
+		state := cache.sponsor(sponsor)
+		prev, known := pool.payers.sponsors[sponsor]
+		pool.payers.sponsors[sponsor] = state
+		if known && prev.equal(state) {
+			continue
+		}
+
+		// the nonces are ordered per sender only
+		bySender := make(map[common.Address]types.Transactions)
+		for hash := range hashes {
+			if tx := pool.all.Get(hash); tx != nil {
+				from, _ := types.Sender(pool.signer, tx) // already validated
+				bySender[from] = append(bySender[from], tx)
+			}
+		}
+		senders := make([]common.Address, 0, len(bySender))
+		for from := range bySender {
+			senders = append(senders, from)
+		}
+		sort.Slice(senders, func(i, j int) bool {
+			return bytes.Compare(senders[i].Bytes(), senders[j].Bytes()) < 0
+		})
+
+		available := new(big.Int).Set(state.capped)
+		for _, from := range senders {
+			txs := bySender[from]
+			sort.Sort(types.TxByNonce(txs))
+			evicted := false
+			for _, tx := range txs {
+				if evicted {
+					// the later nonces aren't executable anymore, they don't consume the sponsor's balance
+					pool.payers.put(tx.Hash(), txPayer{sponsor: sponsor})
+					continue
+				}
+				gas := new(big.Int).SetUint64(tx.Gas())
+				covered := new(big.Int).Set(gas)
+				if available.Cmp(covered) < 0 {
+					covered.Set(available)
+				}
+
+				uncovered := new(big.Int).Sub(gas, covered)
+				cost := new(big.Int).Mul(uncovered, tx.GasPrice())
+				cost.Add(cost, tx.Value())
+				if pool.currentState.GetBalance(from).Cmp(cost) < 0 {
+					log.Trace("Removed sponsored transaction with exhausted sponsor", "hash", tx.Hash(), "sponsor", sponsor)
+					sponsorNofundsMeter.Mark(1)
+					pool.removeTx(tx.Hash(), true)
+					evicted = true
+					continue
+				}
+				available.Sub(available, covered)
+				payer := txPayer{sponsor: sponsor}
+				if uncovered.Sign() == 0 {
+					payer.feePerGas = state.feePerGas
+				}
+				pool.payers.put(tx.Hash(), payer)
+			}
+		}
+		pool.payers.pruneSponsor(sponsor, pool.all)
+	}
+}
+
+// SubscriptionFees returns the price of a gas unit for the pooled transactions
+// whose gas is covered by a subscription, either the sender's or the sponsor's one.
+func (pool *TxPool) SubscriptionFees() map[common.Hash]*big.Int {
+	pool.mu.RLock()
+	defer pool.mu.RUnlock()
+
+	fees := make(map[common.Hash]*big.Int, len(pool.payers.byHash))
+	for hash, payer := range pool.payers.byHash {
+		if payer.feePerGas != nil && pool.all.Get(hash) != nil {
+			fees[hash] = payer.feePerGas
+		}
+	}
+	return fees
+}
@@ -0,0 +1,164 @@
+package evmcore
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+
+	"github.com/artheranet/arthera-node/internal/evmcore/vmcontext"
+)
+
+// setTestSubscriptions replaces the Subscribers contract calls of the pool with the stand-in
+func setTestSubscriptions(pool *TxPool, subs *testSubscribers) *subscriptionCache {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+	statedb := pool.currentState.Copy()
+	evm := newTestEVM(subs, statedb, newTestMessage(nil, 0, new(big.Int)))
+	cache := newSubscriptionCache(statedb, &vmcontext.SharedEVMRunner{EVM: evm})
+	pool.subscriptions.Store(cache)
+	return cache
+}
+
+func sponsoredTransaction(nonce uint64, gas uint64, key *ecdsa.PrivateKey) *types.Transaction {
+	tx, _ := types.SignTx(types.NewTransaction(nonce, testContract, new(big.Int), gas, big.NewInt(1), nil), types.HomesteadSigner{}, key)
+	return tx
+}
+
+// sortedTestKeys returns the keys ordered by their addresses
+func sortedTestKeys(n int) []*ecdsa.PrivateKey {
+	keys := make([]*ecdsa.PrivateKey, n)
+	for i := range keys {
+		keys[i], _ = crypto.GenerateKey()
+		for j := i; j > 0 && bytes.Compare(crypto.PubkeyToAddress(keys[j].PublicKey).Bytes(), crypto.PubkeyToAddress(keys[j-1].PublicKey).Bytes()) < 0; j-- {
+			keys[j], keys[j-1] = keys[j-1], keys[j]
+		}
+	}
+	return keys
+}
+
+func setupSponsoredTxPool(keys ...*ecdsa.PrivateKey) *TxPool {
+	pool, _ := setupTxPool()
+	pool.mu.Lock()
+	pool.currentState.SetCode(testContract, []byte{0})
+	for _, key := range keys {
+		pool.currentState.AddBalance(crypto.PubkeyToAddress(key.PublicKey), big.NewInt(1e6))
+	}
+	pool.mu.Unlock()
+	return pool
+}
+
+func TestTxPoolSponsorSlots(t *testing.T) {
+	require := require.New(t)
+
+	keys := sortedTestKeys(4)
+	pool := setupSponsoredTxPool(keys...)
+	defer pool.Stop()
+	pool.config.SponsorSlots = 2
+
+	subs := newTestSubscribers()
+	subs.subscribe(testContract, true, 1, 1000000)
+	for _, key := range keys[:3] {
+		subs.whitelist(testContract, crypto.PubkeyToAddress(key.PublicKey))
+	}
+	setTestSubscriptions(pool, subs)
+
+	sponsored := []*types.Transaction{
+		sponsoredTransaction(0, 100000, keys[0]),
+		sponsoredTransaction(0, 100000, keys[1]),
+	}
+	for _, err := range pool.AddRemotesSync(sponsored) {
+		require.NoError(err)
+	}
+	// the sponsor's slots are exhausted for the remote transactions
+	require.ErrorIs(pool.AddRemote(sponsoredTransaction(0, 100000, keys[2])), ErrSponsorSlotsExceeded)
+	// the transactions of non-whitelisted senders aren't sponsored
+	unsponsored := sponsoredTransaction(0, 100000, keys[3])
+	require.NoError(pool.AddRemote(unsponsored))
+	// the local transactions aren't limited
+	local := sponsoredTransaction(1, 100000, keys[0])
+	require.NoError(pool.AddLocal(local))
+
+	pending, queued := pool.Stats()
+	require.Equal(4, pending)
+	require.Zero(queued)
+	require.Equal(uint64(3), pool.sponsorSlotsUsed(testContract))
+
+	fees := pool.SubscriptionFees()
+	require.Len(fees, 3)
+	for _, tx := range append(sponsored, local) {
+		require.Equal(big.NewInt(5), fees[tx.Hash()])
+	}
+
+	// the slots are released when the transactions leave the pool
+	pool.mu.Lock()
+	pool.removeTx(sponsored[1].Hash(), true)
+	pool.removeTx(local.Hash(), true)
+	pool.mu.Unlock()
+	require.NoError(pool.AddRemote(sponsoredTransaction(0, 100000, keys[2])))
+	require.ErrorIs(pool.AddRemote(sponsoredTransaction(1, 100000, keys[2])), ErrSponsorSlotsExceeded)
+}
+
+func TestTxPoolSponsorEviction(t *testing.T) {
+	require := require.New(t)
+
+	keys := sortedTestKeys(2)
+	pool := setupSponsoredTxPool(keys...)
+	defer pool.Stop()
+
+	subs := newTestSubscribers()
+	sponsor := subs.subscribe(testContract, true, 1, 300000)
+	for _, key := range keys {
+		subs.whitelist(testContract, crypto.PubkeyToAddress(key.PublicKey))
+	}
+	cache := setTestSubscriptions(pool, subs)
+
+	txs := []*types.Transaction{
+		sponsoredTransaction(0, 100000, keys[0]),
+		sponsoredTransaction(1, 100000, keys[0]),
+		sponsoredTransaction(0, 100000, keys[1]),
+	}
+	for _, err := range pool.AddRemotesSync(txs) {
+		require.NoError(err)
+	}
+	require.Len(pool.SubscriptionFees(), 3)
+
+	pool.mu.Lock()
+	pool.revalidateSponsored(cache)
+	pool.mu.Unlock()
+	require.Len(pool.SubscriptionFees(), 3, "unchanged sponsor")
+
+	// the sponsor's balance covers a single transaction,
+	// the first sender can't pay for the rest of its second transaction
+	sponsor.Balance = big.NewInt(150000)
+	cache = setTestSubscriptions(pool, subs)
+	pool.mu.Lock()
+	pool.currentState.SetBalance(crypto.PubkeyToAddress(keys[0].PublicKey), new(big.Int))
+	pool.revalidateSponsored(cache)
+	pool.mu.Unlock()
+
+	require.NotNil(pool.all.Get(txs[0].Hash()))
+	require.Nil(pool.all.Get(txs[1].Hash()))
+	require.NotNil(pool.all.Get(txs[2].Hash()))
+	fees := pool.SubscriptionFees()
+	require.Len(fees, 1)
+	require.Equal(big.NewInt(5), fees[txs[0].Hash()])
+	require.Equal(uint64(2), pool.sponsorSlotsUsed(testContract))
+
+	// the expired sponsor covers nothing, the transactions which can't be paid are evicted
+	sponsor.EndTime = big.NewInt(500)
+	cache = setTestSubscriptions(pool, subs)
+	pool.mu.Lock()
+	pool.revalidateSponsored(cache)
+	pool.mu.Unlock()
+
+	require.Nil(pool.all.Get(txs[0].Hash()))
+	require.NotNil(pool.all.Get(txs[2].Hash()))
+	require.Empty(pool.SubscriptionFees())
+	require.Equal(uint64(1), pool.sponsorSlotsUsed(testContract))
+}
@@ -0,0 +1,32 @@
+package iblockproc
+
+import (
+	"math/big"
+
+	"github.com/artheranet/lachesis/inter/idx"
+
+	"github.com/artheranet/arthera-node/internal/inter"
+)
+
+// ValidatorEpochMetrics is a performance record of a validator within one epoch.
+// Counters are accumulated block by block, the rest is filled when the epoch is sealed.
+type ValidatorEpochMetrics struct {
+	// Events is the number of confirmed events created by the validator
+	Events uint32
+	// BlockVotes is the number of LLR block votes in the confirmed events
+	BlockVotes uint32
+	// EpochVotes is the number of LLR epoch votes in the confirmed events
+	EpochVotes uint32
+	// MisbehaviourProofs is the number of misbehaviour proofs reported against the validator
+	MisbehaviourProofs uint32
+
+	Sealed       bool
+	Weight       *big.Int
+	Uptime       inter.Timestamp
+	MissedBlocks idx.Block
+	MissedTime   inter.Timestamp
+	// DowntimeForgiven is true if the missed blocks are within BlockMissedSlack
+	DowntimeForgiven bool
+	OriginatedFee    *big.Int
+	Cheater          bool
+}
@@ -5,6 +5,7 @@ import (
 	"github.com/artheranet/lachesis/hash"
 	"github.com/artheranet/lachesis/inter/idx"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rlp"
 
 	"github.com/artheranet/arthera-node/internal/inter"
 )
@@ -30,6 +31,9 @@ type LlrFullBlockRecord struct {
 type LlrIdxFullBlockRecord struct {
 	LlrFullBlockRecord
 	Idx idx.Block
+	// GasPaymentsRLP is the gas payments breakdown of the block transactions.
+	// It isn't covered by the record hash, so it's accepted only from trusted sources such as genesis files
+	GasPaymentsRLP rlp.RawValue `rlp:"optional"`
 }
 
 func (bv LlrBlockVote) Hash() hash.Hash {
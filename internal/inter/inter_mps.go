@@ -53,3 +53,46 @@ type MisbehaviourProof struct {
 
 	WrongEpochVote *WrongEpochVote `rlp:"nil"`
 }
+
+// MisbehaviourType is a type of misbehaviour proof
+type MisbehaviourType uint8
+
+const (
+	EventsDoublesignType MisbehaviourType = iota + 1
+	BlockVoteDoublesignType
+	WrongBlockVoteType
+	EpochVoteDoublesignType
+	WrongEpochVoteType
+)
+
+func (t MisbehaviourType) String() string {
+	switch t {
+	case EventsDoublesignType:
+		return "EventsDoublesign"
+	case BlockVoteDoublesignType:
+		return "BlockVoteDoublesign"
+	case WrongBlockVoteType:
+		return "WrongBlockVote"
+	case EpochVoteDoublesignType:
+		return "EpochVoteDoublesign"
+	case WrongEpochVoteType:
+		return "WrongEpochVote"
+	default:
+		return "unknown"
+	}
+}
+
+// MisbehaviourEvidence is a record of a misbehaviour proof which caused a validator to be reported as a cheater
+type MisbehaviourEvidence struct {
+	Type     MisbehaviourType
+	Reporter idx.ValidatorID
+	Offender idx.ValidatorID
+	// Epoch is the epoch of the doublesigned events or the epoch of the voted block/epoch
+	Epoch idx.Epoch
+	// Block is the voted block, zero for events and epoch vote proofs
+	Block idx.Block
+	// Event is the event which carried the proof
+	Event hash.Event
+	// AppliedBlock is the block which applied the proof
+	AppliedBlock idx.Block
+}
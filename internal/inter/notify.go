@@ -0,0 +1,15 @@
+package inter
+
+import (
+	"time"
+
+	"github.com/artheranet/lachesis/hash"
+)
+
+// ConfirmedEventNotify is posted when an event gets confirmed by an Atropos.
+type ConfirmedEventNotify struct {
+	Event   EventI
+	Atropos hash.Event
+	// Time is the local time of the confirmation
+	Time time.Time
+}
@@ -1,6 +1,8 @@
 package validatorpk
 
 import (
+	"bytes"
+
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/pkg/errors"
 )
@@ -16,14 +18,22 @@ type PubKey struct {
 
 var Types = struct {
 	Secp256k1 uint8
+	Ed25519   uint8
+	Bls12381  uint8
 }{
 	Secp256k1: 0xc0,
+	Ed25519:   0xc1,
+	Bls12381:  0xc2,
 }
 
 func (pk PubKey) Empty() bool {
 	return len(pk.Raw) == 0 && pk.Type == 0
 }
 
+func (pk PubKey) Equal(other PubKey) bool {
+	return pk.Type == other.Type && bytes.Equal(pk.Raw, other.Raw)
+}
+
 func (pk PubKey) String() string {
 	return "0x" + common.Bytes2Hex(pk.Bytes())
 }
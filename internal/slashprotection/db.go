@@ -0,0 +1,378 @@
+// Package slashprotection keeps the history of everything signed by validator keys,
+// so a validator never signs a doublesign even after a crash, a migration to another host or a key rotation.
+// The records are kept per key, and the keys are linked to the validator which signed with them,
+// so the checks span all the keys of the validator.
+package slashprotection
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/artheranet/lachesis/hash"
+	"github.com/artheranet/lachesis/inter/idx"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/opt"
+	"github.com/syndtr/goleveldb/leveldb/storage"
+	"github.com/syndtr/goleveldb/leveldb/util"
+
+	"github.com/artheranet/arthera-node/internal/inter"
+	"github.com/artheranet/arthera-node/internal/inter/validatorpk"
+)
+
+var (
+	ErrRefused = errors.New("refused by doublesign protection")
+)
+
+const (
+	keyPrefix        = 'k'
+	eventPrefix      = 'e'
+	blockVotesPrefix = 'b'
+	epochVotePrefix  = 'v'
+	legacyPrefix     = 'l'
+	validatorPrefix  = 'i'
+)
+
+type (
+	// Event is a signed event along with the LLR votes it carries
+	Event struct {
+		ID hash.Event
+		// Creator is the validator of the key, zero if unknown
+		Creator    idx.ValidatorID
+		Seq        idx.Event
+		BlockVotes BlockVotes
+		EpochVote  inter.LlrEpochVote
+	}
+	// BlockVotes is a signed range of block votes. Zero if the event has no block votes
+	BlockVotes struct {
+		Start idx.Block
+		Last  idx.Block
+		Epoch idx.Epoch
+	}
+)
+
+func NewEvent(id hash.Event, creator idx.ValidatorID, seq idx.Event, bvs inter.LlrBlockVotes, ev inter.LlrEpochVote) Event {
+	e := Event{
+		ID:        id,
+		Creator:   creator,
+		Seq:       seq,
+		EpochVote: ev,
+	}
+	if len(bvs.Votes) != 0 {
+		e.BlockVotes = BlockVotes{
+			Start: bvs.Start,
+			Last:  bvs.LastBlock(),
+			Epoch: bvs.Epoch,
+		}
+	}
+	return e
+}
+
+func EventOf(e inter.EventPayloadI) Event {
+	return NewEvent(e.ID(), e.Creator(), e.Seq(), e.BlockVotes(), e.EpochVote())
+}
+
+// DB is the doublesign protection database
+type DB struct {
+	db    *leveldb.DB
+	write *opt.WriteOptions
+	mu    sync.Mutex
+}
+
+// Open opens the database in the dir. If isSyncMode is true, every record is flushed to disk before returning.
+func Open(dir string, isSyncMode bool) (*DB, error) {
+	db, err := leveldb.OpenFile(dir, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &DB{
+		db:    db,
+		write: &opt.WriteOptions{Sync: isSyncMode},
+	}, nil
+}
+
+// NewMem creates an in-memory database
+func NewMem() *DB {
+	db, err := leveldb.Open(storage.NewMemStorage(), nil)
+	if err != nil {
+		panic(err)
+	}
+	return &DB{
+		db:    db,
+		write: &opt.WriteOptions{},
+	}
+}
+
+func (p *DB) Close() error {
+	return p.db.Close()
+}
+
+func keyID(pubkey validatorpk.PubKey) []byte {
+	return hash.Of(pubkey.Bytes()).Bytes()
+}
+
+func validatorKey(validator idx.ValidatorID, pubkey validatorpk.PubKey) []byte {
+	return append(append([]byte{validatorPrefix}, validator.Bytes()...), keyID(pubkey)...)
+}
+
+func recordKey(prefix byte, pubkey validatorpk.PubKey, suffix ...[]byte) []byte {
+	key := append([]byte{prefix}, keyID(pubkey)...)
+	for _, s := range suffix {
+		key = append(key, s...)
+	}
+	return key
+}
+
+func (p *DB) get(key []byte) []byte {
+	val, err := p.db.Get(key, nil)
+	if err == leveldb.ErrNotFound {
+		return nil
+	}
+	if err != nil {
+		panic(fmt.Errorf("failed to read doublesign protection DB: %v", err))
+	}
+	return val
+}
+
+// last returns the record with the greatest key within the prefix
+func (p *DB) last(prefix []byte) (key, val []byte) {
+	it := p.db.NewIterator(util.BytesPrefix(prefix), nil)
+	defer it.Release()
+	if !it.Last() {
+		return nil, nil
+	}
+	return append([]byte{}, it.Key()...), append([]byte{}, it.Value()...)
+}
+
+// ValidatorKeys returns the key along with the other keys which signed for the validator
+func (p *DB) ValidatorKeys(pubkey validatorpk.PubKey, validator idx.ValidatorID) []validatorpk.PubKey {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.validatorKeys(pubkey, validator)
+}
+
+func (p *DB) validatorKeys(pubkey validatorpk.PubKey, validator idx.ValidatorID) []validatorpk.PubKey {
+	res := []validatorpk.PubKey{pubkey}
+	if validator == 0 {
+		return res
+	}
+	prefix := append([]byte{validatorPrefix}, validator.Bytes()...)
+	it := p.db.NewIterator(util.BytesPrefix(prefix), nil)
+	defer it.Release()
+	for it.Next() {
+		linked, err := validatorpk.FromBytes(it.Value())
+		if err != nil {
+			panic(fmt.Errorf("failed to read doublesign protection DB: %v", err))
+		}
+		if !linked.Equal(pubkey) {
+			res = append(res, linked)
+		}
+	}
+	return res
+}
+
+// LastEvent returns the latest signed event of the key
+func (p *DB) LastEvent(pubkey validatorpk.PubKey) *hash.Event {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	_, val := p.last(recordKey(eventPrefix, pubkey))
+	if val == nil {
+		return nil
+	}
+	id := hash.BytesToEvent(val)
+	return &id
+}
+
+// LastBlockVote returns the latest block voted by the key
+func (p *DB) LastBlockVote(pubkey validatorpk.PubKey) *idx.Block {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.lastBlockVote(pubkey)
+}
+
+func (p *DB) lastBlockVote(pubkeys ...validatorpk.PubKey) *idx.Block {
+	var last *idx.Block
+	for _, pubkey := range pubkeys {
+		_, val := p.last(recordKey(blockVotesPrefix, pubkey))
+		if val == nil {
+			continue
+		}
+		if b := idx.BytesToBlock(val[:8]); last == nil || b > *last {
+			last = &b
+		}
+	}
+	return last
+}
+
+// LastEpochVote returns the latest epoch voted by the key
+func (p *DB) LastEpochVote(pubkey validatorpk.PubKey) *idx.Epoch {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.lastEpochVote(pubkey)
+}
+
+func (p *DB) lastEpochVote(pubkeys ...validatorpk.PubKey) *idx.Epoch {
+	var last *idx.Epoch
+	for _, pubkey := range pubkeys {
+		key, _ := p.last(recordKey(epochVotePrefix, pubkey))
+		if key == nil {
+			continue
+		}
+		if epoch := idx.BytesToEpoch(key[len(key)-4:]); last == nil || epoch > *last {
+			last = &epoch
+		}
+	}
+	return last
+}
+
+// Check returns ErrRefused if signing of the event may lead to a doublesign.
+// The event is checked against the records of all the keys of its creator.
+// Signing the same event again is allowed.
+func (p *DB) Check(pubkey validatorpk.PubKey, e Event) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	_, err := p.check(pubkey, e)
+	return err
+}
+
+func (p *DB) check(pubkey validatorpk.PubKey, e Event) (signed bool, err error) {
+	pubkeys := p.validatorKeys(pubkey, e.Creator)
+	for _, pk := range pubkeys {
+		if prev := p.get(recordKey(eventPrefix, pk, e.ID.Epoch().Bytes(), e.Seq.Bytes())); prev != nil {
+			if hash.BytesToEvent(prev) == e.ID && pk.Equal(pubkey) {
+				return true, nil
+			}
+			if hash.BytesToEvent(prev) == e.ID {
+				continue
+			}
+			return false, fmt.Errorf("%w: event %d:%d is already signed as %s", ErrRefused, e.ID.Epoch(), e.Seq, hash.BytesToEvent(prev).String())
+		}
+	}
+	for _, pk := range pubkeys {
+		key, val := p.last(recordKey(eventPrefix, pk))
+		if val == nil {
+			continue
+		}
+		prevEpoch := idx.BytesToEpoch(key[len(key)-8 : len(key)-4])
+		prevSeq := idx.BytesToEvent(key[len(key)-4:])
+		prev := hash.BytesToEvent(val)
+		if prev == e.ID {
+			// the same event is signed by another key of the validator
+			continue
+		}
+		if e.ID.Epoch() < prevEpoch || e.ID.Epoch() == prevEpoch && (e.Seq < prevSeq || e.ID.Lamport() <= prev.Lamport()) {
+			return false, fmt.Errorf("%w: event %s isn't after the last signed event %s", ErrRefused, e.ID.String(), prev.String())
+		}
+	}
+	if e.BlockVotes.Start != 0 {
+		if last := p.lastBlockVote(pubkeys...); last != nil && e.BlockVotes.Start <= *last {
+			return false, fmt.Errorf("%w: block votes from %d overlap the signed block votes up to %d", ErrRefused, e.BlockVotes.Start, *last)
+		}
+	}
+	if e.EpochVote.Epoch != 0 {
+		if last := p.lastEpochVote(pubkeys...); last != nil && e.EpochVote.Epoch <= *last {
+			return false, fmt.Errorf("%w: epoch vote %d isn't after the signed epoch vote %d", ErrRefused, e.EpochVote.Epoch, *last)
+		}
+	}
+	return false, nil
+}
+
+// Record saves the signed event
+func (p *DB) Record(pubkey validatorpk.PubKey, e Event) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.record(pubkey, e)
+}
+
+func (p *DB) record(pubkey validatorpk.PubKey, e Event) error {
+	batch := new(leveldb.Batch)
+	batch.Put(recordKey(keyPrefix, pubkey), pubkey.Bytes())
+	if e.Creator != 0 {
+		batch.Put(validatorKey(e.Creator, pubkey), pubkey.Bytes())
+	}
+	batch.Put(recordKey(eventPrefix, pubkey, e.ID.Epoch().Bytes(), e.Seq.Bytes()), e.ID.Bytes())
+	if e.BlockVotes.Start != 0 {
+		batch.Put(recordKey(blockVotesPrefix, pubkey, e.BlockVotes.Start.Bytes()), append(e.BlockVotes.Last.Bytes(), e.BlockVotes.Epoch.Bytes()...))
+	}
+	if e.EpochVote.Epoch != 0 {
+		batch.Put(recordKey(epochVotePrefix, pubkey, e.EpochVote.Epoch.Bytes()), e.EpochVote.Vote.Bytes())
+	}
+	return p.db.Write(batch, p.write)
+}
+
+// CheckAndRecord checks the event and saves it if signing is allowed
+func (p *DB) CheckAndRecord(pubkey validatorpk.PubKey, e Event) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	signed, err := p.check(pubkey, e)
+	if err != nil || signed {
+		return err
+	}
+	return p.record(pubkey, e)
+}
+
+// LegacyRecords are the last signed event and votes, which were kept by the emitter in the files before the DB
+type LegacyRecords struct {
+	Validator     idx.ValidatorID
+	LastEvent     *hash.Event
+	LastBlockVote *idx.Block
+	LastEpochVote *idx.Epoch
+}
+
+// ImportLegacy imports the legacy records of the key once, the later calls are no-op.
+// The seq of the legacy event is unknown, so the following events are checked only by their lamport.
+func (p *DB) ImportLegacy(pubkey validatorpk.PubKey, legacy LegacyRecords) (imported bool, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.get(recordKey(legacyPrefix, pubkey)) != nil {
+		return false, nil
+	}
+	batch := new(leveldb.Batch)
+	batch.Put(recordKey(legacyPrefix, pubkey), []byte{1})
+	if legacy.LastEvent != nil || legacy.LastBlockVote != nil || legacy.LastEpochVote != nil {
+		batch.Put(recordKey(keyPrefix, pubkey), pubkey.Bytes())
+		if legacy.Validator != 0 {
+			batch.Put(validatorKey(legacy.Validator, pubkey), pubkey.Bytes())
+		}
+	}
+	if id := legacy.LastEvent; id != nil {
+		if _, val := p.last(recordKey(eventPrefix, pubkey, id.Epoch().Bytes())); val == nil {
+			batch.Put(recordKey(eventPrefix, pubkey, id.Epoch().Bytes(), idx.Event(0).Bytes()), id.Bytes())
+		}
+	}
+	if b := legacy.LastBlockVote; b != nil && *b != 0 {
+		if last := p.lastBlockVote(pubkey); last == nil || *last < *b {
+			batch.Put(recordKey(blockVotesPrefix, pubkey, b.Bytes()), append(b.Bytes(), idx.Epoch(0).Bytes()...))
+		}
+	}
+	if e := legacy.LastEpochVote; e != nil && *e != 0 {
+		if last := p.lastEpochVote(pubkey); last == nil || *last < *e {
+			batch.Put(recordKey(epochVotePrefix, pubkey, e.Bytes()), hash.Hash{}.Bytes())
+		}
+	}
+	return true, p.db.Write(batch, p.write)
+}
+
+// pubkeys returns all the keys with records
+func (p *DB) pubkeys() ([]validatorpk.PubKey, error) {
+	it := p.db.NewIterator(util.BytesPrefix([]byte{keyPrefix}), nil)
+	defer it.Release()
+	var res []validatorpk.PubKey
+	for it.Next() {
+		pubkey, err := validatorpk.FromBytes(it.Value())
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, pubkey)
+	}
+	return res, it.Error()
+}
+
+func (p *DB) forEach(prefix []byte, fn func(key, val []byte)) error {
+	it := p.db.NewIterator(util.BytesPrefix(prefix), nil)
+	defer it.Release()
+	for it.Next() {
+		fn(it.Key()[len(prefix):], it.Value())
+	}
+	return it.Error()
+}
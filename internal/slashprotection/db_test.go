@@ -0,0 +1,209 @@
+package slashprotection
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/artheranet/lachesis/hash"
+	"github.com/artheranet/lachesis/inter/idx"
+	"github.com/stretchr/testify/require"
+
+	"github.com/artheranet/arthera-node/internal/inter"
+	"github.com/artheranet/arthera-node/internal/inter/validatorpk"
+)
+
+var (
+	pubkey1, _ = validatorpk.FromString("0xc0045ea4ce3ab0748574f0290dadcb45545aff82d8baa72e5b4c84a19d2e1f16fb3dc487430b4189ded650a94148e57a60ca8cbf4da414dbfd3b072f0a5b9a746235")
+	pubkey2, _ = validatorpk.FromString("0xc00459b25a40ac4af6d114deb2f899bb371869b467955dd3106302309263c6c7786209306dae5564cbeb75805ff517bb49dce467f785c138837782a0c0becf4b122c")
+)
+
+func testEvent(epoch idx.Epoch, seq idx.Event, lamport idx.Lamport, h byte) Event {
+	var id hash.Event
+	copy(id[0:4], epoch.Bytes())
+	copy(id[4:8], lamport.Bytes())
+	id[31] = h
+	return Event{ID: id, Seq: seq}
+}
+
+func TestDBEvents(t *testing.T) {
+	require := require.New(t)
+	db := NewMem()
+	defer db.Close()
+
+	require.Nil(db.LastEvent(pubkey1))
+	e1 := testEvent(2, 1, 1, 1)
+	require.NoError(db.CheckAndRecord(pubkey1, e1))
+	require.Equal(e1.ID, *db.LastEvent(pubkey1))
+
+	// signing the same event again is allowed
+	require.NoError(db.CheckAndRecord(pubkey1, e1))
+	// fork of the signed event
+	require.ErrorIs(db.Check(pubkey1, testEvent(2, 1, 1, 2)), ErrRefused)
+	require.ErrorIs(db.Check(pubkey1, testEvent(2, 1, 2, 1)), ErrRefused)
+	// events of the past epochs
+	require.ErrorIs(db.Check(pubkey1, testEvent(1, 5, 5, 1)), ErrRefused)
+	// other keys are independent
+	require.NoError(db.Check(pubkey2, testEvent(2, 1, 1, 2)))
+
+	e2 := testEvent(2, 2, 3, 1)
+	require.NoError(db.CheckAndRecord(pubkey1, e2))
+	require.ErrorIs(db.Check(pubkey1, testEvent(2, 3, 3, 1)), ErrRefused)
+	require.NoError(db.Check(pubkey1, testEvent(3, 1, 1, 1)))
+}
+
+func TestDBVotes(t *testing.T) {
+	require := require.New(t)
+	db := NewMem()
+	defer db.Close()
+
+	require.Nil(db.LastBlockVote(pubkey1))
+	require.Nil(db.LastEpochVote(pubkey1))
+
+	e := testEvent(2, 1, 1, 1)
+	e.BlockVotes = BlockVotes{Start: 10, Last: 12, Epoch: 2}
+	e.EpochVote = inter.LlrEpochVote{Epoch: 1, Vote: hash.HexToHash("0x1")}
+	require.NoError(db.CheckAndRecord(pubkey1, e))
+	require.Equal(idx.Block(12), *db.LastBlockVote(pubkey1))
+	require.Equal(idx.Epoch(1), *db.LastEpochVote(pubkey1))
+
+	e = testEvent(2, 2, 2, 1)
+	e.BlockVotes = BlockVotes{Start: 12, Last: 13, Epoch: 2}
+	require.ErrorIs(db.Check(pubkey1, e), ErrRefused)
+	e.BlockVotes = BlockVotes{Start: 13, Last: 13, Epoch: 2}
+	require.NoError(db.Check(pubkey1, e))
+
+	e.EpochVote = inter.LlrEpochVote{Epoch: 1, Vote: hash.HexToHash("0x2")}
+	require.ErrorIs(db.Check(pubkey1, e), ErrRefused)
+	e.EpochVote = inter.LlrEpochVote{Epoch: 2, Vote: hash.HexToHash("0x2")}
+	require.NoError(db.CheckAndRecord(pubkey1, e))
+	require.Equal(idx.Block(13), *db.LastBlockVote(pubkey1))
+	require.Equal(idx.Epoch(2), *db.LastEpochVote(pubkey1))
+}
+
+func TestDBInterchange(t *testing.T) {
+	require := require.New(t)
+	src := NewMem()
+	defer src.Close()
+
+	e := testEvent(2, 1, 1, 1)
+	e.BlockVotes = BlockVotes{Start: 10, Last: 12, Epoch: 2}
+	e.EpochVote = inter.LlrEpochVote{Epoch: 1, Vote: hash.HexToHash("0x1")}
+	require.NoError(src.Record(pubkey1, e))
+	require.NoError(src.Record(pubkey1, testEvent(2, 2, 2, 1)))
+	require.NoError(src.Record(pubkey2, testEvent(3, 1, 1, 1)))
+
+	buf := &bytes.Buffer{}
+	require.NoError(src.Export(buf))
+
+	dst := NewMem()
+	defer dst.Close()
+	// existing records take priority
+	require.NoError(dst.Record(pubkey1, testEvent(2, 1, 1, 1)))
+	imported, err := dst.Import(bytes.NewReader(buf.Bytes()))
+	require.NoError(err)
+	require.Equal(4, imported)
+
+	require.Equal(*src.LastEvent(pubkey1), *dst.LastEvent(pubkey1))
+	require.Equal(*src.LastEvent(pubkey2), *dst.LastEvent(pubkey2))
+	require.Equal(idx.Block(12), *dst.LastBlockVote(pubkey1))
+	require.Equal(idx.Epoch(1), *dst.LastEpochVote(pubkey1))
+	require.ErrorIs(dst.Check(pubkey1, testEvent(2, 2, 2, 2)), ErrRefused)
+
+	// the exported records are the same
+	buf2 := &bytes.Buffer{}
+	require.NoError(dst.Export(buf2))
+	require.JSONEq(buf.String(), buf2.String())
+}
+
+func TestDBImportLegacy(t *testing.T) {
+	require := require.New(t)
+	db := NewMem()
+	defer db.Close()
+
+	last := testEvent(2, 5, 5, 1)
+	lastBlock := idx.Block(12)
+	lastEpoch := idx.Epoch(1)
+	imported, err := db.ImportLegacy(pubkey1, LegacyRecords{
+		LastEvent:     &last.ID,
+		LastBlockVote: &lastBlock,
+		LastEpochVote: &lastEpoch,
+	})
+	require.NoError(err)
+	require.True(imported)
+	require.Equal(last.ID, *db.LastEvent(pubkey1))
+	require.Equal(lastBlock, *db.LastBlockVote(pubkey1))
+	require.Equal(lastEpoch, *db.LastEpochVote(pubkey1))
+
+	// the seq of the legacy event is unknown, the events are checked by the lamport
+	require.ErrorIs(db.Check(pubkey1, testEvent(2, 6, 5, 2)), ErrRefused)
+	require.NoError(db.Check(pubkey1, testEvent(2, 6, 6, 2)))
+	e := testEvent(3, 1, 1, 1)
+	e.BlockVotes = BlockVotes{Start: 12, Last: 13, Epoch: 3}
+	require.ErrorIs(db.Check(pubkey1, e), ErrRefused)
+	e.BlockVotes = BlockVotes{}
+	e.EpochVote = inter.LlrEpochVote{Epoch: 1}
+	require.ErrorIs(db.Check(pubkey1, e), ErrRefused)
+
+	// the import is done only once
+	later := testEvent(4, 1, 1, 1)
+	imported, err = db.ImportLegacy(pubkey1, LegacyRecords{LastEvent: &later.ID})
+	require.NoError(err)
+	require.False(imported)
+	require.Equal(last.ID, *db.LastEvent(pubkey1))
+
+	// no legacy records
+	imported, err = db.ImportLegacy(pubkey2, LegacyRecords{})
+	require.NoError(err)
+	require.True(imported)
+	require.Nil(db.LastEvent(pubkey2))
+}
+
+func TestDBKeyRotation(t *testing.T) {
+	require := require.New(t)
+	db := NewMem()
+	defer db.Close()
+
+	// the old key of validator 1
+	e1 := testEvent(2, 1, 1, 1)
+	e1.Creator = 1
+	e1.BlockVotes = BlockVotes{Start: 10, Last: 12, Epoch: 2}
+	e1.EpochVote = inter.LlrEpochVote{Epoch: 1, Vote: hash.HexToHash("0x1")}
+	require.NoError(db.CheckAndRecord(pubkey1, e1))
+
+	// the new key of the validator is checked against the records of the old key
+	e2 := testEvent(2, 1, 1, 2)
+	e2.Creator = 1
+	require.ErrorIs(db.Check(pubkey2, e2), ErrRefused)
+	e2 = testEvent(3, 1, 1, 2)
+	e2.Creator = 1
+	e2.BlockVotes = BlockVotes{Start: 12, Last: 13, Epoch: 3}
+	require.ErrorIs(db.Check(pubkey2, e2), ErrRefused)
+	e2.BlockVotes = BlockVotes{}
+	e2.EpochVote = inter.LlrEpochVote{Epoch: 1, Vote: hash.HexToHash("0x2")}
+	require.ErrorIs(db.Check(pubkey2, e2), ErrRefused)
+	e2.BlockVotes = BlockVotes{Start: 13, Last: 13, Epoch: 3}
+	e2.EpochVote = inter.LlrEpochVote{Epoch: 2, Vote: hash.HexToHash("0x2")}
+	require.NoError(db.CheckAndRecord(pubkey2, e2))
+	require.Equal([]validatorpk.PubKey{pubkey2, pubkey1}, db.ValidatorKeys(pubkey2, 1))
+
+	// the old key can't sign behind the new one either
+	e3 := testEvent(3, 1, 1, 3)
+	e3.Creator = 1
+	require.ErrorIs(db.Check(pubkey1, e3), ErrRefused)
+
+	// the key of another validator isn't affected
+	pubkey3 := validatorpk.PubKey{Type: pubkey1.Type, Raw: []byte{3}}
+	e4 := testEvent(2, 1, 1, 4)
+	e4.Creator = 2
+	e4.BlockVotes = BlockVotes{Start: 10, Last: 12, Epoch: 2}
+	require.NoError(db.Check(pubkey3, e4))
+
+	// the links are exported
+	buf := &bytes.Buffer{}
+	require.NoError(db.Export(buf))
+	dst := NewMem()
+	defer dst.Close()
+	_, err := dst.Import(bytes.NewReader(buf.Bytes()))
+	require.NoError(err)
+	require.ErrorIs(dst.Check(pubkey1, e3), ErrRefused)
+}
@@ -0,0 +1,176 @@
+package slashprotection
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/artheranet/lachesis/hash"
+	"github.com/artheranet/lachesis/inter/idx"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/opt"
+
+	"github.com/artheranet/arthera-node/internal/inter/validatorpk"
+)
+
+// InterchangeVersion is the version of the interchange format
+const InterchangeVersion = "1"
+
+type (
+	// Interchange is the format to move the doublesign protection records between hosts
+	Interchange struct {
+		Metadata InterchangeMetadata    `json:"metadata"`
+		Data     []InterchangeValidator `json:"data"`
+	}
+	InterchangeMetadata struct {
+		Version string `json:"interchange_format_version"`
+	}
+	InterchangeValidator struct {
+		PubKey string `json:"pubkey"`
+		// Validators are the validators which the key signed for
+		Validators       []idx.ValidatorID       `json:"validators,omitempty"`
+		SignedEvents     []InterchangeEvent      `json:"signed_events"`
+		SignedBlockVotes []InterchangeBlockVotes `json:"signed_block_votes"`
+		SignedEpochVotes []InterchangeEpochVote  `json:"signed_epoch_votes"`
+	}
+	InterchangeEvent struct {
+		Epoch   idx.Epoch     `json:"epoch"`
+		Seq     idx.Event     `json:"seq"`
+		Lamport idx.Lamport   `json:"lamport"`
+		ID      hexutil.Bytes `json:"id"`
+	}
+	InterchangeBlockVotes struct {
+		Start idx.Block `json:"start"`
+		Last  idx.Block `json:"last"`
+		Epoch idx.Epoch `json:"epoch"`
+	}
+	InterchangeEpochVote struct {
+		Epoch idx.Epoch     `json:"epoch"`
+		Vote  hexutil.Bytes `json:"vote"`
+	}
+)
+
+// Export writes all the records in the interchange format
+func (p *DB) Export(w io.Writer) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	pubkeys, err := p.pubkeys()
+	if err != nil {
+		return err
+	}
+	validators := make(map[string][]idx.ValidatorID)
+	err = p.forEach([]byte{validatorPrefix}, func(key, val []byte) {
+		validators[string(val)] = append(validators[string(val)], idx.BytesToValidatorID(key[:4]))
+	})
+	if err != nil {
+		return err
+	}
+	res := Interchange{
+		Metadata: InterchangeMetadata{Version: InterchangeVersion},
+		Data:     make([]InterchangeValidator, 0, len(pubkeys)),
+	}
+	for _, pubkey := range pubkeys {
+		v := InterchangeValidator{
+			PubKey:           pubkey.String(),
+			Validators:       validators[string(pubkey.Bytes())],
+			SignedEvents:     []InterchangeEvent{},
+			SignedBlockVotes: []InterchangeBlockVotes{},
+			SignedEpochVotes: []InterchangeEpochVote{},
+		}
+		err = p.forEach(recordKey(eventPrefix, pubkey), func(key, val []byte) {
+			id := hash.BytesToEvent(val)
+			v.SignedEvents = append(v.SignedEvents, InterchangeEvent{
+				Epoch:   idx.BytesToEpoch(key[:4]),
+				Seq:     idx.BytesToEvent(key[4:]),
+				Lamport: id.Lamport(),
+				ID:      id.Bytes(),
+			})
+		})
+		if err != nil {
+			return err
+		}
+		err = p.forEach(recordKey(blockVotesPrefix, pubkey), func(key, val []byte) {
+			v.SignedBlockVotes = append(v.SignedBlockVotes, InterchangeBlockVotes{
+				Start: idx.BytesToBlock(key),
+				Last:  idx.BytesToBlock(val[:8]),
+				Epoch: idx.BytesToEpoch(val[8:]),
+			})
+		})
+		if err != nil {
+			return err
+		}
+		err = p.forEach(recordKey(epochVotePrefix, pubkey), func(key, val []byte) {
+			v.SignedEpochVotes = append(v.SignedEpochVotes, InterchangeEpochVote{
+				Epoch: idx.BytesToEpoch(key),
+				Vote:  append([]byte{}, val...),
+			})
+		})
+		if err != nil {
+			return err
+		}
+		res.Data = append(res.Data, v)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(&res)
+}
+
+// Import merges the records in the interchange format into the database.
+// Existing records take priority over the conflicting imported ones, and the number of imported records is returned.
+func (p *DB) Import(r io.Reader) (int, error) {
+	var in Interchange
+	if err := json.NewDecoder(r).Decode(&in); err != nil {
+		return 0, err
+	}
+	if in.Metadata.Version != InterchangeVersion {
+		return 0, fmt.Errorf("unsupported interchange format version %q", in.Metadata.Version)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	imported := 0
+	batch := new(leveldb.Batch)
+	put := func(key, val []byte) {
+		if p.get(key) == nil {
+			batch.Put(key, val)
+			imported++
+		}
+	}
+	for _, v := range in.Data {
+		pubkey, err := validatorpk.FromString(v.PubKey)
+		if err != nil {
+			return 0, err
+		}
+		batch.Put(recordKey(keyPrefix, pubkey), pubkey.Bytes())
+		for _, validator := range v.Validators {
+			batch.Put(validatorKey(validator, pubkey), pubkey.Bytes())
+		}
+		for _, e := range v.SignedEvents {
+			if len(e.ID) != 32 {
+				return 0, fmt.Errorf("malformed event ID %s", e.ID.String())
+			}
+			id := hash.BytesToEvent(e.ID)
+			if id.Epoch() != e.Epoch || id.Lamport() != e.Lamport {
+				return 0, fmt.Errorf("event ID %s doesn't match epoch %d and lamport %d", id.String(), e.Epoch, e.Lamport)
+			}
+			put(recordKey(eventPrefix, pubkey, e.Epoch.Bytes(), e.Seq.Bytes()), id.Bytes())
+		}
+		for _, bvs := range v.SignedBlockVotes {
+			if bvs.Start == 0 || bvs.Last < bvs.Start {
+				return 0, fmt.Errorf("malformed block votes range %d-%d", bvs.Start, bvs.Last)
+			}
+			put(recordKey(blockVotesPrefix, pubkey, bvs.Start.Bytes()), append(bvs.Last.Bytes(), bvs.Epoch.Bytes()...))
+		}
+		for _, ev := range v.SignedEpochVotes {
+			if len(ev.Vote) != 32 {
+				return 0, fmt.Errorf("malformed epoch vote %s", ev.Vote.String())
+			}
+			put(recordKey(epochVotePrefix, pubkey, ev.Epoch.Bytes()), ev.Vote)
+		}
+	}
+	return imported, p.db.Write(batch, &opt.WriteOptions{Sync: true})
+}
@@ -2,6 +2,7 @@ package topicsdb
 
 import (
 	"context"
+	"time"
 
 	"github.com/artheranet/lachesis/inter/idx"
 	"github.com/artheranet/lachesis/kvdb"
@@ -9,13 +10,18 @@ import (
 	"github.com/artheranet/lachesis/kvdb/table"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
 )
 
 // index is a specialized indexes for log records storing and fetching.
 type index struct {
 	table struct {
-		// topic+topicN+(blockN+TxHash+logIndex) -> topic_count (where topicN=0 is for address)
+		// topic+topicN+(blockN+TxHash+logIndex) -> topic_count (where topicN starts from 1, topicN=0 is a legacy address index)
 		Topic kvdb.Store `table:"t"`
+		// address+(blockN+TxHash+logIndex) -> topic_count
+		Address kvdb.Store `table:"a"`
+		// address+topic+topicN+(blockN+TxHash+logIndex) -> topic_count
+		AddressTopic kvdb.Store `table:"A"`
 		// (blockN+TxHash+logIndex) -> ordered topic_count topics, blockHash, address, data
 		Logrec kvdb.Store `table:"r"`
 	}
@@ -38,9 +44,15 @@ func (tt *index) WrapTablesAsBatched() (unwrap func()) {
 	tt.table.Topic = batchedTopic
 	batchedLogrec := batched.Wrap(tt.table.Logrec)
 	tt.table.Logrec = batchedLogrec
+	batchedAddress := batched.Wrap(tt.table.Address)
+	tt.table.Address = batchedAddress
+	batchedAddressTopic := batched.Wrap(tt.table.AddressTopic)
+	tt.table.AddressTopic = batchedAddressTopic
 	return func() {
 		_ = batchedTopic.Flush()
 		_ = batchedLogrec.Flush()
+		_ = batchedAddress.Flush()
+		_ = batchedAddressTopic.Flush()
 		tt.table = origTables
 	}
 }
@@ -80,11 +92,62 @@ func (tt *index) ForEachInBlocks(ctx context.Context, from, to idx.Block, patter
 		return
 	}
 
-	return tt.searchParallel(ctx, pattern, uint64(from), uint64(to), onMatched, doNothing)
+	return tt.searchParallel(ctx, tt.planScan(pattern), uint8(len(pattern)-1), uint64(from), uint64(to), onMatched, doNothing)
 }
 
 func doNothing() {}
 
+// planScan converts the pattern into the index variants to scan.
+// Addresses are scanned over the address index if there are no topics in the pattern,
+// otherwise they are merged into the topic position with the least variants.
+func (tt *index) planScan(pattern [][]common.Hash) (plan [][]scanVariant) {
+	addresses := make([]common.Address, len(pattern[0]))
+	for i, h := range pattern[0] {
+		addresses[i] = common.BytesToAddress(h.Bytes())
+	}
+
+	merged := 0
+	for pos := 1; pos < len(pattern); pos++ {
+		if len(pattern[pos]) == 0 {
+			continue
+		}
+		if merged == 0 || len(pattern[pos]) < len(pattern[merged]) {
+			merged = pos
+		}
+	}
+
+	if len(addresses) > 0 && merged == 0 {
+		variants := make([]scanVariant, len(addresses))
+		for i, addr := range addresses {
+			variants[i] = scanVariant{tt.table.Address, addr.Bytes()}
+		}
+		return append(plan, variants)
+	}
+
+	for pos := 1; pos < len(pattern); pos++ {
+		if len(pattern[pos]) == 0 {
+			continue
+		}
+		var variants []scanVariant
+		if pos == merged && len(addresses) > 0 {
+			variants = make([]scanVariant, 0, len(addresses)*len(pattern[pos]))
+			for _, addr := range addresses {
+				for _, topic := range pattern[pos] {
+					variants = append(variants, scanVariant{tt.table.AddressTopic, addressTopicPrefix(addr, topic, uint8(pos))})
+				}
+			}
+		} else {
+			variants = make([]scanVariant, len(pattern[pos]))
+			for i, topic := range pattern[pos] {
+				variants[i] = scanVariant{tt.table.Topic, topicPrefix(topic, uint8(pos))}
+			}
+		}
+		plan = append(plan, variants)
+	}
+
+	return plan
+}
+
 // Push log record to database batch
 func (tt *index) Push(recs ...*types.Log) error {
 	for _, rec := range recs {
@@ -107,35 +170,189 @@ func (tt *index) Push(recs ...*types.Log) error {
 		}
 
 		// write index
-		var (
-			count = posToBytes(uint8(len(rec.Topics)))
-			pos   uint8
-		)
-		pushIndex := func(topic common.Hash) error {
-			key := topicKey(topic, pos, id)
+		count := posToBytes(uint8(len(rec.Topics)))
+		for i, topic := range rec.Topics {
+			key := topicKey(topic, uint8(i+1), id)
 			if err := tt.table.Topic.Put(key, count); err != nil {
 				return err
 			}
-			pos++
-			return nil
 		}
 
-		if err := pushIndex(rec.Address.Hash()); err != nil {
+		if err := tt.pushAddressIndex(rec.Address, rec.Topics, id, count); err != nil {
 			return err
 		}
+	}
 
-		for _, topic := range rec.Topics {
-			if err := pushIndex(topic); err != nil {
+	return nil
+}
+
+func (tt *index) pushAddressIndex(addr common.Address, topics []common.Hash, id ID, count []byte) error {
+	if err := tt.table.Address.Put(addressKey(addr, id), count); err != nil {
+		return err
+	}
+	for i, topic := range topics {
+		key := addressTopicKey(addr, topic, uint8(i+1), id)
+		if err := tt.table.AddressTopic.Put(key, count); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// MigrateAddressIndex moves the legacy address position of the topics index into the address indexes.
+func (tt *index) MigrateAddressIndex() error {
+	var (
+		start    = time.Now()
+		logged   = start
+		migrated uint64
+	)
+	origTables := tt.table
+	batchedTopic := batched.Wrap(tt.table.Topic)
+	batchedAddress := batched.Wrap(tt.table.Address)
+	batchedAddressTopic := batched.Wrap(tt.table.AddressTopic)
+	tt.table.Topic = batchedTopic
+	tt.table.Address = batchedAddress
+	tt.table.AddressTopic = batchedAddressTopic
+	defer func() {
+		tt.table = origTables
+	}()
+
+	it := origTables.Topic.NewIterator(nil, nil)
+	defer it.Release()
+	for it.Next() {
+		key := it.Key()
+		if len(key) != topicKeySize || bytesToPos(key[hashSize:]) != 0 {
+			continue
+		}
+		id := extractLogrecID(key)
+		rec := newLogrec(id, bytesToPos(it.Value()))
+		rec.fetch(tt.table.Logrec)
+		if rec.err != nil {
+			return rec.err
+		}
+
+		addr := common.BytesToAddress(key[:hashSize])
+		if err := tt.pushAddressIndex(addr, rec.result.Topics, id, common.CopyBytes(it.Value())); err != nil {
+			return err
+		}
+		if err := tt.table.Topic.Delete(common.CopyBytes(key)); err != nil {
+			return err
+		}
+		migrated++
+		if time.Since(logged) > 8*time.Second {
+			log.Info("Migrating logs address index", "block", id.BlockNumber(), "migrated", migrated,
+				"elapsed", common.PrettyDuration(time.Since(start)))
+			logged = time.Now()
+		}
+	}
+	if err := it.Error(); err != nil {
+		return err
+	}
+	if err := batchedAddress.Flush(); err != nil {
+		return err
+	}
+	if err := batchedAddressTopic.Flush(); err != nil {
+		return err
+	}
+	if err := batchedTopic.Flush(); err != nil {
+		return err
+	}
+	log.Info("Migrated logs address index", "migrated", migrated, "elapsed", common.PrettyDuration(time.Since(start)))
+	return nil
+}
+
+// DeleteBefore deletes up to limit oldest log records of the blocks below the given one, along with their indexes.
+// It returns the number of deleted records.
+func (tt *index) DeleteBefore(before idx.Block, limit int) (deleted int, err error) {
+	var (
+		ids  = make([]ID, 0, limit)
+		bufs = make([][]byte, 0, limit)
+	)
+	it := tt.table.Logrec.NewIterator(nil, nil)
+	for len(ids) < limit && it.Next() {
+		if len(it.Key()) != logrecKeySize {
+			continue
+		}
+		var id ID
+		copy(id[:], it.Key())
+		if id.BlockNumber() >= uint64(before) {
+			break
+		}
+		ids = append(ids, id)
+		bufs = append(bufs, common.CopyBytes(it.Value()))
+	}
+	err = it.Error()
+	it.Release()
+	if err != nil {
+		return 0, err
+	}
+
+	for i, id := range ids {
+		if err := tt.deleteRecord(id, bufs[i]); err != nil {
+			return i, err
+		}
+	}
+	return len(ids), nil
+}
+
+// deleteRecord deletes the log record and its index keys
+func (tt *index) deleteRecord(id ID, buf []byte) error {
+	rec, err := tt.parseRecord(id, buf)
+	if err != nil {
+		return err
+	}
+	if rec != nil {
+		for i, topic := range rec.Topics {
+			if err := tt.table.Topic.Delete(topicKey(topic, uint8(i+1), id)); err != nil {
+				return err
+			}
+			if err := tt.table.AddressTopic.Delete(addressTopicKey(rec.Address, topic, uint8(i+1), id)); err != nil {
 				return err
 			}
 		}
-
+		if err := tt.table.Address.Delete(addressKey(rec.Address, id)); err != nil {
+			return err
+		}
+		if err := tt.table.Topic.Delete(topicKey(rec.Address.Hash(), 0, id)); err != nil {
+			return err
+		}
 	}
+	return tt.table.Logrec.Delete(id.Bytes())
+}
 
-	return nil
+// parseRecord decodes the log record. The topics count isn't stored in the record itself,
+// so it's the count whose address is found in the address index (or in the legacy one) with the same count.
+// It returns nil if the record isn't indexed.
+func (tt *index) parseRecord(id ID, buf []byte) (*types.Log, error) {
+	for n := 0; n <= maxTopicsCount; n++ {
+		offset := n*hashSize + hashSize
+		if offset+addressSize > len(buf) {
+			break
+		}
+		addr := common.BytesToAddress(buf[offset : offset+addressSize])
+		count, err := tt.table.Address.Get(addressKey(addr, id))
+		if err != nil {
+			return nil, err
+		}
+		if count == nil {
+			count, err = tt.table.Topic.Get(topicKey(addr.Hash(), 0, id))
+			if err != nil {
+				return nil, err
+			}
+		}
+		if len(count) == 0 || bytesToPos(count) != uint8(n) {
+			continue
+		}
+		rec := newLogrec(id, uint8(n))
+		rec.fetch(tt.table.Logrec)
+		return rec.result, rec.err
+	}
+	return nil, nil
 }
 
 func (tt *index) Close() {
 	_ = tt.table.Topic.Close()
 	_ = tt.table.Logrec.Close()
+	_ = tt.table.Address.Close()
+	_ = tt.table.AddressTopic.Close()
 }
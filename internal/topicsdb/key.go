@@ -6,13 +6,16 @@ import (
 )
 
 const (
-	uint8Size  = 1
-	uint64Size = 8
-	hashSize   = common.HashLength
-
-	logrecKeySize = uint64Size + hashSize + uint64Size
-	topicKeySize  = hashSize + uint8Size + logrecKeySize
-	otherKeySize  = logrecKeySize + uint8Size
+	uint8Size   = 1
+	uint64Size  = 8
+	hashSize    = common.HashLength
+	addressSize = common.AddressLength
+
+	logrecKeySize       = uint64Size + hashSize + uint64Size
+	topicKeySize        = hashSize + uint8Size + logrecKeySize
+	addressKeySize      = addressSize + logrecKeySize
+	addressTopicKeySize = addressSize + hashSize + uint8Size + logrecKeySize
+	otherKeySize        = logrecKeySize + uint8Size
 )
 
 type (
@@ -55,6 +58,43 @@ func topicKey(topic common.Hash, pos uint8, logrec ID) []byte {
 	return key
 }
 
+func topicPrefix(topic common.Hash, pos uint8) []byte {
+	prefix := make([]byte, 0, hashSize+uint8Size)
+
+	prefix = append(prefix, topic.Bytes()...)
+	prefix = append(prefix, posToBytes(pos)...)
+
+	return prefix
+}
+
+func addressKey(addr common.Address, logrec ID) []byte {
+	key := make([]byte, 0, addressKeySize)
+
+	key = append(key, addr.Bytes()...)
+	key = append(key, logrec.Bytes()...)
+
+	return key
+}
+
+func addressTopicKey(addr common.Address, topic common.Hash, pos uint8, logrec ID) []byte {
+	key := make([]byte, 0, addressTopicKeySize)
+
+	key = append(key, addressTopicPrefix(addr, topic, pos)...)
+	key = append(key, logrec.Bytes()...)
+
+	return key
+}
+
+func addressTopicPrefix(addr common.Address, topic common.Hash, pos uint8) []byte {
+	prefix := make([]byte, 0, addressSize+hashSize+uint8Size)
+
+	prefix = append(prefix, addr.Bytes()...)
+	prefix = append(prefix, topic.Bytes()...)
+	prefix = append(prefix, posToBytes(pos)...)
+
+	return prefix
+}
+
 func posToBytes(pos uint8) []byte {
 	return []byte{pos}
 }
@@ -76,6 +116,12 @@ func extractLogrecID(key []byte) (id ID) {
 	case topicKeySize:
 		copy(id[:], key[hashSize+uint8Size:])
 		return
+	case addressKeySize:
+		copy(id[:], key[addressSize:])
+		return
+	case addressTopicKeySize:
+		copy(id[:], key[addressSize+hashSize+uint8Size:])
+		return
 	default:
 		panic("wrong key type")
 	}
@@ -4,12 +4,21 @@ import (
 	"context"
 	"sync"
 
-	"github.com/ethereum/go-ethereum/common"
+	"github.com/artheranet/lachesis/kvdb"
 )
 
-type logHandler func(rec *logrec) (gonext bool, err error)
+type (
+	logHandler func(rec *logrec) (gonext bool, err error)
 
-func (tt *index) searchParallel(ctx context.Context, pattern [][]common.Hash, blockStart, blockEnd uint64, onMatched logHandler, onDbIterator func()) error {
+	// scanVariant is a key prefix of one of the index tables
+	scanVariant struct {
+		table  kvdb.Iteratee
+		prefix []byte
+	}
+)
+
+// searchParallel matches log records which are found by any variant of each plan position.
+func (tt *index) searchParallel(ctx context.Context, plan [][]scanVariant, topicsCount uint8, blockStart, blockEnd uint64, onMatched logHandler, onDbIterator func()) error {
 	if ctx == nil {
 		ctx = context.Background()
 	}
@@ -36,7 +45,7 @@ func (tt *index) searchParallel(ctx context.Context, pattern [][]common.Hash, bl
 			if blockEnd > 0 && block > blockEnd {
 				return
 			}
-			if rec.topicsCount < uint8(len(pattern)-1) {
+			if rec.topicsCount < topicsCount {
 				gonext = true
 				return
 			}
@@ -81,16 +90,13 @@ func (tt *index) searchParallel(ctx context.Context, pattern [][]common.Hash, bl
 	// start the threads
 	var preparing sync.WaitGroup
 	preparing.Add(1)
-	for pos := range pattern {
-		if len(pattern[pos]) == 0 {
-			continue
-		}
-		for i, variant := range pattern[pos] {
+	for pos := range plan {
+		for i, variant := range plan[pos] {
 			syncing.StartThread(pos, i)
-			go func(pos, i int, variant common.Hash) {
+			go func(pos, i int, variant scanVariant) {
 				onMatched := aggregator(pos, i)
 				preparing.Wait()
-				tt.scanPatternVariant(uint8(pos), variant, blockStart, onMatched, onDbIterator)
+				tt.scanVariant(variant, blockStart, onMatched, onDbIterator)
 			}(pos, i, variant)
 		}
 	}
@@ -101,11 +107,9 @@ func (tt *index) searchParallel(ctx context.Context, pattern [][]common.Hash, bl
 	return ctx.Err()
 }
 
-func (tt *index) scanPatternVariant(pos uint8, variant common.Hash, start uint64, onMatched logHandler, onDbIterator func()) {
-	prefix := append(variant.Bytes(), posToBytes(pos)...)
-
+func (tt *index) scanVariant(variant scanVariant, start uint64, onMatched logHandler, onDbIterator func()) {
 	onDbIterator()
-	it := tt.table.Topic.NewIterator(prefix, uintToBytes(start))
+	it := variant.table.NewIterator(variant.prefix, uintToBytes(start))
 	defer it.Release()
 	for it.Next() {
 		id := extractLogrecID(it.Key())
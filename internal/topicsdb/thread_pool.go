@@ -54,15 +54,16 @@ func (tt *withThreadPool) ForEachInBlocks(ctx context.Context, from, to idx.Bloc
 		return
 	}
 
+	plan := tt.planScan(pattern)
 	splitby := 0
 	parallels := 0
-	for i := range pattern {
-		parallels += len(pattern[i])
-		if len(pattern[splitby]) < len(pattern[i]) {
+	for i := range plan {
+		parallels += len(plan[i])
+		if len(plan[splitby]) < len(plan[i]) {
 			splitby = i
 		}
 	}
-	rest := pattern[splitby]
+	rest := plan[splitby]
 	parallels -= len(rest)
 
 	if parallels >= threads.GlobalPool.Cap() {
@@ -85,9 +86,9 @@ func (tt *withThreadPool) ForEachInBlocks(ctx context.Context, from, to idx.Bloc
 			release(1)
 		}
 
-		pattern[splitby] = rest[:got-parallels]
+		plan[splitby] = rest[:got-parallels]
 		rest = rest[got-parallels:]
-		err = tt.searchParallel(ctx, pattern, uint64(from), uint64(to), onMatched, onDbIterator)
+		err = tt.searchParallel(ctx, plan, uint8(len(pattern)-1), uint64(from), uint64(to), onMatched, onDbIterator)
 		if err != nil {
 			return err
 		}
@@ -21,6 +21,8 @@ type Index interface {
 	FindInBlocks(ctx context.Context, from, to idx.Block, pattern [][]common.Hash) (logs []*types.Log, err error)
 	ForEachInBlocks(ctx context.Context, from, to idx.Block, pattern [][]common.Hash, onLog func(*types.Log) (gonext bool)) error
 	Push(recs ...*types.Log) error
+	MigrateAddressIndex() error
+	DeleteBefore(before idx.Block, limit int) (deleted int, err error)
 	Close()
 
 	WrapTablesAsBatched() (unwrap func())
@@ -11,6 +11,7 @@ import (
 
 	"github.com/artheranet/lachesis/hash"
 	"github.com/artheranet/lachesis/inter/idx"
+	"github.com/artheranet/lachesis/kvdb"
 	"github.com/artheranet/lachesis/kvdb/memorydb"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
@@ -364,6 +365,200 @@ func TestIndexSearchSimple(t *testing.T) {
 
 }
 
+func TestIndexSearchByAddress(t *testing.T) {
+	logger.SetTestMode(t)
+
+	var (
+		hash1 = common.BytesToHash([]byte("topic1"))
+		hash2 = common.BytesToHash([]byte("topic2"))
+		addr1 = randAddress()
+		addr2 = randAddress()
+	)
+	testdata := []*types.Log{{
+		BlockNumber: 1,
+		Address:     addr1,
+	}, {
+		BlockNumber: 2,
+		Address:     addr1,
+		Topics:      []common.Hash{hash1},
+	}, {
+		BlockNumber: 3,
+		Address:     addr2,
+		Topics:      []common.Hash{hash1, hash2},
+	}, {
+		BlockNumber: 4,
+		Address:     addr1,
+		Topics:      []common.Hash{hash2, hash1},
+	},
+	}
+
+	index := newTestIndex()
+
+	for _, l := range testdata {
+		err := index.Push(l)
+		require.NoError(t, err)
+	}
+
+	pooled := withThreadPool{index}
+
+	for dsc, method := range map[string]func(context.Context, idx.Block, idx.Block, [][]common.Hash) ([]*types.Log, error){
+		"index":  index.FindInBlocks,
+		"pooled": pooled.FindInBlocks,
+	} {
+		t.Run(dsc, func(t *testing.T) {
+			require := require.New(t)
+
+			got, err := method(nil, 0, 1000, [][]common.Hash{
+				{addr1.Hash()},
+			})
+			require.NoError(err)
+			require.Equal(3, len(got))
+			for _, l := range got {
+				require.Equal(addr1, l.Address)
+			}
+
+			got, err = method(nil, 2, 3, [][]common.Hash{
+				{addr1.Hash(), addr2.Hash()},
+			})
+			require.NoError(err)
+			require.Equal(2, len(got))
+
+			got, err = method(nil, 0, 1000, [][]common.Hash{
+				{addr1.Hash()},
+				{hash1},
+			})
+			require.NoError(err)
+			require.Equal(1, len(got))
+			require.Equal(uint64(2), got[0].BlockNumber)
+
+			got, err = method(nil, 0, 1000, [][]common.Hash{
+				{addr1.Hash(), addr2.Hash()},
+				{},
+				{hash1},
+			})
+			require.NoError(err)
+			require.Equal(1, len(got))
+			require.Equal(uint64(4), got[0].BlockNumber)
+
+			got, err = method(nil, 0, 1000, [][]common.Hash{
+				{addr2.Hash()},
+				{hash1, hash2},
+				{hash1, hash2},
+			})
+			require.NoError(err)
+			require.Equal(1, len(got))
+			require.Equal(uint64(3), got[0].BlockNumber)
+
+			got, err = method(nil, 0, 1000, [][]common.Hash{
+				{addr1.Hash()},
+				{},
+				{},
+			})
+			require.NoError(err)
+			require.Equal(1, len(got))
+			require.Equal(uint64(4), got[0].BlockNumber)
+		})
+	}
+}
+
+func TestMigrateAddressIndex(t *testing.T) {
+	logger.SetTestMode(t)
+	require := require.New(t)
+
+	_, recs, _ := genTestData(20)
+	index := newTestIndex()
+	for _, rec := range recs {
+		require.NoError(index.Push(rec))
+	}
+
+	// convert to the legacy schema, where the address is the 0 position of the topics index
+	for _, rec := range recs {
+		id := NewID(rec.BlockNumber, rec.TxHash, rec.Index)
+		count := posToBytes(uint8(len(rec.Topics)))
+		require.NoError(index.table.Topic.Put(topicKey(rec.Address.Hash(), 0, id), count))
+		require.NoError(index.table.Address.Delete(addressKey(rec.Address, id)))
+		for i, topic := range rec.Topics {
+			require.NoError(index.table.AddressTopic.Delete(addressTopicKey(rec.Address, topic, uint8(i+1), id)))
+		}
+	}
+	for _, rec := range recs {
+		got, err := index.FindInBlocks(nil, 0, 1000, [][]common.Hash{{rec.Address.Hash()}})
+		require.NoError(err)
+		require.Empty(got)
+	}
+
+	require.NoError(index.MigrateAddressIndex())
+
+	for _, rec := range recs {
+		got, err := index.FindInBlocks(nil, 0, 1000, [][]common.Hash{{rec.Address.Hash()}})
+		require.NoError(err)
+		require.Equal([]*types.Log{rec}, got)
+
+		got, err = index.FindInBlocks(nil, 0, 1000, [][]common.Hash{{rec.Address.Hash()}, {}, {rec.Topics[1]}})
+		require.NoError(err)
+		require.Equal([]*types.Log{rec}, got)
+
+		id := NewID(rec.BlockNumber, rec.TxHash, rec.Index)
+		legacy, err := index.table.Topic.Has(topicKey(rec.Address.Hash(), 0, id))
+		require.NoError(err)
+		require.False(legacy)
+	}
+}
+
+func TestDeleteBefore(t *testing.T) {
+	logger.SetTestMode(t)
+	require := require.New(t)
+
+	_, recs, _ := genTestData(20)
+	index := newTestIndex()
+	for i, rec := range recs {
+		rec.Topics = rec.Topics[:i%4]
+		require.NoError(index.Push(rec))
+	}
+	// records of the block 0 are indexed with the legacy schema
+	for _, rec := range recs[:5] {
+		id := NewID(rec.BlockNumber, rec.TxHash, rec.Index)
+		count := posToBytes(uint8(len(rec.Topics)))
+		require.NoError(index.table.Topic.Put(topicKey(rec.Address.Hash(), 0, id), count))
+		require.NoError(index.table.Address.Delete(addressKey(rec.Address, id)))
+	}
+
+	deleted, err := index.DeleteBefore(2, 7)
+	require.NoError(err)
+	require.Equal(7, deleted)
+	deleted, err = index.DeleteBefore(2, 100)
+	require.NoError(err)
+	require.Equal(3, deleted)
+	deleted, err = index.DeleteBefore(2, 100)
+	require.NoError(err)
+	require.Zero(deleted)
+
+	for _, rec := range recs {
+		got, err := index.FindInBlocks(nil, 0, 1000, [][]common.Hash{{rec.Address.Hash()}})
+		require.NoError(err)
+		if rec.BlockNumber < 2 {
+			require.Empty(got)
+		} else {
+			require.Equal([]*types.Log{rec}, got)
+		}
+	}
+	// no keys of the deleted records are left
+	for _, table := range []kvdb.Store{index.table.Topic, index.table.Address, index.table.AddressTopic} {
+		it := table.NewIterator(nil, nil)
+		for it.Next() {
+			id := extractLogrecID(it.Key())
+			require.GreaterOrEqual(id.BlockNumber(), uint64(2))
+		}
+		require.NoError(it.Error())
+		it.Release()
+	}
+	it := index.table.Logrec.NewIterator(nil, nil)
+	for it.Next() {
+		require.GreaterOrEqual(bytesToUint(it.Key()), uint64(2))
+	}
+	it.Release()
+}
+
 func TestMaxTopicsCount(t *testing.T) {
 	logger.SetTestMode(t)
 
@@ -2,9 +2,7 @@ package encryption
 
 import (
 	"bytes"
-	"crypto/ecdsa"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"io/ioutil"
 	"os"
@@ -14,18 +12,43 @@ import (
 	"github.com/ethereum/go-ethereum/crypto"
 
 	"github.com/artheranet/arthera-node/internal/inter/validatorpk"
+	"github.com/artheranet/arthera-node/internal/valsig"
 )
 
 var (
-	ErrNotSupportedType = errors.New("not supported key type")
+	ErrNotSupportedType = valsig.ErrNotSupportedType
 )
 
 type PrivateKey struct {
-	Type    uint8
-	Bytes   []byte
+	Type  uint8
+	Bytes []byte
+	// Decoded is *ecdsa.PrivateKey for secp256k1 keys, and nil for other key types
 	Decoded interface{}
 }
 
+// NewPrivateKey checks the private key of the type and decodes it
+func NewPrivateKey(keyType uint8, key []byte) (*PrivateKey, error) {
+	scheme, err := valsig.Of(keyType)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := scheme.PubKey(key); err != nil {
+		return nil, err
+	}
+	var decoded interface{}
+	if keyType == validatorpk.Types.Secp256k1 {
+		decoded, err = crypto.ToECDSA(key)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &PrivateKey{
+		Type:    keyType,
+		Bytes:   key,
+		Decoded: decoded,
+	}, nil
+}
+
 type EncryptedKeyJSON struct {
 	Type      uint8               `json:"type"`
 	PublicKey string              `json:"pubkey"`
@@ -55,8 +78,17 @@ func (ks Keystore) ReadKey(wantPubkey validatorpk.PubKey, filename, auth string)
 		return nil, err
 	}
 	// Make sure we're really operating on the requested key (no swap attacks)
-	keySecp256k1 := key.Decoded.(*ecdsa.PrivateKey)
-	gotPubkey := crypto.FromECDSAPub(&keySecp256k1.PublicKey)
+	if key.Type != wantPubkey.Type {
+		return nil, fmt.Errorf("key type mismatch: have %#x from file %s, want %#x", key.Type, filename, wantPubkey.Type)
+	}
+	scheme, err := valsig.Of(key.Type)
+	if err != nil {
+		return nil, err
+	}
+	gotPubkey, err := scheme.PubKey(key.Bytes)
+	if err != nil {
+		return nil, err
+	}
 	if bytes.Compare(wantPubkey.Raw, gotPubkey) != 0 {
 		return nil, fmt.Errorf("key content mismatch: have public key %X from file %s, want %X", gotPubkey, filename, wantPubkey.Raw)
 	}
@@ -79,8 +111,8 @@ func (ks Keystore) StoreKey(filename string, pubkey validatorpk.PubKey, key []by
 // EncryptKey encrypts a key using the specified scrypt parameters into a json
 // blob that can be decrypted later on.
 func (ks Keystore) EncryptKey(pubkey validatorpk.PubKey, key []byte, auth string) ([]byte, error) {
-	if pubkey.Type != validatorpk.Types.Secp256k1 {
-		return nil, ErrNotSupportedType
+	if _, err := valsig.Of(pubkey.Type); err != nil {
+		return nil, err
 	}
 	cryptoStruct, err := keystore.EncryptDataV3(key, []byte(auth), ks.scryptN, ks.scryptP)
 	if err != nil {
@@ -109,28 +141,20 @@ func DecryptKey(keyjson []byte, auth string) (*PrivateKey, error) {
 	if err := json.Unmarshal(keyjson, k); err != nil {
 		return nil, err
 	}
-	if k.Type != validatorpk.Types.Secp256k1 {
-		return nil, ErrNotSupportedType
-	}
-	keyBytes, err = decryptKey_secp256k1(k, auth)
-	// Handle any decryption errors and return the key
-	if err != nil {
+	if _, err := valsig.Of(k.Type); err != nil {
 		return nil, err
 	}
-
-	decoded, err := crypto.ToECDSA(keyBytes)
+	// all the key types are encrypted the same way
+	keyBytes, err = decryptKey(k, auth)
+	// Handle any decryption errors and return the key
 	if err != nil {
 		return nil, err
 	}
 
-	return &PrivateKey{
-		Type:    k.Type,
-		Bytes:   keyBytes,
-		Decoded: decoded,
-	}, nil
+	return NewPrivateKey(k.Type, keyBytes)
 }
 
-func decryptKey_secp256k1(keyProtected *EncryptedKeyJSON, auth string) (keyBytes []byte, err error) {
+func decryptKey(keyProtected *EncryptedKeyJSON, auth string) (keyBytes []byte, err error) {
 	plainText, err := keystore.DecryptDataV3(keyProtected.Crypto, auth)
 	if err != nil {
 		return nil, err
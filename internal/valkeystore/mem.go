@@ -3,8 +3,6 @@ package valkeystore
 import (
 	"errors"
 
-	"github.com/ethereum/go-ethereum/crypto"
-
 	"github.com/artheranet/arthera-node/internal/inter/validatorpk"
 	"github.com/artheranet/arthera-node/internal/valkeystore/encryption"
 )
@@ -30,18 +28,11 @@ func (m *MemKeystore) Add(pubkey validatorpk.PubKey, key []byte, auth string) er
 	if m.Has(pubkey) {
 		return ErrAlreadyExists
 	}
-	if pubkey.Type != validatorpk.Types.Secp256k1 {
-		return encryption.ErrNotSupportedType
-	}
-	decoded, err := crypto.ToECDSA(key)
+	decoded, err := encryption.NewPrivateKey(pubkey.Type, key)
 	if err != nil {
 		return err
 	}
-	m.mem[m.idxOf(pubkey)] = &encryption.PrivateKey{
-		Type:    pubkey.Type,
-		Bytes:   key,
-		Decoded: decoded,
-	}
+	m.mem[m.idxOf(pubkey)] = decoded
 	m.auth[m.idxOf(pubkey)] = auth
 	return nil
 }
@@ -0,0 +1,248 @@
+package valkeystore
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/artheranet/lachesis/hash"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rlp"
+
+	"github.com/artheranet/arthera-node/internal/inter"
+	"github.com/artheranet/arthera-node/internal/inter/validatorpk"
+)
+
+var (
+	ErrPayloadMismatch = errors.New("event payload hash doesn't match the signed votes")
+)
+
+// EventSignerI is a signer which receives the content of the signed event,
+// so it's able to enforce the doublesign protection on its own
+type EventSignerI interface {
+	SignerI
+	SignEvent(pubkey validatorpk.PubKey, req *EventSigningRequest) ([]byte, error)
+}
+
+// EventSigningRequest describes an event to sign.
+// The signed digest is derived from the locator, and the votes are checked against the locator's payload hash.
+type EventSigningRequest struct {
+	Version                      uint8
+	Locator                      inter.EventLocator
+	TxsAndMisbehaviourProofsHash hash.Hash
+	BlockVotes                   inter.LlrBlockVotes
+	EpochVote                    inter.LlrEpochVote
+}
+
+// NewEventSigningRequest creates a signing request for the event
+func NewEventSigningRequest(e *inter.MutableEventPayload) *EventSigningRequest {
+	return &EventSigningRequest{
+		Version:                      e.Version(),
+		Locator:                      e.Locator(),
+		TxsAndMisbehaviourProofsHash: hash.Of(inter.CalcTxHash(e.Txs()).Bytes(), inter.CalcMisbehaviourProofsHash(e.MisbehaviourProofs()).Bytes()),
+		BlockVotes:                   e.BlockVotes(),
+		EpochVote:                    e.EpochVote(),
+	}
+}
+
+// Digest returns the hash to sign
+func (r *EventSigningRequest) Digest() hash.Hash {
+	if r.Version < 1 {
+		return r.Locator.BaseHash
+	}
+	return r.Locator.HashToSign()
+}
+
+// ID returns the ID of the signed event
+func (r *EventSigningRequest) ID() hash.Event {
+	h := r.Digest()
+	copy(h[0:4], r.Locator.Epoch.Bytes())
+	copy(h[4:8], r.Locator.Lamport.Bytes())
+	return hash.Event(h)
+}
+
+// Validate checks that the votes are the ones which are covered by the signature
+func (r *EventSigningRequest) Validate() error {
+	if r.Version < 1 {
+		if len(r.BlockVotes.Votes) != 0 || r.EpochVote.Epoch != 0 {
+			return ErrPayloadMismatch
+		}
+		return nil
+	}
+	payloadHash := hash.Of(r.TxsAndMisbehaviourProofsHash.Bytes(), hash.Of(r.EpochVote.Hash().Bytes(), r.BlockVotes.Hash().Bytes()).Bytes())
+	if payloadHash != r.Locator.PayloadHash {
+		return ErrPayloadMismatch
+	}
+	return nil
+}
+
+type (
+	remoteSignRequest struct {
+		PubKey string        `json:"pubkey"`
+		Digest hexutil.Bytes `json:"digest,omitempty"`
+		// Event is the RLP-encoded EventSigningRequest
+		Event hexutil.Bytes `json:"event,omitempty"`
+	}
+	remoteSignResponse struct {
+		Signature hexutil.Bytes `json:"signature"`
+	}
+	remoteErrorResponse struct {
+		Error string `json:"error"`
+	}
+)
+
+const (
+	remotePubkeysPath   = "/api/v1/pubkeys"
+	remoteSignPath      = "/api/v1/sign"
+	remoteSignEventPath = "/api/v1/sign/event"
+
+	remoteSignerTimeout = 10 * time.Second
+)
+
+// RemoteSignerConfig is the authentication of the connection to the remote signer
+type RemoteSignerConfig struct {
+	// Token is the bearer token sent with every request
+	Token string
+	// TLS is the client TLS config of the https:// URLs, it carries the client certificate for mTLS
+	TLS *tls.Config
+}
+
+// RemoteSigner signs through an external signing process over HTTP.
+// The URL is either http(s)://host:port or unix:///path/to/socket,
+// plain http is allowed only for the loopback hosts.
+type RemoteSigner struct {
+	endpoint string
+	token    string
+	client   *http.Client
+}
+
+func NewRemoteSigner(rawurl string, cfg RemoteSignerConfig) (*RemoteSigner, error) {
+	s := &RemoteSigner{
+		token:  cfg.Token,
+		client: &http.Client{Timeout: remoteSignerTimeout},
+	}
+	switch {
+	case strings.HasPrefix(rawurl, "unix://"):
+		socket := strings.TrimPrefix(rawurl, "unix://")
+		s.client.Transport = &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, "unix", socket)
+			},
+		}
+		s.endpoint = "http://signer"
+	case strings.HasPrefix(rawurl, "http://"):
+		u, err := url.Parse(rawurl)
+		if err != nil {
+			return nil, err
+		}
+		if !IsLoopbackHost(u.Hostname()) {
+			return nil, fmt.Errorf("remote signer %s isn't a loopback host, https is required", u.Host)
+		}
+		s.endpoint = strings.TrimSuffix(rawurl, "/")
+	case strings.HasPrefix(rawurl, "https://"):
+		s.client.Transport = &http.Transport{
+			TLSClientConfig: cfg.TLS,
+		}
+		s.endpoint = strings.TrimSuffix(rawurl, "/")
+	default:
+		return nil, fmt.Errorf("unsupported remote signer URL %s", rawurl)
+	}
+	return s, nil
+}
+
+func (s *RemoteSigner) call(method, path string, req interface{}, res interface{}) error {
+	var body io.Reader
+	if req != nil {
+		b, err := json.Marshal(req)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(b)
+	}
+	httpReq, err := http.NewRequest(method, s.endpoint+path, body)
+	if err != nil {
+		return err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if s.token != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+s.token)
+	}
+	resp, err := s.client.Do(httpReq)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		var errRes remoteErrorResponse
+		if json.NewDecoder(resp.Body).Decode(&errRes) == nil && errRes.Error != "" {
+			return fmt.Errorf("remote signer: %s", errRes.Error)
+		}
+		return fmt.Errorf("remote signer: %s", resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(res)
+}
+
+// PubKeys returns the validator keys available in the remote signer
+func (s *RemoteSigner) PubKeys() ([]validatorpk.PubKey, error) {
+	var res []string
+	if err := s.call(http.MethodGet, remotePubkeysPath, nil, &res); err != nil {
+		return nil, err
+	}
+	pubkeys := make([]validatorpk.PubKey, 0, len(res))
+	for _, str := range res {
+		pubkey, err := validatorpk.FromString(str)
+		if err != nil {
+			return nil, err
+		}
+		pubkeys = append(pubkeys, pubkey)
+	}
+	return pubkeys, nil
+}
+
+// Has returns true if the remote signer holds the key
+func (s *RemoteSigner) Has(pubkey validatorpk.PubKey) (bool, error) {
+	pubkeys, err := s.PubKeys()
+	if err != nil {
+		return false, err
+	}
+	for _, pk := range pubkeys {
+		if pk.Type == pubkey.Type && bytes.Equal(pk.Raw, pubkey.Raw) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Sign signs a raw digest. Remote signers usually refuse it, as it bypasses the doublesign protection
+func (s *RemoteSigner) Sign(pubkey validatorpk.PubKey, digest []byte) ([]byte, error) {
+	var res remoteSignResponse
+	err := s.call(http.MethodPost, remoteSignPath, &remoteSignRequest{
+		PubKey: pubkey.String(),
+		Digest: digest,
+	}, &res)
+	return res.Signature, err
+}
+
+// SignEvent signs the event if the remote signer's doublesign protection allows it
+func (s *RemoteSigner) SignEvent(pubkey validatorpk.PubKey, req *EventSigningRequest) ([]byte, error) {
+	event, err := rlp.EncodeToBytes(req)
+	if err != nil {
+		return nil, err
+	}
+	var res remoteSignResponse
+	err = s.call(http.MethodPost, remoteSignEventPath, &remoteSignRequest{
+		PubKey: pubkey.String(),
+		Event:  event,
+	}, &res)
+	return res.Signature, err
+}
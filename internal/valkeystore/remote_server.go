@@ -0,0 +1,137 @@
+package valkeystore
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/ethereum/go-ethereum/rlp"
+
+	"github.com/artheranet/arthera-node/internal/inter/validatorpk"
+	"github.com/artheranet/arthera-node/internal/slashprotection"
+)
+
+// SignerServer serves the remote signer API for the keys of a local signer.
+// Events are signed only if the doublesign protection allows it.
+// All the requests but the upcheck have to carry the bearer token if it's set.
+type SignerServer struct {
+	signer     SignerI
+	pubkeys    []validatorpk.PubKey
+	protection *slashprotection.DB
+	// allowRaw enables signing of arbitrary digests, which bypasses the doublesign protection
+	allowRaw bool
+	token    string
+	mux      *http.ServeMux
+}
+
+func NewSignerServer(signer SignerI, pubkeys []validatorpk.PubKey, protection *slashprotection.DB, allowRaw bool, token string) *SignerServer {
+	s := &SignerServer{
+		signer:     signer,
+		pubkeys:    pubkeys,
+		protection: protection,
+		allowRaw:   allowRaw,
+		token:      token,
+		mux:        http.NewServeMux(),
+	}
+	s.mux.HandleFunc("/upcheck", s.upcheck)
+	s.mux.HandleFunc(remotePubkeysPath, s.listPubkeys)
+	s.mux.HandleFunc(remoteSignPath, s.sign)
+	s.mux.HandleFunc(remoteSignEventPath, s.signEvent)
+	return s
+}
+
+func (s *SignerServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if s.token != "" && r.URL.Path != "/upcheck" && !checkToken(r, s.token) {
+		writeError(w, http.StatusUnauthorized, ErrUnauthorized)
+		return
+	}
+	s.mux.ServeHTTP(w, r)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, &remoteErrorResponse{Error: err.Error()})
+}
+
+func (s *SignerServer) upcheck(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, "OK")
+}
+
+func (s *SignerServer) listPubkeys(w http.ResponseWriter, r *http.Request) {
+	res := make([]string, len(s.pubkeys))
+	for i, pubkey := range s.pubkeys {
+		res[i] = pubkey.String()
+	}
+	writeJSON(w, http.StatusOK, res)
+}
+
+func (s *SignerServer) decodeRequest(w http.ResponseWriter, r *http.Request) (*remoteSignRequest, validatorpk.PubKey, bool) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		return nil, validatorpk.PubKey{}, false
+	}
+	var req remoteSignRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return nil, validatorpk.PubKey{}, false
+	}
+	pubkey, err := validatorpk.FromString(req.PubKey)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return nil, validatorpk.PubKey{}, false
+	}
+	return &req, pubkey, true
+}
+
+func (s *SignerServer) sign(w http.ResponseWriter, r *http.Request) {
+	req, pubkey, ok := s.decodeRequest(w, r)
+	if !ok {
+		return
+	}
+	if !s.allowRaw {
+		writeError(w, http.StatusForbidden, errors.New("signing of raw digests is disabled"))
+		return
+	}
+	sig, err := s.signer.Sign(pubkey, req.Digest)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, &remoteSignResponse{Signature: sig})
+}
+
+func (s *SignerServer) signEvent(w http.ResponseWriter, r *http.Request) {
+	req, pubkey, ok := s.decodeRequest(w, r)
+	if !ok {
+		return
+	}
+	var event EventSigningRequest
+	if err := rlp.DecodeBytes(req.Event, &event); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if err := event.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	err := s.protection.CheckAndRecord(pubkey, slashprotection.NewEvent(event.ID(), event.Locator.Creator, event.Locator.Seq, event.BlockVotes, event.EpochVote))
+	if err != nil {
+		if errors.Is(err, slashprotection.ErrRefused) {
+			writeError(w, http.StatusPreconditionFailed, err)
+		} else {
+			writeError(w, http.StatusInternalServerError, err)
+		}
+		return
+	}
+	sig, err := s.signer.Sign(pubkey, event.Digest().Bytes())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, &remoteSignResponse{Signature: sig})
+}
@@ -0,0 +1,133 @@
+package valkeystore
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/artheranet/lachesis/hash"
+	"github.com/artheranet/lachesis/inter/idx"
+	"github.com/stretchr/testify/require"
+
+	"github.com/artheranet/arthera-node/internal/inter"
+	"github.com/artheranet/arthera-node/internal/inter/validatorpk"
+	"github.com/artheranet/arthera-node/internal/slashprotection"
+)
+
+func testSignedEvent(seq idx.Event, lamport idx.Lamport, time inter.Timestamp, bvs inter.LlrBlockVotes) *inter.MutableEventPayload {
+	me := &inter.MutableEventPayload{}
+	me.SetVersion(1)
+	me.SetEpoch(2)
+	me.SetCreator(1)
+	me.SetSeq(seq)
+	me.SetLamport(lamport)
+	me.SetCreationTime(time)
+	me.SetBlockVotes(bvs)
+	me.SetPayloadHash(inter.CalcPayloadHash(me))
+	return me
+}
+
+func TestRemoteSigner(t *testing.T) {
+	require := require.New(t)
+
+	keystore := NewDefaultMemKeystore()
+	require.NoError(keystore.Add(pubkey1, key1, "auth1"))
+	require.NoError(keystore.Unlock(pubkey1, "auth1"))
+	local := NewSigner(keystore)
+
+	server := httptest.NewServer(NewSignerServer(local, []validatorpk.PubKey{pubkey1}, slashprotection.NewMem(), false, ""))
+	defer server.Close()
+	remote, err := NewRemoteSigner(server.URL, RemoteSignerConfig{})
+	require.NoError(err)
+
+	has, err := remote.Has(pubkey1)
+	require.NoError(err)
+	require.True(has)
+	has, err = remote.Has(pubkey2)
+	require.NoError(err)
+	require.False(has)
+
+	// raw digests are refused
+	_, err = remote.Sign(pubkey1, hash.HexToHash("0x1").Bytes())
+	require.Error(err)
+
+	bvs := inter.LlrBlockVotes{Start: 10, Epoch: 2, Votes: []hash.Hash{hash.HexToHash("0x1"), hash.HexToHash("0x2")}}
+	e1 := testSignedEvent(1, 1, 100, bvs)
+	sig, err := remote.SignEvent(pubkey1, NewEventSigningRequest(e1))
+	require.NoError(err)
+	expSig, err := local.Sign(pubkey1, e1.HashToSign().Bytes())
+	require.NoError(err)
+	require.Equal(expSig, sig)
+
+	// retry of the same event is allowed
+	_, err = remote.SignEvent(pubkey1, NewEventSigningRequest(e1))
+	require.NoError(err)
+
+	// fork of the signed event
+	_, err = remote.SignEvent(pubkey1, NewEventSigningRequest(testSignedEvent(1, 1, 101, inter.LlrBlockVotes{})))
+	require.ErrorContains(err, slashprotection.ErrRefused.Error())
+
+	// overlapping block votes
+	_, err = remote.SignEvent(pubkey1, NewEventSigningRequest(testSignedEvent(2, 2, 102, inter.LlrBlockVotes{Start: 11, Epoch: 2, Votes: []hash.Hash{hash.HexToHash("0x3")}})))
+	require.ErrorContains(err, slashprotection.ErrRefused.Error())
+
+	// votes which aren't covered by the payload hash
+	e2 := testSignedEvent(2, 2, 102, inter.LlrBlockVotes{})
+	req := NewEventSigningRequest(e2)
+	req.EpochVote = inter.LlrEpochVote{Epoch: 1, Vote: hash.HexToHash("0x4")}
+	_, err = remote.SignEvent(pubkey1, req)
+	require.ErrorContains(err, ErrPayloadMismatch.Error())
+
+	e2.SetBlockVotes(inter.LlrBlockVotes{Start: 12, Epoch: 2, Votes: []hash.Hash{hash.HexToHash("0x3")}})
+	e2.SetPayloadHash(inter.CalcPayloadHash(e2))
+	sig, err = remote.SignEvent(pubkey1, NewEventSigningRequest(e2))
+	require.NoError(err)
+	expSig, err = local.Sign(pubkey1, e2.HashToSign().Bytes())
+	require.NoError(err)
+	require.Equal(expSig, sig)
+}
+
+func TestRemoteSignerAuth(t *testing.T) {
+	require := require.New(t)
+
+	keystore := NewDefaultMemKeystore()
+	require.NoError(keystore.Add(pubkey1, key1, "auth1"))
+	require.NoError(keystore.Unlock(pubkey1, "auth1"))
+
+	server := httptest.NewTLSServer(NewSignerServer(NewSigner(keystore), []validatorpk.PubKey{pubkey1}, slashprotection.NewMem(), false, "secret"))
+	defer server.Close()
+	tlsConfig := server.Client().Transport.(*http.Transport).TLSClientConfig
+
+	// missing and wrong tokens
+	for _, token := range []string{"", "wrong"} {
+		remote, err := NewRemoteSigner(server.URL, RemoteSignerConfig{Token: token, TLS: tlsConfig})
+		require.NoError(err)
+		_, err = remote.Has(pubkey1)
+		require.ErrorContains(err, ErrUnauthorized.Error())
+		_, err = remote.SignEvent(pubkey1, NewEventSigningRequest(testSignedEvent(1, 1, 100, inter.LlrBlockVotes{})))
+		require.ErrorContains(err, ErrUnauthorized.Error())
+	}
+
+	// the server certificate isn't trusted
+	remote, err := NewRemoteSigner(server.URL, RemoteSignerConfig{Token: "secret", TLS: &tls.Config{}})
+	require.NoError(err)
+	_, err = remote.Has(pubkey1)
+	require.Error(err)
+
+	remote, err = NewRemoteSigner(server.URL, RemoteSignerConfig{Token: "secret", TLS: tlsConfig})
+	require.NoError(err)
+	has, err := remote.Has(pubkey1)
+	require.NoError(err)
+	require.True(has)
+	_, err = remote.SignEvent(pubkey1, NewEventSigningRequest(testSignedEvent(1, 1, 100, inter.LlrBlockVotes{})))
+	require.NoError(err)
+
+	// plain http is allowed only for the loopback hosts
+	_, err = NewRemoteSigner("http://10.0.0.1:18545", RemoteSignerConfig{Token: "secret"})
+	require.Error(err)
+	_, err = NewRemoteSigner("http://localhost:18545", RemoteSignerConfig{Token: "secret"})
+	require.NoError(err)
+	_, err = NewRemoteSigner("http://[::1]:18545", RemoteSignerConfig{Token: "secret"})
+	require.NoError(err)
+}
@@ -1,12 +1,8 @@
 package valkeystore
 
 import (
-	"crypto/ecdsa"
-
-	"github.com/ethereum/go-ethereum/crypto"
-
 	"github.com/artheranet/arthera-node/internal/inter/validatorpk"
-	"github.com/artheranet/arthera-node/internal/valkeystore/encryption"
+	"github.com/artheranet/arthera-node/internal/valsig"
 )
 
 type SignerI interface {
@@ -24,20 +20,9 @@ func NewSigner(backend KeystoreI) *Signer {
 }
 
 func (s *Signer) Sign(pubkey validatorpk.PubKey, digest []byte) ([]byte, error) {
-	if pubkey.Type != validatorpk.Types.Secp256k1 {
-		return nil, encryption.ErrNotSupportedType
-	}
 	key, err := s.backend.GetUnlocked(pubkey)
 	if err != nil {
 		return nil, err
 	}
-
-	secp256k1Key := key.Decoded.(*ecdsa.PrivateKey)
-
-	sigRSV, err := crypto.Sign(digest, secp256k1Key)
-	if err != nil {
-		return nil, err
-	}
-	sigRS := sigRSV[:64]
-	return sigRS, err
+	return valsig.Sign(pubkey, key.Bytes, digest)
 }
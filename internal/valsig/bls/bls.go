@@ -0,0 +1,116 @@
+// Package bls implements BLS signatures over the BLS12-381 curve in the minimal-signature-size variant:
+// signatures are points of G1, public keys are points of G2.
+// The curve operations are done by blst, which keeps the secret key operations constant-time.
+package bls
+
+import (
+	"errors"
+	"io"
+
+	blst "github.com/supranational/blst/bindings/go"
+)
+
+const (
+	// SecretKeySize is the size of a big-endian secret scalar
+	SecretKeySize = 32
+	// PubKeySize is the size of a compressed G2 point
+	PubKeySize = 96
+	// SignatureSize is the size of a compressed G1 point
+	SignatureSize = 48
+)
+
+// DST is the domain separation tag of the hashing to G1 of the basic scheme
+var DST = []byte("BLS_SIG_BLS12381G1_XMD:SHA-256_SSWU_RO_NUL_")
+
+var (
+	ErrInvalidSecretKey = errors.New("invalid BLS secret key")
+	ErrInvalidPubKey    = errors.New("invalid BLS public key")
+	ErrInvalidSignature = errors.New("invalid BLS signature")
+)
+
+// GenerateKey creates a new secret key from 32 bytes of the randomness as KeyGen of the IETF BLS signature draft
+func GenerateKey(rand io.Reader) ([]byte, error) {
+	ikm := make([]byte, 32)
+	if _, err := io.ReadFull(rand, ikm); err != nil {
+		return nil, err
+	}
+	sk := blst.KeyGen(ikm)
+	defer sk.Zeroize()
+	for i := range ikm {
+		ikm[i] = 0
+	}
+	return sk.Serialize(), nil
+}
+
+func secretKeyOf(priv []byte) (*blst.SecretKey, error) {
+	if len(priv) != SecretKeySize {
+		return nil, ErrInvalidSecretKey
+	}
+	// the zero scalar and the scalars above the group order are refused
+	sk := new(blst.SecretKey).Deserialize(priv)
+	if sk == nil {
+		return nil, ErrInvalidSecretKey
+	}
+	return sk, nil
+}
+
+// PubKey returns the compressed public key of the secret key
+func PubKey(priv []byte) ([]byte, error) {
+	sk, err := secretKeyOf(priv)
+	if err != nil {
+		return nil, err
+	}
+	defer sk.Zeroize()
+	return new(blst.P2Affine).From(sk).Compress(), nil
+}
+
+func pubKeyOf(pub []byte) (*blst.P2Affine, error) {
+	if len(pub) != PubKeySize {
+		return nil, ErrInvalidPubKey
+	}
+	p := new(blst.P2Affine).Uncompress(pub)
+	// the infinity and the points outside of the G2 subgroup are refused
+	if p == nil || !p.KeyValidate() {
+		return nil, ErrInvalidPubKey
+	}
+	return p, nil
+}
+
+// ValidatePubKey returns an error if the public key isn't a valid point of G2
+func ValidatePubKey(pub []byte) error {
+	_, err := pubKeyOf(pub)
+	return err
+}
+
+// Sign signs the message
+func Sign(priv []byte, msg []byte) ([]byte, error) {
+	return sign(priv, msg, DST)
+}
+
+func sign(priv []byte, msg []byte, dst []byte) ([]byte, error) {
+	sk, err := secretKeyOf(priv)
+	if err != nil {
+		return nil, err
+	}
+	defer sk.Zeroize()
+	return new(blst.P1Affine).Sign(sk, msg, dst).Compress(), nil
+}
+
+// Verify checks the signature of the message
+func Verify(pub []byte, msg []byte, sig []byte) bool {
+	return verify(pub, msg, sig, DST)
+}
+
+func verify(pub []byte, msg []byte, sig []byte, dst []byte) bool {
+	pk, err := pubKeyOf(pub)
+	if err != nil || len(sig) != SignatureSize || sig[0]&0x40 != 0 {
+		// the infinity signature is refused
+		return false
+	}
+	s := new(blst.P1Affine).Uncompress(sig)
+	if s == nil {
+		return false
+	}
+	// the signature is checked to be in the G1 subgroup, the key is already validated
+	return s.Verify(true, pk, false, msg, dst)
+}
@@ -0,0 +1,115 @@
+package bls
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func fromHex(s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+func scalar(v byte) []byte {
+	sk := make([]byte, SecretKeySize)
+	sk[SecretKeySize-1] = v
+	return sk
+}
+
+// TestKnownAnswers checks the encodings and the hashing to G1 against the known vectors.
+// The public key of the scalar 1 is the G2 generator, and a signature by the scalar 1 is the hash of the message,
+// so the signatures are checked against the hash_to_curve vectors of RFC 9380 (J.9.1).
+func TestKnownAnswers(t *testing.T) {
+	require := require.New(t)
+
+	pub, err := PubKey(scalar(1))
+	require.NoError(err)
+	require.Equal("93e02b6052719f607dacd3a088274f65596bd0d09920b61ab5da61bbdc7f5049334cf11213945d57e5ac7d055d042b7e024aa2b2f08f0a91260805272dc51051c6e47ad4fa403b02b4510b647ae3d1770bac0326a805bbefd48056c8c121bdb8", hex.EncodeToString(pub))
+	pub2, err := PubKey(scalar(2))
+	require.NoError(err)
+	require.Equal("aa4edef9c1ed7f729f520e47730a124fd70662a904ba1074728114d1031e1572c6c886f6b57ec72a6178288c47c335771638533957d540a9d2370f17cc7ed5863bc0b995b8825e0ee1ea1e1e4d00dbae81f14b0bf3611b78c952aacab827a053", hex.EncodeToString(pub2))
+
+	dst := []byte("QUUX-V01-CS02-with-BLS12381G1_XMD:SHA-256_SSWU_RO_")
+	for _, v := range []struct {
+		sk  []byte
+		pub []byte
+		msg string
+		sig string
+	}{
+		// H("") of RFC 9380
+		{scalar(1), pub, "", "852926add2207b76ca4fa57a8734416c8dc95e24501772c814278700eed6d1e4e8cf62d9c09db0fac349612b759e79a1"},
+		// H("abc") of RFC 9380
+		{scalar(1), pub, "abc", "83567bc5ef9c690c2ab2ecdf6a96ef1c139cc0b2f284dca0a9a7943388a49a3aee664ba5379a7655d3c68900be2f6903"},
+		// 2*H("") and 2*H("abc")
+		{scalar(2), pub2, "", "a63722943b65ec4c28ad8e4259d3efbb1843795eaaa2c84328c83d1f9302e338ded66b81e9a6bf96cf1f19eaa0dcbd52"},
+		{scalar(2), pub2, "abc", "a66bc330f20b95878d43e890f6e92172d05ec9f40d410271012c8860263471ab64ccabe5ed59ebeb6af6e5d1a3a49c3f"},
+	} {
+		sig, err := sign(v.sk, []byte(v.msg), dst)
+		require.NoError(err)
+		require.Equal(v.sig, hex.EncodeToString(sig), v.msg)
+		require.True(verify(v.pub, []byte(v.msg), sig, dst), v.msg)
+		require.False(verify(v.pub, []byte(v.msg), sig, DST), v.msg)
+	}
+}
+
+func TestSignVerify(t *testing.T) {
+	require := require.New(t)
+
+	priv, err := GenerateKey(rand.Reader)
+	require.NoError(err)
+	require.Len(priv, SecretKeySize)
+	pub, err := PubKey(priv)
+	require.NoError(err)
+	require.Len(pub, PubKeySize)
+	require.NoError(ValidatePubKey(pub))
+
+	msg := []byte("message")
+	sig, err := Sign(priv, msg)
+	require.NoError(err)
+	require.Len(sig, SignatureSize)
+	require.True(Verify(pub, msg, sig))
+	require.False(Verify(pub, []byte("other message"), sig))
+
+	other, err := GenerateKey(rand.Reader)
+	require.NoError(err)
+	otherPub, err := PubKey(other)
+	require.NoError(err)
+	require.False(Verify(otherPub, msg, sig))
+
+	// malformed signatures
+	require.False(Verify(pub, msg, sig[:SignatureSize-1]))
+	corrupted := append([]byte{}, sig...)
+	corrupted[SignatureSize-1] ^= 1
+	require.False(Verify(pub, msg, corrupted))
+	infinity := make([]byte, SignatureSize)
+	infinity[0] = 0xc0
+	require.False(Verify(pub, msg, infinity))
+}
+
+func TestInvalidKeys(t *testing.T) {
+	require := require.New(t)
+
+	// the zero scalar and the group order
+	_, err := PubKey(make([]byte, SecretKeySize))
+	require.ErrorIs(err, ErrInvalidSecretKey)
+	_, err = Sign(fromHex("73eda753299d7d483339d80809a1d80553bda402fffe5bfeffffffff00000001"), nil)
+	require.ErrorIs(err, ErrInvalidSecretKey)
+	_, err = PubKey(scalar(1)[1:])
+	require.ErrorIs(err, ErrInvalidSecretKey)
+
+	// the infinity, the uncompressed G2 generator and a point which isn't on the curve
+	infinity := make([]byte, PubKeySize)
+	infinity[0] = 0xc0
+	require.ErrorIs(ValidatePubKey(infinity), ErrInvalidPubKey)
+	require.ErrorIs(ValidatePubKey(make([]byte, 2*PubKeySize)), ErrInvalidPubKey)
+	pub, err := PubKey(scalar(1))
+	require.NoError(err)
+	pub[PubKeySize-1] ^= 1
+	require.ErrorIs(ValidatePubKey(pub), ErrInvalidPubKey)
+}
@@ -0,0 +1,225 @@
+// Package valsig contains the signature schemes of validator keys, selected by the validatorpk.PubKey type.
+package valsig
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"github.com/artheranet/arthera-node/internal/inter/validatorpk"
+	"github.com/artheranet/arthera-node/internal/valsig/bls"
+)
+
+var (
+	ErrNotSupportedType = errors.New("not supported key type")
+)
+
+// Scheme is a signature scheme of validator keys
+type Scheme interface {
+	// Name is the human-readable name of the scheme
+	Name() string
+	// GenerateKey creates a new private key
+	GenerateKey(rand io.Reader) ([]byte, error)
+	// PubKey returns the raw public key of the private key, or an error if the private key is malformed
+	PubKey(priv []byte) ([]byte, error)
+	// ValidatePubKey returns an error if the raw public key is malformed
+	ValidatePubKey(pub []byte) error
+	// Sign signs the digest. Signatures are at most inter.SigSize bytes
+	Sign(priv []byte, digest []byte) ([]byte, error)
+	// Verify checks the signature, which may be padded with zeros up to inter.SigSize bytes
+	Verify(pub []byte, digest []byte, sig []byte) bool
+}
+
+var schemes = map[uint8]Scheme{
+	validatorpk.Types.Secp256k1: secp256k1Scheme{},
+	validatorpk.Types.Ed25519:   ed25519Scheme{},
+	validatorpk.Types.Bls12381:  blsScheme{},
+}
+
+// Of returns the signature scheme of the key type
+func Of(keyType uint8) (Scheme, error) {
+	s, ok := schemes[keyType]
+	if !ok {
+		return nil, ErrNotSupportedType
+	}
+	return s, nil
+}
+
+// TypeOf returns the key type by the scheme name
+func TypeOf(name string) (uint8, error) {
+	for t, s := range schemes {
+		if strings.EqualFold(s.Name(), name) {
+			return t, nil
+		}
+	}
+	return 0, fmt.Errorf("%w: %s", ErrNotSupportedType, name)
+}
+
+// GenerateKey creates a new private key of the type
+func GenerateKey(keyType uint8, rand io.Reader) ([]byte, validatorpk.PubKey, error) {
+	s, err := Of(keyType)
+	if err != nil {
+		return nil, validatorpk.PubKey{}, err
+	}
+	priv, err := s.GenerateKey(rand)
+	if err != nil {
+		return nil, validatorpk.PubKey{}, err
+	}
+	raw, err := s.PubKey(priv)
+	if err != nil {
+		return nil, validatorpk.PubKey{}, err
+	}
+	return priv, validatorpk.PubKey{Type: keyType, Raw: raw}, nil
+}
+
+// ValidatePubKey returns an error if the key type isn't supported or the key is malformed
+func ValidatePubKey(pubkey validatorpk.PubKey) error {
+	s, err := Of(pubkey.Type)
+	if err != nil {
+		return err
+	}
+	return s.ValidatePubKey(pubkey.Raw)
+}
+
+// Sign signs the digest by the private key of the pubkey
+func Sign(pubkey validatorpk.PubKey, priv []byte, digest []byte) ([]byte, error) {
+	s, err := Of(pubkey.Type)
+	if err != nil {
+		return nil, err
+	}
+	return s.Sign(priv, digest)
+}
+
+// Verify checks the signature of the digest
+func Verify(pubkey validatorpk.PubKey, digest []byte, sig []byte) bool {
+	s, err := Of(pubkey.Type)
+	if err != nil {
+		return false
+	}
+	return s.Verify(pubkey.Raw, digest, sig)
+}
+
+type secp256k1Scheme struct{}
+
+func (secp256k1Scheme) Name() string {
+	return "secp256k1"
+}
+
+func (secp256k1Scheme) GenerateKey(rand io.Reader) ([]byte, error) {
+	key, err := ecdsa.GenerateKey(crypto.S256(), rand)
+	if err != nil {
+		return nil, err
+	}
+	return crypto.FromECDSA(key), nil
+}
+
+func (secp256k1Scheme) PubKey(priv []byte) ([]byte, error) {
+	key, err := crypto.ToECDSA(priv)
+	if err != nil {
+		return nil, err
+	}
+	return crypto.FromECDSAPub(&key.PublicKey), nil
+}
+
+func (secp256k1Scheme) ValidatePubKey(pub []byte) error {
+	_, err := crypto.UnmarshalPubkey(pub)
+	return err
+}
+
+func (secp256k1Scheme) Sign(priv []byte, digest []byte) ([]byte, error) {
+	key, err := crypto.ToECDSA(priv)
+	if err != nil {
+		return nil, err
+	}
+	sigRSV, err := crypto.Sign(digest, key)
+	if err != nil {
+		return nil, err
+	}
+	return sigRSV[:64], nil
+}
+
+func (secp256k1Scheme) Verify(pub []byte, digest []byte, sig []byte) bool {
+	return crypto.VerifySignature(pub, digest, sig)
+}
+
+type ed25519Scheme struct{}
+
+func (ed25519Scheme) Name() string {
+	return "ed25519"
+}
+
+func (ed25519Scheme) GenerateKey(rand io.Reader) ([]byte, error) {
+	_, key, err := ed25519.GenerateKey(rand)
+	if err != nil {
+		return nil, err
+	}
+	return key.Seed(), nil
+}
+
+func (ed25519Scheme) PubKey(priv []byte) ([]byte, error) {
+	if len(priv) != ed25519.SeedSize {
+		return nil, errors.New("invalid ed25519 private key")
+	}
+	return ed25519.NewKeyFromSeed(priv).Public().(ed25519.PublicKey), nil
+}
+
+func (ed25519Scheme) ValidatePubKey(pub []byte) error {
+	if len(pub) != ed25519.PublicKeySize {
+		return errors.New("invalid ed25519 public key")
+	}
+	return nil
+}
+
+func (ed25519Scheme) Sign(priv []byte, digest []byte) ([]byte, error) {
+	if len(priv) != ed25519.SeedSize {
+		return nil, errors.New("invalid ed25519 private key")
+	}
+	return ed25519.Sign(ed25519.NewKeyFromSeed(priv), digest), nil
+}
+
+func (ed25519Scheme) Verify(pub []byte, digest []byte, sig []byte) bool {
+	if len(pub) != ed25519.PublicKeySize || len(sig) != ed25519.SignatureSize {
+		return false
+	}
+	return ed25519.Verify(pub, digest, sig)
+}
+
+type blsScheme struct{}
+
+func (blsScheme) Name() string {
+	return "bls12381"
+}
+
+func (blsScheme) GenerateKey(rand io.Reader) ([]byte, error) {
+	return bls.GenerateKey(rand)
+}
+
+func (blsScheme) PubKey(priv []byte) ([]byte, error) {
+	return bls.PubKey(priv)
+}
+
+func (blsScheme) ValidatePubKey(pub []byte) error {
+	return bls.ValidatePubKey(pub)
+}
+
+func (blsScheme) Sign(priv []byte, digest []byte) ([]byte, error) {
+	return bls.Sign(priv, digest)
+}
+
+func (blsScheme) Verify(pub []byte, digest []byte, sig []byte) bool {
+	// signatures are padded up to the size of inter.Signature
+	if len(sig) < bls.SignatureSize {
+		return false
+	}
+	for _, b := range sig[bls.SignatureSize:] {
+		if b != 0 {
+			return false
+		}
+	}
+	return bls.Verify(pub, digest, sig[:bls.SignatureSize])
+}
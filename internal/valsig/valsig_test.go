@@ -0,0 +1,55 @@
+package valsig
+
+import (
+	"crypto/rand"
+	"testing"
+
+	"github.com/artheranet/lachesis/hash"
+	"github.com/stretchr/testify/require"
+
+	"github.com/artheranet/arthera-node/internal/inter"
+	"github.com/artheranet/arthera-node/internal/inter/validatorpk"
+)
+
+func TestSchemes(t *testing.T) {
+	for _, keyType := range []uint8{validatorpk.Types.Secp256k1, validatorpk.Types.Ed25519, validatorpk.Types.Bls12381} {
+		s, err := Of(keyType)
+		require.NoError(t, err)
+		t.Run(s.Name(), func(t *testing.T) {
+			require := require.New(t)
+
+			parsedType, err := TypeOf(s.Name())
+			require.NoError(err)
+			require.Equal(keyType, parsedType)
+
+			priv, pubkey, err := GenerateKey(keyType, rand.Reader)
+			require.NoError(err)
+			require.Equal(keyType, pubkey.Type)
+			require.NoError(ValidatePubKey(pubkey))
+
+			digest := hash.HexToHash("0x1234")
+			sig, err := Sign(pubkey, priv, digest.Bytes())
+			require.NoError(err)
+			require.LessOrEqual(len(sig), inter.SigSize)
+
+			// signatures are verified as they are stored in events
+			var eventSig inter.Signature
+			copy(eventSig[:], sig)
+			require.True(Verify(pubkey, digest.Bytes(), eventSig.Bytes()))
+			require.False(Verify(pubkey, hash.HexToHash("0x1235").Bytes(), eventSig.Bytes()))
+
+			_, otherPubkey, err := GenerateKey(keyType, rand.Reader)
+			require.NoError(err)
+			require.False(Verify(otherPubkey, digest.Bytes(), eventSig.Bytes()))
+
+			eventSig[0]++
+			require.False(Verify(pubkey, digest.Bytes(), eventSig.Bytes()))
+		})
+	}
+
+	_, err := Of(0xff)
+	require.ErrorIs(t, err, ErrNotSupportedType)
+	_, err = TypeOf("rsa")
+	require.ErrorIs(t, err, ErrNotSupportedType)
+	require.False(Verify(validatorpk.PubKey{Type: 0xff}, nil, nil))
+}
@@ -20,6 +20,11 @@ func (r ProtocolRules) EncodeRLP(w io.Writer) error {
 	rType := uint8(0)
 	if r.Upgrades != (Upgrades{}) {
 		rType = 1
+	}
+	if !r.Precompiles.IsZero() {
+		rType = 2
+	}
+	if rType > 0 {
 		_, err := w.Write([]byte{rType})
 		if err != nil {
 			return err
@@ -38,6 +43,12 @@ func (r ProtocolRules) EncodeRLP(w io.Writer) error {
 			return err
 		}
 	}
+	if rType > 1 {
+		err := rlp.Encode(w, &r.Precompiles)
+		if err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -58,7 +69,7 @@ func (r *ProtocolRules) DecodeRLP(s *rlp.Stream) error {
 			return errors.New("empty typed")
 		}
 		rType = b[0]
-		if rType == 0 || rType > 1 {
+		if rType == 0 || rType > 2 {
 			return errors.New("unknown type")
 		}
 	}
@@ -76,6 +87,12 @@ func (r *ProtocolRules) DecodeRLP(s *rlp.Stream) error {
 			return err
 		}
 	}
+	if rType >= 2 {
+		err = s.Decode(&r.Precompiles)
+		if err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -93,6 +110,15 @@ func (u Upgrades) EncodeRLP(w io.Writer) error {
 	if u.Llr {
 		bitmap.V |= llrBit
 	}
+	if u.ValidatorKeyTypes {
+		bitmap.V |= validatorKeyTypesBit
+	}
+	if u.NativeTokenPermit {
+		bitmap.V |= nativeTokenPermitBit
+	}
+	if u.PrecompilesGas {
+		bitmap.V |= precompilesGasBit
+	}
 	return rlp.Encode(w, &bitmap)
 }
 
@@ -108,6 +134,9 @@ func (u *Upgrades) DecodeRLP(s *rlp.Stream) error {
 	u.Berlin = (bitmap.V & berlinBit) != 0
 	u.London = (bitmap.V & londonBit) != 0
 	u.Llr = (bitmap.V & llrBit) != 0
+	u.ValidatorKeyTypes = (bitmap.V & validatorKeyTypesBit) != 0
+	u.NativeTokenPermit = (bitmap.V & nativeTokenPermitBit) != 0
+	u.PrecompilesGas = (bitmap.V & precompilesGasBit) != 0
 	return nil
 }
 
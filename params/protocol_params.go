@@ -50,7 +50,10 @@ var (
 	MaxGasForDebitSubscription     = 500 * thousand
 	MaxGasForCreditSubscription    = 500 * thousand
 	MaxGasForGetSub                = 500 * thousand
+	MaxGasForGetPlans              = 5 * million
+	MaxGasForGetPlan               = 500 * thousand
 	MaxGasForIsWhitelisted         = 500 * thousand
 	MaxGasForSetOwnerOfContract    = 500 * thousand
 	MaxGasForAddReward             = 500 * thousand
+	MaxGasForGetRewards            = 500 * thousand
 )
@@ -4,7 +4,9 @@ import (
 	"encoding/json"
 	"github.com/artheranet/arthera-node/contracts"
 	"github.com/artheranet/arthera-node/contracts/native_token"
+	"github.com/artheranet/arthera-node/contracts/precompiles"
 	"math/big"
+	"reflect"
 	"time"
 
 	"github.com/artheranet/lachesis/inter/idx"
@@ -17,16 +19,20 @@ import (
 )
 
 const (
-	MainNetworkID   uint64 = 10242
-	TestNetworkID   uint64 = 10243
-	FakeNetworkID   uint64 = 10244
-	DevNetworkID    uint64 = 10245
-	DefaultEventGas uint64 = 28000
-	berlinBit              = 1 << 0
-	londonBit              = 1 << 1
-	llrBit                 = 1 << 2
+	MainNetworkID        uint64 = 10242
+	TestNetworkID        uint64 = 10243
+	FakeNetworkID        uint64 = 10244
+	DevNetworkID         uint64 = 10245
+	DefaultEventGas      uint64 = 28000
+	berlinBit                   = 1 << 0
+	londonBit                   = 1 << 1
+	llrBit                      = 1 << 2
+	validatorKeyTypesBit        = 1 << 3
+	nativeTokenPermitBit        = 1 << 4
+	precompilesGasBit           = 1 << 5
 )
 
+// DefaultVMConfig is the VM config with stateful precompiled contracts, which aren't upgraded by any network rules
 var DefaultVMConfig = vm.Config{
 	StatePrecompiles: map[common.Address]vm.PrecompiledStateContract{
 		contracts.EvmWriterSmartContractAddress:   &evmwriter.PreCompiledContract{},
@@ -51,6 +57,9 @@ type ProtocolRulesRLP struct {
 	Economy EconomyRules
 
 	Upgrades Upgrades `rlp:"-"`
+
+	// Precompiles is the gas schedule of the stateful precompiled contracts
+	Precompiles precompiles.GasRules `rlp:"-"`
 }
 
 // ProtocolRules describes arthera net.
@@ -119,6 +128,13 @@ type Upgrades struct {
 	Berlin bool
 	London bool
 	Llr    bool
+	// ValidatorKeyTypes enables validator keys of types other than secp256k1
+	ValidatorKeyTypes bool
+	// NativeTokenPermit enables EIP-2612 permits and allowance helpers of the native token,
+	// and approvals on behalf of the caller rather than the tx origin
+	NativeTokenPermit bool
+	// PrecompilesGas enables metering of the stateful precompiled contracts by the Precompiles gas schedule
+	PrecompilesGas bool
 }
 
 type UpgradeHeight struct {
@@ -324,9 +340,42 @@ func FakeShortGasPowerRules() GasPowerRules {
 func (r ProtocolRules) Copy() ProtocolRules {
 	cp := r
 	cp.Economy.MinGasPrice = new(big.Int).Set(r.Economy.MinGasPrice)
+	cp.Precompiles = r.Precompiles.Copy()
 	return cp
 }
 
+// PrecompilesGasRules returns the gas schedule of the stateful precompiled contracts.
+// The default schedule is used if the rules don't specify one.
+func (r ProtocolRules) PrecompilesGasRules() precompiles.GasRules {
+	if r.Precompiles.IsZero() {
+		return precompiles.DefaultGasRules()
+	}
+	return r.Precompiles
+}
+
+// StatePrecompiles returns the stateful precompiled contracts configured by the rules.
+// The contracts are metered only after the PrecompilesGas upgrade, the legacy flat costs are charged before.
+func (r ProtocolRules) StatePrecompiles() map[common.Address]vm.PrecompiledStateContract {
+	var gasRules *precompiles.GasRules
+	if r.Upgrades.PrecompilesGas {
+		rules := r.PrecompilesGasRules()
+		gasRules = &rules
+	}
+	return map[common.Address]vm.PrecompiledStateContract{
+		contracts.EvmWriterSmartContractAddress: &evmwriter.PreCompiledContract{GasRules: gasRules},
+		contracts.NativeTokenSmartContractAddress: &native_token.PreCompiledContract{
+			GasRules: gasRules,
+			Permit:   r.Upgrades.NativeTokenPermit,
+		},
+	}
+}
+
+// VMConfig returns a copy of the VM config with the stateful precompiled contracts configured by the rules
+func (r ProtocolRules) VMConfig(cfg vm.Config) vm.Config {
+	cfg.StatePrecompiles = r.StatePrecompiles()
+	return cfg
+}
+
 func (r ProtocolRules) String() string {
 	b, _ := json.Marshal(&r)
 	return string(b)
@@ -334,10 +383,18 @@ func (r ProtocolRules) String() string {
 
 func UpdateRules(src ProtocolRules, diff []byte) (res ProtocolRules, err error) {
 	changed := src.Copy()
+	if src.Precompiles.IsZero() {
+		// apply the precompiles diff on top of the default gas schedule
+		changed.Precompiles = precompiles.DefaultGasRules()
+	}
 	err = json.Unmarshal(diff, &changed)
 	if err != nil {
 		return src, err
 	}
+	if src.Precompiles.IsZero() && reflect.DeepEqual(changed.Precompiles, precompiles.DefaultGasRules()) {
+		// keep the default gas schedule implicit
+		changed.Precompiles = precompiles.GasRules{}
+	}
 	// protect readonly fields
 	res = changed
 	res.NetworkID = src.NetworkID
@@ -6,6 +6,11 @@ import (
 
 	"github.com/ethereum/go-ethereum/rlp"
 	"github.com/stretchr/testify/require"
+
+	"github.com/artheranet/arthera-node/contracts"
+	"github.com/artheranet/arthera-node/contracts/evmwriter"
+	"github.com/artheranet/arthera-node/contracts/native_token"
+	"github.com/artheranet/arthera-node/contracts/precompiles"
 )
 
 func TestUpdateRules(t *testing.T) {
@@ -79,6 +84,21 @@ func TestRulesLondonRLP(t *testing.T) {
 	require.True(decodedRules.Upgrades.London)
 }
 
+func TestRulesValidatorKeyTypesRLP(t *testing.T) {
+	rules := MainNetRules()
+	rules.Upgrades.ValidatorKeyTypes = true
+	require := require.New(t)
+
+	b, err := rlp.EncodeToBytes(rules)
+	require.NoError(err)
+
+	decodedRules := ProtocolRules{}
+	require.NoError(rlp.DecodeBytes(b, &decodedRules))
+
+	require.Equal(rules.String(), decodedRules.String())
+	require.True(decodedRules.Upgrades.ValidatorKeyTypes)
+}
+
 func TestRulesBerlinCompatibilityRLP(t *testing.T) {
 	require := require.New(t)
 
@@ -120,3 +140,65 @@ func TestGasRulesLLRCompatibilityRLP(t *testing.T) {
 
 	require.Equal(b2, b1)
 }
+
+func TestRulesPrecompilesRLP(t *testing.T) {
+	rules := MainNetRules()
+	rules.Precompiles = precompiles.DefaultGasRules()
+	rules.Precompiles.LogGas = 1000
+	require := require.New(t)
+
+	b, err := rlp.EncodeToBytes(rules)
+	require.NoError(err)
+
+	decodedRules := ProtocolRules{}
+	require.NoError(rlp.DecodeBytes(b, &decodedRules))
+
+	require.Equal(rules.String(), decodedRules.String())
+	require.Equal(uint64(1000), decodedRules.PrecompilesGasRules().LogGas)
+	require.True(decodedRules.Upgrades.London)
+}
+
+func TestUpdatePrecompilesRules(t *testing.T) {
+	require := require.New(t)
+
+	rules := MainNetRules()
+	require.True(rules.Precompiles.IsZero())
+	require.Equal(precompiles.DefaultGasRules(), rules.PrecompilesGasRules())
+
+	got, err := UpdateRules(rules, []byte(`{"Blocks":{"MaxBlockGas":1000}}`))
+	require.NoError(err)
+	require.True(got.Precompiles.IsZero(), "untouched section")
+
+	got, err = UpdateRules(rules, []byte(`{"Precompiles":{"ColdSloadGas":3000}}`))
+	require.NoError(err)
+	exp := precompiles.DefaultGasRules()
+	exp.ColdSloadGas = 3000
+	require.Equal(exp, got.PrecompilesGasRules(), "diff on top of defaults")
+}
+
+func TestStatePrecompilesUpgrades(t *testing.T) {
+	require := require.New(t)
+
+	rules := MainNetRules()
+	nativeToken := rules.StatePrecompiles()[contracts.NativeTokenSmartContractAddress].(*native_token.PreCompiledContract)
+	require.Nil(nativeToken.GasRules, "legacy flat costs")
+	require.False(nativeToken.Permit)
+	writer := rules.StatePrecompiles()[contracts.EvmWriterSmartContractAddress].(*evmwriter.PreCompiledContract)
+	require.Nil(writer.GasRules)
+
+	rules.Upgrades.NativeTokenPermit = true
+	rules.Upgrades.PrecompilesGas = true
+	rules.Precompiles = precompiles.DefaultGasRules()
+	rules.Precompiles.LogGas = 1000
+	nativeToken = rules.StatePrecompiles()[contracts.NativeTokenSmartContractAddress].(*native_token.PreCompiledContract)
+	require.True(nativeToken.Permit)
+	require.Equal(uint64(1000), nativeToken.GasRules.LogGas)
+	writer = rules.StatePrecompiles()[contracts.EvmWriterSmartContractAddress].(*evmwriter.PreCompiledContract)
+	require.Equal(uint64(1000), writer.GasRules.LogGas)
+
+	b, err := rlp.EncodeToBytes(rules.Upgrades)
+	require.NoError(err)
+	var decoded Upgrades
+	require.NoError(rlp.DecodeBytes(b, &decoded))
+	require.Equal(rules.Upgrades, decoded)
+}